@@ -34,6 +34,9 @@ const (
 	logsFlagErrors     = "errors"
 	logsFlagTail       = "tail"
 
+	frameSystemFlagFormat = "format"
+	frameSystemFlagOutput = "output"
+
 	runFlagData   = "data"
 	runFlagStream = "stream"
 
@@ -2575,6 +2578,25 @@ Note: There is no progress meter while copying is in progress.
 							}...),
 							Action: createCommandWithT[robotsPartTunnelArgs](RobotsPartTunnelAction),
 						},
+						{
+							Name:  "frame-system",
+							Usage: "export the frame system of a machine part",
+							Description: `Export the frame system of a machine part, including the kinematics and geometries of its components.
+The URDF format can be loaded into tools such as RViz, MoveIt or simulators.`,
+							UsageText: createUsageText("machines part frame-system", []string{generalFlagPart}, true, false),
+							Flags: append(commonPartFlags, []cli.Flag{
+								&cli.StringFlag{
+									Name:  frameSystemFlagFormat,
+									Usage: "export format (json or urdf)",
+									Value: "json",
+								},
+								&cli.StringFlag{
+									Name:  frameSystemFlagOutput,
+									Usage: "path to output file, defaults to printing to stdout",
+								},
+							}...),
+							Action: createCommandWithT[machinesPartFrameSystemArgs](MachinesPartFrameSystemAction),
+						},
 						{
 							Name: "motion",
 							Subcommands: []*cli.Command{
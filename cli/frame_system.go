@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"go.viam.com/utils"
+
+	"go.viam.com/rdk/referenceframe"
+)
+
+const (
+	frameSystemFormatJSON = "json"
+	frameSystemFormatURDF = "urdf"
+)
+
+type machinesPartFrameSystemArgs struct {
+	Organization string
+	Location     string
+	Machine      string
+	Part         string
+	Format       string
+	Output       string
+}
+
+// MachinesPartFrameSystemAction is the corresponding action for 'machines part frame-system'. It exports the frame system
+// of a machine part, including the kinematics of its components, as JSON or URDF.
+func MachinesPartFrameSystemAction(c *cli.Context, args machinesPartFrameSystemArgs) error {
+	format := args.Format
+	if format == "" {
+		format = frameSystemFormatJSON
+	}
+	if format != frameSystemFormatJSON && format != frameSystemFormatURDF {
+		return fmt.Errorf("unsupported frame system format %q, must be %q or %q", format, frameSystemFormatJSON, frameSystemFormatURDF)
+	}
+
+	client, err := newViamClient(c)
+	if err != nil {
+		return err
+	}
+
+	globalArgs, err := getGlobalArgs(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, fqdn, rpcOpts, err := client.prepareDial(args.Organization, args.Location, args.Machine, args.Part, globalArgs.Debug)
+	if err != nil {
+		return err
+	}
+
+	logger := globalArgs.createLogger()
+
+	robotClient, err := client.connectToRobot(ctx, fqdn, rpcOpts, globalArgs.Debug, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		utils.UncheckedError(robotClient.Close(ctx))
+	}()
+
+	fsCfg, err := robotClient.FrameSystemConfig(ctx)
+	if err != nil {
+		return err
+	}
+	fs, err := referenceframe.NewFrameSystem(args.Part, fsCfg.Parts, nil)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch format {
+	case frameSystemFormatURDF:
+		urdf, err := referenceframe.NewModelFromFrameSystem(fs, args.Part)
+		if err != nil {
+			return err
+		}
+		data, err = xml.MarshalIndent(urdf, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append([]byte(xml.Header), data...)
+	default:
+		data, err = json.MarshalIndent(fs, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+
+	if args.Output == "" {
+		printf(c.App.Writer, "%s", data)
+		return nil
+	}
+	//nolint:gosec
+	if err := os.WriteFile(args.Output, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	printf(c.App.Writer, "Wrote %s frame system to %s", format, args.Output)
+	return nil
+}
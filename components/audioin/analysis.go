@@ -0,0 +1,203 @@
+package audioin
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+	"gonum.org/v1/gonum/dsp/window"
+)
+
+// MinDBFS is the lowest level levels are reported at, that of silence.
+const MinDBFS = -120.0
+
+// DefaultBandEdgesHz are the edges of the octave bands levels are computed for by default.
+var DefaultBandEdgesHz = []float64{0, 125, 250, 500, 1000, 2000, 4000, 8000, 16000}
+
+// speech band, holding most of the energy of voices
+const (
+	speechLowHz  = 300
+	speechHighHz = 3400
+)
+
+// AnalysisConfig configures the analysis of audio, zero values selecting defaults.
+type AnalysisConfig struct {
+	// BandEdgesHz are the increasing edges of the spectral bands to compute levels of, bands above the Nyquist
+	// frequency being left out.
+	BandEdgesHz []float64
+	// FrameDuration is the duration in seconds of the frames audio is analyzed in, 20ms by default.
+	FrameDuration float64
+	// VoiceMinDBFS is the lowest level of frames holding voice, -50dBFS by default.
+	VoiceMinDBFS float64
+	// VoiceMaxZeroCrossingRateHz is the highest zero crossing rate of frames holding voice, which is low for voiced
+	// speech and high for noise, 5000 crossings per second by default.
+	VoiceMaxZeroCrossingRateHz float64
+	// VoiceMinSpeechRatio is the lowest fraction of the energy of frames holding voice which is in the speech band,
+	// 0.5 by default.
+	VoiceMinSpeechRatio float64
+	// VoiceMinActivity is the lowest fraction of frames holding voice for voice to be detected, 0.3 by default.
+	VoiceMinActivity float64
+}
+
+func (conf AnalysisConfig) withDefaults() AnalysisConfig {
+	if len(conf.BandEdgesHz) == 0 {
+		conf.BandEdgesHz = DefaultBandEdgesHz
+	}
+	if conf.FrameDuration <= 0 {
+		conf.FrameDuration = 0.02
+	}
+	if conf.VoiceMinDBFS == 0 {
+		conf.VoiceMinDBFS = -50
+	}
+	if conf.VoiceMaxZeroCrossingRateHz <= 0 {
+		conf.VoiceMaxZeroCrossingRateHz = 5000
+	}
+	if conf.VoiceMinSpeechRatio <= 0 {
+		conf.VoiceMinSpeechRatio = 0.5
+	}
+	if conf.VoiceMinActivity <= 0 {
+		conf.VoiceMinActivity = 0.3
+	}
+	return conf
+}
+
+// A BandLevel is the level of a spectral band of audio.
+type BandLevel struct {
+	LowHz  float64
+	HighHz float64
+	DBFS   float64
+}
+
+// Levels are the levels of a window of audio, in dBFS relative to the level of a full scale square wave.
+type Levels struct {
+	RMSDBFS  float64
+	PeakDBFS float64
+	// ZeroCrossingRateHz is the number of times per second the audio crosses zero.
+	ZeroCrossingRateHz float64
+	Bands              []BandLevel
+	// VoiceActivity is the fraction of frames of the window detected as holding voice, from their energy, zero
+	// crossing rate and the share of their energy in the speech band. Tones in the speech band are detected too.
+	VoiceActivity float64
+	Voice         bool
+}
+
+// toDBFS returns the level of the mean square of samples.
+func toDBFS(meanSquare float64) float64 {
+	if meanSquare <= 0 {
+		return MinDBFS
+	}
+	return max(MinDBFS, 10*math.Log10(meanSquare))
+}
+
+// AnalyzeAudio returns the levels of interleaved samples, between -1 and 1, of the given number of channels, their
+// channels being mixed down for all levels but the peak.
+func AnalyzeAudio(samples []float32, numChannels, sampleRateHz int, conf AnalysisConfig) Levels {
+	conf = conf.withDefaults()
+	numChannels = max(1, numChannels)
+	mono := make([]float64, len(samples)/numChannels)
+	var peak float64
+	for i := range mono {
+		for c := 0; c < numChannels; c++ {
+			v := float64(samples[i*numChannels+c])
+			mono[i] += v
+			peak = max(peak, math.Abs(v))
+		}
+		mono[i] /= float64(numChannels)
+	}
+
+	levels := Levels{RMSDBFS: MinDBFS, PeakDBFS: MinDBFS}
+	for _, edge := range conf.BandEdgesHz {
+		if len(levels.Bands) > 0 {
+			levels.Bands[len(levels.Bands)-1].HighHz = min(edge, float64(sampleRateHz)/2)
+		}
+		if edge >= float64(sampleRateHz)/2 {
+			break
+		}
+		levels.Bands = append(levels.Bands, BandLevel{LowHz: edge, DBFS: MinDBFS})
+	}
+	if n := len(levels.Bands); n > 0 && levels.Bands[n-1].HighHz == 0 {
+		// the edges end below the Nyquist frequency
+		levels.Bands = levels.Bands[:n-1]
+	}
+	if len(mono) == 0 || sampleRateHz <= 0 {
+		return levels
+	}
+	levels.PeakDBFS = toDBFS(peak * peak)
+	levels.RMSDBFS = toDBFS(meanSquare(mono))
+	levels.ZeroCrossingRateHz = float64(zeroCrossings(mono)) * float64(sampleRateHz) / float64(len(mono))
+
+	frameSize := min(len(mono), max(2, int(conf.FrameDuration*float64(sampleRateHz))))
+	fft := fourier.NewFFT(frameSize)
+	hann := window.Hann(ones(frameSize))
+	var hannMeanSquare float64
+	for _, w := range hann {
+		hannMeanSquare += w * w / float64(frameSize)
+	}
+	bandPower := make([]float64, len(levels.Bands))
+	frame := make([]float64, frameSize)
+	var coeffs []complex128
+	var frames, voiced int
+	for start := 0; start+frameSize <= len(mono); start += frameSize {
+		frames++
+		for i := range frame {
+			frame[i] = mono[start+i] * hann[i]
+		}
+		coeffs = fft.Coefficients(coeffs, frame)
+		// the share of the mean square of the frame of each frequency, by Parseval's theorem
+		var total, speech float64
+		for k, c := range coeffs {
+			power := real(c)*real(c) + imag(c)*imag(c)
+			if k > 0 && 2*k != frameSize {
+				power *= 2
+			}
+			power /= float64(frameSize) * float64(frameSize) * hannMeanSquare
+			freq := fft.Freq(k) * float64(sampleRateHz)
+			total += power
+			if freq >= speechLowHz && freq <= speechHighHz {
+				speech += power
+			}
+			for b, band := range levels.Bands {
+				if freq >= band.LowHz && freq < band.HighHz {
+					bandPower[b] += power
+					break
+				}
+			}
+		}
+		rate := float64(zeroCrossings(mono[start:start+frameSize])) * float64(sampleRateHz) / float64(frameSize)
+		if toDBFS(meanSquare(mono[start:start+frameSize])) >= conf.VoiceMinDBFS &&
+			rate <= conf.VoiceMaxZeroCrossingRateHz && total > 0 && speech/total >= conf.VoiceMinSpeechRatio {
+			voiced++
+		}
+	}
+	for b := range levels.Bands {
+		levels.Bands[b].DBFS = toDBFS(bandPower[b] / float64(frames))
+	}
+	levels.VoiceActivity = float64(voiced) / float64(frames)
+	levels.Voice = levels.VoiceActivity >= conf.VoiceMinActivity
+	return levels
+}
+
+func meanSquare(samples []float64) float64 {
+	var sum float64
+	for _, v := range samples {
+		sum += v * v
+	}
+	return sum / float64(len(samples))
+}
+
+func zeroCrossings(samples []float64) int {
+	var n int
+	for i := 1; i < len(samples); i++ {
+		if (samples[i-1] < 0) != (samples[i] < 0) {
+			n++
+		}
+	}
+	return n
+}
+
+func ones(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = 1
+	}
+	return s
+}
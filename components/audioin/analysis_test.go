@@ -0,0 +1,84 @@
+package audioin_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioin"
+)
+
+const analysisSampleRate = 16000
+
+// tone returns a second of a sine of the given frequency and amplitude on each of the channels.
+func tone(freq, amplitude float64, channels int) []float32 {
+	samples := make([]float32, analysisSampleRate*channels)
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i/channels)/analysisSampleRate))
+	}
+	return samples
+}
+
+func TestAnalyzeAudio(t *testing.T) {
+	t.Run("tone", func(t *testing.T) {
+		levels := audioin.AnalyzeAudio(tone(700, 0.5, 2), 2, analysisSampleRate, audioin.AnalysisConfig{})
+		// the RMS of a sine is its amplitude over the square root of 2
+		test.That(t, levels.RMSDBFS, test.ShouldAlmostEqual, 20*math.Log10(0.5/math.Sqrt2), 0.01)
+		test.That(t, levels.PeakDBFS, test.ShouldAlmostEqual, 20*math.Log10(0.5), 0.01)
+		test.That(t, levels.ZeroCrossingRateHz, test.ShouldAlmostEqual, 1400, 2)
+
+		// octave bands up to the Nyquist frequency
+		test.That(t, levels.Bands, test.ShouldHaveLength, 7)
+		test.That(t, levels.Bands[6].LowHz, test.ShouldEqual, 4000)
+		test.That(t, levels.Bands[6].HighHz, test.ShouldEqual, 8000)
+		for _, band := range levels.Bands {
+			if band.LowHz == 500 {
+				test.That(t, band.DBFS, test.ShouldAlmostEqual, levels.RMSDBFS, 0.1)
+			} else {
+				test.That(t, band.DBFS, test.ShouldBeLessThan, levels.RMSDBFS-40)
+			}
+		}
+		// tones in the speech band are detected as voice
+		test.That(t, levels.VoiceActivity, test.ShouldEqual, 1)
+		test.That(t, levels.Voice, test.ShouldBeTrue)
+	})
+
+	t.Run("noise", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		samples := make([]float32, analysisSampleRate)
+		for i := range samples {
+			samples[i] = float32(0.3 * (2*r.Float64() - 1))
+		}
+		levels := audioin.AnalyzeAudio(samples, 1, analysisSampleRate, audioin.AnalysisConfig{})
+		// the RMS of uniform noise is its amplitude over the square root of 3
+		test.That(t, levels.RMSDBFS, test.ShouldAlmostEqual, 20*math.Log10(0.3/math.Sqrt(3)), 0.1)
+		test.That(t, levels.ZeroCrossingRateHz, test.ShouldAlmostEqual, analysisSampleRate/2, 300)
+		test.That(t, levels.Voice, test.ShouldBeFalse)
+		test.That(t, levels.VoiceActivity, test.ShouldEqual, 0)
+		// white noise spreads evenly over frequencies
+		test.That(t, levels.Bands[6].DBFS-levels.Bands[5].DBFS, test.ShouldAlmostEqual, 10*math.Log10(2), 0.3)
+	})
+
+	t.Run("quiet tone", func(t *testing.T) {
+		levels := audioin.AnalyzeAudio(tone(700, 0.001, 1), 1, analysisSampleRate, audioin.AnalysisConfig{})
+		test.That(t, levels.Voice, test.ShouldBeFalse)
+		levels = audioin.AnalyzeAudio(tone(700, 0.001, 1), 1, analysisSampleRate, audioin.AnalysisConfig{VoiceMinDBFS: -70})
+		test.That(t, levels.Voice, test.ShouldBeTrue)
+	})
+
+	t.Run("silence", func(t *testing.T) {
+		levels := audioin.AnalyzeAudio(make([]float32, 1000), 1, analysisSampleRate, audioin.AnalysisConfig{})
+		test.That(t, levels.RMSDBFS, test.ShouldEqual, audioin.MinDBFS)
+		test.That(t, levels.PeakDBFS, test.ShouldEqual, audioin.MinDBFS)
+		for _, band := range levels.Bands {
+			test.That(t, band.DBFS, test.ShouldEqual, audioin.MinDBFS)
+		}
+		test.That(t, levels.Voice, test.ShouldBeFalse)
+
+		levels = audioin.AnalyzeAudio(nil, 1, analysisSampleRate, audioin.AnalysisConfig{BandEdgesHz: []float64{100, 200}})
+		test.That(t, levels.RMSDBFS, test.ShouldEqual, audioin.MinDBFS)
+		test.That(t, levels.Bands, test.ShouldResemble, []audioin.BandLevel{{LowHz: 100, HighHz: 200, DBFS: audioin.MinDBFS}})
+	})
+}
@@ -0,0 +1,335 @@
+package audioin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/utils"
+)
+
+type method int64
+
+const (
+	audioLevels method = iota
+	getAudio
+	doCommand
+)
+
+func (m method) String() string {
+	switch m {
+	case audioLevels:
+		return "AudioLevels"
+	case getAudio:
+		return "GetAudio"
+	case doCommand:
+		return "DoCommand"
+	}
+	return "Unknown"
+}
+
+const (
+	defaultLevelsWindowSeconds = 1.0
+	defaultClipSeconds         = 5.0
+)
+
+// newAudioLevelsCollector returns a collector capturing the levels, spectral band levels and voice activity of windows
+// of audio of window_seconds, one second by default, which cannot be longer than the interval between captures.
+func newAudioLevelsCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	audioIn, err := assertAudioIn(resource)
+	if err != nil {
+		return nil, err
+	}
+	window, err := floatParam(params.MethodParams, "window_seconds", defaultLevelsWindowSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCaptureSeconds("window_seconds", window, params.Interval); err != nil {
+		return nil, err
+	}
+	conf, err := analysisConfigParams(params.MethodParams)
+	if err != nil {
+		return nil, err
+	}
+
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (data.CaptureResult, error) {
+		timeRequested := time.Now()
+		var res data.CaptureResult
+		samples, info, err := readAudio(ctx, audioIn, window)
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return res, err
+			}
+			return res, data.NewFailedToReadError(params.ComponentName, audioLevels.String(), err)
+		}
+		levels := AnalyzeAudio(samples, int(info.NumChannels), int(info.SampleRateHz), conf)
+
+		ts := data.Timestamps{TimeRequested: timeRequested, TimeReceived: time.Now()}
+		return data.NewTabularCaptureResultReadings(ts, levelsReadings(levels))
+	})
+	return data.NewCollector(cFunc, params)
+}
+
+// levelsReadings returns the readings of levels, each band level being named after its edges.
+func levelsReadings(levels Levels) map[string]interface{} {
+	readings := map[string]interface{}{
+		"rms_dbfs":              levels.RMSDBFS,
+		"peak_dbfs":             levels.PeakDBFS,
+		"zero_crossing_rate_hz": levels.ZeroCrossingRateHz,
+		"voice_activity":        levels.VoiceActivity,
+		"voice":                 levels.Voice,
+	}
+	for _, band := range levels.Bands {
+		readings[fmt.Sprintf("band_%g_%g_hz_dbfs", band.LowHz, band.HighHz)] = band.DBFS
+	}
+	return readings
+}
+
+// newGetAudioCollector returns a collector capturing WAV clips of duration_seconds of audio, five seconds by default,
+// which cannot be longer than the interval between captures.
+// When any of trigger_rms_dbfs, trigger_peak_dbfs or trigger_voice are set, only clips whose level reaches the
+// thresholds or holding voice are captured, which are annotated with the triggers they met.
+func newGetAudioCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	audioIn, err := assertAudioIn(resource)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := floatParam(params.MethodParams, "duration_seconds", defaultClipSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCaptureSeconds("duration_seconds", duration, params.Interval); err != nil {
+		return nil, err
+	}
+	triggerRMS, err := floatParam(params.MethodParams, "trigger_rms_dbfs", 0)
+	if err != nil {
+		return nil, err
+	}
+	triggerPeak, err := floatParam(params.MethodParams, "trigger_peak_dbfs", 0)
+	if err != nil {
+		return nil, err
+	}
+	triggerVoice, err := boolParam(params.MethodParams, "trigger_voice")
+	if err != nil {
+		return nil, err
+	}
+	conf, err := analysisConfigParams(params.MethodParams)
+	if err != nil {
+		return nil, err
+	}
+	rmsTriggered := params.MethodParams["trigger_rms_dbfs"] != nil
+	peakTriggered := params.MethodParams["trigger_peak_dbfs"] != nil
+	triggered := rmsTriggered || peakTriggered || triggerVoice
+
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (data.CaptureResult, error) {
+		timeRequested := time.Now()
+		var res data.CaptureResult
+		samples, info, err := readAudio(ctx, audioIn, duration)
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return res, err
+			}
+			return res, data.NewFailedToReadError(params.ComponentName, getAudio.String(), err)
+		}
+
+		var annotations data.Annotations
+		if triggered {
+			levels := AnalyzeAudio(samples, int(info.NumChannels), int(info.SampleRateHz), conf)
+			if rmsTriggered && levels.RMSDBFS >= triggerRMS {
+				annotations.Classifications = append(annotations.Classifications, data.Classification{Label: "rms"})
+			}
+			if peakTriggered && levels.PeakDBFS >= triggerPeak {
+				annotations.Classifications = append(annotations.Classifications, data.Classification{Label: "peak"})
+			}
+			if triggerVoice && levels.Voice {
+				annotations.Classifications = append(annotations.Classifications, data.Classification{Label: "voice"})
+			}
+			if annotations.Empty() {
+				return res, data.ErrNoCaptureToStore
+			}
+		}
+		wav, err := encodeWAV(samples, info)
+		if err != nil {
+			return res, err
+		}
+
+		ts := data.Timestamps{TimeRequested: timeRequested, TimeReceived: time.Now()}
+		return data.NewBinaryCaptureResult(ts, []data.Binary{{
+			Payload:     wav,
+			MimeType:    data.MimeTypeAudioWav,
+			Annotations: annotations,
+		}}), nil
+	})
+	return data.NewCollector(cFunc, params)
+}
+
+// checkCaptureSeconds checks that captures of the given seconds of audio are done before the next one is due, as the
+// audio of a capture is recorded as it is produced.
+func checkCaptureSeconds(param string, seconds float64, interval time.Duration) error {
+	if interval > 0 && seconds > interval.Seconds() {
+		return errors.Errorf("%s of %gs is longer than the %s between captures, capture_frequency_hz * %s cannot be more than 1",
+			param, seconds, interval, param)
+	}
+	return nil
+}
+
+// newDoCommandCollector returns a collector to register a doCommand action. If one is already registered
+// with the same MethodMetadata it will panic.
+func newDoCommandCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	audioIn, err := assertAudioIn(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	cFunc := data.NewDoCommandCaptureFunc(audioIn, params)
+	return data.NewCollector(cFunc, params)
+}
+
+func assertAudioIn(resource interface{}) (AudioIn, error) {
+	audioIn, ok := resource.(AudioIn)
+	if !ok {
+		return nil, data.InvalidInterfaceErr(API)
+	}
+	return audioIn, nil
+}
+
+// readAudio reads the given seconds of 16 bit PCM audio from the audio input, returning its samples and format.
+func readAudio(ctx context.Context, audioIn AudioIn, seconds float64) ([]float32, *utils.AudioInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunks, err := audioIn.GetAudio(ctx, utils.CodecPCM16, float32(seconds), 0, data.FromDMExtraMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	var samples []float32
+	var info *utils.AudioInfo
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				if info == nil || len(samples) == 0 {
+					return nil, nil, errors.New("audio input streamed no audio")
+				}
+				return samples, info, nil
+			}
+			if chunk.AudioInfo == nil || chunk.AudioInfo.Codec != utils.CodecPCM16 || chunk.AudioInfo.NumChannels <= 0 {
+				return nil, nil, errors.New("audio input streamed audio of an unexpected format")
+			}
+			chunkSamples, err := utils.DecodePCM(chunk.AudioData, utils.CodecPCM16)
+			if err != nil {
+				return nil, nil, err
+			}
+			samples = append(samples, chunkSamples...)
+			info = chunk.AudioInfo
+		}
+	}
+}
+
+// encodeWAV encodes samples as a 16 bit PCM WAV file.
+func encodeWAV(samples []float32, info *utils.AudioInfo) ([]byte, error) {
+	pcm, err := utils.EncodePCM(samples, utils.CodecPCM16)
+	if err != nil {
+		return nil, err
+	}
+	const headerSize = 44
+	blockAlign := 2 * uint16(info.NumChannels)
+	var buf bytes.Buffer
+	buf.Grow(headerSize + len(pcm))
+	buf.WriteString("RIFF")
+	write := func(v interface{}) {
+		//nolint:errcheck
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	write(uint32(headerSize - 8 + len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	write(uint32(16))
+	write(uint16(1)) // PCM
+	write(uint16(info.NumChannels))
+	write(uint32(info.SampleRateHz))
+	write(uint32(info.SampleRateHz) * uint32(blockAlign))
+	write(blockAlign)
+	write(uint16(16))
+	buf.WriteString("data")
+	write(uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes(), nil
+}
+
+// analysisConfigParams returns the analysis config set by the voice_min_dbfs and voice_min_activity params.
+func analysisConfigParams(params map[string]*anypb.Any) (AnalysisConfig, error) {
+	var conf AnalysisConfig
+	var err error
+	if conf.VoiceMinDBFS, err = floatParam(params, "voice_min_dbfs", 0); err != nil {
+		return conf, err
+	}
+	if conf.VoiceMinActivity, err = floatParam(params, "voice_min_activity", 0); err != nil {
+		return conf, err
+	}
+	return conf, nil
+}
+
+// paramValue returns the value of a method param, which is a string when set by older configs.
+func paramValue(params map[string]*anypb.Any, key string) (*structpb.Value, error) {
+	param := params[key]
+	if param == nil {
+		return nil, nil
+	}
+	strVal := &wrapperspb.StringValue{}
+	if err := param.UnmarshalTo(strVal); err == nil {
+		return structpb.NewStringValue(strVal.Value), nil
+	}
+	val := &structpb.Value{}
+	if err := param.UnmarshalTo(val); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s param", key)
+	}
+	return val, nil
+}
+
+func floatParam(params map[string]*anypb.Any, key string, def float64) (float64, error) {
+	val, err := paramValue(params, key)
+	if err != nil || val == nil {
+		return def, err
+	}
+	switch v := val.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		return v.NumberValue, nil
+	case *structpb.Value_StringValue:
+		f, err := strconv.ParseFloat(v.StringValue, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid %s param", key)
+		}
+		return f, nil
+	default:
+		return 0, errors.Errorf("%s param must be a number", key)
+	}
+}
+
+func boolParam(params map[string]*anypb.Any, key string) (bool, error) {
+	val, err := paramValue(params, key)
+	if err != nil || val == nil {
+		return false, err
+	}
+	switch v := val.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return v.BoolValue, nil
+	case *structpb.Value_StringValue:
+		b, err := strconv.ParseBool(v.StringValue)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid %s param", key)
+		}
+		return b, nil
+	default:
+		return false, errors.Errorf("%s param must be a boolean", key)
+	}
+}
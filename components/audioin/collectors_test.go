@@ -0,0 +1,219 @@
+package audioin_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	datasyncpb "go.viam.com/api/app/datasync/v1"
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/data"
+	datatu "go.viam.com/rdk/data/testutils"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/protoutils"
+	tu "go.viam.com/rdk/testutils"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+const (
+	componentName   = "audioin"
+	captureInterval = time.Millisecond
+)
+
+var doCommandMap = map[string]any{"readings": "random-test"}
+
+func TestAudioLevelsCollector(t *testing.T) {
+	start := time.Now()
+	buf := tu.NewMockBuffer(t)
+	mockClock := clock.NewMock()
+	params := data.CollectorParams{
+		DataType:      data.CaptureTypeTabular,
+		ComponentName: componentName,
+		Interval:      time.Second,
+		Logger:        logging.NewTestLogger(t),
+		Target:        buf,
+		Clock:         mockClock,
+		MethodParams:  methodParams(t, map[string]interface{}{"window_seconds": 0.5}),
+	}
+
+	audioIn := newAudioIn(tone(700, 0.5, 2), 2)
+	col, err := audioin.NewAudioLevelsCollector(audioIn, params)
+	test.That(t, err, test.ShouldBeNil)
+
+	defer col.Close()
+	defer buf.Close()
+	col.Collect()
+	mockClock.Add(params.Interval)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	writes := nextWrites(ctx, t, buf)
+	test.That(t, writes, test.ShouldHaveLength, 1)
+	test.That(t, start, test.ShouldHappenOnOrBefore, writes[0].GetMetadata().GetTimeRequested().AsTime())
+	readings := writes[0].GetStruct().AsMap()["readings"].(map[string]interface{})
+	test.That(t, readings["rms_dbfs"], test.ShouldAlmostEqual, 20*math.Log10(0.5/math.Sqrt2), 0.01)
+	test.That(t, readings["peak_dbfs"], test.ShouldAlmostEqual, 20*math.Log10(0.5), 0.01)
+	test.That(t, readings["band_500_1000_hz_dbfs"], test.ShouldAlmostEqual, 20*math.Log10(0.5/math.Sqrt2), 0.1)
+	test.That(t, readings["band_4000_8000_hz_dbfs"], test.ShouldBeLessThan, -40)
+	test.That(t, readings["voice"], test.ShouldBeTrue)
+	test.That(t, readings["voice_activity"], test.ShouldEqual, 1)
+}
+
+func TestGetAudioCollector(t *testing.T) {
+	// captures are taken every 5 seconds, the length of the default clips
+	mockClock := clock.NewMock()
+	newParams := func(buf *tu.MockBuffer, methodParams map[string]*anypb.Any) data.CollectorParams {
+		return data.CollectorParams{
+			DataType:      data.CaptureTypeBinary,
+			ComponentName: componentName,
+			Interval:      5 * time.Second,
+			Logger:        logging.NewTestLogger(t),
+			Target:        buf,
+			Clock:         mockClock,
+			MethodParams:  methodParams,
+		}
+	}
+
+	t.Run("untriggered", func(t *testing.T) {
+		buf := tu.NewMockBuffer(t)
+		audioIn := newAudioIn(make([]float32, analysisSampleRate), 1)
+		col, err := audioin.NewGetAudioCollector(audioIn, newParams(buf, nil))
+		test.That(t, err, test.ShouldBeNil)
+		defer col.Close()
+		defer buf.Close()
+		col.Collect()
+		mockClock.Add(5 * time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		writes := nextWrites(ctx, t, buf)
+		test.That(t, writes, test.ShouldHaveLength, 1)
+		test.That(t, writes[0].GetMetadata().GetAnnotations(), test.ShouldBeNil)
+		test.That(t, data.MimeTypeStringToMimeType(utils.MimeTypeWAV), test.ShouldEqual, data.MimeTypeAudioWav)
+		wav := writes[0].GetBinary()
+		test.That(t, string(wav[:4]), test.ShouldEqual, "RIFF")
+		test.That(t, string(wav[8:12]), test.ShouldEqual, "WAVE")
+		test.That(t, binary.LittleEndian.Uint16(wav[22:]), test.ShouldEqual, 1)
+		test.That(t, binary.LittleEndian.Uint32(wav[24:]), test.ShouldEqual, analysisSampleRate)
+		// clips last 5 seconds by default
+		test.That(t, binary.LittleEndian.Uint32(wav[40:]), test.ShouldEqual, 5*2*analysisSampleRate)
+		test.That(t, wav, test.ShouldHaveLength, 44+5*2*analysisSampleRate)
+	})
+
+	t.Run("triggered", func(t *testing.T) {
+		buf := tu.NewMockBuffer(t)
+		audioIn := newAudioIn(tone(700, 0.5, 1), 1)
+		params := methodParams(t, map[string]interface{}{"duration_seconds": 1, "trigger_rms_dbfs": -20, "trigger_peak_dbfs": -3})
+		col, err := audioin.NewGetAudioCollector(audioIn, newParams(buf, params))
+		test.That(t, err, test.ShouldBeNil)
+		defer col.Close()
+		defer buf.Close()
+		col.Collect()
+		mockClock.Add(5 * time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		writes := nextWrites(ctx, t, buf)
+		test.That(t, writes, test.ShouldHaveLength, 1)
+		classifications := writes[0].GetMetadata().GetAnnotations().GetClassifications()
+		test.That(t, classifications, test.ShouldHaveLength, 1)
+		test.That(t, classifications[0].GetLabel(), test.ShouldEqual, "rms")
+		test.That(t, writes[0].GetBinary(), test.ShouldHaveLength, 44+2*analysisSampleRate)
+	})
+
+	t.Run("not triggered", func(t *testing.T) {
+		buf := tu.NewMockBuffer(t)
+		audioIn := newAudioIn(tone(700, 0.001, 1), 1)
+		params := methodParams(t, map[string]interface{}{"trigger_rms_dbfs": -20, "trigger_voice": true})
+		col, err := audioin.NewGetAudioCollector(audioIn, newParams(buf, params))
+		test.That(t, err, test.ShouldBeNil)
+		defer col.Close()
+		defer buf.Close()
+		col.Collect()
+		mockClock.Add(5 * time.Second)
+
+		select {
+		case <-buf.Writes:
+			t.Fatal("quiet audio was captured")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		buf := tu.NewMockBuffer(t)
+		defer buf.Close()
+		params := methodParams(t, map[string]interface{}{"trigger_voice": 1})
+		_, err := audioin.NewGetAudioCollector(newAudioIn(nil, 1), newParams(buf, params))
+		test.That(t, err, test.ShouldBeError, "trigger_voice param must be a boolean")
+
+		// every capture records its clip, which must be done before the next capture
+		params = methodParams(t, map[string]interface{}{"duration_seconds": 6})
+		_, err = audioin.NewGetAudioCollector(newAudioIn(nil, 1), newParams(buf, params))
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "capture_frequency_hz * duration_seconds")
+		params = methodParams(t, map[string]interface{}{"window_seconds": 6})
+		_, err = audioin.NewAudioLevelsCollector(newAudioIn(nil, 1), newParams(buf, params))
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
+
+func TestDoCommandCollector(t *testing.T) {
+	datatu.TestDoCommandCollector(t, datatu.DoCommandTestConfig{
+		ComponentName:   componentName,
+		CaptureInterval: captureInterval,
+		DoCommandMap:    doCommandMap,
+		Collector:       audioin.NewDoCommandCollector,
+		ResourceFactory: func() interface{} { return newAudioIn(nil, 1) },
+	})
+}
+
+// newAudioIn returns an audio input streaming the samples in chunks of 16 bit PCM audio, looping them for the duration
+// asked for.
+func newAudioIn(samples []float32, channels int) audioin.AudioIn {
+	a := inject.NewAudioIn(componentName)
+	a.GetAudioFunc = func(ctx context.Context, codec string, durationSeconds float32, previousTimestampNs int64,
+		extra map[string]interface{},
+	) (chan *audioin.AudioChunk, error) {
+		info := &utils.AudioInfo{Codec: codec, SampleRateHz: analysisSampleRate, NumChannels: int32(channels)}
+		total := int(durationSeconds*analysisSampleRate) * channels
+		ch := make(chan *audioin.AudioChunk, total/len(samples)+1)
+		for sent := 0; sent < total; sent += len(samples) {
+			data, err := utils.EncodePCM(samples[:min(len(samples), total-sent)], codec)
+			if err != nil {
+				return nil, err
+			}
+			ch <- &audioin.AudioChunk{AudioData: data, AudioInfo: info}
+		}
+		close(ch)
+		return ch, nil
+	}
+	a.DoFunc = func(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+		return doCommandMap, nil
+	}
+	return a
+}
+
+func methodParams(t *testing.T, params map[string]interface{}) map[string]*anypb.Any {
+	t.Helper()
+	methodParams, err := protoutils.ConvertMapToProtoAny(params)
+	test.That(t, err, test.ShouldBeNil)
+	return methodParams
+}
+
+func nextWrites(ctx context.Context, t *testing.T, buf *tu.MockBuffer) []*datasyncpb.SensorData {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		t.Fatal("timeout")
+		return nil
+	case writes := <-buf.Writes:
+		return writes
+	}
+}
@@ -0,0 +1,132 @@
+// Package fake implements a fake audio input generating a tone. The tone is streamed as PCM, and as Opus on builds with
+// cgo when it is sampled at a rate Opus supports, like the default 48kHz; other codecs are not supported.
+package fake
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("fake")
+
+const (
+	defaultToneHz       = 440
+	defaultSampleRateHz = 48000
+	defaultAmplitude    = 0.5
+)
+
+// Config is the config of a fake audio input, generating a sine wave of the given frequency and amplitude, between 0
+// and 1, on all channels.
+type Config struct {
+	ToneHz       float64 `json:"tone_hz,omitempty"`
+	Amplitude    float64 `json:"amplitude,omitempty"`
+	SampleRateHz int     `json:"sample_rate_hz,omitempty"`
+	NumChannels  int     `json:"num_channels,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, []string, error) {
+	if conf.ToneHz < 0 || conf.SampleRateHz < 0 || conf.NumChannels < 0 {
+		return nil, nil, resource.NewConfigValidationError(path,
+			errors.New("tone_hz, sample_rate_hz and num_channels cannot be negative"))
+	}
+	if conf.Amplitude < 0 || conf.Amplitude > 1 {
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("amplitude must be between 0 and 1"))
+	}
+	if sampleRate := conf.SampleRateHz; sampleRate > 0 && conf.ToneHz > float64(sampleRate)/2 {
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("tone_hz must be below half of sample_rate_hz"))
+	}
+	return nil, nil, nil
+}
+
+func init() {
+	resource.RegisterComponent(
+		audioin.API,
+		model,
+		resource.Registration[audioin.AudioIn, *Config]{Constructor: newAudioIn})
+}
+
+func newAudioIn(_ context.Context, _ resource.Dependencies, conf resource.Config, logger logging.Logger) (audioin.AudioIn, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewAudioIn(conf.ResourceName(), newConf, logger), nil
+}
+
+// NewAudioIn returns a fake audio input generating a continuous tone since its creation.
+func NewAudioIn(name resource.Name, conf *Config, logger logging.Logger) audioin.AudioIn {
+	in := &audioIn{
+		Named:   name.AsNamed(),
+		toneHz:  conf.ToneHz,
+		amp:     conf.Amplitude,
+		workers: goutils.NewBackgroundStoppableWorkers(),
+	}
+	if in.toneHz == 0 {
+		in.toneHz = defaultToneHz
+	}
+	if in.amp == 0 {
+		in.amp = defaultAmplitude
+	}
+	in.source = audioin.SampleSource{
+		SampleRateHz: int32(conf.SampleRateHz),
+		NumChannels:  int32(conf.NumChannels),
+		Start:        time.Now(),
+		Read:         in.read,
+		Logger:       logger,
+	}
+	if in.source.SampleRateHz == 0 {
+		in.source.SampleRateHz = defaultSampleRateHz
+	}
+	if in.source.NumChannels == 0 {
+		in.source.NumChannels = 1
+	}
+	return in
+}
+
+type audioIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+	toneHz  float64
+	amp     float64
+	source  audioin.SampleSource
+	workers *goutils.StoppableWorkers
+}
+
+func (in *audioIn) read(frame int64, samples []float32) (int, error) {
+	channels := int(in.source.NumChannels)
+	step := 2 * math.Pi * in.toneHz / float64(in.source.SampleRateHz)
+	for i := 0; i < len(samples)/channels; i++ {
+		// the phase is kept small as precision is lost on large ones
+		phase := math.Mod(float64(frame+int64(i))*step, 2*math.Pi)
+		v := float32(in.amp * math.Sin(phase))
+		for c := 0; c < channels; c++ {
+			samples[i*channels+c] = v
+		}
+	}
+	return len(samples), nil
+}
+
+func (in *audioIn) GetAudio(ctx context.Context, codec string, durationSeconds float32, previousTimestampNs int64,
+	extra map[string]interface{},
+) (chan *audioin.AudioChunk, error) {
+	return in.source.Stream(ctx, in.workers, codec, durationSeconds, previousTimestampNs)
+}
+
+func (in *audioIn) Properties(ctx context.Context, extra map[string]interface{}) (utils.Properties, error) {
+	return in.source.Properties(), nil
+}
+
+func (in *audioIn) Close(ctx context.Context) error {
+	in.workers.Stop()
+	return nil
+}
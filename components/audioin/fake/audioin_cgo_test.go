@@ -0,0 +1,59 @@
+//go:build !no_cgo
+
+package fake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/utils"
+)
+
+func TestOpus(t *testing.T) {
+	ctx := context.Background()
+	in := NewAudioIn(audioin.Named("tone"), &Config{SampleRateHz: 16000, NumChannels: 2}, logging.NewTestLogger(t))
+	defer func() {
+		test.That(t, in.Close(ctx), test.ShouldBeNil)
+	}()
+	props, err := in.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportedCodecs, test.ShouldContain, utils.CodecOpus)
+
+	// every chunk is a single 20ms Opus frame, the last one padded with silence
+	ch, err := in.GetAudio(ctx, utils.CodecOpus, 0.05, 0, nil)
+	test.That(t, err, test.ShouldBeNil)
+	chunks := []*audioin.AudioChunk{}
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	test.That(t, len(chunks), test.ShouldEqual, 3)
+	for i, chunk := range chunks {
+		test.That(t, chunk.AudioInfo.Codec, test.ShouldEqual, utils.CodecOpus)
+		test.That(t, chunk.Sequence, test.ShouldEqual, i)
+		test.That(t, len(chunk.AudioData), test.ShouldBeGreaterThan, 1)
+		// the TOC byte of the packet gives its channels and the duration of its frame
+		test.That(t, chunk.AudioData[0]&0x4, test.ShouldEqual, 0x4)
+		if i < 2 {
+			test.That(t, chunk.EndTimestampNanoseconds-chunk.StartTimestampNanoseconds, test.ShouldEqual, 20*time.Millisecond)
+		}
+	}
+	test.That(t, chunks[2].EndTimestampNanoseconds-chunks[2].StartTimestampNanoseconds, test.ShouldEqual, 10*time.Millisecond)
+
+	// Opus does not encode audio sampled at 44.1kHz
+	in44 := NewAudioIn(audioin.Named("tone"), &Config{SampleRateHz: 44100}, logging.NewTestLogger(t))
+	defer func() {
+		test.That(t, in44.Close(ctx), test.ShouldBeNil)
+	}()
+	props, err = in44.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportedCodecs, test.ShouldResemble, audioin.SampleCodecs)
+	_, err = in44.GetAudio(ctx, utils.CodecOpus, 0.05, 0, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = in44.GetAudio(ctx, utils.CodecMP3, 0.05, 0, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
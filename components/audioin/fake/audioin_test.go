@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/utils"
+)
+
+func TestAudioIn(t *testing.T) {
+	ctx := context.Background()
+	_, _, err := (&Config{Amplitude: 2}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{ToneHz: 5000, SampleRateHz: 8000}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	in := NewAudioIn(audioin.Named("tone"), &Config{ToneHz: 1000, SampleRateHz: 8000, NumChannels: 2}, logging.NewTestLogger(t))
+	defer func() {
+		test.That(t, in.Close(ctx), test.ShouldBeNil)
+	}()
+	props, err := in.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportedCodecs[:len(audioin.SampleCodecs)], test.ShouldResemble, audioin.SampleCodecs)
+	test.That(t, props.SampleRateHz, test.ShouldEqual, 8000)
+
+	ch, err := in.GetAudio(ctx, utils.CodecPCM32Float, 0.1, 0, nil)
+	test.That(t, err, test.ShouldBeNil)
+	chunk := <-ch
+	_, ok := <-ch
+	test.That(t, ok, test.ShouldBeFalse)
+	samples, err := utils.DecodePCM(chunk.AudioData, utils.CodecPCM32Float)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(samples), test.ShouldEqual, 2*800)
+
+	// a tone of 1kHz sampled at 8kHz repeats every 8 samples, on both channels, with the default amplitude
+	var peak float64
+	for i := 0; i < len(samples)/2; i++ {
+		test.That(t, samples[2*i], test.ShouldEqual, samples[2*i+1])
+		if i >= 8 {
+			test.That(t, samples[2*i], test.ShouldAlmostEqual, samples[2*(i-8)], 1e-4)
+		}
+		peak = math.Max(peak, float64(samples[2*i]))
+	}
+	test.That(t, peak, test.ShouldAlmostEqual, defaultAmplitude, 1e-3)
+
+	// the tone continues across streams
+	ch, err = in.GetAudio(ctx, utils.CodecPCM32Float, 0.1, chunk.EndTimestampNanoseconds, nil)
+	test.That(t, err, test.ShouldBeNil)
+	next := <-ch
+	test.That(t, next.StartTimestampNanoseconds, test.ShouldEqual, chunk.EndTimestampNanoseconds)
+	nextSamples, err := utils.DecodePCM(next.AudioData, utils.CodecPCM32Float)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, nextSamples[0], test.ShouldAlmostEqual, samples[len(samples)-16], 1e-4)
+}
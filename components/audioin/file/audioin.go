@@ -0,0 +1,168 @@
+// Package file implements an audio input playing a WAV or FLAC file. The file is decoded and streamed as PCM, or as Opus
+// on builds with cgo for mono and stereo files at 8, 12, 16, 24 or 48kHz. Files are not streamed in their own codec, so
+// FLAC, like AAC and MP3, is not a supported codec.
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/go-audio/wav"
+	"github.com/pkg/errors"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("file")
+
+// Config is the config of a file audio input, which plays the audio file at path once from when it is created, or in a
+// loop.
+type Config struct {
+	Path string `json:"path"`
+	Loop bool   `json:"loop,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, []string, error) {
+	if conf.Path == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "path")
+	}
+	return nil, nil, nil
+}
+
+func init() {
+	resource.RegisterComponent(
+		audioin.API,
+		model,
+		resource.Registration[audioin.AudioIn, *Config]{Constructor: newAudioIn})
+}
+
+type audioIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+	samples  []float32
+	channels int
+	loop     bool
+	source   audioin.SampleSource
+	workers  *goutils.StoppableWorkers
+}
+
+func newAudioIn(_ context.Context, _ resource.Dependencies, conf resource.Config, logger logging.Logger) (audioin.AudioIn, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(newConf.Path)
+	if err != nil {
+		return nil, err
+	}
+	var sampleRate, channels int
+	var samples []float32
+	if bytes.HasPrefix(data, flacMagic) {
+		stream, err := decodeFLAC(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decode %s", newConf.Path)
+		}
+		sampleRate, channels, samples = stream.sampleRate, stream.channels, stream.samples
+	} else {
+		sampleRate, channels, samples, err = decodeWAV(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decode %s", newConf.Path)
+		}
+	}
+	if len(samples) < channels {
+		return nil, errors.Errorf("%s has no audio", newConf.Path)
+	}
+
+	in := &audioIn{
+		Named:    conf.ResourceName().AsNamed(),
+		samples:  samples,
+		channels: channels,
+		loop:     newConf.Loop,
+		workers:  goutils.NewBackgroundStoppableWorkers(),
+	}
+	in.source = audioin.SampleSource{
+		SampleRateHz: int32(sampleRate),
+		NumChannels:  int32(channels),
+		Start:        time.Now(),
+		Read:         in.read,
+		Logger:       logger,
+	}
+	return in, nil
+}
+
+// decodeWAV decodes the PCM or float samples of a WAV file.
+func decodeWAV(data []byte) (int, int, []float32, error) {
+	dec := wav.NewDecoder(bytes.NewReader(data))
+	if !dec.IsValidFile() {
+		return 0, 0, nil, errors.New("not a WAV or FLAC file")
+	}
+	buf, err := dec.FullPCMBuffer()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	samples := make([]float32, len(buf.Data))
+	bitDepth := int(dec.BitDepth)
+	for i, v := range buf.Data {
+		switch {
+		case dec.WavAudioFormat == 3 && bitDepth == 32:
+			samples[i] = math.Float32frombits(uint32(int32(v)))
+		case dec.WavAudioFormat == 3:
+			return 0, 0, nil, errors.Errorf("float WAV files of %d bits are not supported", bitDepth)
+		case bitDepth == 8:
+			// 8 bit samples are unsigned
+			samples[i] = float32(v-128) / 128
+		default:
+			samples[i] = float32(float64(v) / float64(int64(1)<<(bitDepth-1)))
+		}
+	}
+	return int(dec.SampleRate), int(dec.NumChans), samples, nil
+}
+
+// frames returns the number of frames of the file.
+func (in *audioIn) frames() int64 {
+	return int64(len(in.samples) / in.channels)
+}
+
+func (in *audioIn) read(frame int64, samples []float32) (int, error) {
+	frames := in.frames()
+	n := 0
+	for n < len(samples) {
+		if frame >= frames && !in.loop {
+			return n, io.EOF
+		}
+		i := int(frame%frames) * in.channels
+		copied := copy(samples[n:], in.samples[i:])
+		n += copied
+		frame += int64(copied / in.channels)
+	}
+	return n, nil
+}
+
+func (in *audioIn) GetAudio(ctx context.Context, codec string, durationSeconds float32, previousTimestampNs int64,
+	extra map[string]interface{},
+) (chan *audioin.AudioChunk, error) {
+	// without a previous chunk, the stream starts at the current time, at which the file may have ended
+	if !in.loop && previousTimestampNs == 0 &&
+		time.Since(in.source.Start) >= time.Duration(in.frames())*time.Second/time.Duration(in.source.SampleRateHz) {
+		return nil, errors.New("the audio file ended")
+	}
+	return in.source.Stream(ctx, in.workers, codec, durationSeconds, previousTimestampNs)
+}
+
+func (in *audioIn) Properties(ctx context.Context, extra map[string]interface{}) (utils.Properties, error) {
+	return in.source.Properties(), nil
+}
+
+func (in *audioIn) Close(ctx context.Context) error {
+	in.workers.Stop()
+	return nil
+}
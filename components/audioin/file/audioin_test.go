@@ -0,0 +1,304 @@
+package file
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+const (
+	testSampleRate = 8000
+	testBlockSize  = 400
+)
+
+// testSamples returns the samples of a stereo tone of 16 bits, with different tones on each channel.
+func testSamples(frames int) [2][]int64 {
+	var samples [2][]int64
+	for i := 0; i < frames; i++ {
+		samples[0] = append(samples[0], int64(10000*math.Sin(float64(i)*2*math.Pi*440/testSampleRate)))
+		samples[1] = append(samples[1], int64(5000*math.Sin(float64(i)*2*math.Pi*300/testSampleRate)))
+	}
+	return samples
+}
+
+// bitWriter writes big endian bits.
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (7 - w.bits%8)
+		w.bits++
+	}
+}
+
+func (w *bitWriter) writeSigned(v int64, n int) {
+	w.write(uint64(v)&(1<<n-1), n)
+}
+
+func (w *bitWriter) align() {
+	w.bits = (w.bits + 7) / 8 * 8
+}
+
+// writeResidual writes the residual of the samples after the warm up samples with a single Rice partition.
+func (w *bitWriter) writeResidual(residual []int64, param int) {
+	w.write(0, 2) // 4 bit Rice parameters
+	w.write(0, 4) // a single partition
+	w.write(uint64(param), 4)
+	for _, v := range residual {
+		u := uint64(v<<1 ^ v>>63)
+		w.write(1, int(u>>param)+1)
+		w.write(u&(1<<param-1), param)
+	}
+}
+
+// encodeFLAC encodes the samples as a FLAC file with a frame for each kind of subframe and channel assignment.
+func encodeFLAC(samples [2][]int64) []byte {
+	w := &bitWriter{}
+	for _, b := range flacMagic {
+		w.write(uint64(b), 8)
+	}
+	// an application block, which is skipped, then the stream info
+	w.write(0, 1)
+	w.write(2, 7)
+	w.write(4, 24)
+	w.write(0, 32)
+	w.write(1, 1)
+	w.write(0, 7)
+	w.write(34, 24)
+	w.write(testBlockSize, 16)
+	w.write(testBlockSize, 16)
+	w.write(0, 48)
+	w.write(testSampleRate, 20)
+	w.write(1, 3)  // 2 channels
+	w.write(15, 5) // 16 bits
+	w.write(uint64(len(samples[0])), 36)
+	w.write(0, 64)
+	w.write(0, 64)
+
+	for frame := 0; frame*testBlockSize < len(samples[0]); frame++ {
+		left := samples[0][frame*testBlockSize : (frame+1)*testBlockSize]
+		right := samples[1][frame*testBlockSize : (frame+1)*testBlockSize]
+		start := len(w.data)
+		assignment := []int{1, 8, 9, 10, 1}[frame%5]
+		w.write(0x3ffe, 14)
+		w.write(0, 2)
+		w.write(7, 4) // 16 bit block size
+		w.write(0, 4) // sample rate of the stream info
+		w.write(uint64(assignment), 4)
+		w.write(0, 4) // sample size of the stream info
+		w.write(uint64(frame), 8)
+		w.write(testBlockSize-1, 16)
+		w.write(uint64(crc8(w.data[start:])), 8)
+
+		channels := [2][]int64{left, right}
+		side := make([]int64, testBlockSize)
+		for i := range side {
+			side[i] = left[i] - right[i]
+		}
+		switch assignment {
+		case 8:
+			channels[1] = side
+		case 9:
+			channels[0] = side
+		case 10:
+			mid := make([]int64, testBlockSize)
+			for i := range mid {
+				mid[i] = (left[i] + right[i]) >> 1
+			}
+			channels = [2][]int64{mid, side}
+		}
+		for c, ch := range channels {
+			bits := 16
+			if (assignment == 8 || assignment == 10) && c == 1 || assignment == 9 && c == 0 {
+				bits++
+			}
+			switch kind := (frame + c) % 4; {
+			case frame == 4 && c == 1:
+				// silence, as a constant with wasted bits
+				w.write(0, 1)
+				w.write(0, 6)
+				w.write(1, 1)
+				w.write(1, 2)
+				w.writeSigned(0, bits-2)
+			case kind == 0:
+				w.write(1<<1, 8)
+				for _, v := range ch {
+					w.writeSigned(v, bits)
+				}
+			case kind == 1 || kind == 2:
+				// fixed predictors of order 1 and 2
+				order := kind
+				w.write(uint64(8+order)<<1, 8)
+				for _, v := range ch[:order] {
+					w.writeSigned(v, bits)
+				}
+				residual := make([]int64, 0, len(ch))
+				for i := order; i < len(ch); i++ {
+					prediction := ch[i-1]
+					if order == 2 {
+						prediction = 2*ch[i-1] - ch[i-2]
+					}
+					residual = append(residual, ch[i]-prediction)
+				}
+				w.writeResidual(residual, 8)
+			default:
+				// LPC of order 2 with a shift
+				w.write(uint64(32+1)<<1, 8)
+				for _, v := range ch[:2] {
+					w.writeSigned(v, bits)
+				}
+				w.write(7, 4) // 8 bits of precision
+				w.writeSigned(1, 5)
+				w.writeSigned(3, 8)
+				w.writeSigned(-1, 8)
+				residual := make([]int64, 0, len(ch))
+				for i := 2; i < len(ch); i++ {
+					residual = append(residual, ch[i]-(3*ch[i-1]-ch[i-2])>>1)
+				}
+				w.writeResidual(residual, 8)
+			}
+		}
+		w.align()
+		crc := crc16(w.data[start:])
+		w.write(uint64(crc), 16)
+	}
+	return w.data
+}
+
+func TestDecodeFLAC(t *testing.T) {
+	samples := testSamples(5 * testBlockSize)
+	// the silent frame
+	for i := 4 * testBlockSize; i < 5*testBlockSize; i++ {
+		samples[1][i] = 0
+	}
+	data := encodeFLAC(samples)
+	stream, err := decodeFLAC(data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stream.sampleRate, test.ShouldEqual, testSampleRate)
+	test.That(t, stream.channels, test.ShouldEqual, 2)
+	test.That(t, len(stream.samples), test.ShouldEqual, 2*len(samples[0]))
+	for i := range samples[0] {
+		for c := 0; c < 2; c++ {
+			test.That(t, stream.samples[2*i+c], test.ShouldEqual, float32(samples[c][i])/(1<<15))
+		}
+	}
+
+	data[len(data)-5]++
+	_, err = decodeFLAC(data)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = decodeFLAC(data[:len(data)/2])
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func writeWAV(t *testing.T, path string, samples [2][]int64) {
+	t.Helper()
+	f, err := os.Create(path)
+	test.That(t, err, test.ShouldBeNil)
+	buf := &audio.IntBuffer{Format: &audio.Format{NumChannels: 2, SampleRate: testSampleRate}, SourceBitDepth: 16}
+	for i := range samples[0] {
+		buf.Data = append(buf.Data, int(samples[0][i]), int(samples[1][i]))
+	}
+	enc := wav.NewEncoder(f, testSampleRate, 16, 2, 1)
+	test.That(t, enc.Write(buf), test.ShouldBeNil)
+	test.That(t, enc.Close(), test.ShouldBeNil)
+	test.That(t, f.Close(), test.ShouldBeNil)
+}
+
+func TestAudioIn(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	dir := t.TempDir()
+	// a second of audio
+	samples := testSamples(testSampleRate)
+	wavPath := filepath.Join(dir, "audio.wav")
+	writeWAV(t, wavPath, samples)
+	flacPath := filepath.Join(dir, "audio.flac")
+	test.That(t, os.WriteFile(flacPath, encodeFLAC(testSamples(5*testBlockSize)), 0o600), test.ShouldBeNil)
+
+	_, _, err := (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	newAudioIn := func(conf *Config) (audioin.AudioIn, error) {
+		return newAudioIn(ctx, nil, resource.Config{Name: "audio", ConvertedAttributes: conf}, logger)
+	}
+	_, err = newAudioIn(&Config{Path: filepath.Join(dir, "missing.wav")})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	in, err := newAudioIn(&Config{Path: wavPath})
+	test.That(t, err, test.ShouldBeNil)
+	props, err := in.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SampleRateHz, test.ShouldEqual, testSampleRate)
+	test.That(t, props.NumChannels, test.ShouldEqual, 2)
+	_, err = in.GetAudio(ctx, utils.CodecMP3, 0, 0, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// the audio is played from when the audio input was created, and streamed in real time
+	start := time.Now()
+	ch, err := in.GetAudio(ctx, utils.CodecPCM16, 0.2, 0, nil)
+	test.That(t, err, test.ShouldBeNil)
+	var chunks []*audioin.AudioChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 150*time.Millisecond)
+	test.That(t, len(chunks), test.ShouldEqual, 2)
+	test.That(t, chunks[0].AudioInfo.Codec, test.ShouldEqual, utils.CodecPCM16)
+	test.That(t, len(chunks[0].AudioData), test.ShouldEqual, 2*2*testSampleRate/10)
+	test.That(t, chunks[1].Sequence, test.ShouldEqual, 1)
+	test.That(t, chunks[1].StartTimestampNanoseconds, test.ShouldEqual, chunks[0].EndTimestampNanoseconds)
+	test.That(t, chunks[1].EndTimestampNanoseconds-chunks[0].StartTimestampNanoseconds, test.ShouldEqual, int64(200*time.Millisecond))
+
+	// the stream resumes after the previous chunk, and ends with the file
+	ch, err = in.GetAudio(ctx, utils.CodecPCM32Float, 0, chunks[1].EndTimestampNanoseconds, nil)
+	test.That(t, err, test.ShouldBeNil)
+	var frames int
+	first := true
+	for chunk := range ch {
+		decoded, err := utils.DecodePCM(chunk.AudioData, utils.CodecPCM32Float)
+		test.That(t, err, test.ShouldBeNil)
+		if first {
+			test.That(t, chunk.StartTimestampNanoseconds, test.ShouldEqual, chunks[1].EndTimestampNanoseconds)
+			offset := time.Unix(0, chunk.StartTimestampNanoseconds).Sub(in.(*audioIn).source.Start)
+			frame := int(math.Round(offset.Seconds() * testSampleRate))
+			test.That(t, decoded[0], test.ShouldAlmostEqual, float32(samples[0][frame])/(1<<15), 1e-4)
+			first = false
+		}
+		frames += len(decoded) / 2
+	}
+	test.That(t, frames, test.ShouldEqual, testSampleRate-int(math.Round(
+		time.Unix(0, chunks[1].EndTimestampNanoseconds).Sub(in.(*audioIn).source.Start).Seconds()*testSampleRate)))
+	_, err = in.GetAudio(ctx, "", 0, 0, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, in.Close(ctx), test.ShouldBeNil)
+
+	// FLAC files are played in a loop
+	in, err = newAudioIn(&Config{Path: flacPath, Loop: true})
+	test.That(t, err, test.ShouldBeNil)
+	ch, err = in.GetAudio(ctx, "", 0, time.Now().Add(-time.Minute).UnixNano(), nil)
+	test.That(t, err, test.ShouldBeNil)
+	chunk := <-ch
+	test.That(t, chunk.AudioInfo.Codec, test.ShouldEqual, utils.CodecPCM16)
+	test.That(t, len(chunk.AudioData), test.ShouldEqual, 2*2*testSampleRate/10)
+	// closing the audio input ends the streams
+	test.That(t, in.Close(ctx), test.ShouldBeNil)
+	for range ch {
+	}
+}
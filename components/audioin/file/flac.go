@@ -0,0 +1,388 @@
+package file
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// flacMagic starts FLAC files.
+var flacMagic = []byte("fLaC")
+
+// flacStream is the audio of a FLAC file.
+type flacStream struct {
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+	// samples are interleaved samples between -1 and 1
+	samples []float32
+}
+
+// decodeFLAC decodes a FLAC file, as specified in https://xiph.org/flac/format.html. Seek tables and metadata other
+// than the stream info are skipped.
+func decodeFLAC(data []byte) (*flacStream, error) {
+	if !bytes.HasPrefix(data, flacMagic) {
+		return nil, errors.New("not a FLAC file")
+	}
+	r := &bitReader{data: data, pos: 8 * len(flacMagic)}
+	var stream flacStream
+	var totalSamples uint64
+	for last := false; !last; {
+		last = r.read(1) == 1
+		blockType := r.read(7)
+		length := int(r.read(24))
+		if r.err != nil {
+			return nil, r.err
+		}
+		if blockType != 0 {
+			r.skipBytes(length)
+			continue
+		}
+		// the stream info
+		r.read(16 + 16 + 24 + 24) // block and frame sizes
+		stream.sampleRate = int(r.read(20))
+		stream.channels = int(r.read(3)) + 1
+		stream.bitsPerSample = int(r.read(5)) + 1
+		totalSamples = r.read(36)
+		r.skipBytes(16) // MD5 signature
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if stream.sampleRate == 0 {
+		return nil, errors.New("FLAC file has no stream info")
+	}
+	stream.samples = make([]float32, 0, int(totalSamples)*stream.channels)
+
+	channels := make([][]int64, stream.channels)
+	for !r.eof() {
+		n, err := r.frame(&stream, channels)
+		if err != nil {
+			return nil, err
+		}
+		scale := float32(int64(1) << (stream.bitsPerSample - 1))
+		for i := 0; i < n; i++ {
+			for c := range channels {
+				stream.samples = append(stream.samples, float32(channels[c][i])/scale)
+			}
+		}
+	}
+	return &stream, nil
+}
+
+// bitReader reads big endian bits from the data.
+type bitReader struct {
+	data []byte
+	pos  int // in bits
+	err  error
+}
+
+func (r *bitReader) eof() bool {
+	return r.err != nil || r.pos >= 8*len(r.data)
+}
+
+func (r *bitReader) read(n int) uint64 {
+	if r.pos+n > 8*len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	var v uint64
+	for n > 0 {
+		b := r.data[r.pos/8]
+		offset := r.pos % 8
+		take := min(8-offset, n)
+		v = v<<take | uint64(b>>(8-offset-take))&(1<<take-1)
+		r.pos += take
+		n -= take
+	}
+	return v
+}
+
+// readSigned reads a two's complement number of n bits.
+func (r *bitReader) readSigned(n int) int64 {
+	if n == 0 {
+		return 0
+	}
+	v := int64(r.read(n))
+	if v&(1<<(n-1)) != 0 {
+		v -= 1 << n
+	}
+	return v
+}
+
+// readUnary reads the number of zeros before a one.
+func (r *bitReader) readUnary() uint64 {
+	var n uint64
+	for r.read(1) == 0 && r.err == nil {
+		n++
+	}
+	return n
+}
+
+func (r *bitReader) skipBytes(n int) {
+	if r.pos/8+n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return
+	}
+	r.pos += 8 * n
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+// frame decodes the samples of the next frame into channels, returning the number of samples per channel.
+func (r *bitReader) frame(stream *flacStream, channels [][]int64) (int, error) {
+	start := r.pos / 8
+	if sync := r.read(15); sync != 0x3ffe<<1 {
+		return 0, errors.Errorf("invalid FLAC frame sync code at byte %d", start)
+	}
+	r.read(1) // blocking strategy
+	blockSizeCode := r.read(4)
+	sampleRateCode := r.read(4)
+	assignment := int(r.read(4))
+	sampleSizeCode := r.read(3)
+	r.read(1)
+
+	// the UTF-8 like coded frame or sample number
+	extra := 0
+	for first := r.read(8); first&0x80 != 0 && extra < 7; first <<= 1 {
+		extra++
+	}
+	if extra > 0 {
+		r.skipBytes(extra - 1)
+	}
+
+	var blockSize int
+	switch {
+	case blockSizeCode == 1:
+		blockSize = 192
+	case blockSizeCode >= 2 && blockSizeCode <= 5:
+		blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		blockSize = int(r.read(8)) + 1
+	case blockSizeCode == 7:
+		blockSize = int(r.read(16)) + 1
+	case blockSizeCode >= 8:
+		blockSize = 256 << (blockSizeCode - 8)
+	default:
+		return 0, errors.New("reserved FLAC block size")
+	}
+	switch sampleRateCode {
+	case 12:
+		r.read(8)
+	case 13, 14:
+		r.read(16)
+	case 15:
+		return 0, errors.New("invalid FLAC sample rate")
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	if crc := crc8(r.data[start : r.pos/8]); byte(r.read(8)) != crc {
+		return 0, errors.Errorf("invalid CRC of the FLAC frame header at byte %d", start)
+	}
+
+	bitsPerSample := stream.bitsPerSample
+	if sampleSizeCode != 0 {
+		bitsPerSample = []int{0, 8, 12, 0, 16, 20, 24, 32}[sampleSizeCode]
+		if bitsPerSample == 0 || bitsPerSample != stream.bitsPerSample {
+			return 0, errors.New("FLAC frames with a sample size other than the stream's are not supported")
+		}
+	}
+	numChannels := assignment + 1
+	if assignment > 7 {
+		numChannels = 2
+	}
+	if assignment > 10 || numChannels != stream.channels {
+		return 0, errors.Errorf("invalid FLAC channel assignment %d", assignment)
+	}
+
+	for c := range channels {
+		if cap(channels[c]) < blockSize {
+			channels[c] = make([]int64, blockSize)
+		}
+		channels[c] = channels[c][:blockSize]
+		bits := bitsPerSample
+		// side channels have an extra bit
+		if (assignment == 8 || assignment == 10) && c == 1 || assignment == 9 && c == 0 {
+			bits++
+		}
+		if err := r.subframe(channels[c], bits); err != nil {
+			return 0, err
+		}
+	}
+	r.align()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if crc := crc16(r.data[start : r.pos/8]); uint16(r.read(16)) != crc {
+		return 0, errors.Errorf("invalid CRC of the FLAC frame at byte %d", start)
+	}
+
+	left, right := channels[0], channels[min(1, len(channels)-1)]
+	for i := 0; i < blockSize; i++ {
+		switch assignment {
+		case 8: // left and side
+			right[i] = left[i] - right[i]
+		case 9: // side and right
+			left[i] += right[i]
+		case 10: // mid and side
+			mid := left[i]<<1 | right[i]&1
+			left[i], right[i] = (mid+right[i])>>1, (mid-right[i])>>1
+		}
+	}
+	return blockSize, r.err
+}
+
+// fixedCoefficients are the coefficients of the fixed predictors of each order.
+var fixedCoefficients = [][]int64{{}, {1}, {2, -1}, {3, -3, 1}, {4, -6, 4, -1}}
+
+func (r *bitReader) subframe(samples []int64, bits int) error {
+	r.read(1)
+	typ := r.read(6)
+	wasted := 0
+	if r.read(1) == 1 {
+		wasted = int(r.readUnary()) + 1
+		bits -= wasted
+	}
+
+	switch {
+	case typ == 0: // constant
+		v := r.readSigned(bits)
+		for i := range samples {
+			samples[i] = v
+		}
+	case typ == 1: // verbatim
+		for i := range samples {
+			samples[i] = r.readSigned(bits)
+		}
+	case typ >= 8 && typ <= 12:
+		order := int(typ - 8)
+		if err := r.predicted(samples, bits, fixedCoefficients[order], 0); err != nil {
+			return err
+		}
+	case typ >= 32:
+		order := int(typ-32) + 1
+		if order > len(samples) {
+			return errors.New("invalid FLAC LPC order")
+		}
+		for i := 0; i < order; i++ {
+			samples[i] = r.readSigned(bits)
+		}
+		precision := int(r.read(4)) + 1
+		if precision == 16 {
+			return errors.New("invalid FLAC LPC precision")
+		}
+		shift := r.readSigned(5)
+		if shift < 0 {
+			return errors.New("negative FLAC LPC shifts are not supported")
+		}
+		coeffs := make([]int64, order)
+		for i := range coeffs {
+			coeffs[i] = r.readSigned(precision)
+		}
+		if err := r.residual(samples, order); err != nil {
+			return err
+		}
+		predict(samples, coeffs, int(shift))
+	default:
+		return errors.Errorf("reserved FLAC subframe type %d", typ)
+	}
+	for i := range samples {
+		samples[i] <<= wasted
+	}
+	return r.err
+}
+
+// predicted reads the warm up samples and residual of a subframe predicted with the coefficients.
+func (r *bitReader) predicted(samples []int64, bits int, coeffs []int64, shift int) error {
+	if len(coeffs) > len(samples) {
+		return errors.New("invalid FLAC predictor order")
+	}
+	for i := range coeffs {
+		samples[i] = r.readSigned(bits)
+	}
+	if err := r.residual(samples, len(coeffs)); err != nil {
+		return err
+	}
+	predict(samples, coeffs, shift)
+	return nil
+}
+
+// predict adds the predictions of the samples to their residuals, which follow the warm up samples.
+func predict(samples, coeffs []int64, shift int) {
+	for i := len(coeffs); i < len(samples); i++ {
+		var prediction int64
+		for j, c := range coeffs {
+			prediction += c * samples[i-1-j]
+		}
+		samples[i] += prediction >> shift
+	}
+}
+
+// residual reads the Rice coded residual of the samples after the warm up samples.
+func (r *bitReader) residual(samples []int64, order int) error {
+	method := r.read(2)
+	if method > 1 {
+		return errors.New("reserved FLAC residual coding method")
+	}
+	paramBits, escape := 4, uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+	partitionOrder := r.read(4)
+	partitions := 1 << partitionOrder
+	if len(samples)%partitions != 0 || len(samples)/partitions < order {
+		return errors.New("invalid FLAC partition order")
+	}
+	i := order
+	for p := 0; p < partitions; p++ {
+		end := (p + 1) * len(samples) / partitions
+		param := r.read(paramBits)
+		if param == escape {
+			bits := int(r.read(5))
+			for ; i < end; i++ {
+				samples[i] = r.readSigned(bits)
+			}
+			continue
+		}
+		for ; i < end && r.err == nil; i++ {
+			v := r.readUnary()<<param | r.read(int(param))
+			// zigzag decoding
+			samples[i] = int64(v>>1) ^ -int64(v&1)
+		}
+	}
+	return r.err
+}
+
+func crc8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
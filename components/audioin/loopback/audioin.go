@@ -0,0 +1,86 @@
+// Package loopback implements an audio input streaming the audio played by a loopback audio output. It streams in the
+// codecs of audioin.SampleSource, whatever codec the audio was played in.
+package loopback
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/components/audioout"
+	outloopback "go.viam.com/rdk/components/audioout/loopback"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("loopback")
+
+// Config is the config of a loopback audio input, which streams the audio played by the loopback audio output.
+type Config struct {
+	AudioOut string `json:"audio_out"`
+}
+
+// Validate ensures all parts of the config are valid and returns the audio output it depends on.
+func (conf *Config) Validate(path string) ([]string, []string, error) {
+	if conf.AudioOut == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "audio_out")
+	}
+	return []string{conf.AudioOut}, nil, nil
+}
+
+func init() {
+	resource.RegisterComponent(
+		audioin.API,
+		model,
+		resource.Registration[audioin.AudioIn, *Config]{Constructor: newAudioIn})
+}
+
+type audioIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+	source  audioin.SampleSource
+	workers *goutils.StoppableWorkers
+}
+
+func newAudioIn(_ context.Context, deps resource.Dependencies, conf resource.Config, _ logging.Logger) (audioin.AudioIn, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	out, err := audioout.FromProvider(deps, newConf.AudioOut)
+	if err != nil {
+		return nil, err
+	}
+	loopback, ok := out.(outloopback.Loopback)
+	if !ok {
+		return nil, errors.Errorf("audio output %q is not a loopback audio output", newConf.AudioOut)
+	}
+	return NewAudioIn(conf.ResourceName(), loopback), nil
+}
+
+// NewAudioIn returns an audio input streaming the audio played by the loopback audio output.
+func NewAudioIn(name resource.Name, out outloopback.Loopback) audioin.AudioIn {
+	return &audioIn{
+		Named:   name.AsNamed(),
+		source:  out.SampleSource(),
+		workers: goutils.NewBackgroundStoppableWorkers(),
+	}
+}
+
+func (in *audioIn) GetAudio(ctx context.Context, codec string, durationSeconds float32, previousTimestampNs int64,
+	extra map[string]interface{},
+) (chan *audioin.AudioChunk, error) {
+	return in.source.Stream(ctx, in.workers, codec, durationSeconds, previousTimestampNs)
+}
+
+func (in *audioIn) Properties(ctx context.Context, extra map[string]interface{}) (utils.Properties, error) {
+	return in.source.Properties(), nil
+}
+
+func (in *audioIn) Close(ctx context.Context) error {
+	in.workers.Stop()
+	return nil
+}
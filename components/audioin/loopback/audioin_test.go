@@ -0,0 +1,75 @@
+package loopback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioout"
+	outloopback "go.viam.com/rdk/components/audioout/loopback"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+func TestLoopback(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	deps, _, err := (&Config{AudioOut: "speaker"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"speaker"})
+
+	out := outloopback.NewLoopback(audioout.Named("speaker"), &outloopback.Config{SampleRateHz: 8000}, logger)
+	_, err = newAudioIn(ctx, resource.Dependencies{out.Name(): inject.NewAudioOut("speaker")},
+		resource.Config{Name: "mic", ConvertedAttributes: &Config{AudioOut: "speaker"}}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+	in, err := newAudioIn(ctx, resource.Dependencies{out.Name(): out},
+		resource.Config{Name: "mic", ConvertedAttributes: &Config{AudioOut: "speaker"}}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, in.Close(ctx), test.ShouldBeNil)
+	}()
+
+	info := &utils.AudioInfo{Codec: utils.CodecPCM16, SampleRateHz: 8000, NumChannels: 1}
+	test.That(t, out.Play(ctx, []byte{0, 0}, &utils.AudioInfo{Codec: utils.CodecPCM16, SampleRateHz: 16000, NumChannels: 1}, nil),
+		test.ShouldNotBeNil)
+	test.That(t, out.Play(ctx, []byte{0, 0}, &utils.AudioInfo{Codec: utils.CodecOpus, SampleRateHz: 8000, NumChannels: 1}, nil),
+		test.ShouldNotBeNil)
+
+	// the audio played is streamed between silences, as PCM of another format
+	ch, err := in.GetAudio(ctx, utils.CodecPCM32, 0.5, 0, nil)
+	test.That(t, err, test.ShouldBeNil)
+	time.Sleep(100 * time.Millisecond)
+	played := make([]float32, 800)
+	for i := range played {
+		played[i] = 0.5
+	}
+	data, err := utils.EncodePCM(played, utils.CodecPCM16)
+	test.That(t, err, test.ShouldBeNil)
+	start := time.Now()
+	test.That(t, out.Play(ctx, data, info, nil), test.ShouldBeNil)
+	// playing takes the duration of the audio
+	test.That(t, time.Since(start), test.ShouldBeGreaterThanOrEqualTo, 90*time.Millisecond)
+
+	var samples []float32
+	for chunk := range ch {
+		test.That(t, chunk.AudioInfo.Codec, test.ShouldEqual, utils.CodecPCM32)
+		decoded, err := utils.DecodePCM(chunk.AudioData, utils.CodecPCM32)
+		test.That(t, err, test.ShouldBeNil)
+		samples = append(samples, decoded...)
+	}
+	test.That(t, len(samples), test.ShouldEqual, 4000)
+	loud := 0
+	for _, s := range samples {
+		if s != 0 {
+			test.That(t, s, test.ShouldAlmostEqual, 0.5, 1e-3)
+			loud++
+		}
+	}
+	test.That(t, loud, test.ShouldEqual, 800)
+	test.That(t, samples[0], test.ShouldEqual, 0)
+	test.That(t, samples[len(samples)-1], test.ShouldEqual, 0)
+}
@@ -0,0 +1,9 @@
+// Package register registers all relevant audio inputs and also API specific functions
+package register
+
+import (
+	// for audio inputs.
+	_ "go.viam.com/rdk/components/audioin/fake"
+	_ "go.viam.com/rdk/components/audioin/file"
+	_ "go.viam.com/rdk/components/audioin/loopback"
+)
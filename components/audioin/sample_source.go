@@ -0,0 +1,188 @@
+package audioin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/utils"
+)
+
+// DefaultChunkDuration is the duration of the chunks streamed by a SampleSource by default.
+const DefaultChunkDuration = 100 * time.Millisecond
+
+// SampleCodecs are the PCM codecs audio is streamed in by every SampleSource, the first being the default.
+var SampleCodecs = []string{utils.CodecPCM16, utils.CodecPCM32, utils.CodecPCM32Float}
+
+// opusFrameDuration is the duration of the chunks streamed as Opus, which each hold a single Opus frame.
+const opusFrameDuration = 20 * time.Millisecond
+
+// opusSampleRates are the sample rates Opus encodes audio at.
+var opusSampleRates = []int32{8000, 12000, 16000, 24000, 48000}
+
+// A sampleEncoder encodes the interleaved samples of the chunks of a stream.
+type sampleEncoder interface {
+	encode(samples []float32) ([]byte, error)
+	close() error
+}
+
+// newOpusEncoder returns an encoder of chunks of a single Opus frame, and is set on builds with cgo.
+var newOpusEncoder func(sampleRateHz, numChannels int) (sampleEncoder, error)
+
+// pcmEncoder encodes samples to the PCM codec it is.
+type pcmEncoder string
+
+func (codec pcmEncoder) encode(samples []float32) ([]byte, error) {
+	return utils.EncodePCM(samples, string(codec))
+}
+
+func (codec pcmEncoder) close() error {
+	return nil
+}
+
+// A SampleReader reads the interleaved samples, between -1 and 1, of the frames of an audio source from the given frame,
+// frames being counted from the start of the source. It returns the number of samples read, which is only fewer than
+// asked for with io.EOF once the source ends.
+type SampleReader func(frame int64, samples []float32) (int, error)
+
+// A SampleSource is a source of audio samples which started at a given time, streamed in real time as chunks
+// timestamped from the frames they hold. It lets audio inputs implement GetAudio from a SampleReader.
+type SampleSource struct {
+	SampleRateHz  int32
+	NumChannels   int32
+	Start         time.Time
+	ChunkDuration time.Duration
+	Read          SampleReader
+	Logger        logging.Logger
+}
+
+// Properties returns the properties of the audio streamed from the source.
+func (s *SampleSource) Properties() utils.Properties {
+	return utils.Properties{SupportedCodecs: s.Codecs(), SampleRateHz: s.SampleRateHz, NumChannels: s.NumChannels}
+}
+
+// Codecs returns the codecs the audio of the source can be streamed in: the PCM codecs, and Opus on builds with cgo when
+// the source is mono or stereo at a sample rate Opus supports. Other codecs, like AAC, MP3 and FLAC, are not supported.
+func (s *SampleSource) Codecs() []string {
+	codecs := slices.Clone(SampleCodecs)
+	if newOpusEncoder != nil && slices.Contains(opusSampleRates, s.SampleRateHz) && (s.NumChannels == 1 || s.NumChannels == 2) {
+		codecs = append(codecs, utils.CodecOpus)
+	}
+	return codecs
+}
+
+// frameAt returns the frame of the source at the given time.
+func (s *SampleSource) frameAt(t time.Time) int64 {
+	return int64(math.Round(float64(t.Sub(s.Start)) * float64(s.SampleRateHz) / float64(time.Second)))
+}
+
+// timeOf returns the time of the start of the given frame.
+func (s *SampleSource) timeOf(frame int64) time.Time {
+	return s.Start.Add(time.Duration(math.Round(float64(frame) * float64(time.Second) / float64(s.SampleRateHz))))
+}
+
+// Stream streams the audio of the source in the given codec, from the end of the previous chunk a client received when
+// previousTimestampNs is set so that no audio is missed, and from the current time otherwise. Chunks are sent once
+// the audio they hold was produced, until the duration elapses, if it is not 0, the source ends, ctx is done or the
+// workers are stopped.
+func (s *SampleSource) Stream(
+	ctx context.Context,
+	workers *goutils.StoppableWorkers,
+	codec string,
+	durationSeconds float32,
+	previousTimestampNs int64,
+) (chan *AudioChunk, error) {
+	codecs := s.Codecs()
+	if codec == "" {
+		codec = codecs[0]
+	}
+	if !slices.Contains(codecs, codec) {
+		return nil, errors.New("unsupported codec " + codec + ", the audio can be streamed as " + strings.Join(codecs, ", "))
+	}
+	if durationSeconds < 0 {
+		return nil, errors.New("duration cannot be negative")
+	}
+	if workers.Context().Err() != nil {
+		return nil, errors.New("audio input is closed")
+	}
+
+	frame := s.frameAt(time.Now())
+	if previousTimestampNs > 0 {
+		frame = max(0, s.frameAt(time.Unix(0, previousTimestampNs)))
+	}
+	end := int64(math.MaxInt64)
+	if durationSeconds > 0 {
+		end = frame + int64(math.Round(float64(durationSeconds)*float64(s.SampleRateHz)))
+	}
+	chunkDuration := s.ChunkDuration
+	if chunkDuration <= 0 {
+		chunkDuration = DefaultChunkDuration
+	}
+	var enc sampleEncoder = pcmEncoder(codec)
+	if codec == utils.CodecOpus {
+		chunkDuration = opusFrameDuration
+		var err error
+		if enc, err = newOpusEncoder(int(s.SampleRateHz), int(s.NumChannels)); err != nil {
+			return nil, err
+		}
+	}
+	chunkFrames := max(1, int64(float64(chunkDuration)*float64(s.SampleRateHz)/float64(time.Second)))
+	info := &utils.AudioInfo{Codec: codec, SampleRateHz: s.SampleRateHz, NumChannels: s.NumChannels}
+
+	ch := make(chan *AudioChunk, 8)
+	workers.Add(func(workersCtx context.Context) {
+		ctx, cancel := goutils.MergeContext(ctx, workersCtx)
+		defer cancel()
+		defer close(ch)
+		defer func() {
+			if err := enc.close(); err != nil {
+				s.Logger.CWarnw(ctx, "cannot close audio encoder", "error", err)
+			}
+		}()
+		samples := make([]float32, chunkFrames*int64(s.NumChannels))
+		for sequence := int32(0); frame < end; sequence++ {
+			frames := min(chunkFrames, end-frame)
+			// the chunk is sent once its audio was produced
+			if wait := time.Until(s.timeOf(frame + frames)); wait > 0 && !goutils.SelectContextOrWait(ctx, wait) {
+				return
+			}
+			n, err := s.Read(frame, samples[:frames*int64(s.NumChannels)])
+			if err != nil && !errors.Is(err, io.EOF) {
+				s.Logger.CErrorw(ctx, "cannot read audio samples", "error", err)
+				return
+			}
+			frames = int64(n) / int64(s.NumChannels)
+			if frames > 0 {
+				data, encErr := enc.encode(samples[:frames*int64(s.NumChannels)])
+				if encErr != nil {
+					s.Logger.CErrorw(ctx, "cannot encode audio samples", "error", encErr)
+					return
+				}
+				chunk := &AudioChunk{
+					AudioData:                 data,
+					AudioInfo:                 info,
+					Sequence:                  sequence,
+					StartTimestampNanoseconds: s.timeOf(frame).UnixNano(),
+					EndTimestampNanoseconds:   s.timeOf(frame + frames).UnixNano(),
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- chunk:
+				}
+			}
+			if err != nil {
+				return
+			}
+			frame += frames
+		}
+	})
+	return ch, nil
+}
@@ -0,0 +1,73 @@
+//go:build !no_cgo
+
+package audioin
+
+import (
+	"io"
+	"time"
+
+	"github.com/pion/mediadevices/pkg/codec"
+	"github.com/pion/mediadevices/pkg/codec/opus"
+	"github.com/pion/mediadevices/pkg/io/audio"
+	"github.com/pion/mediadevices/pkg/prop"
+	"github.com/pion/mediadevices/pkg/wave"
+)
+
+func init() {
+	newOpusEncoder = newLibopusEncoder
+}
+
+// opusEncoder encodes chunks of a single Opus frame with libopus, as the Opus encoder of gostream does. The gostream
+// encoder itself encodes in the background and returns the frames of earlier chunks, when they are ready, so it cannot
+// encode every chunk into its own frame.
+type opusEncoder struct {
+	enc          codec.ReadCloser
+	sampleRateHz int
+	numChannels  int
+	// chunk is the chunk being encoded, read once by enc
+	chunk *wave.Float32Interleaved
+}
+
+func newLibopusEncoder(sampleRateHz, numChannels int) (sampleEncoder, error) {
+	params, err := opus.NewParams()
+	if err != nil {
+		return nil, err
+	}
+	params.Latency = opus.Latency(opusFrameDuration)
+	e := &opusEncoder{sampleRateHz: sampleRateHz, numChannels: numChannels}
+	e.enc, err = params.BuildAudioEncoder(audio.ReaderFunc(e.read), prop.Media{Audio: prop.Audio{
+		SampleRate:   sampleRateHz,
+		ChannelCount: numChannels,
+		Latency:      opusFrameDuration,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *opusEncoder) read() (wave.Audio, func(), error) {
+	if e.chunk == nil {
+		return nil, func() {}, io.EOF
+	}
+	chunk := e.chunk
+	e.chunk = nil
+	return chunk, func() {}, nil
+}
+
+// encode encodes the samples of a frame, padding them with silence when the audio ends before the end of the frame.
+func (e *opusEncoder) encode(samples []float32) ([]byte, error) {
+	frameLen := int(int64(opusFrameDuration) * int64(e.sampleRateHz) / int64(time.Second))
+	e.chunk = wave.NewFloat32Interleaved(wave.ChunkInfo{Len: frameLen, Channels: e.numChannels, SamplingRate: e.sampleRateHz})
+	copy(e.chunk.Data, samples)
+	data, release, err := e.enc.Read()
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return data, nil
+}
+
+func (e *opusEncoder) close() error {
+	return e.enc.Close()
+}
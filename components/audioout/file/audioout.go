@@ -0,0 +1,168 @@
+// Package file implements an audio output writing the audio it plays to files.
+package file
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"go.viam.com/rdk/components/audioout"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("file")
+
+// the audio formats of WAV files
+const (
+	wavFormatPCM   = 1
+	wavFormatFloat = 3
+)
+
+// supportedCodecs are the codecs of the audio which can be played. PCM audio is written to WAV files, opus packets to
+// Ogg files and audio of the other codecs to files of their format as is.
+var supportedCodecs = []string{
+	utils.CodecPCM16, utils.CodecPCM32, utils.CodecPCM32Float, utils.CodecOpus, utils.CodecMP3, utils.CodecFLAC, utils.CodecAAC,
+}
+
+// Config is the config of a file audio output, which writes the audio of every call to Play to a new file in the
+// directory.
+type Config struct {
+	Directory string `json:"directory"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, []string, error) {
+	if conf.Directory == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "directory")
+	}
+	return nil, nil, nil
+}
+
+func init() {
+	resource.RegisterComponent(
+		audioout.API,
+		model,
+		resource.Registration[audioout.AudioOut, *Config]{Constructor: newAudioOut})
+}
+
+type audioOut struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+	dir string
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func newAudioOut(_ context.Context, _ resource.Dependencies, conf resource.Config, _ logging.Logger) (audioout.AudioOut, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(newConf.Directory, 0o750); err != nil {
+		return nil, err
+	}
+	return &audioOut{Named: conf.ResourceName().AsNamed(), dir: newConf.Directory}, nil
+}
+
+// Play writes the audio to a new file named after the current time. Opus audio must be a single opus packet.
+func (out *audioOut) Play(ctx context.Context, data []byte, info *utils.AudioInfo, extra map[string]interface{}) error {
+	if info == nil {
+		return errors.New("audio info is required to play audio")
+	}
+	if len(data) == 0 {
+		return errors.New("no audio to play")
+	}
+	ext := ""
+	switch info.Codec {
+	case utils.CodecPCM16, utils.CodecPCM32, utils.CodecPCM32Float:
+		ext = ".wav"
+	case utils.CodecOpus:
+		ext = ".ogg"
+	case utils.CodecMP3, utils.CodecFLAC, utils.CodecAAC:
+		ext = "." + info.Codec
+	default:
+		return errors.Errorf("unsupported codec %q, supported codecs are %v", info.Codec, supportedCodecs)
+	}
+	if utils.AudioSampleSize(info.Codec) > 0 || info.Codec == utils.CodecOpus {
+		if info.SampleRateHz <= 0 || info.NumChannels <= 0 {
+			return errors.New("the sample rate and number of channels of the audio are required")
+		}
+	}
+
+	// files are named after the time they are written at, which is made unique
+	out.mu.Lock()
+	now := time.Now()
+	if !now.After(out.last) {
+		now = out.last.Add(time.Nanosecond)
+	}
+	out.last = now
+	out.mu.Unlock()
+	path := filepath.Join(out.dir, fmt.Sprintf("%s-%d%s", out.Name().ShortName(), now.UnixNano(), ext))
+
+	switch ext {
+	case ".wav":
+		return writeWAV(path, data, info)
+	case ".ogg":
+		return writeOgg(path, data, info.SampleRateHz, info.NumChannels)
+	default:
+		//nolint:gosec
+		return os.WriteFile(path, data, 0o640)
+	}
+}
+
+// writeWAV writes PCM audio to a WAV file of samples of the same format.
+func writeWAV(path string, data []byte, info *utils.AudioInfo) (err error) {
+	size := utils.AudioSampleSize(info.Codec)
+	if len(data)%(size*int(info.NumChannels)) != 0 {
+		return errors.Errorf("%q audio of %d bytes does not have whole frames of %d channels", info.Codec, len(data), info.NumChannels)
+	}
+	buf := &audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: int(info.NumChannels), SampleRate: int(info.SampleRateHz)},
+		Data:           make([]int, len(data)/size),
+		SourceBitDepth: 8 * size,
+	}
+	for i := range buf.Data {
+		if size == 2 {
+			buf.Data[i] = int(int16(binary.LittleEndian.Uint16(data[2*i:])))
+		} else {
+			// float samples are written as they are
+			buf.Data[i] = int(int32(binary.LittleEndian.Uint32(data[4*i:])))
+		}
+	}
+	format := wavFormatPCM
+	if info.Codec == utils.CodecPCM32Float {
+		format = wavFormatFloat
+	}
+
+	//nolint:gosec
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Combine(err, f.Close())
+	}()
+	enc := wav.NewEncoder(f, int(info.SampleRateHz), 8*size, int(info.NumChannels), format)
+	if err := enc.Write(buf); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func (out *audioOut) Properties(ctx context.Context, extra map[string]interface{}) (utils.Properties, error) {
+	// audio of any sample rate and number of channels can be played
+	return utils.Properties{SupportedCodecs: supportedCodecs}, nil
+}
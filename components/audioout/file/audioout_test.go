@@ -0,0 +1,116 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-audio/wav"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+func TestAudioOut(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "audio")
+	_, _, err := (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	out, err := newAudioOut(ctx, nil, resource.Config{Name: "speaker", ConvertedAttributes: &Config{Directory: dir}},
+		logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	props, err := out.Properties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportedCodecs, test.ShouldContain, utils.CodecOpus)
+
+	test.That(t, out.Play(ctx, []byte{1, 2}, nil, nil), test.ShouldNotBeNil)
+	test.That(t, out.Play(ctx, []byte{1, 2}, &utils.AudioInfo{Codec: "vorbis", SampleRateHz: 8000, NumChannels: 1}, nil),
+		test.ShouldNotBeNil)
+	test.That(t, out.Play(ctx, []byte{1, 2, 3}, &utils.AudioInfo{Codec: utils.CodecPCM16, SampleRateHz: 8000, NumChannels: 1}, nil),
+		test.ShouldNotBeNil)
+
+	samples := []float32{0, 0.25, -0.25, 0.5}
+	for _, codec := range []string{utils.CodecPCM16, utils.CodecPCM32, utils.CodecPCM32Float} {
+		data, err := utils.EncodePCM(samples, codec)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, out.Play(ctx, data, &utils.AudioInfo{Codec: codec, SampleRateHz: 8000, NumChannels: 2}, nil), test.ShouldBeNil)
+	}
+	// a CELT packet of 20ms
+	opus := []byte{31 << 3, 1, 2, 3}
+	test.That(t, out.Play(ctx, opus, &utils.AudioInfo{Codec: utils.CodecOpus, SampleRateHz: 48000, NumChannels: 1}, nil),
+		test.ShouldBeNil)
+	test.That(t, out.Play(ctx, []byte("mp3"), &utils.AudioInfo{Codec: utils.CodecMP3}, nil), test.ShouldBeNil)
+
+	files, err := filepath.Glob(filepath.Join(dir, "speaker-*"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(files), test.ShouldEqual, 5)
+
+	// PCM audio is written to WAV files of the same sample format
+	for i, format := range []struct {
+		bitDepth, audioFormat int
+	}{{16, wavFormatPCM}, {32, wavFormatPCM}, {32, wavFormatFloat}} {
+		test.That(t, filepath.Ext(files[i]), test.ShouldEqual, ".wav")
+		b, err := os.ReadFile(files[i])
+		test.That(t, err, test.ShouldBeNil)
+		dec := wav.NewDecoder(bytes.NewReader(b))
+		buf, err := dec.FullPCMBuffer()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int(dec.BitDepth), test.ShouldEqual, format.bitDepth)
+		test.That(t, int(dec.WavAudioFormat), test.ShouldEqual, format.audioFormat)
+		test.That(t, dec.SampleRate, test.ShouldEqual, 8000)
+		test.That(t, dec.NumChans, test.ShouldEqual, 2)
+		test.That(t, len(buf.Data), test.ShouldEqual, 4)
+	}
+
+	// opus packets are written to Ogg files, of the header, tags and audio pages
+	b, err := os.ReadFile(files[3])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, filepath.Ext(files[3]), test.ShouldEqual, ".ogg")
+	var pages int
+	for len(b) > 0 {
+		test.That(t, string(b[:4]), test.ShouldEqual, "OggS")
+		segments := int(b[26])
+		size := 27 + segments
+		for _, lacing := range b[27 : 27+segments] {
+			size += int(lacing)
+		}
+		page := append([]byte{}, b[:size]...)
+		crc := binary.LittleEndian.Uint32(page[22:])
+		binary.LittleEndian.PutUint32(page[22:], 0)
+		test.That(t, oggCRC(page), test.ShouldEqual, crc)
+		if pages == 2 {
+			test.That(t, binary.LittleEndian.Uint64(page[6:]), test.ShouldEqual, opusPreSkip+960)
+			test.That(t, page[27+segments:], test.ShouldResemble, opus)
+		}
+		pages++
+		b = b[size:]
+	}
+	test.That(t, pages, test.ShouldEqual, 3)
+
+	b, err = os.ReadFile(files[4])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(b), test.ShouldEqual, "mp3")
+}
+
+func TestOpusPacketDuration(t *testing.T) {
+	for _, tc := range []struct {
+		packet   []byte
+		duration uint32
+	}{
+		{[]byte{1 << 3}, 960},           // a SILK frame of 20ms
+		{[]byte{3<<3 | 1}, 2 * 2880},    // two SILK frames of 60ms
+		{[]byte{13<<3 | 2}, 2 * 960},    // two hybrid frames of 20ms
+		{[]byte{16<<3 | 3, 5}, 5 * 120}, // five CELT frames of 2.5ms
+	} {
+		duration, err := opusPacketDuration(tc.packet)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, duration, test.ShouldEqual, tc.duration)
+	}
+	_, err := opusPacketDuration([]byte{3})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	oggBeginningOfStream = 0x02
+	oggEndOfStream       = 0x04
+	// opusPreSkip is the number of 48kHz samples decoders discard at the start of an opus stream, the usual lookahead of
+	// encoders.
+	opusPreSkip = 312
+)
+
+// writeOgg writes an opus packet to an Ogg Opus file, as specified in RFC 7845.
+func writeOgg(path string, packet []byte, sampleRateHz, numChannels int32) error {
+	duration, err := opusPacketDuration(packet)
+	if err != nil {
+		return err
+	}
+	if numChannels > 2 {
+		return errors.New("opus audio of more than 2 channels is not supported")
+	}
+
+	var head bytes.Buffer
+	head.WriteString("OpusHead")
+	head.WriteByte(1) // version
+	head.WriteByte(byte(numChannels))
+	//nolint:errcheck
+	binary.Write(&head, binary.LittleEndian, uint16(opusPreSkip))
+	//nolint:errcheck
+	binary.Write(&head, binary.LittleEndian, uint32(sampleRateHz))
+	head.Write([]byte{0, 0, 0}) // output gain and channel mapping family
+
+	var tags bytes.Buffer
+	tags.WriteString("OpusTags")
+	vendor := "viam"
+	//nolint:errcheck
+	binary.Write(&tags, binary.LittleEndian, uint32(len(vendor)))
+	tags.WriteString(vendor)
+	tags.Write([]byte{0, 0, 0, 0}) // no comments
+
+	var file []byte
+	for i, page := range []struct {
+		headerType byte
+		granule    uint64
+		data       []byte
+	}{
+		{oggBeginningOfStream, 0, head.Bytes()},
+		{0, 0, tags.Bytes()},
+		{oggEndOfStream, opusPreSkip + uint64(duration), packet},
+	} {
+		p, err := oggPage(page.headerType, page.granule, uint32(i), page.data)
+		if err != nil {
+			return err
+		}
+		file = append(file, p...)
+	}
+	//nolint:gosec
+	return os.WriteFile(path, file, 0o640)
+}
+
+// oggPage returns a page of the stream holding a single packet.
+func oggPage(headerType byte, granule uint64, sequence uint32, packet []byte) ([]byte, error) {
+	// the packet is split in segments of 255 bytes, ended by a shorter one
+	segments := len(packet)/255 + 1
+	if segments > 255 {
+		return nil, errors.New("opus packet is too large")
+	}
+	page := make([]byte, 27, 27+segments+len(packet))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	binary.LittleEndian.PutUint32(page[14:], 1) // the serial number of the stream
+	binary.LittleEndian.PutUint32(page[18:], sequence)
+	page[26] = byte(segments)
+	for i := 0; i < segments-1; i++ {
+		page = append(page, 255)
+	}
+	page = append(page, byte(len(packet)%255))
+	page = append(page, packet...)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+	return page, nil
+}
+
+// oggCRC returns the CRC-32 of a page, which is not the usual reflected one.
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// opusPacketDuration returns the duration of an opus packet in 48kHz samples, from its table of contents as specified
+// in section 3.1 of RFC 6716.
+func opusPacketDuration(packet []byte) (uint32, error) {
+	if len(packet) == 0 {
+		return 0, errors.New("empty opus packet")
+	}
+	config := packet[0] >> 3
+	var frameSize uint32
+	switch {
+	case config < 12: // SILK
+		frameSize = []uint32{480, 960, 1920, 2880}[config%4]
+	case config < 16: // hybrid
+		frameSize = []uint32{480, 960}[config%2]
+	default: // CELT
+		frameSize = []uint32{120, 240, 480, 960}[config%4]
+	}
+	frames := uint32(1)
+	switch packet[0] & 3 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0, errors.New("opus packet is missing its frame count")
+		}
+		frames = uint32(packet[1] & 0x3f)
+	}
+	return frames * frameSize, nil
+}
@@ -0,0 +1,176 @@
+// Package loopback implements an audio output whose audio can be read back by loopback audio inputs, for end to end
+// tests of audio pipelines.
+package loopback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/audioin"
+	"go.viam.com/rdk/components/audioout"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("loopback")
+
+const (
+	defaultSampleRateHz  = 48000
+	defaultBufferSeconds = 10
+)
+
+// Config is the config of a loopback audio output, which plays PCM audio of the given sample rate and number of
+// channels, keeping the last buffer_seconds of it for loopback audio inputs.
+type Config struct {
+	SampleRateHz  int     `json:"sample_rate_hz,omitempty"`
+	NumChannels   int     `json:"num_channels,omitempty"`
+	BufferSeconds float64 `json:"buffer_seconds,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (conf *Config) Validate(path string) ([]string, []string, error) {
+	if conf.SampleRateHz < 0 || conf.NumChannels < 0 || conf.BufferSeconds < 0 {
+		return nil, nil, resource.NewConfigValidationError(path,
+			errors.New("sample_rate_hz, num_channels and buffer_seconds cannot be negative"))
+	}
+	return nil, nil, nil
+}
+
+// A Loopback is an audio output whose audio can be read back.
+type Loopback interface {
+	audioout.AudioOut
+	// SampleSource returns the audio played by the output, which is silent when nothing plays.
+	SampleSource() audioin.SampleSource
+}
+
+func init() {
+	resource.RegisterComponent(
+		audioout.API,
+		model,
+		resource.Registration[audioout.AudioOut, *Config]{Constructor: newAudioOut})
+}
+
+type audioOut struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+	sampleRate int32
+	channels   int32
+	start      time.Time
+	logger     logging.Logger
+
+	mu sync.Mutex
+	// buffer is a ring buffer of the frames up to end, the frame after the last one played or queued
+	buffer []float32
+	end    int64
+}
+
+func newAudioOut(_ context.Context, _ resource.Dependencies, conf resource.Config, logger logging.Logger) (audioout.AudioOut, error) {
+	newConf, err := resource.NativeConfig[*Config](conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoopback(conf.ResourceName(), newConf, logger), nil
+}
+
+// NewLoopback returns a loopback audio output.
+func NewLoopback(name resource.Name, conf *Config, logger logging.Logger) Loopback {
+	out := &audioOut{
+		Named:      name.AsNamed(),
+		sampleRate: int32(conf.SampleRateHz),
+		channels:   int32(conf.NumChannels),
+		start:      time.Now(),
+		logger:     logger,
+	}
+	if out.sampleRate == 0 {
+		out.sampleRate = defaultSampleRateHz
+	}
+	if out.channels == 0 {
+		out.channels = 1
+	}
+	bufferSeconds := conf.BufferSeconds
+	if bufferSeconds == 0 {
+		bufferSeconds = defaultBufferSeconds
+	}
+	out.buffer = make([]float32, int(bufferSeconds*float64(out.sampleRate))*int(out.channels))
+	return out
+}
+
+// frameAt returns the frame played at the given time.
+func (out *audioOut) frameAt(t time.Time) int64 {
+	return int64(t.Sub(out.start)) * int64(out.sampleRate) / int64(time.Second)
+}
+
+// Play queues the audio after the audio already playing, and returns once it was played.
+func (out *audioOut) Play(ctx context.Context, data []byte, info *utils.AudioInfo, extra map[string]interface{}) error {
+	if info == nil {
+		return errors.New("audio info is required to play audio")
+	}
+	if info.SampleRateHz != out.sampleRate || info.NumChannels != out.channels {
+		return errors.New("audio must have the sample rate and number of channels of the audio output")
+	}
+	samples, err := utils.DecodePCM(data, info.Codec)
+	if err != nil {
+		return err
+	}
+	if len(samples)%int(out.channels) != 0 {
+		return errors.New("audio does not have whole frames")
+	}
+
+	out.mu.Lock()
+	frames := int64(len(out.buffer) / int(out.channels))
+	start := max(out.end, out.frameAt(time.Now()))
+	// the audio since the last one played is silent
+	for f := out.end; f < start && f < out.end+frames; f++ {
+		clear(out.buffer[int(f%frames)*int(out.channels):][:out.channels])
+	}
+	for i := 0; i < len(samples); i += int(out.channels) {
+		copy(out.buffer[int((start+int64(i)/int64(out.channels))%frames)*int(out.channels):], samples[i:i+int(out.channels)])
+	}
+	out.end = start + int64(len(samples))/int64(out.channels)
+	end := out.end
+	out.mu.Unlock()
+
+	played := out.start.Add(time.Duration(end * int64(time.Second) / int64(out.sampleRate)))
+	if !goutils.SelectContextOrWait(ctx, time.Until(played)) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (out *audioOut) SampleSource() audioin.SampleSource {
+	return audioin.SampleSource{
+		SampleRateHz: out.sampleRate,
+		NumChannels:  out.channels,
+		Start:        out.start,
+		Read:         out.read,
+		Logger:       out.logger,
+	}
+}
+
+// read reads the played audio, which is silent outside of the buffer.
+func (out *audioOut) read(frame int64, samples []float32) (int, error) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	channels := int(out.channels)
+	frames := int64(len(out.buffer) / channels)
+	for i := 0; i < len(samples)/channels; i++ {
+		f := frame + int64(i)
+		dst := samples[i*channels : (i+1)*channels]
+		if f < 0 || f >= out.end || f < out.end-frames {
+			clear(dst)
+			continue
+		}
+		copy(dst, out.buffer[int(f%frames)*channels:])
+	}
+	return len(samples), nil
+}
+
+func (out *audioOut) Properties(ctx context.Context, extra map[string]interface{}) (utils.Properties, error) {
+	return utils.Properties{SupportedCodecs: audioin.SampleCodecs, SampleRateHz: out.sampleRate, NumChannels: out.channels}, nil
+}
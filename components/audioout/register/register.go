@@ -0,0 +1,8 @@
+// Package register registers all relevant audio outputs and also API specific functions
+package register
+
+import (
+	// for audio outputs.
+	_ "go.viam.com/rdk/components/audioout/file"
+	_ "go.viam.com/rdk/components/audioout/loopback"
+)
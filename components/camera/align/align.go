@@ -0,0 +1,254 @@
+//go:build !no_cgo
+
+// Package align defines a camera that composes a color camera and a depth camera into a single RGB-D camera, by
+// aligning the depth map to the color image using the intrinsics of both cameras and the extrinsics between them.
+package align
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"time"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("align_color_depth")
+
+const (
+	// ColorSourceName is the source name of the color image returned by Images.
+	ColorSourceName = "color"
+	// DepthSourceName is the source name of the aligned depth map returned by Images.
+	DepthSourceName = "depth"
+)
+
+func init() {
+	resource.RegisterComponent(camera.API, model,
+		resource.Registration[camera.Camera, *Config]{
+			Constructor: func(
+				ctx context.Context,
+				deps resource.Dependencies,
+				conf resource.Config,
+				logger logging.Logger,
+			) (camera.Camera, error) {
+				newConf, err := resource.NativeConfig[*Config](conf)
+				if err != nil {
+					return nil, err
+				}
+				colorCam, err := camera.FromProvider(deps, newConf.Color)
+				if err != nil {
+					return nil, errors.Wrapf(err, "no color camera (%s)", newConf.Color)
+				}
+				depthCam, err := camera.FromProvider(deps, newConf.Depth)
+				if err != nil {
+					return nil, errors.Wrapf(err, "no depth camera (%s)", newConf.Depth)
+				}
+				return newColorDepthCamera(conf.ResourceName().AsNamed(), colorCam, depthCam, newConf, logger)
+			},
+		})
+}
+
+// Config is the attribute struct for the align_color_depth camera. IntrinsicExtrinsic has the format read by
+// transform.NewDepthColorIntrinsicsExtrinsicsFromBytes, with the intrinsics of both cameras and the extrinsics from
+// the depth camera to the color camera.
+type Config struct {
+	Color              string      `json:"color_camera_name"`
+	Depth              string      `json:"depth_camera_name"`
+	IntrinsicExtrinsic interface{} `json:"intrinsic_extrinsic"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.Color == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "color_camera_name")
+	}
+	if cfg.Depth == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "depth_camera_name")
+	}
+	if cfg.IntrinsicExtrinsic == nil {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "intrinsic_extrinsic")
+	}
+	if _, err := cfg.intrinsicExtrinsic(); err != nil {
+		return nil, nil, resource.NewConfigValidationError(path, err)
+	}
+	return []string{cfg.Color, cfg.Depth}, nil, nil
+}
+
+func (cfg *Config) intrinsicExtrinsic() (*transform.DepthColorIntrinsicsExtrinsics, error) {
+	b, err := json.Marshal(cfg.IntrinsicExtrinsic)
+	if err != nil {
+		return nil, err
+	}
+	dcie, err := transform.NewDepthColorIntrinsicsExtrinsicsFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := dcie.CheckValid(); err != nil {
+		return nil, err
+	}
+	return dcie, nil
+}
+
+// colorDepthCamera aligns the depth maps of a depth camera to the images of a color camera.
+type colorDepthCamera struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+	color  camera.Camera
+	depth  camera.Camera
+	dcie   *transform.DepthColorIntrinsicsExtrinsics
+	logger logging.Logger
+}
+
+func newColorDepthCamera(
+	named resource.Named,
+	color, depth camera.Camera,
+	cfg *Config,
+	logger logging.Logger,
+) (camera.Camera, error) {
+	dcie, err := cfg.intrinsicExtrinsic()
+	if err != nil {
+		return nil, err
+	}
+	return &colorDepthCamera{
+		Named:  named,
+		color:  color,
+		depth:  depth,
+		dcie:   dcie,
+		logger: logger,
+	}, nil
+}
+
+// readAligned reads an image from each camera and returns the color image with the depth map aligned to it.
+func (cdc *colorDepthCamera) readAligned(ctx context.Context) (*rimage.Image, *rimage.DepthMap, error) {
+	colorImg, err := camera.DecodeImageFromCamera(ctx, "", nil, cdc.color)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get image from color camera")
+	}
+	depthImg, err := camera.DecodeImageFromCamera(ctx, utils.MimeTypeRawDepth, nil, cdc.depth)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get image from depth camera")
+	}
+	dm, err := rimage.ConvertImageToDepthMap(ctx, depthImg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cdc.dcie.AlignColorAndDepthImage(rimage.ConvertImage(colorImg), dm)
+}
+
+// Image returns the color image, or the aligned depth map when the raw depth mime type is requested.
+func (cdc *colorDepthCamera) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::align::Image")
+	defer span.End()
+	col, dm, err := cdc.readAligned(ctx)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	var img image.Image = col
+	if actualType, _ := utils.CheckLazyMIMEType(mimeType); actualType == utils.MimeTypeRawDepth {
+		img = dm
+	}
+	if mimeType == "" {
+		mimeType = utils.MimeTypePNG
+	}
+	imgBytes, err := rimage.EncodeImage(ctx, img, mimeType)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	return imgBytes, camera.ImageMetadata{MimeType: mimeType}, nil
+}
+
+// Images returns the color image and the depth map aligned to it, as the sources "color" and "depth".
+func (cdc *colorDepthCamera) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::align::Images")
+	defer span.End()
+	col, dm, err := cdc.readAligned(ctx)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	ts := time.Now()
+	wanted := func(name string) bool {
+		if len(filterSourceNames) == 0 {
+			return true
+		}
+		for _, n := range filterSourceNames {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	images := make([]camera.NamedImage, 0, 2)
+	if wanted(ColorSourceName) {
+		namedImg, err := camera.NamedImageFromImage(col, ColorSourceName, utils.MimeTypeJPEG)
+		if err != nil {
+			return nil, resource.ResponseMetadata{}, err
+		}
+		images = append(images, namedImg)
+	}
+	if wanted(DepthSourceName) {
+		namedImg, err := camera.NamedImageFromImage(dm, DepthSourceName, utils.MimeTypeRawDepth)
+		if err != nil {
+			return nil, resource.ResponseMetadata{}, err
+		}
+		images = append(images, namedImg)
+	}
+	return images, resource.ResponseMetadata{CapturedAt: ts}, nil
+}
+
+// NextPointCloud projects the aligned color image and depth map to a colored point cloud in the frame of the
+// color camera, leaving out the pixels without depth.
+func (cdc *colorDepthCamera) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::align::NextPointCloud")
+	defer span.End()
+	col, dm, err := cdc.readAligned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := cdc.dcie.RGBDToPointCloud(col, dm)
+	if err != nil {
+		return nil, err
+	}
+	// pixels without depth are projected to the origin, so leave them out
+	filtered := pointcloud.NewBasicPointCloud(pc.Size())
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		if p.Z == 0 {
+			return true
+		}
+		err = filtered.Set(p, d)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return filtered, nil
+}
+
+func (cdc *colorDepthCamera) Properties(ctx context.Context) (camera.Properties, error) {
+	intrinsics := cdc.dcie.ColorCamera
+	return camera.Properties{
+		SupportsPCD:     true,
+		ImageType:       camera.ColorStream,
+		IntrinsicParams: &intrinsics,
+		MimeTypes:       []string{utils.MimeTypeJPEG, utils.MimeTypePNG, utils.MimeTypeRawDepth},
+	}, nil
+}
+
+func (cdc *colorDepthCamera) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return []spatialmath.Geometry{}, nil
+}
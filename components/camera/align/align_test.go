@@ -0,0 +1,116 @@
+//go:build !no_cgo
+
+package align
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+func testIntrinsicExtrinsic() map[string]interface{} {
+	intrinsics := map[string]interface{}{
+		"width_px": 20, "height_px": 10, "fx": 100, "fy": 100, "ppx": 10, "ppy": 5,
+	}
+	return map[string]interface{}{
+		"color_intrinsic_parameters": intrinsics,
+		"depth_intrinsic_parameters": intrinsics,
+		"depth_to_color_extrinsic_parameters": map[string]interface{}{
+			"rotation_rads":  []float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+			"translation_mm": []float64{0, 0, 0},
+		},
+	}
+}
+
+func TestConfig(t *testing.T) {
+	conf := &Config{}
+	_, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Color = "color"
+	conf.Depth = "depth"
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.IntrinsicExtrinsic = map[string]interface{}{"color_intrinsic_parameters": map[string]interface{}{}}
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.IntrinsicExtrinsic = testIntrinsicExtrinsic()
+	deps, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"color", "depth"})
+}
+
+func TestColorDepthCamera(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	img := rimage.NewImage(20, 10)
+	dm := rimage.NewEmptyDepthMap(20, 10)
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 10; y++ {
+			img.Set(image.Point{x, y}, rimage.Red)
+			// only the left half of the scene has depth
+			if x < 10 {
+				dm.Set(x, y, 500)
+			}
+		}
+	}
+	colorCam := inject.NewCamera("color")
+	colorCam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, img, utils.MimeTypePNG)
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+	depthCam := inject.NewCamera("depth")
+	depthCam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, dm, utils.MimeTypeRawDepth)
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypeRawDepth}, err
+	}
+
+	conf := &Config{Color: "color", Depth: "depth", IntrinsicExtrinsic: testIntrinsicExtrinsic()}
+	cam, err := newColorDepthCamera(camera.Named("rgbd").AsNamed(), colorCam, depthCam, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+	test.That(t, props.IntrinsicParams.Width, test.ShouldEqual, 20)
+
+	images, _, err := cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(images), test.ShouldEqual, 2)
+	test.That(t, images[0].SourceName, test.ShouldEqual, ColorSourceName)
+	test.That(t, images[1].SourceName, test.ShouldEqual, DepthSourceName)
+	test.That(t, images[1].MimeType(), test.ShouldEqual, utils.MimeTypeRawDepth)
+
+	images, _, err = cam.Images(ctx, []string{DepthSourceName}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(images), test.ShouldEqual, 1)
+	depthImg, err := images[0].Image(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	aligned, err := rimage.ConvertImageToDepthMap(ctx, depthImg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, aligned.GetDepth(5, 5), test.ShouldEqual, 500)
+	test.That(t, aligned.GetDepth(15, 5), test.ShouldEqual, 0)
+
+	pc, err := cam.NextPointCloud(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	// depth pixels are spread over the color pixels they cover, which can widen the region by a column
+	test.That(t, pc.Size(), test.ShouldBeBetweenOrEqual, 10*10, 11*10)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		test.That(t, p.Z, test.ShouldEqual, 500)
+		test.That(t, d.HasColor(), test.ShouldBeTrue)
+		return true
+	})
+}
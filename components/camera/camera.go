@@ -60,6 +60,10 @@ func init() {
 		API:        API,
 		MethodName: doCommand.String(),
 	}, newDoCommandCollector)
+	data.RegisterCollector(data.MethodMetadata{
+		API:        API,
+		MethodName: imageQuality.String(),
+	}, newImageQualityCollector)
 }
 
 // SubtypeName is a constant that identifies the camera resource subtype string.
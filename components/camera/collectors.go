@@ -2,6 +2,7 @@ package camera
 
 import (
 	"context"
+	"image"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,7 @@ import (
 
 	"go.viam.com/rdk/data"
 	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/rimage"
 	"go.viam.com/rdk/utils"
 )
 
@@ -22,6 +24,7 @@ const (
 	readImage
 	getImages
 	doCommand
+	imageQuality
 )
 
 func (m method) String() string {
@@ -34,6 +37,8 @@ func (m method) String() string {
 		return "GetImages"
 	case doCommand:
 		return "DoCommand"
+	case imageQuality:
+		return "ImageQuality"
 	}
 	return "Unknown"
 }
@@ -175,6 +180,55 @@ func newGetImagesCollector(resource interface{}, params data.CollectorParams) (d
 	return data.NewCollector(cFunc, params)
 }
 
+// newImageQualityCollector returns a collector capturing the quality metrics of the images of a camera, and how much
+// each image changed from the previous one of the same size.
+func newImageQualityCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
+	camera, err := assertCamera(resource)
+	if err != nil {
+		return nil, err
+	}
+	var previous image.Image
+	cFunc := data.CaptureFunc(func(ctx context.Context, _ map[string]*anypb.Any) (data.CaptureResult, error) {
+		timeRequested := time.Now()
+		var res data.CaptureResult
+		ctx, span := trace.StartSpan(ctx, "camera::data::collector::CaptureFunc::ImageQuality")
+		defer span.End()
+
+		img, err := DecodeImageFromCamera(ctx, "", data.FromDMExtraMap, camera)
+		if err != nil {
+			if errors.Is(err, data.ErrNoCaptureToStore) {
+				return res, err
+			}
+			return res, data.NewFailedToReadError(params.ComponentName, imageQuality.String(), err)
+		}
+
+		quality := rimage.MeasureImageQuality(img)
+		histogram := make([]interface{}, len(quality.Histogram))
+		for i, v := range quality.Histogram {
+			histogram[i] = v
+		}
+		readings := map[string]interface{}{
+			"focus":                 quality.Focus,
+			"mean_luma":             quality.MeanLuma,
+			"contrast":              quality.Contrast,
+			"underexposed_fraction": quality.UnderexposedFraction,
+			"overexposed_fraction":  quality.OverexposedFraction,
+			"saturated_fraction":    quality.SaturatedFraction,
+			"luma_histogram":        histogram,
+		}
+		if previous != nil {
+			if change, err := rimage.ImageChange(previous, img); err == nil {
+				readings["change"] = change
+			}
+		}
+		previous = img
+
+		ts := data.Timestamps{TimeRequested: timeRequested, TimeReceived: time.Now()}
+		return data.NewTabularCaptureResultReadings(ts, readings)
+	})
+	return data.NewCollector(cFunc, params)
+}
+
 // newDoCommandCollector returns a collector to register a doCommand action. If one is already registered
 // with the same MethodMetadata it will panic.
 func newDoCommandCollector(resource interface{}, params data.CollectorParams) (data.Collector, error) {
@@ -180,6 +180,60 @@ func TestDoCommandCollector(t *testing.T) {
 	})
 }
 
+func TestImageQualityCollector(t *testing.T) {
+	buf := tu.NewMockBuffer(t)
+	params := data.CollectorParams{
+		DataType:      data.CaptureTypeTabular,
+		ComponentName: serviceName,
+		Interval:      captureInterval,
+		Logger:        logging.NewTestLogger(t),
+		Target:        buf,
+		Clock:         clock.New(),
+	}
+	gray := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range gray.Pix {
+		gray.Pix[i] = 100
+	}
+	cam := inject.NewCamera(serviceName)
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		imgBytes, err := rimage.EncodeImage(ctx, gray, utils.MimeTypePNG)
+		return imgBytes, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+
+	col, err := camera.NewImageQualityCollector(cam, params)
+	test.That(t, err, test.ShouldBeNil)
+	defer col.Close()
+	defer buf.Close()
+	col.Collect()
+
+	histogram := make([]any, rimage.HistogramBins)
+	for i := range histogram {
+		histogram[i] = 0.0
+	}
+	histogram[100*rimage.HistogramBins/256] = 1.0
+	readings := map[string]any{
+		"focus":                 0.0,
+		"mean_luma":             100.0,
+		"contrast":              0.0,
+		"underexposed_fraction": 0.0,
+		"overexposed_fraction":  0.0,
+		"saturated_fraction":    0.0,
+		"luma_histogram":        histogram,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// the change from the previous image is captured from the second image on
+	for i := 0; i < 2; i++ {
+		tu.CheckMockBufferWrites(t, ctx, time.Time{}, buf.Writes, []*datasyncpb.SensorData{{
+			Metadata: &datasyncpb.SensorMetadata{},
+			Data: &datasyncpb.SensorData_Struct{Struct: tu.ToStructPBStruct(t, map[string]any{
+				"readings": readings,
+			})},
+		}})
+		readings["change"] = 0.0
+	}
+}
+
 func newCamera(
 	left, right image.Image,
 	pcd pointcloud.PointCloud,
@@ -0,0 +1,37 @@
+package camera
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/robot/framesystem"
+)
+
+// UpdateOccupancyMap integrates the next point cloud of each camera into an occupancy map built in the frame
+// mapFrame, using the pose of each camera in the frame system at the time its point cloud is read. Cameras are
+// read one at a time, so the robot should not move while the map is being updated.
+func UpdateOccupancyMap(
+	ctx context.Context,
+	m *pointcloud.OccupancyMap,
+	fs framesystem.RobotFrameSystem,
+	mapFrame string,
+	cams ...Camera,
+) error {
+	for _, cam := range cams {
+		name := cam.Name().ShortName()
+		pc, err := cam.NextPointCloud(ctx, nil)
+		if err != nil {
+			return errors.Wrapf(err, "could not get point cloud from camera %q", name)
+		}
+		pose, err := fs.GetPose(ctx, name, mapFrame, nil, nil)
+		if err != nil {
+			return errors.Wrapf(err, "could not get pose of camera %q", name)
+		}
+		if err := m.InsertFromPose(pc, pose.Pose()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
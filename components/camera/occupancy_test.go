@@ -0,0 +1,58 @@
+package camera_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+func TestUpdateOccupancyMap(t *testing.T) {
+	ctx := context.Background()
+	m, err := pointcloud.NewOccupancyMap(pointcloud.OccupancyMapConfig{VoxelSize: 10})
+	test.That(t, err, test.ShouldBeNil)
+
+	// each camera sees a single point 500mm in front of it
+	newCam := func(name string) *inject.Camera {
+		cam := inject.NewCamera(name)
+		cam.NextPointCloudFunc = func(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+			pc := pointcloud.NewBasicPointCloud(1)
+			return pc, pc.Set(r3.Vector{Z: 505}, nil)
+		}
+		return cam
+	}
+	poses := map[string]spatialmath.Pose{
+		"cam1": spatialmath.NewZeroPose(),
+		"cam2": spatialmath.NewPose(r3.Vector{X: 1000}, &spatialmath.OrientationVectorDegrees{OX: -1}),
+	}
+	fs := inject.NewFrameSystemService("fs")
+	fs.GetPoseFunc = func(
+		ctx context.Context,
+		componentName, destinationFrame string,
+		supplementalTransforms []*referenceframe.LinkInFrame,
+		extra map[string]interface{},
+	) (*referenceframe.PoseInFrame, error) {
+		pose, ok := poses[componentName]
+		if !ok {
+			return nil, errors.New("unknown frame")
+		}
+		return referenceframe.NewPoseInFrame(destinationFrame, pose), nil
+	}
+
+	err = camera.UpdateOccupancyMap(ctx, m, fs, referenceframe.World, newCam("cam1"), newCam("cam2"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Occupied(r3.Vector{Z: 505}), test.ShouldBeTrue)
+	test.That(t, m.Occupied(r3.Vector{X: 495}), test.ShouldBeTrue)
+	test.That(t, m.Occupied(r3.Vector{Z: 255}), test.ShouldBeFalse)
+
+	err = camera.UpdateOccupancyMap(ctx, m, fs, referenceframe.World, newCam("cam3"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
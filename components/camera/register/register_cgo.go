@@ -5,6 +5,7 @@ package register
 
 import (
 	// for cameras.
+	_ "go.viam.com/rdk/components/camera/align"
 	_ "go.viam.com/rdk/components/camera/ffmpeg"
 	_ "go.viam.com/rdk/components/camera/replaypcd"
 	_ "go.viam.com/rdk/components/camera/videosource"
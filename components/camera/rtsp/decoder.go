@@ -0,0 +1,206 @@
+package rtsp
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/pkg/errors"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/logging"
+)
+
+// errNoFFmpeg is returned by Image for H.264 and H.265 streams when ffmpeg cannot be found to decode them.
+var errNoFFmpeg = errors.New("decoding H.264 and H.265 streams needs ffmpeg on the PATH or at ffmpeg_path, RTP passthrough does not")
+
+const (
+	// decodeQueueSize is the number of access units waiting to be decoded before access units are dropped.
+	decodeQueueSize = 30
+	// stderrTailSize is the number of bytes of the output of ffmpeg kept to tell why it exited.
+	stderrTailSize = 1024
+)
+
+// ffmpegDecoder decodes an H.264 or H.265 elementary stream to RGBA frames with an ffmpeg process, without scaling or
+// encoding them again.
+//
+// TODO: decode in process so that the camera does not depend on ffmpeg being installed. None of the dependencies
+// decode H.264 or H.265 without cgo bindings to a codec library, which would also have to be installed.
+type ffmpegDecoder struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	aus     chan [][]byte
+	stderr  *tailWriter
+	logger  logging.Logger
+	workers sync.WaitGroup
+
+	// dropping is set when the queue was full, until the next random access point
+	dropping bool
+	// closing is set by close, so that ffmpeg being killed is not reported as an exit
+	closing atomic.Bool
+	// done is closed when ffmpeg stops writing frames, and stderrDone when it closes stderr, as it exits
+	done       chan struct{}
+	stderrDone chan struct{}
+}
+
+// newFFmpegDecoder starts the ffmpeg executable decoding the given format, "h264" or "hevc", of frames of the given
+// size, calling onFrame with every decoded frame, and onExit if ffmpeg exits before the decoder is closed.
+func newFFmpegDecoder(
+	ffmpeg, format string,
+	width, height int,
+	onFrame func(image.Image),
+	onExit func(error),
+	logger logging.Logger,
+) (*ffmpegDecoder, error) {
+	path, err := exec.LookPath(ffmpeg)
+	if err != nil {
+		return nil, errNoFFmpeg
+	}
+	//nolint:gosec
+	cmd := exec.Command(path,
+		"-hide_banner", "-loglevel", "error",
+		"-fflags", "nobuffer", "-flags", "low_delay", "-probesize", "32", "-analyzeduration", "0",
+		"-f", format, "-i", "pipe:0",
+		"-f", "rawvideo", "-pix_fmt", "rgba", "pipe:1",
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "cannot start ffmpeg")
+	}
+	d := &ffmpegDecoder{
+		cmd:        cmd,
+		stdin:      stdin,
+		aus:        make(chan [][]byte, decodeQueueSize),
+		stderr:     &tailWriter{},
+		logger:     logger,
+		done:       make(chan struct{}),
+		stderrDone: make(chan struct{}),
+	}
+
+	d.workers.Add(3)
+	goutils.ManagedGo(func() {
+		defer close(d.stderrDone)
+		//nolint:errcheck
+		io.Copy(d.stderr, stderr)
+	}, d.workers.Done)
+	goutils.ManagedGo(func() {
+		for au := range d.aus {
+			b, err := h264.AnnexBMarshal(au)
+			if err != nil {
+				d.logger.Debugw("cannot marshal access unit", "error", err)
+				continue
+			}
+			if _, err := d.stdin.Write(b); err != nil {
+				d.logger.Debugw("cannot write to ffmpeg", "error", err)
+				return
+			}
+		}
+	}, d.workers.Done)
+	goutils.ManagedGo(func() {
+		defer close(d.done)
+		size := width * height * 4
+		for {
+			img := image.NewRGBA(image.Rect(0, 0, width, height))
+			if _, err := io.ReadFull(stdout, img.Pix[:size]); err != nil {
+				if !d.closing.Load() {
+					onExit(d.exitError())
+				}
+				return
+			}
+			onFrame(img)
+		}
+	}, d.workers.Done)
+	return d, nil
+}
+
+// exitError describes why ffmpeg stopped writing frames, with the last lines it wrote to stderr.
+func (d *ffmpegDecoder) exitError() error {
+	// ffmpeg closing stdout usually means it is exiting, after which its stderr is complete
+	select {
+	case <-d.stderrDone:
+	case <-time.After(time.Second):
+	}
+	if out := d.stderr.String(); out != "" {
+		return errors.Errorf("ffmpeg stopped decoding the RTSP stream: %s", out)
+	}
+	return errors.New("ffmpeg stopped decoding the RTSP stream")
+}
+
+// exited returns whether ffmpeg stopped writing frames.
+func (d *ffmpegDecoder) exited() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// decode queues the access unit for decoding. When the decoder falls behind, access units are dropped until the next
+// random access point, since the ones in between cannot be decoded without the ones dropped.
+func (d *ffmpegDecoder) decode(au [][]byte, randomAccess bool) {
+	if d.dropping && !randomAccess {
+		return
+	}
+	select {
+	case d.aus <- au:
+		d.dropping = false
+	default:
+		d.dropping = true
+	}
+}
+
+// close stops ffmpeg and waits for the decoded frames to be read.
+func (d *ffmpegDecoder) close() {
+	d.closing.Store(true)
+	close(d.aus)
+	//nolint:errcheck
+	d.stdin.Close()
+	if d.cmd.Process != nil {
+		//nolint:errcheck
+		d.cmd.Process.Kill()
+	}
+	d.workers.Wait()
+	//nolint:errcheck
+	d.cmd.Wait()
+}
+
+// tailWriter keeps the last stderrTailSize bytes written to it.
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > stderrTailSize {
+		w.buf = bytes.Clone(w.buf[len(w.buf)-stderrTailSize:])
+	}
+	return len(p), nil
+}
+
+// String returns the bytes kept, trimmed of surrounding whitespace.
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.TrimSpace(string(w.buf))
+}
@@ -0,0 +1,157 @@
+package rtsp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ONVIFConfig is the config of an ONVIF camera, whose RTSP address is asked to its device service. Profile is the
+// token of the media profile to stream, the first profile of the camera by default.
+type ONVIFConfig struct {
+	Address  string `json:"address"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+const soapEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" ` +
+	`xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:trt="http://www.onvif.org/ver10/media/wsdl" ` +
+	`xmlns:tt="http://www.onvif.org/ver10/schema">
+<s:Header>%s</s:Header>
+<s:Body>%s</s:Body>
+</s:Envelope>`
+
+const securityHeader = `<Security s:mustUnderstand="1" ` +
+	`xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">
+<UsernameToken>
+<Username>%s</Username>
+<Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest">%s</Password>
+<Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-soap-message-security-1.0#Base64Binary">%s</Nonce>
+<Created xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">%s</Created>
+</UsernameToken>
+</Security>`
+
+// onvifResponse holds the parts of the responses of the ONVIF requests made to find the stream address.
+type onvifResponse struct {
+	Fault      string `xml:"Body>Fault>Reason>Text"`
+	MediaXAddr string `xml:"Body>GetCapabilitiesResponse>Capabilities>Media>XAddr"`
+	Profiles   []struct {
+		Token string `xml:"token,attr"`
+	} `xml:"Body>GetProfilesResponse>Profiles"`
+	StreamURI string `xml:"Body>GetStreamUriResponse>MediaUri>Uri"`
+}
+
+// resolveONVIF asks the ONVIF device for the RTSP address of the configured profile, with the credentials of the
+// device added to it.
+func resolveONVIF(ctx context.Context, conf *ONVIFConfig) (string, error) {
+	caps, err := onvifCall(ctx, conf, conf.Address,
+		`<tds:GetCapabilities><tds:Category>Media</tds:Category></tds:GetCapabilities>`)
+	if err != nil {
+		return "", err
+	}
+	if caps.MediaXAddr == "" {
+		return "", errors.Errorf("ONVIF device %s has no media service", conf.Address)
+	}
+
+	profile := conf.Profile
+	if profile == "" {
+		profiles, err := onvifCall(ctx, conf, caps.MediaXAddr, `<trt:GetProfiles/>`)
+		if err != nil {
+			return "", err
+		}
+		if len(profiles.Profiles) == 0 {
+			return "", errors.Errorf("ONVIF device %s has no media profiles", conf.Address)
+		}
+		profile = profiles.Profiles[0].Token
+	}
+
+	stream, err := onvifCall(ctx, conf, caps.MediaXAddr, fmt.Sprintf(`<trt:GetStreamUri>
+<trt:StreamSetup><tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport></trt:StreamSetup>
+<trt:ProfileToken>%s</trt:ProfileToken>
+</trt:GetStreamUri>`, xmlEscape(profile)))
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(stream.StreamURI)
+	if err != nil || u.Scheme == "" {
+		return "", errors.Errorf("ONVIF device %s returned an invalid stream address %q", conf.Address, stream.StreamURI)
+	}
+	// the stream address usually does not carry the credentials of the device
+	if u.User == nil && conf.Username != "" {
+		u.User = url.UserPassword(conf.Username, conf.Password)
+	}
+	return u.String(), nil
+}
+
+// onvifCall sends the SOAP request body to the ONVIF service at the address.
+func onvifCall(ctx context.Context, conf *ONVIFConfig, address, body string) (*onvifResponse, error) {
+	header := ""
+	if conf.Username != "" {
+		var err error
+		if header, err = usernameToken(conf.Username, conf.Password, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address,
+		bytes.NewBufferString(fmt.Sprintf(soapEnvelope, header, body)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot reach ONVIF service %s", address)
+	}
+	defer func() {
+		//nolint:errcheck
+		resp.Body.Close()
+	}()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	out := &onvifResponse{}
+	if err := xml.Unmarshal(b, out); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse the response of ONVIF service %s", address)
+	}
+	if out.Fault != "" {
+		return nil, errors.Errorf("ONVIF service %s failed: %s", address, out.Fault)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("ONVIF service %s failed: %s", address, resp.Status)
+	}
+	return out, nil
+}
+
+// usernameToken returns the WS-Security header authenticating the user with a password digest.
+func usernameToken(username, password string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	created := now.UTC().Format(time.RFC3339)
+	//nolint:gosec
+	digest := sha1.Sum(append(append(append([]byte{}, nonce...), created...), password...))
+	return fmt.Sprintf(securityHeader,
+		xmlEscape(username), base64.StdEncoding.EncodeToString(digest[:]), base64.StdEncoding.EncodeToString(nonce), created,
+	), nil
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	//nolint:errcheck
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
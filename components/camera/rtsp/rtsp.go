@@ -0,0 +1,674 @@
+// Package rtsp implements a camera reading an IP camera over RTSP, with the RTSP address either configured or asked to
+// the camera over ONVIF. H.264 streams are also passed through as RTP packets to WebRTC, without transcoding.
+//
+// MJPEG frames are returned as they are received, while H.264 and H.265 frames are decoded by an ffmpeg process, so
+// ffmpeg must be installed, on the PATH or at ffmpeg_path, for Image and Images to work with those streams.
+package rtsp
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph264"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtph265"
+	"github.com/bluenviron/gortsplib/v4/pkg/format/rtpmjpeg"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/pkg/codecs/h265"
+	"github.com/pion/rtp"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/camera/rtppassthrough"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("rtsp")
+
+// ErrPassthroughUnsupported is returned by SubscribeRTP when the stream of the camera is not H.264, the only codec
+// passed through to WebRTC.
+var ErrPassthroughUnsupported = errors.New("rtp passthrough is only supported for H.264 streams")
+
+// webrtcPayloadMaxSize is the largest payload of the RTP packets passed through, 1200 - 12 (RTP header).
+const webrtcPayloadMaxSize = 1188
+
+var (
+	// minReconnectBackoff and maxReconnectBackoff bound the wait between attempts to reconnect to the camera, which
+	// doubles after every failed attempt.
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = time.Minute
+)
+
+const (
+	// staleFrameIntervals is the number of frame intervals after which the latest frame is too old to be returned.
+	staleFrameIntervals = 5
+	// minStaleFrameAge is the age under which the latest frame is never too old, whatever the frame rate.
+	minStaleFrameAge = time.Second
+)
+
+func init() {
+	resource.RegisterComponent(camera.API, model, resource.Registration[camera.Camera, *Config]{
+		Constructor: func(
+			ctx context.Context, _ resource.Dependencies, conf resource.Config, logger logging.Logger,
+		) (camera.Camera, error) {
+			newConf, err := resource.NativeConfig[*Config](conf)
+			if err != nil {
+				return nil, err
+			}
+			return newRTSPCamera(conf.ResourceName().AsNamed(), newConf, logger)
+		},
+	})
+}
+
+// Config is the attribute struct for RTSP cameras. Either Address, an rtsp:// URL which may hold credentials, or
+// ONVIF must be set. Transport is "tcp" or "udp", the client tries UDP then TCP by default. FFmpegPath is the ffmpeg
+// executable decoding H.264 and H.265 streams, ffmpeg on the PATH by default.
+type Config struct {
+	Address              string                             `json:"rtsp_address,omitempty"`
+	ONVIF                *ONVIFConfig                       `json:"onvif,omitempty"`
+	Transport            string                             `json:"transport,omitempty"`
+	FFmpegPath           string                             `json:"ffmpeg_path,omitempty"`
+	CameraParameters     *transform.PinholeCameraIntrinsics `json:"intrinsic_parameters,omitempty"`
+	DistortionParameters *transform.BrownConrady            `json:"distortion_parameters,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	switch {
+	case cfg.Address == "" && cfg.ONVIF == nil:
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "rtsp_address")
+	case cfg.Address != "" && cfg.ONVIF != nil:
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("set only one of rtsp_address and onvif"))
+	case cfg.ONVIF != nil && cfg.ONVIF.Address == "":
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path+".onvif", "address")
+	}
+	if cfg.Address != "" {
+		if _, err := base.ParseURL(cfg.Address); err != nil {
+			return nil, nil, resource.NewConfigValidationError(path, err)
+		}
+	}
+	if _, err := cfg.transport(); err != nil {
+		return nil, nil, resource.NewConfigValidationError(path, err)
+	}
+	if cfg.FFmpegPath != "" {
+		if _, err := exec.LookPath(cfg.FFmpegPath); err != nil {
+			return nil, nil, resource.NewConfigValidationError(path, errors.Wrap(err, "invalid ffmpeg_path"))
+		}
+	}
+	return nil, nil, nil
+}
+
+// ffmpeg returns the ffmpeg executable to decode H.264 and H.265 streams with.
+func (cfg *Config) ffmpeg() string {
+	if cfg.FFmpegPath != "" {
+		return cfg.FFmpegPath
+	}
+	return "ffmpeg"
+}
+
+func (cfg *Config) transport() (*gortsplib.Transport, error) {
+	var t gortsplib.Transport
+	switch cfg.Transport {
+	case "":
+		return nil, nil
+	case "tcp":
+		t = gortsplib.TransportTCP
+	case "udp":
+		t = gortsplib.TransportUDP
+	default:
+		return nil, errors.Errorf("transport must be tcp or udp, got %q", cfg.Transport)
+	}
+	return &t, nil
+}
+
+type subscriber struct {
+	cb  rtppassthrough.PacketCallback
+	buf *rtppassthrough.Buffer
+}
+
+// rtspCamera keeps a connection to the camera, reconnecting with a backoff when it is lost, and holds the latest frame.
+type rtspCamera struct {
+	resource.Named
+	resource.AlwaysRebuild
+	conf   *Config
+	logger logging.Logger
+
+	cancelFn context.CancelFunc
+	workers  sync.WaitGroup
+
+	mu sync.Mutex
+	// codec is the codec of the current stream
+	codec string
+	// jpegFrame is the latest frame of MJPEG streams, and frame the latest decoded frame of other streams, both cleared
+	// when the connection or the decoder is lost
+	jpegFrame []byte
+	frame     image.Image
+	frameTime time.Time
+	// frameInterval is the time between the latest two frames
+	frameInterval time.Duration
+	// frameErr is why there is no frame, returned by Image and Images until the next frame
+	frameErr    error
+	subscribers map[rtppassthrough.SubscriptionID]subscriber
+}
+
+func newRTSPCamera(named resource.Named, conf *Config, logger logging.Logger) (camera.Camera, error) {
+	if _, _, err := conf.Validate(""); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rc := &rtspCamera{
+		Named:       named,
+		conf:        conf,
+		logger:      logger,
+		cancelFn:    cancel,
+		subscribers: make(map[rtppassthrough.SubscriptionID]subscriber),
+	}
+	if _, err := exec.LookPath(conf.ffmpeg()); err != nil {
+		logger.Warnw("ffmpeg not found, frames of H.264 and H.265 streams will not be decoded", "error", err)
+	}
+	rc.workers.Add(1)
+	goutils.ManagedGo(func() { rc.run(ctx) }, rc.workers.Done)
+	return rc, nil
+}
+
+// run connects to the camera until the context is done, waiting longer after every failed attempt.
+func (rc *rtspCamera) run(ctx context.Context) {
+	backoff := minReconnectBackoff
+	for {
+		played, err := rc.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if played {
+			backoff = minReconnectBackoff
+		}
+		rc.clearFrame(errors.Wrap(err, "lost connection to RTSP camera"))
+		rc.logger.CWarnw(ctx, "lost connection to RTSP camera, reconnecting", "error", err, "backoff", backoff)
+		if !goutils.SelectContextOrWait(ctx, backoff) {
+			return
+		}
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
+}
+
+// stream reads the stream of the camera until the connection fails or the context is done. It returns whether the
+// stream was played at all.
+func (rc *rtspCamera) stream(ctx context.Context) (bool, error) {
+	address := rc.conf.Address
+	if rc.conf.ONVIF != nil {
+		var err error
+		if address, err = resolveONVIF(ctx, rc.conf.ONVIF); err != nil {
+			return false, err
+		}
+	}
+	u, err := base.ParseURL(address)
+	if err != nil {
+		return false, err
+	}
+	transport, err := rc.conf.transport()
+	if err != nil {
+		return false, err
+	}
+	client := &gortsplib.Client{Transport: transport}
+	if err := client.Start(u.Scheme, u.Host); err != nil {
+		return false, err
+	}
+	// closing the client stops the callbacks and makes Wait return
+	stop := context.AfterFunc(ctx, client.Close)
+	var s *session
+	defer func() {
+		stop()
+		client.Close()
+		if s != nil {
+			s.close()
+		}
+	}()
+
+	desc, _, err := client.Describe(u)
+	if err != nil {
+		return false, err
+	}
+	if s, err = rc.newSession(desc); err != nil {
+		return false, err
+	}
+	if _, err := client.Setup(desc.BaseURL, s.media, 0, 0); err != nil {
+		return false, err
+	}
+	client.OnPacketRTP(s.media, s.format, s.handlePacket)
+	if _, err := client.Play(nil); err != nil {
+		return false, err
+	}
+	rc.logger.CInfow(ctx, "playing RTSP stream", "codec", s.format.Codec())
+	return true, client.Wait()
+}
+
+// Image returns the latest frame of the camera, as JPEG unless another mime type is requested. The frames of MJPEG
+// streams are returned as they were received.
+func (rc *rtspCamera) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+	_, span := trace.StartSpan(ctx, "camera::rtsp::Image")
+	defer span.End()
+	jpegFrame, img, _, err := rc.latest()
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	actualType, _ := utils.CheckLazyMIMEType(mimeType)
+	if jpegFrame != nil && (actualType == "" || actualType == utils.MimeTypeJPEG) {
+		return jpegFrame, camera.ImageMetadata{MimeType: utils.MimeTypeJPEG}, nil
+	}
+	if img == nil {
+		if img, err = jpeg.Decode(bytes.NewReader(jpegFrame)); err != nil {
+			return nil, camera.ImageMetadata{}, err
+		}
+	}
+	if mimeType == "" {
+		mimeType = utils.MimeTypeJPEG
+	}
+	imgBytes, err := rimage.EncodeImage(ctx, img, mimeType)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	return imgBytes, camera.ImageMetadata{MimeType: mimeType}, nil
+}
+
+// Images returns the latest frame of the camera, named after the camera.
+func (rc *rtspCamera) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	_, span := trace.StartSpan(ctx, "camera::rtsp::Images")
+	defer span.End()
+	jpegFrame, img, ts, err := rc.latest()
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	var namedImg camera.NamedImage
+	if jpegFrame != nil {
+		namedImg, err = camera.NamedImageFromBytes(jpegFrame, rc.Name().Name, utils.MimeTypeJPEG)
+	} else {
+		namedImg, err = camera.NamedImageFromImage(img, rc.Name().Name, utils.MimeTypeJPEG)
+	}
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	return []camera.NamedImage{namedImg}, resource.ResponseMetadata{CapturedAt: ts}, nil
+}
+
+// latest returns the latest frame, either as JPEG bytes or as an image, unless it is older than a few frame intervals,
+// as when the camera stops sending frames without the connection being closed.
+func (rc *rtspCamera) latest() ([]byte, image.Image, time.Time, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.jpegFrame == nil && rc.frame == nil {
+		if rc.frameErr != nil {
+			return nil, nil, time.Time{}, rc.frameErr
+		}
+		return nil, nil, time.Time{}, errors.Errorf("no frame received from RTSP camera %q yet", rc.Name().Name)
+	}
+	if age := time.Since(rc.frameTime); age > max(staleFrameIntervals*rc.frameInterval, minStaleFrameAge) {
+		return nil, nil, time.Time{}, errors.Errorf("no frame received from RTSP camera %q for %v", rc.Name().Name, age)
+	}
+	return rc.jpegFrame, rc.frame, rc.frameTime, nil
+}
+
+func (rc *rtspCamera) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	return nil, errors.New("RTSP cameras do not support point clouds")
+}
+
+func (rc *rtspCamera) Properties(ctx context.Context) (camera.Properties, error) {
+	return camera.Properties{
+		ImageType:        camera.ColorStream,
+		IntrinsicParams:  rc.conf.CameraParameters,
+		DistortionParams: rc.conf.DistortionParameters,
+		MimeTypes:        []string{utils.MimeTypeJPEG, utils.MimeTypePNG},
+	}, nil
+}
+
+func (rc *rtspCamera) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return []spatialmath.Geometry{}, nil
+}
+
+// SubscribeRTP begins a subscription to the RTP packets of H.264 streams, which are repacketized to fit WebRTC with
+// the parameter sets before every IDR frame. The subscription lasts across reconnections.
+func (rc *rtspCamera) SubscribeRTP(
+	ctx context.Context,
+	bufferSize int,
+	packetsCB rtppassthrough.PacketCallback,
+) (rtppassthrough.Subscription, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.codec != "" && rc.codec != "H264" {
+		return rtppassthrough.NilSubscription, ErrPassthroughUnsupported
+	}
+	sub, buf, err := rtppassthrough.NewSubscription(bufferSize)
+	if err != nil {
+		return rtppassthrough.NilSubscription, err
+	}
+	rc.subscribers[sub.ID] = subscriber{cb: packetsCB, buf: buf}
+	buf.Start()
+	return sub, nil
+}
+
+// Unsubscribe terminates the subscription.
+func (rc *rtspCamera) Unsubscribe(ctx context.Context, id rtppassthrough.SubscriptionID) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	sub, ok := rc.subscribers[id]
+	if !ok {
+		return errors.New("id not found")
+	}
+	delete(rc.subscribers, id)
+	sub.buf.Close()
+	return nil
+}
+
+// publish sends the packets to every subscriber.
+func (rc *rtspCamera) publish(pkts []*rtp.Packet) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, sub := range rc.subscribers {
+		cb := sub.cb
+		if err := sub.buf.Publish(func() { cb(pkts) }); err != nil {
+			rc.logger.Debugw("dropping RTP packets", "error", err)
+		}
+	}
+}
+
+// unsubscribeAll terminates every subscription, when the stream is not H.264 anymore or the camera is closed.
+func (rc *rtspCamera) unsubscribeAll() {
+	for id, sub := range rc.subscribers {
+		delete(rc.subscribers, id)
+		sub.buf.Close()
+	}
+}
+
+func (rc *rtspCamera) setJPEGFrame(b []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.setFrameTime()
+	rc.jpegFrame, rc.frame = b, nil
+}
+
+func (rc *rtspCamera) setFrame(img image.Image) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.setFrameTime()
+	rc.jpegFrame, rc.frame = nil, img
+}
+
+func (rc *rtspCamera) setFrameTime() {
+	now := time.Now()
+	if !rc.frameTime.IsZero() {
+		rc.frameInterval = now.Sub(rc.frameTime)
+	}
+	rc.frameTime = now
+	rc.frameErr = nil
+}
+
+// clearFrame drops the latest frame, Image and Images returning err until the next frame.
+func (rc *rtspCamera) clearFrame(err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.jpegFrame, rc.frame, rc.frameTime, rc.frameInterval = nil, nil, time.Time{}, 0
+	rc.frameErr = err
+}
+
+func (rc *rtspCamera) Close(ctx context.Context) error {
+	rc.cancelFn()
+	rc.workers.Wait()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.unsubscribeAll()
+	return nil
+}
+
+// session holds the state of a single connection to the camera.
+type session struct {
+	rc     *rtspCamera
+	media  *description.Media
+	format format.Format
+
+	mjpeg *rtpmjpeg.Decoder
+	h264  *rtph264.Decoder
+	h265  *rtph265.Decoder
+	// encoder repacketizes H.264 access units for WebRTC
+	encoder *rtph264.Encoder
+	// params are the latest parameter sets of H.264 or H.265 streams, VPS, SPS and PPS, nil when not used
+	params [3][]byte
+	// size is the frame size in the latest SPS, and decoderSize the one the decoder was started for
+	size        image.Point
+	decoder     *ffmpegDecoder
+	decoderSize image.Point
+	noDecoder   bool
+}
+
+// newSession picks the first H.264, H.265 or MJPEG format of the stream.
+func (rc *rtspCamera) newSession(desc *description.Session) (*session, error) {
+	s := &session{rc: rc}
+	var h264Format *format.H264
+	var h265Format *format.H265
+	var mjpegFormat *format.MJPEG
+	var err error
+	switch {
+	case desc.FindFormat(&h264Format) != nil:
+		s.media, s.format = desc.FindFormat(&h264Format), h264Format
+		if s.h264, err = h264Format.CreateDecoder(); err != nil {
+			return nil, err
+		}
+		s.encoder = &rtph264.Encoder{PayloadType: 96, PayloadMaxSize: webrtcPayloadMaxSize}
+		if err := s.encoder.Init(); err != nil {
+			return nil, err
+		}
+		sps, pps := h264Format.SafeParams()
+		s.params[2] = pps
+		if sps != nil {
+			s.setH264SPS(sps)
+		}
+	case desc.FindFormat(&h265Format) != nil:
+		s.media, s.format = desc.FindFormat(&h265Format), h265Format
+		if s.h265, err = h265Format.CreateDecoder(); err != nil {
+			return nil, err
+		}
+		vps, sps, pps := h265Format.SafeParams()
+		s.params[0], s.params[2] = vps, pps
+		if sps != nil {
+			s.setH265SPS(sps)
+		}
+	case desc.FindFormat(&mjpegFormat) != nil:
+		s.media, s.format = desc.FindFormat(&mjpegFormat), mjpegFormat
+		if s.mjpeg, err = mjpegFormat.CreateDecoder(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("the RTSP stream has no H.264, H.265 or MJPEG video")
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.codec = s.format.Codec()
+	rc.frameErr = nil
+	if rc.codec != "H264" {
+		rc.unsubscribeAll()
+	}
+	return s, nil
+}
+
+// handlePacket is called with every RTP packet of the stream.
+func (s *session) handlePacket(pkt *rtp.Packet) {
+	var err error
+	switch {
+	case s.mjpeg != nil:
+		var frame []byte
+		if frame, err = s.mjpeg.Decode(pkt); err == nil {
+			s.rc.setJPEGFrame(frame)
+		}
+	case s.h264 != nil:
+		var au [][]byte
+		if au, err = s.h264.Decode(pkt); err == nil {
+			s.handleH264(au, pkt.Timestamp)
+		}
+	default:
+		var au [][]byte
+		if au, err = s.h265.Decode(pkt); err == nil {
+			s.handleH265(au)
+		}
+	}
+	if err != nil && !isMorePacketsNeeded(err) {
+		s.rc.logger.Debugw("cannot decode RTP packet", "error", err)
+	}
+}
+
+func isMorePacketsNeeded(err error) bool {
+	return errors.Is(err, rtph264.ErrMorePacketsNeeded) || errors.Is(err, rtph264.ErrNonStartingPacketAndNoPrevious) ||
+		errors.Is(err, rtph265.ErrMorePacketsNeeded) || errors.Is(err, rtph265.ErrNonStartingPacketAndNoPrevious) ||
+		errors.Is(err, rtpmjpeg.ErrMorePacketsNeeded) || errors.Is(err, rtpmjpeg.ErrNonStartingPacketAndNoPrevious)
+}
+
+func (s *session) handleH264(au [][]byte, timestamp uint32) {
+	for _, nalu := range au {
+		switch h264.NALUType(nalu[0] & 0x1f) {
+		case h264.NALUTypeSPS:
+			if !bytes.Equal(nalu, s.params[1]) {
+				s.setH264SPS(nalu)
+			}
+		case h264.NALUTypePPS:
+			s.params[2] = nalu
+		default:
+		}
+	}
+	idr := h264.IDRPresent(au)
+	if idr {
+		au = withParams(au, s.params[1:])
+	}
+
+	pkts, err := s.encoder.Encode(au)
+	if err != nil {
+		s.rc.logger.Debugw("cannot packetize access unit", "error", err)
+	} else {
+		for _, p := range pkts {
+			p.Timestamp = timestamp
+		}
+		s.rc.publish(pkts)
+	}
+	s.decode("h264", au, idr)
+}
+
+func (s *session) setH264SPS(nalu []byte) {
+	s.params[1] = nalu
+	var sps h264.SPS
+	if err := sps.Unmarshal(nalu); err != nil {
+		s.rc.logger.Debugw("cannot parse SPS", "error", err)
+		return
+	}
+	s.size = image.Pt(sps.Width(), sps.Height())
+}
+
+func (s *session) handleH265(au [][]byte) {
+	for _, nalu := range au {
+		switch h265.NALUType((nalu[0] >> 1) & 0x3f) {
+		case h265.NALUType_VPS_NUT:
+			s.params[0] = nalu
+		case h265.NALUType_SPS_NUT:
+			if !bytes.Equal(nalu, s.params[1]) {
+				s.setH265SPS(nalu)
+			}
+		case h265.NALUType_PPS_NUT:
+			s.params[2] = nalu
+		default:
+		}
+	}
+	randomAccess := h265.IsRandomAccess(au)
+	if randomAccess {
+		au = withParams(au, s.params[:])
+	}
+	s.decode("hevc", au, randomAccess)
+}
+
+func (s *session) setH265SPS(nalu []byte) {
+	s.params[1] = nalu
+	var sps h265.SPS
+	if err := sps.Unmarshal(nalu); err != nil {
+		s.rc.logger.Debugw("cannot parse SPS", "error", err)
+		return
+	}
+	s.size = image.Pt(sps.Width(), sps.Height())
+}
+
+// withParams prepends the parameter sets missing from the access unit.
+func withParams(au, params [][]byte) [][]byte {
+	var missing [][]byte
+	for _, p := range params {
+		if p == nil {
+			continue
+		}
+		found := false
+		for _, nalu := range au {
+			if bytes.Equal(nalu, p) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return au
+	}
+	return append(missing, au...)
+}
+
+// decode passes the access unit to the decoder, starting it at the first random access point and again whenever the
+// frame size changes or the decoder exited.
+func (s *session) decode(codec string, au [][]byte, randomAccess bool) {
+	if s.noDecoder || s.size == (image.Point{}) {
+		return
+	}
+	if s.decoder != nil && (s.size != s.decoderSize || s.decoder.exited()) && randomAccess {
+		s.decoder.close()
+		s.decoder = nil
+	}
+	if s.decoder == nil {
+		if !randomAccess {
+			return
+		}
+		d, err := newFFmpegDecoder(s.rc.conf.ffmpeg(), codec, s.size.X, s.size.Y, s.rc.setFrame, s.onDecoderExit, s.rc.logger)
+		if err != nil {
+			// do not try again for every frame
+			s.noDecoder = true
+			s.rc.clearFrame(err)
+			s.rc.logger.Warnw("cannot decode RTSP stream", "error", err)
+			return
+		}
+		s.decoder, s.decoderSize = d, s.size
+	}
+	s.decoder.decode(au, randomAccess)
+}
+
+// onDecoderExit is called when the decoder exits on its own, which is retried at the next random access point.
+func (s *session) onDecoderExit(err error) {
+	s.rc.clearFrame(err)
+	s.rc.logger.Warnw("RTSP stream decoder exited, restarting it at the next random access point", "error", err)
+}
+
+func (s *session) close() {
+	if s.decoder != nil {
+		s.decoder.close()
+	}
+}
@@ -0,0 +1,558 @@
+// Package synchronizer defines a camera that buffers the frames of several source cameras and returns sets of frames
+// captured at the same time, within a tolerance, for stereo and multi-view pipelines. Sources may be triggered together
+// through a board GPIO pin for hardware synchronization.
+package synchronizer
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("synchronizer")
+
+const (
+	defaultToleranceMs = 20
+	defaultBufferSize  = 8
+	defaultFrameRate   = 30
+	defaultMaxWaitMs   = 1000
+	defaultPulseMs     = 1
+	// skewWindow is the number of latest sets skew statistics are computed over.
+	skewWindow = 100
+)
+
+func init() {
+	resource.RegisterComponent(camera.API, model,
+		resource.Registration[camera.Camera, *Config]{
+			Constructor: func(
+				ctx context.Context,
+				deps resource.Dependencies,
+				conf resource.Config,
+				logger logging.Logger,
+			) (camera.Camera, error) {
+				newConf, err := resource.NativeConfig[*Config](conf)
+				if err != nil {
+					return nil, err
+				}
+				cams := make([]camera.Camera, 0, len(newConf.Cameras))
+				for _, name := range newConf.Cameras {
+					cam, err := camera.FromProvider(deps, name)
+					if err != nil {
+						return nil, errors.Wrapf(err, "no source camera (%s)", name)
+					}
+					cams = append(cams, cam)
+				}
+				var pin board.GPIOPin
+				if newConf.Trigger != nil {
+					b, err := board.FromProvider(deps, newConf.Trigger.Board)
+					if err != nil {
+						return nil, errors.Wrapf(err, "no trigger board (%s)", newConf.Trigger.Board)
+					}
+					if pin, err = b.GPIOPinByName(newConf.Trigger.Pin); err != nil {
+						return nil, err
+					}
+				}
+				return newSynchronizer(conf.ResourceName().AsNamed(), cams, pin, newConf, logger), nil
+			},
+		})
+}
+
+// Config is the attribute struct for the synchronizer camera. Sources are read frame_rate times per second, after
+// pulsing the trigger pin if there is one, keeping their last buffer_size frames captured in the last max_age_ms, by
+// default the time the buffer takes to fill at the frame rate.
+type Config struct {
+	Cameras     []string       `json:"camera_names"`
+	ToleranceMs float64        `json:"tolerance_ms,omitempty"`
+	BufferSize  int            `json:"buffer_size,omitempty"`
+	FrameRate   float64        `json:"frame_rate,omitempty"`
+	MaxWaitMs   float64        `json:"max_wait_ms,omitempty"`
+	MaxAgeMs    float64        `json:"max_age_ms,omitempty"`
+	Trigger     *TriggerConfig `json:"trigger,omitempty"`
+}
+
+// TriggerConfig configures the GPIO pin of a board which triggers the source cameras. The pin is pulsed high, or low
+// when active_low is set, for pulse_ms, and the sources are read read_delay_ms after the start of the pulse, giving
+// them time to capture the frame.
+type TriggerConfig struct {
+	Board       string  `json:"board"`
+	Pin         string  `json:"pin"`
+	PulseMs     float64 `json:"pulse_ms,omitempty"`
+	ReadDelayMs float64 `json:"read_delay_ms,omitempty"`
+	ActiveLow   bool    `json:"active_low,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if len(cfg.Cameras) == 0 {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "camera_names")
+	}
+	for i, name := range cfg.Cameras {
+		if name == "" || slices.Contains(cfg.Cameras[:i], name) {
+			return nil, nil, resource.NewConfigValidationError(path, errors.Errorf("invalid or duplicate camera name %q", name))
+		}
+	}
+	if cfg.ToleranceMs < 0 || cfg.BufferSize < 0 || cfg.FrameRate < 0 || cfg.MaxWaitMs < 0 || cfg.MaxAgeMs < 0 {
+		return nil, nil, resource.NewConfigValidationError(path,
+			errors.New("tolerance_ms, buffer_size, frame_rate, max_wait_ms and max_age_ms cannot be negative"))
+	}
+	deps := slices.Clone(cfg.Cameras)
+	if cfg.Trigger != nil {
+		if cfg.Trigger.Board == "" {
+			return nil, nil, resource.NewConfigValidationFieldRequiredError(path+".trigger", "board")
+		}
+		if cfg.Trigger.Pin == "" {
+			return nil, nil, resource.NewConfigValidationFieldRequiredError(path+".trigger", "pin")
+		}
+		if cfg.Trigger.PulseMs < 0 || cfg.Trigger.ReadDelayMs < 0 {
+			return nil, nil, resource.NewConfigValidationError(path, errors.New("pulse_ms and read_delay_ms cannot be negative"))
+		}
+		deps = append(deps, cfg.Trigger.Board)
+	}
+	return deps, nil, nil
+}
+
+// frame is a frame read from a source camera.
+type frame struct {
+	images     []camera.NamedImage
+	capturedAt time.Time
+}
+
+// synchronizer buffers frames of its sources and returns sets of them captured within a tolerance of each other.
+type synchronizer struct {
+	resource.Named
+	resource.AlwaysRebuild
+	cams      []camera.Camera
+	names     []string
+	tolerance time.Duration
+	maxWait   time.Duration
+	maxAge    time.Duration
+	bufSize   int
+	logger    logging.Logger
+	workers   *goutils.StoppableWorkers
+
+	mu sync.Mutex
+	// frames are the buffered frames of each source, oldest first
+	frames [][]frame
+	// newFrame is closed and replaced when a frame is buffered
+	newFrame chan struct{}
+	stats    stats
+}
+
+// stats are the statistics of the sets of frames returned.
+type stats struct {
+	sets      int
+	unmatched int
+	// skews are the latest skews, the durations between the first and last frames of sets
+	skews []time.Duration
+	// offsets are the sums of the offsets of the frames of each source from the mean time of their sets
+	offsets []time.Duration
+	frames  []int
+}
+
+func newSynchronizer(
+	named resource.Named,
+	cams []camera.Camera,
+	pin board.GPIOPin,
+	cfg *Config,
+	logger logging.Logger,
+) camera.Camera {
+	s := &synchronizer{
+		Named:     named,
+		cams:      cams,
+		names:     cfg.Cameras,
+		tolerance: msOrDefault(cfg.ToleranceMs, defaultToleranceMs),
+		maxWait:   msOrDefault(cfg.MaxWaitMs, defaultMaxWaitMs),
+		bufSize:   cfg.BufferSize,
+		logger:    logger,
+		workers:   goutils.NewBackgroundStoppableWorkers(),
+		frames:    make([][]frame, len(cams)),
+		newFrame:  make(chan struct{}),
+		stats:     stats{offsets: make([]time.Duration, len(cams)), frames: make([]int, len(cams))},
+	}
+	if s.bufSize == 0 {
+		s.bufSize = defaultBufferSize
+	}
+	frameRate := cfg.FrameRate
+	if frameRate == 0 {
+		frameRate = defaultFrameRate
+	}
+	period := time.Duration(float64(time.Second) / frameRate)
+	s.maxAge = msOrDefault(cfg.MaxAgeMs, toMs(time.Duration(s.bufSize)*period))
+
+	if pin == nil {
+		// free running sources are each read at the frame rate
+		for i := range cams {
+			s.workers.Add(func(ctx context.Context) {
+				ticker := time.NewTicker(period)
+				defer ticker.Stop()
+				for {
+					s.read(ctx, i, time.Time{})
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+				}
+			})
+		}
+		return s
+	}
+
+	pulse := msOrDefault(cfg.Trigger.PulseMs, defaultPulseMs)
+	readDelay := time.Duration(cfg.Trigger.ReadDelayMs * float64(time.Millisecond))
+	s.workers.Add(func(ctx context.Context) {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			triggeredAt, err := s.trigger(ctx, pin, pulse, !cfg.Trigger.ActiveLow)
+			if err != nil {
+				s.logger.CWarnw(ctx, "cannot trigger source cameras", "error", err)
+			} else if goutils.SelectContextOrWait(ctx, time.Until(triggeredAt.Add(readDelay))) {
+				var wg sync.WaitGroup
+				for i := range cams {
+					wg.Add(1)
+					goutils.PanicCapturingGo(func() {
+						defer wg.Done()
+						s.read(ctx, i, triggeredAt)
+					})
+				}
+				wg.Wait()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	return s
+}
+
+func msOrDefault(ms, def float64) time.Duration {
+	if ms == 0 {
+		ms = def
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// trigger pulses the trigger pin, returning when the pulse started.
+func (s *synchronizer) trigger(ctx context.Context, pin board.GPIOPin, pulse time.Duration, active bool) (time.Time, error) {
+	start := time.Now()
+	if err := pin.Set(ctx, active, nil); err != nil {
+		return start, err
+	}
+	goutils.SelectContextOrWait(ctx, pulse)
+	// the pin is reset even when ctx is done
+	return start, pin.Set(context.Background(), !active, nil)
+}
+
+// read reads a frame of the source i, which was captured when the camera says so, at triggeredAt if the source was
+// triggered, or between the request and its response otherwise.
+func (s *synchronizer) read(ctx context.Context, i int, triggeredAt time.Time) {
+	requested := time.Now()
+	images, meta, err := s.cams[i].Images(ctx, nil, nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.CDebugw(ctx, "cannot read source camera", "camera", s.names[i], "error", err)
+		}
+		return
+	}
+	if len(images) == 0 {
+		return
+	}
+	capturedAt := meta.CapturedAt
+	switch {
+	case !capturedAt.IsZero():
+	case !triggeredAt.IsZero():
+		capturedAt = triggeredAt
+	default:
+		capturedAt = requested.Add(time.Since(requested) / 2)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames := s.frames[i]
+	if n := len(frames); n > 0 && !capturedAt.After(frames[n-1].capturedAt) {
+		// the frame was already read, or is out of order
+		return
+	}
+	if len(frames) == s.bufSize {
+		frames = append(frames[:0], frames[1:]...)
+	}
+	s.frames[i] = append(frames, frame{images: images, capturedAt: capturedAt})
+	s.stats.frames[i]++
+	close(s.newFrame)
+	s.newFrame = make(chan struct{})
+}
+
+// matchFrames returns the indexes of the frames of each source of the latest set of frames captured within the
+// tolerance of each other. Sets are looked for around each frame, latest first, taking the closest frame of each other
+// source.
+func matchFrames(frames [][]frame, tolerance time.Duration) ([]int, bool) {
+	type anchor struct {
+		source, index int
+	}
+	var anchors []anchor
+	for source, sourceFrames := range frames {
+		if len(sourceFrames) == 0 {
+			return nil, false
+		}
+		for index := range sourceFrames {
+			anchors = append(anchors, anchor{source, index})
+		}
+	}
+	sort.Slice(anchors, func(a, b int) bool {
+		return frames[anchors[a].source][anchors[a].index].capturedAt.After(frames[anchors[b].source][anchors[b].index].capturedAt)
+	})
+
+	set := make([]int, len(frames))
+	for _, a := range anchors {
+		t := frames[a.source][a.index].capturedAt
+		first, last := t, t
+		for source, sourceFrames := range frames {
+			best := 0
+			for index, f := range sourceFrames {
+				if absDuration(f.capturedAt.Sub(t)) < absDuration(sourceFrames[best].capturedAt.Sub(t)) {
+					best = index
+				}
+			}
+			set[source] = best
+			first = minTime(first, sourceFrames[best].capturedAt)
+			last = maxTime(last, sourceFrames[best].capturedAt)
+		}
+		if last.Sub(first) <= tolerance {
+			return set, true
+		}
+	}
+	return nil, false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// expire drops the frames captured before oldest, so that a source which stopped returning frames does not match its
+// last frames with the latest frames of the others. It must be called with the mutex held.
+func (s *synchronizer) expire(oldest time.Time) {
+	for source, frames := range s.frames {
+		n := 0
+		for n < len(frames) && frames[n].capturedAt.Before(oldest) {
+			n++
+		}
+		s.frames[source] = frames[n:]
+	}
+}
+
+// latestSet returns the latest set of frames of the sources captured within the tolerance of each other and the max
+// age, and the mean time they were captured at, waiting up to the max wait for one.
+func (s *synchronizer) latestSet(ctx context.Context) ([]frame, time.Time, error) {
+	timeout := time.NewTimer(s.maxWait)
+	defer timeout.Stop()
+	for {
+		s.mu.Lock()
+		s.expire(time.Now().Add(-s.maxAge))
+		indexes, ok := matchFrames(s.frames, s.tolerance)
+		if ok {
+			set := make([]frame, len(indexes))
+			for source, index := range indexes {
+				set[source] = s.frames[source][index]
+			}
+			mean := s.recordSet(set)
+			s.mu.Unlock()
+			return set, mean, nil
+		}
+		newFrame := s.newFrame
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, time.Time{}, ctx.Err()
+		case <-s.workers.Context().Done():
+			return nil, time.Time{}, errors.New("synchronizer camera is closed")
+		case <-timeout.C:
+			s.mu.Lock()
+			s.stats.unmatched++
+			s.mu.Unlock()
+			return nil, time.Time{}, errors.Errorf("no frames of the source cameras captured in the last %v were captured within %v of each other",
+				s.maxAge, s.tolerance)
+		case <-newFrame:
+		}
+	}
+}
+
+// recordSet records the skew and offsets of the set in the stats, returning the mean time the set was captured at.
+// It must be called with the mutex held.
+func (s *synchronizer) recordSet(set []frame) time.Time {
+	first, last := set[0].capturedAt, set[0].capturedAt
+	var sum time.Duration
+	for _, f := range set {
+		first = minTime(first, f.capturedAt)
+		last = maxTime(last, f.capturedAt)
+		sum += f.capturedAt.Sub(set[0].capturedAt)
+	}
+	mean := set[0].capturedAt.Add(sum / time.Duration(len(set)))
+	for source, f := range set {
+		s.stats.offsets[source] += f.capturedAt.Sub(mean)
+	}
+	s.stats.sets++
+	s.stats.skews = append(s.stats.skews, last.Sub(first))
+	if len(s.stats.skews) > skewWindow {
+		s.stats.skews = s.stats.skews[1:]
+	}
+	return mean
+}
+
+// Image returns the image of the first source camera in the latest set of frames.
+func (s *synchronizer) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::synchronizer::Image")
+	defer span.End()
+	set, _, err := s.latestSet(ctx)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	namedImg := set[0].images[0]
+	actualType, _ := utils.CheckLazyMIMEType(mimeType)
+	if actualType == "" || actualType == namedImg.MimeType() {
+		imgBytes, err := namedImg.Bytes(ctx)
+		if err != nil {
+			return nil, camera.ImageMetadata{}, err
+		}
+		return imgBytes, camera.ImageMetadata{MimeType: namedImg.MimeType()}, nil
+	}
+	img, err := namedImg.Image(ctx)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	imgBytes, err := rimage.EncodeImage(ctx, img, mimeType)
+	if err != nil {
+		return nil, camera.ImageMetadata{}, err
+	}
+	return imgBytes, camera.ImageMetadata{MimeType: mimeType}, nil
+}
+
+// Images returns the images of the latest set of frames of the source cameras captured within the tolerance of each
+// other, named after their cameras, or after their cameras and sources as "camera/source" for cameras returning
+// several images. The set is timestamped with the mean time its frames were captured at.
+func (s *synchronizer) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::synchronizer::Images")
+	defer span.End()
+	set, mean, err := s.latestSet(ctx)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	var images []camera.NamedImage
+	for source, f := range set {
+		for _, img := range f.images {
+			img.SourceName = s.names[source] + "/" + img.SourceName
+			if len(f.images) == 1 {
+				img.SourceName = s.names[source]
+			}
+			if len(filterSourceNames) == 0 || slices.Contains(filterSourceNames, img.SourceName) {
+				images = append(images, img)
+			}
+		}
+	}
+	return images, resource.ResponseMetadata{CapturedAt: mean}, nil
+}
+
+// DoCommand returns the skew statistics of the sets of frames returned with the "stats" command: the number of sets
+// returned and of requests no set matched, the mean, 95th percentile and maximum skews of the latest sets, the mean
+// offset of the frames of each source from the time of their sets and the number of frames read from each source.
+func (s *synchronizer) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := cmd["stats"]; !ok {
+		return nil, resource.ErrDoUnimplemented
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	skews := slices.Clone(s.stats.skews)
+	slices.Sort(skews)
+	var sum time.Duration
+	for _, skew := range skews {
+		sum += skew
+	}
+	skewStats := map[string]interface{}{}
+	if len(skews) > 0 {
+		skewStats["mean"] = toMs(sum / time.Duration(len(skews)))
+		skewStats["p95"] = toMs(skews[int(math.Ceil(0.95*float64(len(skews))))-1])
+		skewStats["max"] = toMs(skews[len(skews)-1])
+	}
+	offsets := map[string]interface{}{}
+	frames := map[string]interface{}{}
+	for source, name := range s.names {
+		if s.stats.sets > 0 {
+			offsets[name] = toMs(s.stats.offsets[source] / time.Duration(s.stats.sets))
+		}
+		frames[name] = s.stats.frames[source]
+	}
+	return map[string]interface{}{
+		"sets":       s.stats.sets,
+		"unmatched":  s.stats.unmatched,
+		"skew_ms":    skewStats,
+		"offsets_ms": offsets,
+		"frames":     frames,
+	}, nil
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (s *synchronizer) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	return nil, errors.New("synchronizer cameras do not support point clouds")
+}
+
+// Properties returns the properties of the first source camera, without point cloud support.
+func (s *synchronizer) Properties(ctx context.Context) (camera.Properties, error) {
+	props, err := s.cams[0].Properties(ctx)
+	if err != nil {
+		return camera.Properties{}, err
+	}
+	props.SupportsPCD = false
+	return props, nil
+}
+
+func (s *synchronizer) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return []spatialmath.Geometry{}, nil
+}
+
+func (s *synchronizer) Close(ctx context.Context) error {
+	s.workers.Stop()
+	return nil
+}
@@ -0,0 +1,264 @@
+package synchronizer
+
+import (
+	"context"
+	"image"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+func TestConfig(t *testing.T) {
+	conf := &Config{}
+	_, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Cameras = []string{"left", "left"}
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Cameras = []string{"left", "right"}
+	deps, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"left", "right"})
+
+	conf.ToleranceMs = -1
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.ToleranceMs = 0
+	conf.MaxAgeMs = -1
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.MaxAgeMs = 0
+	conf.Trigger = &TriggerConfig{Board: "board"}
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Trigger.Pin = "11"
+	deps, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"left", "right", "board"})
+	test.That(t, conf.Cameras, test.ShouldResemble, []string{"left", "right"})
+}
+
+func TestMatchFrames(t *testing.T) {
+	start := time.Now()
+	frames := func(offsetsMs ...int) []frame {
+		var fs []frame
+		for _, ms := range offsetsMs {
+			fs = append(fs, frame{capturedAt: start.Add(time.Duration(ms) * time.Millisecond)})
+		}
+		return fs
+	}
+
+	// the latest set within the tolerance
+	set, ok := matchFrames([][]frame{frames(0, 33, 66, 100), frames(2, 36, 80), frames(1, 34, 70)}, 5*time.Millisecond)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, set, test.ShouldResemble, []int{1, 1, 1})
+
+	set, ok = matchFrames([][]frame{frames(0, 33, 66, 100), frames(2, 36, 80), frames(1, 34, 70)}, 15*time.Millisecond)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, set, test.ShouldResemble, []int{2, 2, 2})
+
+	_, ok = matchFrames([][]frame{frames(0, 33, 66), frames(10, 45, 80)}, 5*time.Millisecond)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	_, ok = matchFrames([][]frame{frames(0, 33), nil}, time.Second)
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+// newSource returns a camera returning images of the given width captured on a grid of the period, offset by the
+// offset, or without capture times when the period is 0.
+func newSource(name string, width int, period, offset time.Duration) *inject.Camera {
+	cam := inject.NewCamera(name)
+	cam.ImagesFunc = func(
+		ctx context.Context,
+		filterSourceNames []string,
+		extra map[string]interface{},
+	) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+		img, err := camera.NamedImageFromImage(image.NewRGBA(image.Rect(0, 0, width, 1)), "color", utils.MimeTypePNG)
+		if err != nil {
+			return nil, resource.ResponseMetadata{}, err
+		}
+		var meta resource.ResponseMetadata
+		if period > 0 {
+			meta.CapturedAt = time.Now().Add(-offset).Truncate(period).Add(offset)
+		}
+		return []camera.NamedImage{img}, meta, nil
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{SupportsPCD: true, MimeTypes: []string{utils.MimeTypePNG}}, nil
+	}
+	return cam
+}
+
+func TestSynchronizer(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	period := 20 * time.Millisecond
+	left := newSource("left", 1, period, 0)
+	right := newSource("right", 2, period, 3*time.Millisecond)
+	conf := &Config{Cameras: []string{"left", "right"}, ToleranceMs: 5, FrameRate: 200}
+	cam := newSynchronizer(camera.Named("sync").AsNamed(), []camera.Camera{left, right}, nil, conf, logger)
+	defer cam.Close(ctx)
+
+	images, meta, err := cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, images, test.ShouldHaveLength, 2)
+	test.That(t, images[0].SourceName, test.ShouldEqual, "left")
+	test.That(t, images[1].SourceName, test.ShouldEqual, "right")
+	leftImg, err := images[0].Image(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, leftImg.Bounds().Dx(), test.ShouldEqual, 1)
+	rightImg, err := images[1].Image(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rightImg.Bounds().Dx(), test.ShouldEqual, 2)
+	// the mean of the capture times of the frames
+	test.That(t, meta.CapturedAt.Sub(meta.CapturedAt.Truncate(period)), test.ShouldEqual, 1500*time.Microsecond)
+
+	images, _, err = cam.Images(ctx, []string{"right"}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, images, test.ShouldHaveLength, 1)
+	test.That(t, images[0].SourceName, test.ShouldEqual, "right")
+
+	imgBytes, imgMeta, err := cam.Image(ctx, utils.MimeTypeJPEG, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, imgMeta.MimeType, test.ShouldEqual, utils.MimeTypeJPEG)
+	img, err := rimage.DecodeImage(ctx, imgBytes, utils.MimeTypeJPEG)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img.Bounds().Dx(), test.ShouldEqual, 1)
+
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeFalse)
+
+	stats, err := cam.DoCommand(ctx, map[string]interface{}{"stats": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stats["sets"], test.ShouldEqual, 3)
+	test.That(t, stats["unmatched"], test.ShouldEqual, 0)
+	test.That(t, stats["skew_ms"], test.ShouldResemble, map[string]interface{}{"mean": 3.0, "p95": 3.0, "max": 3.0})
+	test.That(t, stats["offsets_ms"], test.ShouldResemble, map[string]interface{}{"left": -1.5, "right": 1.5})
+	frames := stats["frames"].(map[string]interface{})
+	test.That(t, frames["left"], test.ShouldBeGreaterThan, 0)
+
+	_, err = cam.DoCommand(ctx, map[string]interface{}{"foo": true})
+	test.That(t, err, test.ShouldEqual, resource.ErrDoUnimplemented)
+}
+
+func TestSynchronizerUnmatched(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	period := 40 * time.Millisecond
+	left := newSource("left", 1, period, 0)
+	right := newSource("right", 1, period, 10*time.Millisecond)
+	conf := &Config{Cameras: []string{"left", "right"}, ToleranceMs: 5, FrameRate: 100, MaxWaitMs: 100}
+	cam := newSynchronizer(camera.Named("sync").AsNamed(), []camera.Camera{left, right}, nil, conf, logger)
+	defer cam.Close(ctx)
+
+	_, _, err := cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "within 5ms")
+	stats, err := cam.DoCommand(ctx, map[string]interface{}{"stats": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stats["sets"], test.ShouldEqual, 0)
+	test.That(t, stats["unmatched"], test.ShouldEqual, 1)
+
+	test.That(t, cam.Close(ctx), test.ShouldBeNil)
+	_, _, err = cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestSynchronizerStalledSource(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	period := 20 * time.Millisecond
+	left := newSource("left", 1, period, 0)
+	right := newSource("right", 1, period, 0)
+	var stalled atomic.Bool
+	rightImages := right.ImagesFunc
+	right.ImagesFunc = func(
+		ctx context.Context,
+		filterSourceNames []string,
+		extra map[string]interface{},
+	) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+		if stalled.Load() {
+			return nil, resource.ResponseMetadata{}, errors.New("stalled")
+		}
+		return rightImages(ctx, filterSourceNames, extra)
+	}
+	conf := &Config{Cameras: []string{"left", "right"}, ToleranceMs: 5, FrameRate: 100, MaxWaitMs: 200, MaxAgeMs: 50}
+	cam := newSynchronizer(camera.Named("sync").AsNamed(), []camera.Camera{left, right}, nil, conf, logger)
+	defer cam.Close(ctx)
+
+	_, meta, err := cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, time.Since(meta.CapturedAt), test.ShouldBeLessThan, 50*time.Millisecond+period)
+
+	// the last frames of the stalled source expire instead of matching old frames of the other source
+	stalled.Store(true)
+	time.Sleep(100 * time.Millisecond)
+	_, _, err = cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "in the last 50ms")
+
+	stalled.Store(false)
+	_, meta, err = cam.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, time.Since(meta.CapturedAt), test.ShouldBeLessThan, 50*time.Millisecond+period)
+}
+
+func TestSynchronizerTrigger(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	var mu sync.Mutex
+	var levels []bool
+	pin := &inject.GPIOPin{}
+	pin.SetFunc = func(ctx context.Context, high bool, extra map[string]interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		levels = append(levels, high)
+		return nil
+	}
+	// the sources do not timestamp their frames, which are timestamped with the trigger pulses
+	left := newSource("left", 1, 0, 0)
+	right := newSource("right", 1, 0, 0)
+	conf := &Config{
+		Cameras:   []string{"left", "right"},
+		FrameRate: 100,
+		Trigger:   &TriggerConfig{Board: "board", Pin: "11", ActiveLow: true, ReadDelayMs: 2},
+	}
+	cam := newSynchronizer(camera.Named("sync").AsNamed(), []camera.Camera{left, right}, board.GPIOPin(pin), conf, logger)
+	defer cam.Close(ctx)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := cam.Images(ctx, nil, nil)
+		test.That(t, err, test.ShouldBeNil)
+	}
+	stats, err := cam.DoCommand(ctx, map[string]interface{}{"stats": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stats["skew_ms"].(map[string]interface{})["max"], test.ShouldEqual, 0.0)
+
+	test.That(t, cam.Close(ctx), test.ShouldBeNil)
+	mu.Lock()
+	defer mu.Unlock()
+	// active low pulses
+	test.That(t, len(levels), test.ShouldBeGreaterThanOrEqualTo, 2)
+	test.That(t, len(levels)%2, test.ShouldEqual, 0)
+	for i, high := range levels {
+		test.That(t, high, test.ShouldEqual, i%2 == 1)
+	}
+}
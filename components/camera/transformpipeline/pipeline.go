@@ -156,6 +156,9 @@ func newTransformPipeline(
 	if err != nil {
 		return nil, err
 	}
+	// intrinsicsSource is the output of the last undistort or rectify, as long as no later transform changed the
+	// geometry of the images, whose intrinsics are those of the pipeline when none are configured
+	var intrinsicsSource camera.VideoSource
 	for _, tr := range cfg.Pipeline {
 		src, newStreamType, err := buildTransform(ctx, r, lastSource, streamType, tr)
 		if err != nil {
@@ -168,8 +171,21 @@ func newTransformPipeline(
 		pipeline = append(pipeline, streamSrc)
 		lastSource = streamSrc
 		streamType = newStreamType
+		//nolint:exhaustive
+		switch transformType(tr.Type) {
+		case transformTypeUndistort, transformTypeRectify:
+			intrinsicsSource = streamSrc
+		case transformTypeRotate, transformTypeResize, transformTypeCrop:
+			intrinsicsSource = nil
+		}
 	}
 	cameraModel := camera.NewPinholeModelWithBrownConradyDistortion(cfg.CameraParameters, cfg.DistortionParameters)
+	if cfg.CameraParameters == nil && intrinsicsSource != nil {
+		if props, err := intrinsicsSource.Properties(ctx); err == nil && props.IntrinsicParams != nil {
+			cameraModel.PinholeCameraIntrinsics = props.IntrinsicParams
+			cameraModel.Distortion = props.DistortionParams
+		}
+	}
 	return camera.NewVideoSourceFromReader(
 		ctx,
 		transformPipeline{named, pipeline, lastSource, cfg.CameraParameters, logger},
@@ -196,6 +212,18 @@ func (tp transformPipeline) Read(ctx context.Context) (image.Image, func(), erro
 	return img, func() {}, nil
 }
 
+// Images returns the images of the last transform of the pipeline, passing extra through to it so that it can tell
+// requests of data capture apart.
+func (tp transformPipeline) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::Images")
+	defer span.End()
+	return tp.src.Images(ctx, filterSourceNames, extra)
+}
+
 func (tp transformPipeline) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
 	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::NextPointCloud")
 	defer span.End()
@@ -0,0 +1,292 @@
+package transformpipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/robot"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/segmentation"
+)
+
+var pointCloudModel = resource.DefaultModelFamily.WithModel("pointcloud_transform")
+
+// pointCloudStageType is the list of allowed stages that can be used in the point cloud pipeline.
+type pointCloudStageType string
+
+// the allowed point cloud stages.
+const (
+	pointCloudStageCropBox            = pointCloudStageType("crop_box")
+	pointCloudStageVoxelDownsample    = pointCloudStageType("voxel_downsample")
+	pointCloudStageStatisticalOutlier = pointCloudStageType("statistical_outlier")
+	pointCloudStageRemoveGroundPlane  = pointCloudStageType("remove_ground_plane")
+	pointCloudStageTransformFrame     = pointCloudStageType("transform_frame")
+)
+
+func init() {
+	resource.RegisterComponent(
+		camera.API,
+		pointCloudModel,
+		resource.Registration[camera.Camera, *pointCloudPipelineConfig]{
+			DeprecatedRobotConstructor: func(
+				ctx context.Context,
+				r any,
+				conf resource.Config,
+				logger logging.Logger,
+			) (camera.Camera, error) {
+				actualR, err := utils.AssertType[robot.Robot](r)
+				if err != nil {
+					return nil, err
+				}
+				newConf, err := resource.NativeConfig[*pointCloudPipelineConfig](conf)
+				if err != nil {
+					return nil, err
+				}
+				source, err := camera.FromProvider(actualR, newConf.Source)
+				if err != nil {
+					return nil, fmt.Errorf("no source camera for point cloud pipeline (%s): %w", newConf.Source, err)
+				}
+				return newPointCloudPipeline(source, conf.ResourceName().AsNamed(), newConf, actualR, logger)
+			},
+		})
+}
+
+// pointCloudPipelineConfig specifies a source camera and a list of stages to apply on the point clouds coming from it.
+type pointCloudPipelineConfig struct {
+	Source   string           `json:"source"`
+	Pipeline []Transformation `json:"pipeline"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *pointCloudPipelineConfig) Validate(path string) ([]string, []string, error) {
+	if len(cfg.Source) == 0 {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "source")
+	}
+	if len(cfg.Pipeline) == 0 {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "pipeline")
+	}
+	for i, tr := range cfg.Pipeline {
+		// the robot is only used when the stage runs, so building the stage checks its attributes
+		if _, err := buildPointCloudStage(nil, cfg.Source, tr); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid stage %d of point cloud pipeline", i)
+		}
+	}
+	return []string{cfg.Source}, nil, nil
+}
+
+// cropBoxConfig are the attributes for a crop_box stage, in mm.
+type cropBoxConfig struct {
+	Min r3.Vector `json:"min"`
+	Max r3.Vector `json:"max"`
+}
+
+// voxelDownsampleConfig are the attributes for a voxel_downsample stage.
+type voxelDownsampleConfig struct {
+	VoxelSize float64 `json:"voxel_size_mm"`
+}
+
+// statisticalOutlierConfig are the attributes for a statistical_outlier stage.
+type statisticalOutlierConfig struct {
+	MeanK        int     `json:"mean_k"`
+	StdDevThresh float64 `json:"std_dev_threshold"`
+}
+
+// groundPlaneConfig are the attributes for a remove_ground_plane stage. The normal of the ground defaults to +z, so
+// the stage usually follows a transform_frame stage into the world frame.
+type groundPlaneConfig struct {
+	DistanceThresh float64    `json:"distance_threshold_mm"`
+	MinPoints      int        `json:"min_points"`
+	AngleThresh    float64    `json:"angle_threshold_degs"`
+	Normal         *r3.Vector `json:"ground_normal,omitempty"`
+}
+
+// transformFrameConfig are the attributes for a transform_frame stage. The source frame defaults to the frame of the
+// source camera, and the destination frame to the world frame.
+type transformFrameConfig struct {
+	SourceFrame      string `json:"source_frame,omitempty"`
+	DestinationFrame string `json:"destination_frame,omitempty"`
+}
+
+// pointCloudStage is a single step of the point cloud pipeline.
+type pointCloudStage func(ctx context.Context, pc pointcloud.PointCloud) (pointcloud.PointCloud, error)
+
+// filterStage adapts a point cloud filter into a stage.
+func filterStage(filter func(in, out pointcloud.PointCloud) error) pointCloudStage {
+	return func(ctx context.Context, pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+		out := pointcloud.NewBasicEmpty()
+		if err := filter(pc, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+// buildPointCloudStage uses the Transformation config to build the desired point cloud stage.
+func buildPointCloudStage(r robot.Robot, sourceName string, tr Transformation) (pointCloudStage, error) {
+	switch pointCloudStageType(tr.Type) {
+	case pointCloudStageCropBox:
+		conf, err := resource.TransformAttributeMap[*cropBoxConfig](tr.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse crop_box attribute map")
+		}
+		filter, err := pointcloud.CropBoxFilter(conf.Min, conf.Max)
+		if err != nil {
+			return nil, err
+		}
+		return filterStage(filter), nil
+	case pointCloudStageVoxelDownsample:
+		conf, err := resource.TransformAttributeMap[*voxelDownsampleConfig](tr.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse voxel_downsample attribute map")
+		}
+		filter, err := pointcloud.VoxelDownsampleFilter(conf.VoxelSize)
+		if err != nil {
+			return nil, err
+		}
+		return filterStage(filter), nil
+	case pointCloudStageStatisticalOutlier:
+		conf, err := resource.TransformAttributeMap[*statisticalOutlierConfig](tr.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse statistical_outlier attribute map")
+		}
+		filter, err := pointcloud.StatisticalOutlierFilter(conf.MeanK, conf.StdDevThresh)
+		if err != nil {
+			return nil, err
+		}
+		return filterStage(filter), nil
+	case pointCloudStageRemoveGroundPlane:
+		conf, err := resource.TransformAttributeMap[*groundPlaneConfig](tr.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse remove_ground_plane attribute map")
+		}
+		if conf.DistanceThresh <= 0 {
+			return nil, errors.New("distance_threshold_mm for remove_ground_plane must be positive")
+		}
+		normal := r3.Vector{Z: 1}
+		if conf.Normal != nil {
+			if conf.Normal.Norm() == 0 {
+				return nil, errors.New("ground_normal for remove_ground_plane cannot be the zero vector")
+			}
+			normal = conf.Normal.Normalize()
+		}
+		return func(ctx context.Context, pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			seg := segmentation.NewPointCloudGroundPlaneSegmentation(pc, conf.DistanceThresh, conf.MinPoints, conf.AngleThresh, normal)
+			_, nonGround, err := seg.FindGroundPlane(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return nonGround, nil
+		}, nil
+	case pointCloudStageTransformFrame:
+		conf, err := resource.TransformAttributeMap[*transformFrameConfig](tr.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse transform_frame attribute map")
+		}
+		src := conf.SourceFrame
+		if src == "" {
+			src = sourceName
+		}
+		dst := conf.DestinationFrame
+		if dst == "" {
+			dst = referenceframe.World
+		}
+		return func(ctx context.Context, pc pointcloud.PointCloud) (pointcloud.PointCloud, error) {
+			if r == nil {
+				return nil, errors.New("transform_frame stage requires a robot")
+			}
+			return r.TransformPointCloud(ctx, pc, src, dst)
+		}, nil
+	default:
+		return nil, fmt.Errorf("do not know point cloud stage of type %q", tr.Type)
+	}
+}
+
+func newPointCloudPipeline(
+	source camera.Camera,
+	named resource.Named,
+	cfg *pointCloudPipelineConfig,
+	r robot.Robot,
+	logger logging.Logger,
+) (camera.Camera, error) {
+	if source == nil {
+		return nil, errors.New("no source camera for point cloud pipeline")
+	}
+	if len(cfg.Pipeline) == 0 {
+		return nil, errors.New("point cloud pipeline has no stages in it")
+	}
+	stages := make([]pointCloudStage, 0, len(cfg.Pipeline))
+	for _, tr := range cfg.Pipeline {
+		stage, err := buildPointCloudStage(r, cfg.Source, tr)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return &pointCloudPipeline{
+		Named:  named,
+		src:    source,
+		stages: stages,
+		logger: logger,
+	}, nil
+}
+
+// pointCloudPipeline is a camera that applies a list of stages to the point clouds of a source camera, and passes
+// its images through unchanged.
+type pointCloudPipeline struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+	src    camera.Camera
+	stages []pointCloudStage
+	logger logging.Logger
+}
+
+func (pp *pointCloudPipeline) NextPointCloud(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::pointcloud::NextPointCloud")
+	defer span.End()
+	pc, err := pp.src.NextPointCloud(ctx, extra)
+	if err != nil {
+		return nil, err
+	}
+	for _, stage := range pp.stages {
+		if pc, err = stage(ctx, pc); err != nil {
+			return nil, err
+		}
+	}
+	return pc, nil
+}
+
+func (pp *pointCloudPipeline) Image(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+	return pp.src.Image(ctx, mimeType, extra)
+}
+
+func (pp *pointCloudPipeline) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	return pp.src.Images(ctx, filterSourceNames, extra)
+}
+
+func (pp *pointCloudPipeline) Properties(ctx context.Context) (camera.Properties, error) {
+	props, err := pp.src.Properties(ctx)
+	if err != nil {
+		return camera.Properties{}, err
+	}
+	props.SupportsPCD = true
+	return props, nil
+}
+
+func (pp *pointCloudPipeline) Geometries(ctx context.Context, extra map[string]interface{}) ([]spatialmath.Geometry, error) {
+	return pp.src.Geometries(ctx, extra)
+}
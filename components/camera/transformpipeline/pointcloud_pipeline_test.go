@@ -0,0 +1,119 @@
+package transformpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+// makeTablePointCloud returns a 1m floor at z=0 with a 100mm cube of points resting on it, and a stray point.
+func makeTablePointCloud(t *testing.T) pointcloud.PointCloud {
+	t.Helper()
+	pc := pointcloud.NewBasicEmpty()
+	for x := -500.; x <= 500; x += 20 {
+		for y := -500.; y <= 500; y += 20 {
+			test.That(t, pc.Set(r3.Vector{X: x, Y: y}, nil), test.ShouldBeNil)
+		}
+	}
+	for x := 0.; x < 100; x += 5 {
+		for y := 0.; y < 100; y += 5 {
+			for z := 50.; z < 150; z += 5 {
+				test.That(t, pc.Set(r3.Vector{X: x, Y: y, Z: z}, nil), test.ShouldBeNil)
+			}
+		}
+	}
+	test.That(t, pc.Set(r3.Vector{X: 2000, Y: 2000, Z: 2000}, nil), test.ShouldBeNil)
+	return pc
+}
+
+func TestPointCloudPipelineConfig(t *testing.T) {
+	conf := &pointCloudPipelineConfig{}
+	_, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Source = "source"
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Pipeline = []Transformation{{Type: "voxel_downsample", Attributes: utils.AttributeMap{}}}
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Pipeline = []Transformation{{Type: "blur", Attributes: utils.AttributeMap{}}}
+	_, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	conf.Pipeline = []Transformation{
+		{Type: "crop_box", Attributes: utils.AttributeMap{
+			"min": map[string]interface{}{"x": -1000, "y": -1000, "z": -1000},
+			"max": map[string]interface{}{"x": 1000, "y": 1000, "z": 1000},
+		}},
+		{Type: "voxel_downsample", Attributes: utils.AttributeMap{"voxel_size_mm": 10}},
+		{Type: "statistical_outlier", Attributes: utils.AttributeMap{"mean_k": 8, "std_dev_threshold": 1.5}},
+		{Type: "remove_ground_plane", Attributes: utils.AttributeMap{"distance_threshold_mm": 10, "min_points": 100}},
+		{Type: "transform_frame", Attributes: utils.AttributeMap{}},
+	}
+	deps, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"source"})
+}
+
+func TestPointCloudPipeline(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	source := inject.NewCamera("source")
+	source.NextPointCloudFunc = func(ctx context.Context, extra map[string]interface{}) (pointcloud.PointCloud, error) {
+		return makeTablePointCloud(t), nil
+	}
+	source.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{}, nil
+	}
+	r := &inject.Robot{}
+	r.TransformPointCloudFunc = func(ctx context.Context, srcpc pointcloud.PointCloud, srcName, dstName string,
+	) (pointcloud.PointCloud, error) {
+		test.That(t, srcName, test.ShouldEqual, "source")
+		test.That(t, dstName, test.ShouldEqual, "world")
+		out := pointcloud.NewBasicEmpty()
+		offset := spatialmath.NewPoseFromPoint(r3.Vector{Z: 1000})
+		return out, pointcloud.ApplyOffset(srcpc, offset, out)
+	}
+
+	conf := &pointCloudPipelineConfig{
+		Source: "source",
+		Pipeline: []Transformation{
+			{Type: "crop_box", Attributes: utils.AttributeMap{
+				"min": map[string]interface{}{"x": -1000, "y": -1000, "z": -1000},
+				"max": map[string]interface{}{"x": 1000, "y": 1000, "z": 1000},
+			}},
+			{Type: "voxel_downsample", Attributes: utils.AttributeMap{"voxel_size_mm": 10}},
+			{Type: "remove_ground_plane", Attributes: utils.AttributeMap{"distance_threshold_mm": 5, "min_points": 100}},
+			{Type: "transform_frame", Attributes: utils.AttributeMap{}},
+		},
+	}
+	cam, err := newPointCloudPipeline(source, camera.Named("pipeline").AsNamed(), conf, r, logger)
+	test.That(t, err, test.ShouldBeNil)
+
+	props, err := cam.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.SupportsPCD, test.ShouldBeTrue)
+
+	pc, err := cam.NextPointCloud(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	// the cube is downsampled to 10 voxels per side, and the floor and stray point are gone
+	test.That(t, pc.Size(), test.ShouldEqual, 10*10*10)
+	pc.Iterate(0, 0, func(p r3.Vector, d pointcloud.Data) bool {
+		test.That(t, p.Z, test.ShouldBeGreaterThan, 1050)
+		test.That(t, p.Z, test.ShouldBeLessThan, 1150)
+		return true
+	})
+	test.That(t, cam.Close(ctx), test.ShouldBeNil)
+}
@@ -0,0 +1,143 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/utils"
+)
+
+// qualityConfig are the attributes for a quality filter. Each threshold is only checked when set. Luma ranges from 0
+// to 255, and the change of a frame is the mean absolute difference of its luma from the last frame captured.
+type qualityConfig struct {
+	MinFocus                *float64 `json:"min_focus,omitempty"`
+	MinMeanLuma             *float64 `json:"min_mean_luma,omitempty"`
+	MaxMeanLuma             *float64 `json:"max_mean_luma,omitempty"`
+	MinContrast             *float64 `json:"min_contrast,omitempty"`
+	MaxUnderexposedFraction *float64 `json:"max_underexposed_fraction,omitempty"`
+	MaxOverexposedFraction  *float64 `json:"max_overexposed_fraction,omitempty"`
+	MaxSaturatedFraction    *float64 `json:"max_saturated_fraction,omitempty"`
+	MinChange               *float64 `json:"min_change,omitempty"`
+}
+
+// qualitySource keeps the frames of its source below quality thresholds from being captured, returning
+// data.ErrNoCaptureToStore for them to data capture. Every frame is passed through to other consumers, such as streams
+// and vision services, and to the transforms after it, so captures are only filtered when it is the last transform
+// of a pipeline.
+type qualitySource struct {
+	src    camera.VideoSource
+	stream camera.ImageType
+	conf   *qualityConfig
+
+	mu sync.Mutex
+	// last is the luma of the last frame captured
+	last *image.Gray
+}
+
+// newQualityTransform creates a new quality filter.
+func newQualityTransform(
+	ctx context.Context, source camera.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (camera.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*qualityConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, errors.Wrap(err, "cannot parse quality attribute map")
+	}
+	props, err := propsFromVideoSource(ctx, source)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	var cameraModel transform.PinholeCameraModel
+	cameraModel.PinholeCameraIntrinsics = props.IntrinsicParams
+	if props.DistortionParams != nil {
+		cameraModel.Distortion = props.DistortionParams
+	}
+	reader := &qualitySource{src: source, stream: stream, conf: conf}
+	src, err := camera.NewVideoSourceFromReader(ctx, reader, &cameraModel, stream)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	return src, stream, err
+}
+
+// passes returns whether the frame meets the quality thresholds.
+func (qs *qualitySource) passes(img image.Image) bool {
+	conf := qs.conf
+	quality := rimage.MeasureImageQuality(img)
+	below := func(v float64, threshold *float64) bool { return threshold != nil && v < *threshold }
+	above := func(v float64, threshold *float64) bool { return threshold != nil && v > *threshold }
+	if below(quality.Focus, conf.MinFocus) ||
+		below(quality.MeanLuma, conf.MinMeanLuma) ||
+		above(quality.MeanLuma, conf.MaxMeanLuma) ||
+		below(quality.Contrast, conf.MinContrast) ||
+		above(quality.UnderexposedFraction, conf.MaxUnderexposedFraction) ||
+		above(quality.OverexposedFraction, conf.MaxOverexposedFraction) ||
+		above(quality.SaturatedFraction, conf.MaxSaturatedFraction) {
+		return false
+	}
+	if conf.MinChange == nil {
+		return true
+	}
+	lum := rimage.LumaImage(img)
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	if qs.last != nil {
+		// frames of another size are a change
+		if change, err := rimage.ImageChange(qs.last, lum); err == nil && change < *conf.MinChange {
+			return false
+		}
+	}
+	qs.last = lum
+	return true
+}
+
+// Read returns the next frame of the source, whatever its quality.
+func (qs *qualitySource) Read(ctx context.Context) (image.Image, func(), error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::quality::Read")
+	defer span.End()
+	switch qs.stream {
+	case camera.ColorStream, camera.UnspecifiedStream:
+	default:
+		return nil, nil, camera.NewUnsupportedImageTypeError(qs.stream)
+	}
+	return camera.ReadImage(ctx, qs.src)
+}
+
+// Images returns the next frame of the source, or data.ErrNoCaptureToStore when data capture requests a frame below
+// the quality thresholds.
+func (qs *qualitySource) Images(
+	ctx context.Context,
+	filterSourceNames []string,
+	extra map[string]interface{},
+) ([]camera.NamedImage, resource.ResponseMetadata, error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::quality::Images")
+	defer span.End()
+	img, release, err := qs.Read(ctx)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	if release != nil {
+		defer release()
+	}
+	if fromDM, ok := extra[data.FromDMString].(bool); ok && fromDM && !qs.passes(img) {
+		return nil, resource.ResponseMetadata{}, data.ErrNoCaptureToStore
+	}
+	namedImg, err := camera.NamedImageFromImage(img, "", utils.MimeTypeJPEG)
+	if err != nil {
+		return nil, resource.ResponseMetadata{}, err
+	}
+	return []camera.NamedImage{namedImg}, resource.ResponseMetadata{CapturedAt: time.Now()}, nil
+}
+
+func (qs *qualitySource) Close(ctx context.Context) error {
+	return nil
+}
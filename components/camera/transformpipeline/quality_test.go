@@ -0,0 +1,133 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+// frameSource returns its frames in turn.
+type frameSource struct {
+	frames []image.Image
+	next   int
+}
+
+func (fs *frameSource) Read(ctx context.Context) (image.Image, func(), error) {
+	img := fs.frames[fs.next%len(fs.frames)]
+	fs.next++
+	return img, func() {}, nil
+}
+
+func (fs *frameSource) Close(ctx context.Context) error {
+	return nil
+}
+
+// grayFrame returns an image of the given luma with a bright square at the offset, or without one when it is negative.
+func grayFrame(luma uint8, squareOffset int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for i := range img.Pix {
+		img.Pix[i] = luma
+	}
+	if squareOffset >= 0 {
+		for y := squareOffset; y < squareOffset+5; y++ {
+			for x := squareOffset; x < squareOffset+5; x++ {
+				img.Pix[y*img.Stride+x] = 200
+			}
+		}
+	}
+	return img
+}
+
+func TestQualityFilter(t *testing.T) {
+	ctx := context.Background()
+	black := grayFrame(0, -1)
+	flat := grayFrame(100, -1)
+	scene := grayFrame(100, 5)
+	moved := grayFrame(100, 10)
+	source, err := camera.NewVideoSourceFromReader(
+		ctx, &frameSource{frames: []image.Image{black, scene, flat, scene, moved}}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+
+	qs, stream, err := newQualityTransform(ctx, source, camera.ColorStream, utils.AttributeMap{
+		"min_focus":                 1,
+		"max_underexposed_fraction": 0.5,
+		"min_change":                5,
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stream, test.ShouldEqual, camera.ColorStream)
+	defer qs.Close(ctx)
+
+	capture := func() (image.Image, error) {
+		images, _, err := qs.Images(ctx, nil, data.FromDMExtraMap)
+		if err != nil {
+			return nil, err
+		}
+		test.That(t, images, test.ShouldHaveLength, 1)
+		return images[0].Image(ctx)
+	}
+	// the black frame is underexposed
+	_, err = capture()
+	test.That(t, err, test.ShouldEqual, data.ErrNoCaptureToStore)
+	img, err := capture()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img, test.ShouldEqual, scene)
+	// the flat frame is out of focus
+	_, err = capture()
+	test.That(t, err, test.ShouldEqual, data.ErrNoCaptureToStore)
+	// the scene did not change
+	_, err = capture()
+	test.That(t, err, test.ShouldEqual, data.ErrNoCaptureToStore)
+	_, err = capture()
+	test.That(t, err, test.ShouldBeNil)
+
+	// frames are only filtered for data capture
+	img, release, err := camera.ReadImage(ctx, qs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img, test.ShouldEqual, black)
+	release()
+	images, _, err := qs.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, images, test.ShouldHaveLength, 1)
+
+	_, _, err = newQualityTransform(ctx, source, camera.ColorStream, utils.AttributeMap{"min_focus": "sharp"})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestQualityFilterPipeline(t *testing.T) {
+	ctx := context.Background()
+	black := grayFrame(0, -1)
+	source, err := camera.NewVideoSourceFromReader(ctx, &frameSource{frames: []image.Image{black}}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+	conf := &transformConfig{
+		Source: "source",
+		Pipeline: []Transformation{
+			{Type: "resize", Attributes: utils.AttributeMap{"height_px": 10, "width_px": 10}},
+			{Type: "quality", Attributes: utils.AttributeMap{"min_mean_luma": 10}},
+		},
+	}
+	pipeline, err := newTransformPipeline(ctx, source, nil, conf, &inject.Robot{}, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	defer pipeline.Close(ctx)
+
+	// data capture gets extra through the pipeline
+	_, _, err = pipeline.Images(ctx, nil, data.FromDMExtraMap)
+	test.That(t, err, test.ShouldEqual, data.ErrNoCaptureToStore)
+	images, _, err := pipeline.Images(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, images, test.ShouldHaveLength, 1)
+	img, err := images[0].Image(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img.Bounds().Dx(), test.ShouldEqual, 10)
+	img, release, err := camera.ReadImage(ctx, pipeline)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, img.Bounds().Dx(), test.ShouldEqual, 10)
+	release()
+}
@@ -22,6 +22,9 @@ const (
 	transformTypeCrop            = transformType("crop")
 	transformTypeDetections      = transformType("detections")
 	transformTypeClassifications = transformType("classifications")
+	transformTypeUndistort       = transformType("undistort")
+	transformTypeRectify         = transformType("rectify")
+	transformTypeQuality         = transformType("quality")
 )
 
 // transformRegistration holds pertinent information regarding the available transforms.
@@ -58,6 +61,21 @@ var registeredTransformConfigs = map[transformType]*transformRegistration{
 		&classifierConfig{},
 		"Overlays image classifications on the image. Can use any classifier registered in the vision service.",
 	},
+	transformTypeUndistort: {
+		string(transformTypeUndistort),
+		&undistortConfig{},
+		"Removes the lens distortion from the image, using the intrinsics and distortion of the source camera by default",
+	},
+	transformTypeRectify: {
+		string(transformTypeRectify),
+		&rectifyConfig{},
+		"Undistorts the image and reprojects it through a rectifying rotation and new intrinsics",
+	},
+	transformTypeQuality: {
+		string(transformTypeQuality),
+		&qualityConfig{},
+		"Drops blurry, badly exposed and unchanged frames below quality thresholds so that they are not captured",
+	},
 }
 
 // Transformation states the type of transformation and the attributes that are specific to the given type.
@@ -102,6 +120,12 @@ func buildTransform(
 		return newDetectionsTransform(ctx, source, r, tr.Attributes)
 	case transformTypeClassifications:
 		return newClassificationsTransform(ctx, source, r, tr.Attributes)
+	case transformTypeUndistort:
+		return newUndistortTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeRectify:
+		return newRectifyTransform(ctx, source, stream, tr.Attributes)
+	case transformTypeQuality:
+		return newQualityTransform(ctx, source, stream, tr.Attributes)
 	default:
 		return nil, camera.UnspecifiedStream, fmt.Errorf("do not  know camera transform of type %q", tr.Type)
 	}
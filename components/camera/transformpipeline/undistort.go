@@ -0,0 +1,217 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// undistortConfig are the attributes for an undistort transform. The intrinsics and distortion default to the
+// properties of the source camera.
+type undistortConfig struct {
+	CameraParameters     *transform.PinholeCameraIntrinsics `json:"intrinsic_parameters,omitempty"`
+	DistortionParameters *transform.BrownConrady            `json:"distortion_parameters,omitempty"`
+}
+
+// rectifyConfig are the attributes for a rectify transform. On top of undistorting the image, it rotates the camera
+// by the 3x3 row major rectifying rotation from the source camera frame to the rectified frame, and projects the
+// result with the rectified intrinsics, which default to the intrinsics of the source camera.
+type rectifyConfig struct {
+	CameraParameters     *transform.PinholeCameraIntrinsics `json:"intrinsic_parameters,omitempty"`
+	DistortionParameters *transform.BrownConrady            `json:"distortion_parameters,omitempty"`
+	RectifiedParameters  *transform.PinholeCameraIntrinsics `json:"rectified_intrinsic_parameters,omitempty"`
+	RotationRads         []float64                          `json:"rotation_rads,omitempty"`
+}
+
+// remapSource maps every pixel of the output image to a pixel of the source image through a precomputed table.
+type remapSource struct {
+	src       camera.VideoSource
+	stream    camera.ImageType
+	srcWidth  int
+	srcHeight int
+	dstWidth  int
+	dstHeight int
+	// table holds the index of the nearest source pixel for every output pixel, or -1 if it falls outside the source.
+	table []int
+}
+
+// newUndistortTransform creates a new undistort transform.
+func newUndistortTransform(
+	ctx context.Context, source camera.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (camera.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*undistortConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, errors.Wrap(err, "cannot parse undistort attribute map")
+	}
+	model, err := sourceCameraModel(ctx, source, conf.CameraParameters, conf.DistortionParameters)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	return newRemapTransform(ctx, source, stream, model, model.PinholeCameraIntrinsics, nil)
+}
+
+// newRectifyTransform creates a new rectify transform.
+func newRectifyTransform(
+	ctx context.Context, source camera.VideoSource, stream camera.ImageType, am utils.AttributeMap,
+) (camera.VideoSource, camera.ImageType, error) {
+	conf, err := resource.TransformAttributeMap[*rectifyConfig](am)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, errors.Wrap(err, "cannot parse rectify attribute map")
+	}
+	model, err := sourceCameraModel(ctx, source, conf.CameraParameters, conf.DistortionParameters)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	rectified := model.PinholeCameraIntrinsics
+	if conf.RectifiedParameters != nil {
+		if err := conf.RectifiedParameters.CheckValid(); err != nil {
+			return nil, camera.UnspecifiedStream, errors.Wrap(err, "invalid rectified_intrinsic_parameters")
+		}
+		rectified = conf.RectifiedParameters
+	}
+	var rotation *spatialmath.RotationMatrix
+	if len(conf.RotationRads) != 0 {
+		if rotation, err = spatialmath.NewRotationMatrix(conf.RotationRads); err != nil {
+			return nil, camera.UnspecifiedStream, errors.Wrap(err, "invalid rotation_rads")
+		}
+	}
+	return newRemapTransform(ctx, source, stream, model, rectified, rotation)
+}
+
+// sourceCameraModel returns the camera model of the source, where the configured intrinsics and distortion take
+// precedence over the properties of the source.
+func sourceCameraModel(
+	ctx context.Context,
+	source camera.VideoSource,
+	intrinsics *transform.PinholeCameraIntrinsics,
+	distortion *transform.BrownConrady,
+) (*transform.PinholeCameraModel, error) {
+	model := &transform.PinholeCameraModel{PinholeCameraIntrinsics: intrinsics}
+	if distortion != nil {
+		model.Distortion = distortion
+	}
+	if model.PinholeCameraIntrinsics == nil || model.Distortion == nil {
+		props, err := propsFromVideoSource(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		if model.PinholeCameraIntrinsics == nil {
+			model.PinholeCameraIntrinsics = props.IntrinsicParams
+		}
+		if model.Distortion == nil {
+			model.Distortion = props.DistortionParams
+		}
+	}
+	if model.PinholeCameraIntrinsics == nil {
+		return nil, transform.NewNoIntrinsicsError("intrinsic_parameters are needed to undistort images")
+	}
+	if err := model.PinholeCameraIntrinsics.CheckValid(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// newRemapTransform precomputes the table which maps pixels of the rectified camera, rotated from the source camera by
+// rotation, to pixels of the distorted source camera, following the undistort/rectify map of OpenCV.
+func newRemapTransform(
+	ctx context.Context,
+	source camera.VideoSource,
+	stream camera.ImageType,
+	model *transform.PinholeCameraModel,
+	rectified *transform.PinholeCameraIntrinsics,
+	rotation *spatialmath.RotationMatrix,
+) (camera.VideoSource, camera.ImageType, error) {
+	rs := &remapSource{
+		src:       source,
+		stream:    stream,
+		srcWidth:  model.Width,
+		srcHeight: model.Height,
+		dstWidth:  rectified.Width,
+		dstHeight: rectified.Height,
+		table:     make([]int, rectified.Width*rectified.Height),
+	}
+	for v := 0; v < rectified.Height; v++ {
+		for u := 0; u < rectified.Width; u++ {
+			ray := r3.Vector{X: (float64(u) - rectified.Ppx) / rectified.Fx, Y: (float64(v) - rectified.Ppy) / rectified.Fy, Z: 1}
+			if rotation != nil {
+				// the inverse of a rotation is its transpose
+				ray = r3.Vector{X: rotation.Col(0).Dot(ray), Y: rotation.Col(1).Dot(ray), Z: rotation.Col(2).Dot(ray)}
+			}
+			idx := -1
+			if ray.Z > 0 {
+				x, y := ray.X/ray.Z, ray.Y/ray.Z
+				if model.Distortion != nil {
+					x, y = model.Distortion.Transform(x, y)
+				}
+				sx := int(math.Round(x*model.Fx + model.Ppx))
+				sy := int(math.Round(y*model.Fy + model.Ppy))
+				if sx >= 0 && sy >= 0 && sx < model.Width && sy < model.Height {
+					idx = sy*model.Width + sx
+				}
+			}
+			rs.table[v*rectified.Width+u] = idx
+		}
+	}
+
+	// the output images are free of distortion and follow the rectified intrinsics
+	outIntrinsics := *rectified
+	src, err := camera.NewVideoSourceFromReader(ctx, rs, &transform.PinholeCameraModel{PinholeCameraIntrinsics: &outIntrinsics}, stream)
+	if err != nil {
+		return nil, camera.UnspecifiedStream, err
+	}
+	return src, stream, nil
+}
+
+// Read remaps the 2D image depending on the stream type.
+func (rs *remapSource) Read(ctx context.Context) (image.Image, func(), error) {
+	ctx, span := trace.StartSpan(ctx, "camera::transformpipeline::remap::Read")
+	defer span.End()
+	orig, release, err := camera.ReadImage(ctx, rs.src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if orig.Bounds().Dx() != rs.srcWidth || orig.Bounds().Dy() != rs.srcHeight {
+		return nil, nil, errors.Errorf("image dimensions (%d, %d) don't match the intrinsics (%d, %d)",
+			orig.Bounds().Dx(), orig.Bounds().Dy(), rs.srcWidth, rs.srcHeight)
+	}
+	switch rs.stream {
+	case camera.ColorStream, camera.UnspecifiedStream:
+		img := rimage.ConvertImage(orig)
+		dst := rimage.NewImage(rs.dstWidth, rs.dstHeight)
+		for i, idx := range rs.table {
+			if idx >= 0 {
+				dst.SetXY(i%rs.dstWidth, i/rs.dstWidth, img.GetXY(idx%rs.srcWidth, idx/rs.srcWidth))
+			}
+		}
+		return dst, release, nil
+	case camera.DepthStream:
+		dm, err := rimage.ConvertImageToDepthMap(ctx, orig)
+		if err != nil {
+			return nil, nil, err
+		}
+		dst := rimage.NewEmptyDepthMap(rs.dstWidth, rs.dstHeight)
+		for i, idx := range rs.table {
+			if idx >= 0 {
+				dst.Set(i%rs.dstWidth, i/rs.dstWidth, dm.GetDepth(idx%rs.srcWidth, idx/rs.srcWidth))
+			}
+		}
+		return dst, release, nil
+	default:
+		return nil, nil, camera.NewUnsupportedImageTypeError(rs.stream)
+	}
+}
+
+func (rs *remapSource) Close(ctx context.Context) error {
+	return nil
+}
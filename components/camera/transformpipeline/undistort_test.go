@@ -0,0 +1,178 @@
+package transformpipeline
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/camera/fake"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+// makeGradientImage returns an image whose red and green channels encode the coordinates of each pixel.
+func makeGradientImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	return img
+}
+
+func TestUndistort(t *testing.T) {
+	ctx := context.Background()
+	intrinsics := map[string]interface{}{"width_px": 41, "height_px": 21, "fx": 50, "fy": 50, "ppx": 20, "ppy": 10}
+	img := makeGradientImage(41, 21)
+	source, err := camera.NewVideoSourceFromReader(ctx, &fake.StaticSource{ColorImg: img}, nil, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+
+	// the source has no intrinsics of its own
+	_, _, err = newUndistortTransform(ctx, source, camera.ColorStream, utils.AttributeMap{})
+	test.That(t, err, test.ShouldWrap, transform.ErrNoIntrinsics)
+
+	// without distortion the image is unchanged
+	rs, stream, err := newUndistortTransform(ctx, source, camera.ColorStream, utils.AttributeMap{"intrinsic_parameters": intrinsics})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stream, test.ShouldEqual, camera.ColorStream)
+	out, _, err := camera.ReadImage(ctx, rs)
+	test.That(t, err, test.ShouldBeNil)
+	outImg := rimage.ConvertImage(out)
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 41; x++ {
+			r, g, _ := outImg.GetXY(x, y).RGB255()
+			test.That(t, []uint8{r, g}, test.ShouldResemble, []uint8{uint8(x), uint8(y)})
+		}
+	}
+	test.That(t, rs.Close(ctx), test.ShouldBeNil)
+
+	// with barrel distortion the center stays put, the corners are pulled in from outside of the source image, and
+	// the output has no distortion left
+	rs, _, err = newUndistortTransform(ctx, source, camera.ColorStream, utils.AttributeMap{
+		"intrinsic_parameters":  intrinsics,
+		"distortion_parameters": map[string]interface{}{"rk1": 0.5},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	out, _, err = camera.ReadImage(ctx, rs)
+	test.That(t, err, test.ShouldBeNil)
+	outImg = rimage.ConvertImage(out)
+	r, g, _ := outImg.GetXY(20, 10).RGB255()
+	test.That(t, []uint8{r, g}, test.ShouldResemble, []uint8{20, 10})
+	r, g, b := outImg.GetXY(0, 0).RGB255()
+	test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{0, 0, 0})
+	props, err := rs.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.IntrinsicParams.Width, test.ShouldEqual, 41)
+	test.That(t, props.DistortionParams, test.ShouldBeNil)
+	test.That(t, rs.Close(ctx), test.ShouldBeNil)
+	test.That(t, source.Close(ctx), test.ShouldBeNil)
+}
+
+func TestRectify(t *testing.T) {
+	ctx := context.Background()
+	img := makeGradientImage(41, 21)
+	cameraModel := transform.PinholeCameraModel{PinholeCameraIntrinsics: &transform.PinholeCameraIntrinsics{
+		Width: 41, Height: 21, Fx: 50, Fy: 50, Ppx: 20, Ppy: 10,
+	}}
+	source, err := camera.NewVideoSourceFromReader(ctx, &fake.StaticSource{ColorImg: img}, &cameraModel, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+
+	_, _, err = newRectifyTransform(ctx, source, camera.ColorStream, utils.AttributeMap{"rotation_rads": []float64{1, 0, 0}})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// rotating half a turn about the optical axis flips the image, and the rectified intrinsics halve it
+	rs, _, err := newRectifyTransform(ctx, source, camera.ColorStream, utils.AttributeMap{
+		"rotation_rads": []float64{-1, 0, 0, 0, -1, 0, 0, 0, 1},
+		"rectified_intrinsic_parameters": map[string]interface{}{
+			"width_px": 21, "height_px": 11, "fx": 25, "fy": 25, "ppx": 10, "ppy": 5,
+		},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	out, _, err := camera.ReadImage(ctx, rs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, out.Bounds().Dx(), test.ShouldEqual, 21)
+	test.That(t, out.Bounds().Dy(), test.ShouldEqual, 11)
+	outImg := rimage.ConvertImage(out)
+	r, g, _ := outImg.GetXY(0, 0).RGB255()
+	test.That(t, []uint8{r, g}, test.ShouldResemble, []uint8{40, 20})
+	r, g, _ = outImg.GetXY(10, 5).RGB255()
+	test.That(t, []uint8{r, g}, test.ShouldResemble, []uint8{20, 10})
+	props, err := rs.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.IntrinsicParams.Fx, test.ShouldEqual, 25)
+	test.That(t, rs.Close(ctx), test.ShouldBeNil)
+
+	// depth maps are remapped the same way
+	dm := rimage.NewEmptyDepthMap(41, 21)
+	dm.Set(40, 20, 1234)
+	depthSource, err := camera.NewVideoSourceFromReader(ctx, &fake.StaticSource{DepthImg: dm}, &cameraModel, camera.DepthStream)
+	test.That(t, err, test.ShouldBeNil)
+	rs, _, err = newRectifyTransform(ctx, depthSource, camera.DepthStream, utils.AttributeMap{
+		"rotation_rads": []float64{-1, 0, 0, 0, -1, 0, 0, 0, 1},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	out, _, err = camera.ReadImage(ctx, rs)
+	test.That(t, err, test.ShouldBeNil)
+	outDm, err := rimage.ConvertImageToDepthMap(ctx, out)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, outDm.GetDepth(0, 0), test.ShouldEqual, 1234)
+	test.That(t, rs.Close(ctx), test.ShouldBeNil)
+	test.That(t, depthSource.Close(ctx), test.ShouldBeNil)
+	test.That(t, source.Close(ctx), test.ShouldBeNil)
+}
+
+func TestUndistortPipelineProperties(t *testing.T) {
+	ctx := context.Background()
+	cameraModel := transform.PinholeCameraModel{
+		PinholeCameraIntrinsics: &transform.PinholeCameraIntrinsics{Width: 41, Height: 21, Fx: 50, Fy: 50, Ppx: 20, Ppy: 10},
+		Distortion:              &transform.BrownConrady{RadialK1: 0.1},
+	}
+	source, err := camera.NewVideoSourceFromReader(ctx, &fake.StaticSource{ColorImg: makeGradientImage(41, 21)}, &cameraModel, camera.ColorStream)
+	test.That(t, err, test.ShouldBeNil)
+
+	// the pipeline reports the undistorted camera model when no intrinsics are configured on it
+	conf := &transformConfig{
+		Source:   "source",
+		Pipeline: []Transformation{{Type: "undistort", Attributes: utils.AttributeMap{}}},
+	}
+	pipe, err := newTransformPipeline(ctx, source, nil, conf, &inject.Robot{}, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	props, err := pipe.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.IntrinsicParams, test.ShouldResemble, cameraModel.PinholeCameraIntrinsics)
+	test.That(t, props.DistortionParams, test.ShouldBeNil)
+	test.That(t, pipe.Close(ctx), test.ShouldBeNil)
+
+	// transforms which keep the geometry of the images keep the undistorted camera model
+	conf.Pipeline = append(conf.Pipeline, Transformation{Type: "quality", Attributes: utils.AttributeMap{}})
+	pipe, err = newTransformPipeline(ctx, source, nil, conf, &inject.Robot{}, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+	props, err = pipe.Properties(ctx)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.IntrinsicParams, test.ShouldResemble, cameraModel.PinholeCameraIntrinsics)
+	test.That(t, pipe.Close(ctx), test.ShouldBeNil)
+
+	// a rotation does not report the intrinsics of the source, which no longer match its images
+	for _, pipeline := range [][]Transformation{
+		{{Type: "rotate", Attributes: utils.AttributeMap{"angle_degs": 90}}},
+		{{Type: "undistort", Attributes: utils.AttributeMap{}}, {Type: "rotate", Attributes: utils.AttributeMap{}}},
+	} {
+		conf.Pipeline = pipeline
+		pipe, err = newTransformPipeline(ctx, source, nil, conf, &inject.Robot{}, logging.NewTestLogger(t))
+		test.That(t, err, test.ShouldBeNil)
+		props, err = pipe.Properties(ctx)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, props.IntrinsicParams, test.ShouldBeNil)
+		test.That(t, props.DistortionParams, test.ShouldBeNil)
+		test.That(t, pipe.Close(ctx), test.ShouldBeNil)
+	}
+	test.That(t, source.Close(ctx), test.ShouldBeNil)
+}
@@ -0,0 +1,107 @@
+// Package fiducial implements a pose tracker which tracks the poses of square fiducial markers, like ArUco markers and
+// AprilTags, seen by a camera with intrinsics.
+package fiducial
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/posetracker"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/vision/fiducial"
+)
+
+var model = resource.DefaultModelFamily.WithModel("fiducial")
+
+func init() {
+	resource.RegisterComponent(posetracker.API, model, resource.Registration[posetracker.PoseTracker, *Config]{
+		Constructor: func(
+			ctx context.Context, deps resource.Dependencies, c resource.Config, logger logging.Logger,
+		) (posetracker.PoseTracker, error) {
+			conf, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			cam, err := camera.FromProvider(deps, conf.Camera)
+			if err != nil {
+				return nil, errors.Wrapf(err, "no camera (%s) for fiducial pose tracker", conf.Camera)
+			}
+			return newFiducialTracker(c.ResourceName().AsNamed(), cam, conf, logger)
+		},
+	})
+}
+
+// Config is the config of the fiducial pose tracker. The family is configured like the one of the fiducial vision
+// service, and MarkerSizeMm is the side length of the black square of the markers.
+type Config struct {
+	Camera       string  `json:"camera_name"`
+	Family       string  `json:"family,omitempty"`
+	FamilyPath   string  `json:"family_path,omitempty"`
+	MarkerSizeMm float64 `json:"marker_size_mm"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.Camera == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "camera_name")
+	}
+	if cfg.MarkerSizeMm <= 0 {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "marker_size_mm")
+	}
+	return []string{cfg.Camera}, nil, nil
+}
+
+// fiducialTracker tracks the markers seen by a camera, naming the bodies by the IDs of the markers.
+type fiducialTracker struct {
+	resource.Named
+	resource.AlwaysRebuild
+	resource.TriviallyCloseable
+	cam    camera.Camera
+	family *fiducial.Family
+	sizeMm float64
+	logger logging.Logger
+}
+
+func newFiducialTracker(
+	named resource.Named,
+	cam camera.Camera,
+	conf *Config,
+	logger logging.Logger,
+) (posetracker.PoseTracker, error) {
+	family, err := fiducial.NewFamily(conf.Family, conf.FamilyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &fiducialTracker{Named: named, cam: cam, family: family, sizeMm: conf.MarkerSizeMm, logger: logger}, nil
+}
+
+// Poses returns the poses of the markers in the next image of the camera, in the frame of the camera. Only the markers
+// named in bodyNames are returned, unless it is empty.
+func (ft *fiducialTracker) Poses(
+	ctx context.Context,
+	bodyNames []string,
+	extra map[string]interface{},
+) (referenceframe.FrameSystemPoses, error) {
+	markers, poses, err := fiducial.DetectPosesFromCamera(ctx, ft.cam, ft.family, ft.sizeMm)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(bodyNames))
+	for _, name := range bodyNames {
+		wanted[name] = true
+	}
+	frame := ft.cam.Name().ShortName()
+	result := referenceframe.FrameSystemPoses{}
+	for i, m := range markers {
+		name := strconv.Itoa(m.ID)
+		if len(wanted) == 0 || wanted[name] {
+			result[name] = referenceframe.NewPoseInFrame(frame, poses[i])
+		}
+	}
+	return result, nil
+}
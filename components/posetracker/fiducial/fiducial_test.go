@@ -0,0 +1,71 @@
+package fiducial
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/components/posetracker"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/fiducial"
+)
+
+func TestConfig(t *testing.T) {
+	_, _, err := (&Config{MarkerSizeMm: 100}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{Camera: "cam"}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	deps, _, err := (&Config{Camera: "cam", MarkerSizeMm: 100}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam"})
+}
+
+func TestPoses(t *testing.T) {
+	ctx := context.Background()
+	aruco := fiducial.ArucoOriginal()
+	img := image.NewGray(image.Rect(0, 0, 320, 240))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+	testutils.DrawFiducialMarker(img, aruco.Codes[5], aruco.DataBits, 20, 20, 8)
+	testutils.DrawFiducialMarker(img, aruco.Codes[900], aruco.DataBits, 180, 100, 10)
+	cam := inject.NewCamera("cam")
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, img, utils.MimeTypePNG)
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{IntrinsicParams: &transform.PinholeCameraIntrinsics{
+			Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 159.5, Ppy: 119.5,
+		}}, nil
+	}
+
+	_, err := newFiducialTracker(posetracker.Named("tracker").AsNamed(), cam, &Config{Family: "unknown"}, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldNotBeNil)
+	tracker, err := newFiducialTracker(posetracker.Named("tracker").AsNamed(), cam,
+		&Config{Camera: "cam", MarkerSizeMm: 70}, logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	poses, err := tracker.Poses(ctx, nil, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(poses), test.ShouldEqual, 2)
+	test.That(t, poses, test.ShouldContainKey, "5")
+	pose := poses["900"]
+	test.That(t, pose.Parent(), test.ShouldEqual, "cam")
+	// the 70 mm marker is 70 pixels wide, so it is as far away as the focal length
+	test.That(t, pose.Pose().Point().Z, test.ShouldAlmostEqual, 300, 3)
+	test.That(t, pose.Pose().Point().X, test.ShouldAlmostEqual, 55, 1)
+
+	poses, err = tracker.Poses(ctx, []string{"5", "6"}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(poses), test.ShouldEqual, 1)
+	test.That(t, poses, test.ShouldContainKey, "5")
+}
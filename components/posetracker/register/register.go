@@ -0,0 +1,7 @@
+// Package register registers all relevant pose trackers
+package register
+
+import (
+	// register all pose trackers.
+	_ "go.viam.com/rdk/components/posetracker/fiducial"
+)
@@ -3,6 +3,8 @@ package register
 
 import (
 	// register components.
+	_ "go.viam.com/rdk/components/audioin/register"
+	_ "go.viam.com/rdk/components/audioout/register"
 	_ "go.viam.com/rdk/components/base/register"
 	_ "go.viam.com/rdk/components/board/register"
 	_ "go.viam.com/rdk/components/button/register"
@@ -14,8 +16,7 @@ import (
 	_ "go.viam.com/rdk/components/input/register"
 	_ "go.viam.com/rdk/components/motor/register"
 	_ "go.viam.com/rdk/components/movementsensor/register"
-	// register APIs without implementations directly.
-	_ "go.viam.com/rdk/components/posetracker"
+	_ "go.viam.com/rdk/components/posetracker/register"
 	_ "go.viam.com/rdk/components/powersensor/register"
 	_ "go.viam.com/rdk/components/sensor/register"
 	_ "go.viam.com/rdk/components/servo/register"
@@ -718,6 +718,29 @@ type NetworkConfigData struct {
 
 	// TrafficTunnelEndpoints are the allowed ports and options for tunneling.
 	TrafficTunnelEndpoints []TrafficTunnelEndpoint `json:"traffic_tunnel_endpoints"`
+
+	// CameraHTTP enables HTTP endpoints serving camera snapshots and MJPEG streams when set.
+	CameraHTTP *CameraHTTPConfig `json:"camera_http,omitempty"`
+}
+
+// CameraHTTPConfig configures the HTTP endpoints serving camera images to clients which cannot use gRPC or WebRTC.
+// MaxFPS caps the frame rate of MJPEG streams, and MaxWidth and MaxHeight the size of the images served, which are
+// scaled down to fit. Zero values are unlimited.
+type CameraHTTPConfig struct {
+	MaxFPS    float64 `json:"max_fps,omitempty"`
+	MaxWidth  int     `json:"max_width,omitempty"`
+	MaxHeight int     `json:"max_height,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (c *CameraHTTPConfig) Validate(path string) error {
+	if c.MaxFPS < 0 {
+		return resource.NewConfigValidationError(path, errors.New("max_fps cannot be negative"))
+	}
+	if c.MaxWidth < 0 || c.MaxHeight < 0 {
+		return resource.NewConfigValidationError(path, errors.New("max_width and max_height cannot be negative"))
+	}
+	return nil
 }
 
 // MarshalJSON marshals out this config.
@@ -748,6 +771,11 @@ func (nc *NetworkConfig) Validate(path string) error {
 	if (nc.TLSCertFile == "") != (nc.TLSKeyFile == "") {
 		return resource.NewConfigValidationError(path, errors.New("must provide both tls_cert_file and tls_key_file"))
 	}
+	if nc.CameraHTTP != nil {
+		if err := nc.CameraHTTP.Validate(path + ".camera_http"); err != nil {
+			return err
+		}
+	}
 
 	return nc.Sessions.Validate(path + ".sessions")
 }
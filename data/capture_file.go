@@ -32,6 +32,7 @@ const (
 	nextPointCloud       = "NextPointCloud"
 	pointCloudMap        = "PointCloudMap"
 	captureAllFromCamera = "CaptureAllFromCamera"
+	getAudio             = "GetAudio"
 	// Non-exhaustive list of characters to strip from file paths, since not allowed
 	// on certain file systems.
 	filePathReservedChars = ":"
@@ -212,7 +212,7 @@ func (dt CaptureType) ToProto() datasyncPB.DataType {
 // MethodToCaptureType returns the DataType of the method.
 func MethodToCaptureType(methodName string) CaptureType {
 	switch methodName {
-	case nextPointCloud, readImage, pointCloudMap, GetImages, captureAllFromCamera:
+	case nextPointCloud, readImage, pointCloudMap, GetImages, captureAllFromCamera, getAudio:
 		return CaptureTypeBinary
 	default:
 		return CaptureTypeTabular
@@ -242,6 +242,9 @@ const (
 	MimeTypeImagePng
 	// MimeTypeApplicationPcd means that the mime type is pcd.
 	MimeTypeApplicationPcd
+	// MimeTypeAudioWav means that the mime type is wav. Data sync has no wav mime type, so it is synced as unspecified,
+	// with the .wav extension of the capture files of GetAudio.
+	MimeTypeAudioWav
 )
 
 // ToProto converts MimeType to datasyncPB.
@@ -255,6 +258,8 @@ func (mt MimeType) ToProto() datasyncPB.MimeType {
 		return datasyncPB.MimeType_MIME_TYPE_IMAGE_PNG
 	case MimeTypeApplicationPcd:
 		return datasyncPB.MimeType_MIME_TYPE_APPLICATION_PCD
+	case MimeTypeAudioWav:
+		fallthrough
 	default:
 		return datasyncPB.MimeType_MIME_TYPE_UNSPECIFIED
 	}
@@ -303,6 +308,8 @@ func MimeTypeStringToMimeType(mimeType string) MimeType {
 		return MimeTypeImageJpeg
 	case rutils.MimeTypePNG:
 		return MimeTypeImagePng
+	case rutils.MimeTypeWAV:
+		return MimeTypeAudioWav
 	case rutils.MimeTypeRawRGBA:
 		// TODO: https://viam.atlassian.net/browse/DATA-3497
 		fallthrough
@@ -413,6 +420,8 @@ const (
 	ExtJpeg = ".jpeg"
 	// ExtPng is the file extension for png files.
 	ExtPng = ".png"
+	// ExtWav is the file extension for wav files.
+	ExtWav = ".wav"
 )
 
 // getFileExt gets the file extension for a capture file.
@@ -424,6 +433,9 @@ func getFileExt(dataType CaptureType, methodName string, parameters map[string]i
 		if methodName == nextPointCloud {
 			return ExtPcd
 		}
+		if methodName == getAudio {
+			return ExtWav
+		}
 		if methodName == readImage {
 			// TODO: Add explicit file extensions for all mime types.
 			switch parameters["mime_type"] {
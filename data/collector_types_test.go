@@ -333,6 +333,7 @@ func TestMethodToCaptureType(t *testing.T) {
 	test.That(t, MethodToCaptureType(readImage), test.ShouldEqual, CaptureTypeBinary)
 	test.That(t, MethodToCaptureType(pointCloudMap), test.ShouldEqual, CaptureTypeBinary)
 	test.That(t, MethodToCaptureType(GetImages), test.ShouldEqual, CaptureTypeBinary)
+	test.That(t, MethodToCaptureType(getAudio), test.ShouldEqual, CaptureTypeBinary)
 	test.That(t, MethodToCaptureType("anything else"), test.ShouldEqual, CaptureTypeTabular)
 }
 
@@ -420,6 +421,7 @@ func TestGetFileExt(t *testing.T) {
 	test.That(t, getFileExt(CaptureType(20), "anything", nil), test.ShouldResemble, "")
 	test.That(t, getFileExt(CaptureTypeBinary, "anything", nil), test.ShouldResemble, "")
 	test.That(t, getFileExt(CaptureTypeBinary, "NextPointCloud", nil), test.ShouldResemble, ".pcd")
+	test.That(t, getFileExt(CaptureTypeBinary, "GetAudio", nil), test.ShouldResemble, ".wav")
 	test.That(t, getFileExt(CaptureTypeBinary, "ReadImage", nil), test.ShouldResemble, "")
 	test.That(t, getFileExt(CaptureTypeBinary, "ReadImage",
 		map[string]interface{}{"mime_type": rutils.MimeTypeJPEG}),
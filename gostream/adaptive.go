@@ -0,0 +1,259 @@
+package gostream
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// feedbackTimeout is how long the feedback of a peer constrains the video, so that peers which went away stop
+	// constraining it.
+	feedbackTimeout = 5 * time.Second
+	// adaptInterval is the minimum time between two changes of the encoding.
+	adaptInterval = 2 * time.Second
+	// the bitrate decreases with a packet loss above highPacketLoss and increases below lowPacketLoss
+	highPacketLoss  = 0.1
+	lowPacketLoss   = 0.02
+	bitrateIncrease = 1.08
+	// bitsPerPixel is the number of bits per pixel of a frame for a good quality, as assumed by the x264 encoder.
+	bitsPerPixel       = 0.15
+	minAdaptiveBitrate = 100_000
+	// the frame rate is lowered down to minFrameRateFactor of the target before the frames are downscaled
+	minFrameRateFactor = 0.5
+	// encoderBitrateChange is the relative change of the target bitrate for which the bitrate of the encoder changes, as
+	// encoders are created again to change it
+	encoderBitrateChange = 0.2
+)
+
+// downscaleFactors are the factors frames are downscaled by, from the largest.
+var downscaleFactors = []float64{1, 0.75, 0.5, 0.375, 0.25}
+
+// StreamStats are statistics of the video sent by a stream.
+type StreamStats struct {
+	// TargetBitrate is the bitrate in bits per second the video is adapted to.
+	TargetBitrate int
+	// Bitrate and FrameRate are measured over the last second of encoded frames.
+	Bitrate   int
+	FrameRate float64
+	// TargetFrameRate is the frame rate the frames are encoded at.
+	TargetFrameRate float64
+	// Width and Height are the size of the encoded frames.
+	Width, Height int
+	// PacketLoss is the highest fraction of packets lost reported by the peers, between 0 and 1.
+	PacketLoss float64
+	// EstimatedBitrate is the lowest maximum bitrate estimated by the peers, 0 when none did.
+	EstimatedBitrate int
+}
+
+// peerFeedback is the latest RTCP feedback of a peer.
+type peerFeedback struct {
+	packetLoss       float64
+	estimatedBitrate int
+	time             time.Time
+}
+
+// videoEncoding is how frames are encoded, with bitrate the bitrate of the encoder.
+type videoEncoding struct {
+	bitrate   int
+	frameRate float64
+	downscale float64
+}
+
+// rateController adapts the bitrate, frame rate and size of encoded frames to the RTCP feedback of the peers receiving
+// them. The bitrate is decreased in proportion to the packet loss and capped by the estimated maximum bitrate of the
+// peers, and increased slowly while the packet loss is low. Lower bitrates are first reached with lower frame rates,
+// then with downscaled frames. The video adapts to the peer with the worst connection.
+type rateController struct {
+	mu       sync.Mutex
+	peers    map[string]peerFeedback
+	maxRate  float64
+	width    int
+	height   int
+	full     float64 // the bitrate of frames of the source size at the maximum frame rate
+	target   float64
+	encoding videoEncoding
+	adapted  time.Time
+
+	// the frames sent over the current and last second
+	windowStart  time.Time
+	windowFrames int
+	windowBytes  int
+	frameRate    float64
+	bitrate      int
+	sentWidth    int
+	sentHeight   int
+}
+
+func newRateController(maxFrameRate float64) *rateController {
+	return &rateController{
+		peers:    map[string]peerFeedback{},
+		maxRate:  maxFrameRate,
+		encoding: videoEncoding{frameRate: maxFrameRate, downscale: 1},
+	}
+}
+
+// handleRTCP records the packet loss and estimated maximum bitrate reported in the receiver reports, REMB and
+// transport-wide congestion control packets of a peer.
+func (rc *rateController) handleRTCP(peer string, pkts []rtcp.Packet, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	fb := rc.peers[peer]
+	if now.Sub(fb.time) > feedbackTimeout {
+		fb = peerFeedback{}
+	}
+	updated := false
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			for _, report := range p.Reports {
+				fb.packetLoss = float64(report.FractionLost) / 256
+				updated = true
+			}
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			fb.estimatedBitrate = int(p.Bitrate)
+			updated = true
+		case *rtcp.TransportLayerCC:
+			if p.PacketStatusCount == 0 {
+				continue
+			}
+			received := math.Min(float64(len(p.RecvDeltas)), float64(p.PacketStatusCount))
+			fb.packetLoss = 1 - received/float64(p.PacketStatusCount)
+			updated = true
+		}
+	}
+	if updated {
+		fb.time = now
+		rc.peers[peer] = fb
+	}
+}
+
+// feedback returns the highest packet loss and lowest estimated bitrate of the peers with recent feedback, and whether
+// any has.
+func (rc *rateController) feedback(now time.Time) (float64, int, bool) {
+	var loss float64
+	var estimated int
+	ok := false
+	for peer, fb := range rc.peers {
+		if now.Sub(fb.time) > feedbackTimeout {
+			delete(rc.peers, peer)
+			continue
+		}
+		ok = true
+		loss = math.Max(loss, fb.packetLoss)
+		if fb.estimatedBitrate > 0 && (estimated == 0 || fb.estimatedBitrate < estimated) {
+			estimated = fb.estimatedBitrate
+		}
+	}
+	return loss, estimated, ok
+}
+
+// adapt returns how to encode frames of the given size, adapting it to the feedback at most every adapt interval. The encoding is
+// reset when the size of the frames changes.
+func (rc *rateController) adapt(width, height int, now time.Time) videoEncoding {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if width != rc.width || height != rc.height {
+		rc.width, rc.height = width, height
+		rc.full = math.Max(minAdaptiveBitrate, float64(width*height)*rc.maxRate*bitsPerPixel)
+		rc.target = rc.full
+		rc.encoding = videoEncoding{bitrate: int(rc.full), frameRate: rc.maxRate, downscale: 1}
+		rc.adapted = now
+		return rc.encoding
+	}
+	loss, estimated, ok := rc.feedback(now)
+	if !ok || now.Sub(rc.adapted) < adaptInterval {
+		return rc.encoding
+	}
+
+	switch {
+	case loss > highPacketLoss:
+		rc.target *= 1 - loss/2
+	case loss < lowPacketLoss:
+		rc.target *= bitrateIncrease
+	}
+	if estimated > 0 {
+		rc.target = math.Min(rc.target, float64(estimated))
+	}
+	rc.target = math.Max(minAdaptiveBitrate, math.Min(rc.target, rc.full))
+
+	ratio := rc.target / rc.full
+	frameRateFactor := math.Max(ratio, minFrameRateFactor)
+	downscale := downscaleFactors[len(downscaleFactors)-1]
+	for _, f := range downscaleFactors {
+		if f*f <= ratio/frameRateFactor {
+			downscale = f
+			break
+		}
+	}
+	encoding := videoEncoding{
+		bitrate:   rc.encoding.bitrate,
+		frameRate: math.Max(1, math.Round(rc.maxRate*frameRateFactor)),
+		downscale: downscale,
+	}
+	// the bitrate of the encoder follows large changes of the target, and the target reaching its limits
+	if current := float64(encoding.bitrate); downscale != rc.encoding.downscale ||
+		math.Abs(rc.target-current) > encoderBitrateChange*current ||
+		(rc.target != current && (rc.target == rc.full || rc.target == minAdaptiveBitrate)) {
+		encoding.bitrate = int(rc.target)
+	}
+	rc.encoding = encoding
+	rc.adapted = now
+	return rc.encoding
+}
+
+// sent records an encoded frame sent to the peers.
+func (rc *rateController) sent(size int, now time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if elapsed := now.Sub(rc.windowStart); elapsed >= time.Second {
+		if elapsed < 2*time.Second {
+			rc.frameRate = float64(rc.windowFrames) / elapsed.Seconds()
+			rc.bitrate = int(float64(rc.windowBytes*8) / elapsed.Seconds())
+		} else {
+			rc.frameRate, rc.bitrate = 0, 0
+		}
+		rc.windowStart, rc.windowFrames, rc.windowBytes = now, 0, 0
+	}
+	rc.windowFrames++
+	rc.windowBytes += size
+}
+
+// encoded records the size of the frames given to the encoder.
+func (rc *rateController) encoded(width, height int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.sentWidth, rc.sentHeight = width, height
+}
+
+func (rc *rateController) stats(now time.Time) StreamStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	loss, estimated, _ := rc.feedback(now)
+	stats := StreamStats{
+		TargetBitrate:    int(rc.target),
+		TargetFrameRate:  rc.encoding.frameRate,
+		Width:            rc.sentWidth,
+		Height:           rc.sentHeight,
+		PacketLoss:       loss,
+		EstimatedBitrate: estimated,
+	}
+	// frames stopped being sent
+	if now.Sub(rc.windowStart) < 2*time.Second {
+		stats.Bitrate, stats.FrameRate = rc.bitrate, rc.frameRate
+	}
+	return stats
+}
+
+// downscaledSize returns the size of frames downscaled by the factor, rounded to even numbers as some encoders need.
+func downscaledSize(width, height int, factor float64) (int, int) {
+	if factor >= 1 {
+		return width, height
+	}
+	scale := func(v int) int {
+		return int(math.Max(2, 2*math.Round(float64(v)*factor/2)))
+	}
+	return scale(width), scale(height)
+}
@@ -0,0 +1,117 @@
+package gostream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"go.viam.com/test"
+)
+
+func TestRateController(t *testing.T) {
+	rc := newRateController(20)
+	now := time.Now()
+	full := 640 * 480 * 20 * bitsPerPixel
+
+	encoding := rc.adapt(640, 480, now)
+	test.That(t, encoding, test.ShouldResemble, videoEncoding{bitrate: int(full), frameRate: 20, downscale: 1})
+
+	// without feedback, the video is sent as it is
+	now = now.Add(adaptInterval)
+	test.That(t, rc.adapt(640, 480, now), test.ShouldResemble, encoding)
+
+	// a peer losing half of the packets halves the bitrate, first by lowering the frame rate
+	lossy := []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{FractionLost: 128}}}}
+	rc.handleRTCP("peer1", lossy, now)
+	encoding = rc.adapt(640, 480, now)
+	test.That(t, encoding.bitrate, test.ShouldEqual, int(full*0.75))
+	test.That(t, encoding.frameRate, test.ShouldEqual, 15)
+	test.That(t, encoding.downscale, test.ShouldEqual, 1)
+
+	// the encoding changes at most every adapt interval
+	test.That(t, rc.adapt(640, 480, now.Add(time.Second)), test.ShouldResemble, encoding)
+
+	// then by downscaling the frames
+	for i := 0; i < 3; i++ {
+		now = now.Add(adaptInterval)
+		rc.handleRTCP("peer1", lossy, now)
+		encoding = rc.adapt(640, 480, now)
+	}
+	test.That(t, encoding.frameRate, test.ShouldEqual, 10)
+	test.That(t, encoding.downscale, test.ShouldBeLessThan, 1)
+	stats := rc.stats(now)
+	test.That(t, stats.PacketLoss, test.ShouldEqual, 0.5)
+	test.That(t, stats.TargetBitrate, test.ShouldEqual, int(full*0.75*0.75*0.75*0.75))
+
+	// the estimated maximum bitrate of another peer caps the bitrate
+	rc.handleRTCP("peer1", []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{}}}}, now)
+	rc.handleRTCP("peer2", []rtcp.Packet{&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 150_000}}, now)
+	now = now.Add(adaptInterval)
+	encoding = rc.adapt(640, 480, now)
+	test.That(t, encoding.bitrate, test.ShouldEqual, 150_000)
+	test.That(t, encoding.downscale, test.ShouldEqual, 0.5)
+	test.That(t, rc.stats(now).EstimatedBitrate, test.ShouldEqual, 150_000)
+
+	// once the feedback of the constraining peer times out, the bitrate increases slowly with the low loss of the other
+	now = now.Add(feedbackTimeout)
+	rc.handleRTCP("peer1", []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{}}}}, now)
+	encoding = rc.adapt(640, 480, now)
+	test.That(t, rc.stats(now).TargetBitrate, test.ShouldEqual, int(150_000*bitrateIncrease))
+	test.That(t, rc.stats(now).EstimatedBitrate, test.ShouldEqual, 0)
+	// the encoder keeps its bitrate for small changes of the target
+	test.That(t, encoding.bitrate, test.ShouldEqual, 150_000)
+	for i := 0; i < 100; i++ {
+		now = now.Add(adaptInterval)
+		rc.handleRTCP("peer1", []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{}}}}, now)
+		encoding = rc.adapt(640, 480, now)
+	}
+	test.That(t, encoding, test.ShouldResemble, videoEncoding{bitrate: int(full), frameRate: 20, downscale: 1})
+
+	// a new size of the frames resets the encoding
+	rc.handleRTCP("peer1", lossy, now)
+	test.That(t, rc.adapt(320, 240, now).bitrate, test.ShouldEqual, int(320*240*20*bitsPerPixel))
+}
+
+func TestRateControllerTWCC(t *testing.T) {
+	rc := newRateController(20)
+	now := time.Now()
+	rc.handleRTCP("peer", []rtcp.Packet{&rtcp.TransportLayerCC{
+		PacketStatusCount: 10,
+		RecvDeltas:        make([]*rtcp.RecvDelta, 8),
+	}}, now)
+	test.That(t, rc.stats(now).PacketLoss, test.ShouldAlmostEqual, 0.2)
+
+	// packets other than feedback are ignored
+	rc.handleRTCP("peer", []rtcp.Packet{&rtcp.SenderReport{}}, now.Add(time.Second))
+	test.That(t, rc.stats(now.Add(time.Second)).PacketLoss, test.ShouldAlmostEqual, 0.2)
+	test.That(t, rc.stats(now.Add(feedbackTimeout+time.Second)).PacketLoss, test.ShouldEqual, 0)
+}
+
+func TestRateControllerSent(t *testing.T) {
+	rc := newRateController(20)
+	now := time.Now()
+	rc.encoded(320, 240)
+	for i := 0; i <= 10; i++ {
+		rc.sent(1000, now.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	stats := rc.stats(now.Add(time.Second))
+	test.That(t, stats.FrameRate, test.ShouldAlmostEqual, 10)
+	test.That(t, stats.Bitrate, test.ShouldEqual, 80_000)
+	test.That(t, stats.Width, test.ShouldEqual, 320)
+	test.That(t, stats.Height, test.ShouldEqual, 240)
+
+	// frames stopped being sent
+	test.That(t, rc.stats(now.Add(5*time.Second)).FrameRate, test.ShouldEqual, 0)
+}
+
+func TestDownscaledSize(t *testing.T) {
+	width, height := downscaledSize(641, 481, 1)
+	test.That(t, width, test.ShouldEqual, 641)
+	test.That(t, height, test.ShouldEqual, 481)
+	width, height = downscaledSize(640, 480, 0.375)
+	test.That(t, width, test.ShouldEqual, 240)
+	test.That(t, height, test.ShouldEqual, 180)
+	width, height = downscaledSize(6, 3, 0.25)
+	test.That(t, width, test.ShouldEqual, 2)
+	test.That(t, height, test.ShouldEqual, 2)
+}
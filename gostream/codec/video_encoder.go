@@ -26,3 +26,10 @@ type VideoEncoderFactory interface {
 	New(height, width, keyFrameInterval int, logger logging.Logger) (VideoEncoder, error)
 	MIMEType() string
 }
+
+// A BitrateVideoEncoderFactory is a VideoEncoderFactory whose encoders can target a given bitrate, in bits per second,
+// so that streams can adapt the bitrate of their video to the connection of their peers.
+type BitrateVideoEncoderFactory interface {
+	VideoEncoderFactory
+	NewWithBitrate(width, height, keyFrameInterval, bitrate int, logger logging.Logger) (VideoEncoder, error)
+}
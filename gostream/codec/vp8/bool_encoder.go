@@ -0,0 +1,73 @@
+package vp8
+
+// boolEncoder is the boolean entropy encoder of VP8, as specified in section 7.3 of RFC 6386.
+type boolEncoder struct {
+	out      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// addOneToOutput propagates a carry into the bytes already written.
+func (e *boolEncoder) addOneToOutput() {
+	i := len(e.out) - 1
+	for ; i >= 0 && e.out[i] == 255; i-- {
+		e.out[i] = 0
+	}
+	if i >= 0 {
+		e.out[i]++
+	}
+}
+
+// writeBool writes a bool whose probability of being false is prob/256.
+func (e *boolEncoder) writeBool(prob uint8, value bool) {
+	split := 1 + (((e.rng - 1) * uint32(prob)) >> 8)
+	if value {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			e.addOneToOutput()
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.out = append(e.out, byte(e.bottom>>24))
+			e.bottom &= (1 << 24) - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+// writeLiteral writes the n bits of an unsigned value, most significant first, with even probabilities.
+func (e *boolEncoder) writeLiteral(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.writeBool(128, (v>>i)&1 == 1)
+	}
+}
+
+// flush writes the remaining bits and returns the encoded bytes.
+func (e *boolEncoder) flush() []byte {
+	c := e.bitCount
+	v := e.bottom
+	if v&(1<<(32-c)) != 0 {
+		e.addOneToOutput()
+	}
+	v <<= c & 7
+	for c >>= 3; c > 0; c-- {
+		v <<= 8
+	}
+	for i := 0; i < 4; i++ {
+		e.out = append(e.out, byte(v>>24))
+		v <<= 8
+	}
+	return e.out
+}
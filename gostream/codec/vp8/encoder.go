@@ -0,0 +1,573 @@
+// Package vp8 contains a pure Go VP8 video encoder. Every frame is a key frame predicted from the average of its
+// neighboring pixels, so the encoder needs a lot more bandwidth than x264 for the same quality, but it lets builds
+// without cgo stream video over WebRTC.
+package vp8
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pkg/errors"
+
+	ourcodec "go.viam.com/rdk/gostream/codec"
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	// initialQuantizer is the quantizer index of the first frame, which is adapted to the bitrate for the next ones.
+	initialQuantizer = 40
+	maxQuantizer     = 127
+	// the bitrate is kept within a budget of bytes per frame, with a tolerance
+	budgetTolerance = 0.15
+	maxDCTValue     = 2048
+)
+
+// blocks are 4x4 blocks of coefficients or pixels.
+type block [16]int32
+
+// nonZero holds whether the blocks along an edge of a macroblock had non-zero coefficients, which is the context of the
+// coefficients of the next blocks.
+type nonZero struct {
+	y    [4]uint8
+	u, v [2]uint8
+	y2   uint8
+}
+
+type quantizers struct {
+	y1, y2, uv [2]int32
+}
+
+func newQuantizers(q int) quantizers {
+	qs := quantizers{
+		y1: [2]int32{dcQuant[q], acQuant[q]},
+		y2: [2]int32{dcQuant[q] * 2, acQuant[q] * 155 / 100},
+		uv: [2]int32{dcQuant[min(q, 117)], acQuant[q]},
+	}
+	if qs.y2[1] < 8 {
+		qs.y2[1] = 8
+	}
+	return qs
+}
+
+type encoder struct {
+	width, height int
+	mbw, mbh      int
+	frameBudget   int
+	quantizer     int
+	logger        logging.Logger
+
+	// src are the planes of the frame being encoded and rec the reconstructed planes the decoder sees, which the
+	// macroblocks are predicted from, both padded to a whole number of macroblocks
+	src, rec         [3][]uint8
+	yStride, cStride int
+	above            []nonZero
+	left             nonZero
+	header, tokens   *boolEncoder
+	quant            quantizers
+}
+
+// NewEncoder returns a VP8 encoder of frames of the given size, targeting a bitrate which depends on the size of the
+// frames and their rate, which is given as the key frame interval like with the x264 encoder.
+func NewEncoder(width, height, keyFrameInterval int, logger logging.Logger) (ourcodec.VideoEncoder, error) {
+	return NewEncoderWithBitrate(width, height, keyFrameInterval,
+		calcBitrateFromResolution(width, height, float32(keyFrameInterval)), logger)
+}
+
+// NewEncoderWithBitrate returns a VP8 encoder like NewEncoder, targeting the given bitrate in bits per second.
+func NewEncoderWithBitrate(width, height, keyFrameInterval, bitrate int, logger logging.Logger) (ourcodec.VideoEncoder, error) {
+	if width <= 0 || height <= 0 || width >= 1<<14 || height >= 1<<14 {
+		return nil, errors.Errorf("vp8 encoder does not support frames of %dx%d pixels", width, height)
+	}
+	if keyFrameInterval <= 0 {
+		keyFrameInterval = ourcodec.DefaultKeyFrameInterval
+	}
+	enc := &encoder{
+		width:       width,
+		height:      height,
+		mbw:         (width + 15) / 16,
+		mbh:         (height + 15) / 16,
+		frameBudget: clampBitrate(bitrate) / 8 / keyFrameInterval,
+		quantizer:   initialQuantizer,
+		logger:      logger,
+	}
+	enc.yStride, enc.cStride = 16*enc.mbw, 8*enc.mbw
+	for i := range enc.src {
+		size := enc.cStride * 8 * enc.mbh
+		if i == 0 {
+			size = enc.yStride * 16 * enc.mbh
+		}
+		enc.src[i] = make([]uint8, size)
+		enc.rec[i] = make([]uint8, size)
+	}
+	enc.above = make([]nonZero, enc.mbw)
+	return enc, nil
+}
+
+// Encode encodes the image as a key frame, then adapts the quantizer of the next frames to the bitrate.
+func (enc *encoder) Encode(_ context.Context, img image.Image) ([]byte, error) {
+	if b := img.Bounds(); b.Dx() != enc.width || b.Dy() != enc.height {
+		return nil, errors.Errorf("vp8 encoder expects frames of %dx%d pixels, got %dx%d", enc.width, enc.height, b.Dx(), b.Dy())
+	}
+	enc.load(img)
+	frame := enc.encodeFrame()
+
+	switch size := len(frame); {
+	case size > int(float64(enc.frameBudget)*(1+budgetTolerance)):
+		enc.quantizer += min(8, 1+(size-enc.frameBudget)*4/enc.frameBudget)
+	case size < int(float64(enc.frameBudget)*(1-budgetTolerance)):
+		enc.quantizer--
+	}
+	enc.quantizer = max(0, min(maxQuantizer, enc.quantizer))
+	return frame, nil
+}
+
+// Close closes the encoder.
+func (enc *encoder) Close() error {
+	return nil
+}
+
+// load converts the image to 4:2:0 YCbCr planes, repeating the last row and column into the padding.
+func (enc *encoder) load(img image.Image) {
+	b := img.Bounds()
+	yPlane, cbPlane, crPlane := enc.src[0], enc.src[1], enc.src[2]
+	set := func(x, y int, yy, cb, cr uint8) {
+		yPlane[y*enc.yStride+x] = yy
+		if x%2 == 0 && y%2 == 0 {
+			cbPlane[y/2*enc.cStride+x/2] = cb
+			crPlane[y/2*enc.cStride+x/2] = cr
+		}
+	}
+	switch src := img.(type) {
+	case *image.YCbCr:
+		for y := 0; y < enc.height; y++ {
+			for x := 0; x < enc.width; x++ {
+				c := src.COffset(b.Min.X+x, b.Min.Y+y)
+				set(x, y, src.Y[src.YOffset(b.Min.X+x, b.Min.Y+y)], src.Cb[c], src.Cr[c])
+			}
+		}
+	case *image.RGBA:
+		for y := 0; y < enc.height; y++ {
+			for x := 0; x < enc.width; x++ {
+				i := src.PixOffset(b.Min.X+x, b.Min.Y+y)
+				yy, cb, cr := color.RGBToYCbCr(src.Pix[i], src.Pix[i+1], src.Pix[i+2])
+				set(x, y, yy, cb, cr)
+			}
+		}
+	default:
+		for y := 0; y < enc.height; y++ {
+			for x := 0; x < enc.width; x++ {
+				r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+				set(x, y, yy, cb, cr)
+			}
+		}
+	}
+	pad := func(plane []uint8, stride, width, height, paddedHeight int) {
+		for y := 0; y < height; y++ {
+			row := plane[y*stride : (y+1)*stride]
+			for x := width; x < stride; x++ {
+				row[x] = row[width-1]
+			}
+		}
+		for y := height; y < paddedHeight; y++ {
+			copy(plane[y*stride:(y+1)*stride], plane[(height-1)*stride:height*stride])
+		}
+	}
+	pad(yPlane, enc.yStride, enc.width, enc.height, 16*enc.mbh)
+	pad(cbPlane, enc.cStride, (enc.width+1)/2, (enc.height+1)/2, 8*enc.mbh)
+	pad(crPlane, enc.cStride, (enc.width+1)/2, (enc.height+1)/2, 8*enc.mbh)
+}
+
+// encodeFrame encodes the loaded planes as a key frame, as specified in sections 9 and 19 of RFC 6386.
+func (enc *encoder) encodeFrame() []byte {
+	enc.quant = newQuantizers(enc.quantizer)
+	enc.header, enc.tokens = newBoolEncoder(), newBoolEncoder()
+	h := enc.header
+	h.writeLiteral(0, 1) // color space
+	h.writeLiteral(0, 1) // clamping type
+	h.writeLiteral(0, 1) // segmentation
+	h.writeLiteral(0, 1) // filter type
+	h.writeLiteral(uint32(enc.quantizer/2), 6)
+	h.writeLiteral(0, 3) // sharpness
+	h.writeLiteral(0, 1) // loop filter adjustments
+	h.writeLiteral(0, 2) // a single token partition
+	h.writeLiteral(uint32(enc.quantizer), 7)
+	h.writeLiteral(0, 5) // no quantizer deltas
+	h.writeLiteral(0, 1) // refresh entropy probabilities
+	for i := range coeffUpdateProbs {
+		for j := range coeffUpdateProbs[i] {
+			for k := range coeffUpdateProbs[i][j] {
+				for _, prob := range coeffUpdateProbs[i][j][k] {
+					h.writeBool(prob, false)
+				}
+			}
+		}
+	}
+	h.writeLiteral(0, 1) // no skipped macroblocks
+
+	for i := range enc.above {
+		enc.above[i] = nonZero{}
+	}
+	for mby := 0; mby < enc.mbh; mby++ {
+		enc.left = nonZero{}
+		for mbx := 0; mbx < enc.mbw; mbx++ {
+			enc.encodeMacroblock(mbx, mby)
+		}
+	}
+
+	first, tokens := h.flush(), enc.tokens.flush()
+	frame := make([]byte, 10, 10+len(first)+len(tokens))
+	// a shown key frame of version 0, then the size of the first partition
+	tag := uint32(1<<4) | uint32(len(first))<<5
+	frame[0], frame[1], frame[2] = byte(tag), byte(tag>>8), byte(tag>>16)
+	frame[3], frame[4], frame[5] = 0x9d, 0x01, 0x2a
+	frame[6], frame[7] = byte(enc.width), byte(enc.width>>8)
+	frame[8], frame[9] = byte(enc.height), byte(enc.height>>8)
+	frame = append(frame, first...)
+	return append(frame, tokens...)
+}
+
+// encodeMacroblock encodes a macroblock predicted with the DC modes, writing its residual coefficients and
+// reconstructing it like the decoder does.
+func (enc *encoder) encodeMacroblock(mbx, mby int) {
+	enc.header.writeBool(145, true)  // 16x16 luma prediction
+	enc.header.writeBool(156, false) // DC or vertical
+	enc.header.writeBool(163, false) // DC
+	enc.header.writeBool(142, false) // DC chroma prediction
+
+	// luma, whose DC coefficients are transformed again into the Y2 block
+	above := &enc.above[mbx]
+	x0, y0 := 16*mbx, 16*mby
+	pred := enc.predictDC(0, enc.yStride, x0, y0, 16, mbx, mby)
+	var coeffs [16]block
+	var dc block
+	for n := range coeffs {
+		coeffs[n] = enc.transformBlock(0, enc.yStride, x0+4*(n%4), y0+4*(n/4), pred)
+		dc[n] = coeffs[n][0]
+	}
+	y2 := walshHadamard(&dc)
+	levels := quantize(&y2, enc.quant.y2)
+	nz := writeCoefficients(enc.tokens, typeY2, above.y2+enc.left.y2, &levels, 0)
+	above.y2, enc.left.y2 = nz, nz
+	dequantize(&levels, enc.quant.y2)
+	dc = inverseWalshHadamard(&levels)
+	for n := range coeffs {
+		x, y := n%4, n/4
+		coeffs[n][0] = 0
+		levels := quantize(&coeffs[n], enc.quant.y1)
+		nz := writeCoefficients(enc.tokens, typeYAfterY2, above.y[x]+enc.left.y[y], &levels, 1)
+		above.y[x], enc.left.y[y] = nz, nz
+		dequantize(&levels, enc.quant.y1)
+		levels[0] = dc[n]
+		enc.reconstructBlock(0, enc.yStride, x0+4*x, y0+4*y, pred, &levels)
+	}
+
+	// chroma
+	for plane := 1; plane < 3; plane++ {
+		x0, y0 := 8*mbx, 8*mby
+		pred := enc.predictDC(plane, enc.cStride, x0, y0, 8, mbx, mby)
+		aboveNZ, leftNZ := &above.u, &enc.left.u
+		if plane == 2 {
+			aboveNZ, leftNZ = &above.v, &enc.left.v
+		}
+		for n := 0; n < 4; n++ {
+			x, y := n%2, n/2
+			coeffs := enc.transformBlock(plane, enc.cStride, x0+4*x, y0+4*y, pred)
+			levels := quantize(&coeffs, enc.quant.uv)
+			nz := writeCoefficients(enc.tokens, typeUV, aboveNZ[x]+leftNZ[y], &levels, 0)
+			aboveNZ[x], leftNZ[y] = nz, nz
+			dequantize(&levels, enc.quant.uv)
+			enc.reconstructBlock(plane, enc.cStride, x0+4*x, y0+4*y, pred, &levels)
+		}
+	}
+}
+
+// predictDC returns the DC prediction of a square of the reconstructed plane, the average of the row above and the
+// column left of it when they are within the frame, as specified in section 12.2.
+func (enc *encoder) predictDC(plane, stride, x0, y0, size, mbx, mby int) int32 {
+	rec := enc.rec[plane]
+	var sum, count int32
+	if mby > 0 {
+		for i := 0; i < size; i++ {
+			sum += int32(rec[(y0-1)*stride+x0+i])
+		}
+		count += int32(size)
+	}
+	if mbx > 0 {
+		for j := 0; j < size; j++ {
+			sum += int32(rec[(y0+j)*stride+x0-1])
+		}
+		count += int32(size)
+	}
+	if count == 0 {
+		return 128
+	}
+	return (sum + count/2) / count
+}
+
+// transformBlock returns the DCT coefficients of the difference between a 4x4 block of the plane and its prediction.
+func (enc *encoder) transformBlock(plane, stride, x0, y0 int, pred int32) block {
+	src := enc.src[plane]
+	var residual block
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			residual[4*y+x] = int32(src[(y0+y)*stride+x0+x]) - pred
+		}
+	}
+	return forwardDCT(&residual)
+}
+
+// reconstructBlock adds the inverse DCT of the dequantized coefficients to the prediction of a 4x4 block of the plane.
+func (enc *encoder) reconstructBlock(plane, stride, x0, y0 int, pred int32, coeffs *block) {
+	rec := enc.rec[plane]
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			rec[(y0+y)*stride+x0+x] = uint8(pred)
+		}
+	}
+	inverseDCT(coeffs, rec[y0*stride+x0:], stride)
+}
+
+func quantize(coeffs *block, quant [2]int32) block {
+	var levels block
+	for i, c := range coeffs {
+		q := quant[min(i, 1)]
+		level := (abs(c) + q/2) / q
+		if level > maxDCTValue {
+			level = maxDCTValue
+		}
+		if c < 0 {
+			level = -level
+		}
+		levels[i] = level
+	}
+	return levels
+}
+
+func dequantize(levels *block, quant [2]int32) {
+	for i := range levels {
+		levels[i] *= quant[min(i, 1)]
+	}
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// writeCoefficients writes the tokens of the quantized coefficients of a block from the first one, as specified in
+// section 13, and returns whether any was non-zero. ctx is the number of blocks above and left with non-zero
+// coefficients.
+func writeCoefficients(e *boolEncoder, typ int, ctx uint8, levels *block, first int) uint8 {
+	probs := &defaultCoeffProbs[typ]
+	last := -1
+	for i := first; i < 16; i++ {
+		if levels[zigzag[i]] != 0 {
+			last = i
+		}
+	}
+	p := &probs[bands[first]][ctx]
+	e.writeBool(p[0], last >= 0)
+	if last < 0 {
+		return 0
+	}
+	for i := first; i <= last; i++ {
+		v := levels[zigzag[i]]
+		if v == 0 {
+			// zeros are never followed by the end of the block
+			e.writeBool(p[1], false)
+			p = &probs[bands[i+1]][0]
+			continue
+		}
+		e.writeBool(p[1], true)
+		a := abs(v)
+		if a == 1 {
+			e.writeBool(p[2], false)
+		} else {
+			e.writeBool(p[2], true)
+			writeValue(e, p, a)
+		}
+		e.writeBool(128, v < 0)
+		if a == 1 {
+			p = &probs[bands[i+1]][1]
+		} else {
+			p = &probs[bands[i+1]][2]
+		}
+		if i < 15 {
+			e.writeBool(p[0], i < last)
+		}
+	}
+	return 1
+}
+
+// writeValue writes the token of a value above 1 and its extra bits.
+func writeValue(e *boolEncoder, p *[numTokenProbs]uint8, a int32) {
+	if a <= 4 {
+		e.writeBool(p[3], false)
+		if a == 2 {
+			e.writeBool(p[4], false)
+			return
+		}
+		e.writeBool(p[4], true)
+		e.writeBool(p[5], a == 4)
+		return
+	}
+	e.writeBool(p[3], true)
+	var cat int
+	switch {
+	case a <= 6:
+		cat = 0
+	case a <= 10:
+		cat = 1
+	case a <= 18:
+		cat = 2
+	case a <= 34:
+		cat = 3
+	case a <= 66:
+		cat = 4
+	default:
+		cat = 5
+	}
+	e.writeBool(p[6], cat >= 2)
+	if cat < 2 {
+		e.writeBool(p[7], cat == 1)
+	} else {
+		e.writeBool(p[8], cat >= 4)
+		if cat < 4 {
+			e.writeBool(p[9], cat == 3)
+		} else {
+			e.writeBool(p[10], cat == 5)
+		}
+	}
+	extra := a - catBase[cat]
+	probs := catProbs[cat]
+	for i, prob := range probs {
+		e.writeBool(prob, (extra>>(len(probs)-1-i))&1 == 1)
+	}
+}
+
+// The transforms are scaled so that the inverse transforms of the decoder, as specified in section 14, are their
+// inverses.
+const (
+	idctC1 = 85627 // 65536 * cos(pi/8) * sqrt(2)
+	idctC2 = 35468 // 65536 * sin(pi/8) * sqrt(2)
+)
+
+// dctBasis is the matrix of the inverse DCT of the decoder, whose transpose divided by 4 is its inverse.
+var dctBasis = [4][4]float64{
+	{1, idctC1 / 65536., 1, idctC2 / 65536.},
+	{1, idctC2 / 65536., -1, -idctC1 / 65536.},
+	{1, -idctC2 / 65536., -1, idctC1 / 65536.},
+	{1, -idctC1 / 65536., 1, -idctC2 / 65536.},
+}
+
+// whtBasis is the matrix of the inverse Walsh-Hadamard transform of the decoder.
+var whtBasis = [4][4]float64{
+	{1, 1, 1, 1},
+	{1, 1, -1, -1},
+	{1, -1, -1, 1},
+	{1, -1, 1, -1},
+}
+
+// forwardTransform returns basis^T * b * basis / 2, the inverse of the transforms of the decoder which compute
+// basis * c * basis^T / 8.
+func forwardTransform(b *block, basis *[4][4]float64) block {
+	var tmp [4][4]float64
+	for r := 0; r < 4; r++ {
+		for v := 0; v < 4; v++ {
+			var sum float64
+			for c := 0; c < 4; c++ {
+				sum += float64(b[4*r+c]) * basis[c][v]
+			}
+			tmp[r][v] = sum
+		}
+	}
+	var out block
+	for u := 0; u < 4; u++ {
+		for v := 0; v < 4; v++ {
+			var sum float64
+			for r := 0; r < 4; r++ {
+				sum += basis[r][u] * tmp[r][v]
+			}
+			out[4*u+v] = int32(math.Round(sum / 2))
+		}
+	}
+	return out
+}
+
+func forwardDCT(residual *block) block {
+	return forwardTransform(residual, &dctBasis)
+}
+
+func walshHadamard(dc *block) block {
+	return forwardTransform(dc, &whtBasis)
+}
+
+// inverseDCT adds the inverse DCT of the coefficients to a 4x4 block of pixels, exactly like the decoder.
+func inverseDCT(coeffs *block, pixels []uint8, stride int) {
+	var m [4][4]int32
+	for i := 0; i < 4; i++ {
+		a := coeffs[i] + coeffs[8+i]
+		b := coeffs[i] - coeffs[8+i]
+		c := (coeffs[4+i]*idctC2)>>16 - (coeffs[12+i]*idctC1)>>16
+		d := (coeffs[4+i]*idctC1)>>16 + (coeffs[12+i]*idctC2)>>16
+		m[i][0] = a + d
+		m[i][1] = b + c
+		m[i][2] = b - c
+		m[i][3] = a - d
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a := dc + m[2][j]
+		b := dc - m[2][j]
+		c := (m[1][j]*idctC2)>>16 - (m[3][j]*idctC1)>>16
+		d := (m[1][j]*idctC1)>>16 + (m[3][j]*idctC2)>>16
+		row := pixels[j*stride : j*stride+4]
+		row[0] = clip8(int32(row[0]) + (a+d)>>3)
+		row[1] = clip8(int32(row[1]) + (b+c)>>3)
+		row[2] = clip8(int32(row[2]) + (b-c)>>3)
+		row[3] = clip8(int32(row[3]) + (a-d)>>3)
+	}
+}
+
+// inverseWalshHadamard returns the DC coefficients of the luma blocks from the dequantized Y2 block, exactly like the
+// decoder.
+func inverseWalshHadamard(coeffs *block) block {
+	var m, out block
+	for i := 0; i < 4; i++ {
+		a0 := coeffs[i] + coeffs[12+i]
+		a1 := coeffs[4+i] + coeffs[8+i]
+		a2 := coeffs[4+i] - coeffs[8+i]
+		a3 := coeffs[i] - coeffs[12+i]
+		m[i] = a0 + a1
+		m[8+i] = a0 - a1
+		m[4+i] = a3 + a2
+		m[12+i] = a3 - a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[4*i] + 3
+		a0 := dc + m[4*i+3]
+		a1 := m[4*i+1] + m[4*i+2]
+		a2 := m[4*i+1] - m[4*i+2]
+		a3 := dc - m[4*i+3]
+		out[4*i] = (a0 + a1) >> 3
+		out[4*i+1] = (a3 + a2) >> 3
+		out[4*i+2] = (a0 - a1) >> 3
+		out[4*i+3] = (a3 - a2) >> 3
+	}
+	return out
+}
+
+func clip8(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,128 @@
+package vp8
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"go.viam.com/test"
+	"golang.org/x/image/vp8"
+
+	"go.viam.com/rdk/logging"
+)
+
+// testImage returns an image with gradients, stripes and a flat area, of a size which is not a whole number of
+// macroblocks.
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{uint8(x * 255 / width), uint8(y * 255 / height), 128, 255}
+			switch {
+			case x > width/2 && y > height/2:
+				c = color.RGBA{200, 40, 40, 255}
+			case x < width/4 && (y/4)%2 == 0:
+				c = color.RGBA{250, 250, 250, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func decode(t *testing.T, frame []byte) *image.YCbCr {
+	t.Helper()
+	d := vp8.NewDecoder()
+	d.Init(bytes.NewReader(frame), len(frame))
+	header, err := d.DecodeFrameHeader()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, header.KeyFrame, test.ShouldBeTrue)
+	img, err := d.DecodeFrame()
+	test.That(t, err, test.ShouldBeNil)
+	return img
+}
+
+// psnr returns the peak signal to noise ratio of the luma of the decoded image.
+func psnr(src image.Image, decoded *image.YCbCr) float64 {
+	var sum float64
+	b := src.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			r, g, bl, _ := src.At(x, y).RGBA()
+			yy, _, _ := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			d := float64(yy) - float64(decoded.Y[decoded.YOffset(x, y)])
+			sum += d * d
+		}
+	}
+	return 10 * math.Log10(255*255/(sum/float64(b.Dx()*b.Dy())))
+}
+
+func TestEncoder(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	img := testImage(100, 70)
+
+	_, err := NewEncoder(0, 70, 30, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	enc, err := NewEncoderWithBitrate(100, 70, 30, maxBitrate, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, enc.Close(), test.ShouldBeNil)
+	}()
+	_, err = enc.Encode(context.Background(), image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// with plenty of bandwidth, the quality increases as the quantizer decreases
+	frame, err := enc.Encode(context.Background(), img)
+	test.That(t, err, test.ShouldBeNil)
+	decoded := decode(t, frame)
+	test.That(t, decoded.Bounds(), test.ShouldResemble, img.Bounds())
+	first := psnr(img, decoded)
+	test.That(t, first, test.ShouldBeGreaterThan, 35)
+	for i := 0; i < 30; i++ {
+		frame, err = enc.Encode(context.Background(), img)
+		test.That(t, err, test.ShouldBeNil)
+	}
+	quality := psnr(img, decode(t, frame))
+	test.That(t, quality, test.ShouldBeGreaterThan, first)
+
+	// YCbCr images are encoded like RGBA images
+	ycbcr := image.NewYCbCr(img.Bounds(), image.YCbCrSubsampleRatio420)
+	for y := 0; y < 70; y++ {
+		for x := 0; x < 100; x++ {
+			c := img.RGBAAt(x, y)
+			ycbcr.Y[ycbcr.YOffset(x, y)], ycbcr.Cb[ycbcr.COffset(x, y)], ycbcr.Cr[ycbcr.COffset(x, y)] =
+				color.RGBToYCbCr(c.R, c.G, c.B)
+		}
+	}
+	enc2, err := NewEncoderWithBitrate(100, 70, 30, maxBitrate, logger)
+	test.That(t, err, test.ShouldBeNil)
+	frame, err = enc2.Encode(context.Background(), ycbcr)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, psnr(img, decode(t, frame)), test.ShouldAlmostEqual, first, 0.5)
+}
+
+func TestEncoderBitrate(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	img := testImage(320, 240)
+	encode := func(bitrate int) []byte {
+		enc, err := NewEncoderWithBitrate(320, 240, 10, bitrate, logger)
+		test.That(t, err, test.ShouldBeNil)
+		var frame []byte
+		for i := 0; i < 30; i++ {
+			frame, err = enc.Encode(context.Background(), img)
+			test.That(t, err, test.ShouldBeNil)
+		}
+		return frame
+	}
+
+	// frames fit in the budget of the bitrate, with a lower quality for lower bitrates
+	high, low := encode(4_000_000), encode(minBitrate)
+	test.That(t, len(low), test.ShouldBeLessThanOrEqualTo, minBitrate/8/10*(1+budgetTolerance))
+	test.That(t, len(high), test.ShouldBeGreaterThan, len(low))
+	test.That(t, psnr(img, decode(t, high)), test.ShouldBeGreaterThan, psnr(img, decode(t, low)))
+	test.That(t, psnr(img, decode(t, low)), test.ShouldBeGreaterThan, 25)
+}
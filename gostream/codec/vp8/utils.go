@@ -0,0 +1,60 @@
+package vp8
+
+import (
+	"math"
+
+	"go.viam.com/rdk/gostream"
+	"go.viam.com/rdk/gostream/codec"
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	// encodeCompressionRatio is the number of bits per pixel of encoded frames, twice that of x264 as every frame is a
+	// key frame.
+	encodeCompressionRatio = 0.3
+	minBitrate             = 300_000    // 300kbps
+	maxBitrate             = 25_000_000 // 25Mbps
+)
+
+// DefaultStreamConfig configures VP8 as the encoder for a stream.
+var DefaultStreamConfig gostream.StreamConfig
+
+func init() {
+	DefaultStreamConfig.VideoEncoderFactory = NewEncoderFactory()
+}
+
+// NewEncoderFactory returns a VP8 encoder factory, whose encoders can target a given bitrate.
+func NewEncoderFactory() codec.VideoEncoderFactory {
+	return &factory{}
+}
+
+type factory struct{}
+
+func (f *factory) New(width, height, keyFrameInterval int, logger logging.Logger) (codec.VideoEncoder, error) {
+	return NewEncoder(width, height, keyFrameInterval, logger)
+}
+
+func (f *factory) NewWithBitrate(width, height, keyFrameInterval, bitrate int, logger logging.Logger) (codec.VideoEncoder, error) {
+	return NewEncoderWithBitrate(width, height, keyFrameInterval, bitrate, logger)
+}
+
+func (f *factory) MIMEType() string {
+	return "video/VP8"
+}
+
+// calcBitrateFromResolution calculates the bitrate based on the given resolution and framerate.
+func calcBitrateFromResolution(width, height int, framerate float32) int {
+	bitrate := float64(width) * float64(height) * float64(framerate) * encodeCompressionRatio
+	return clampBitrate(int(math.Ceil(bitrate)))
+}
+
+// clampBitrate returns the bitrate within the range supported by the encoder.
+func clampBitrate(bitrate int) int {
+	if bitrate < minBitrate {
+		return minBitrate
+	}
+	if bitrate > maxBitrate {
+		return maxBitrate
+	}
+	return bitrate
+}
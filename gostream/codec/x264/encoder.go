@@ -23,6 +23,12 @@ type encoder struct {
 // NewEncoder returns an x264 encoder that can encode images of the given width and height. It will
 // also ensure that it produces key frames at the given interval.
 func NewEncoder(width, height, keyFrameInterval int, logger logging.Logger) (ourcodec.VideoEncoder, error) {
+	return NewEncoderWithBitrate(width, height, keyFrameInterval,
+		calcBitrateFromResolution(width, height, float32(keyFrameInterval)), logger)
+}
+
+// NewEncoderWithBitrate returns an x264 encoder like NewEncoder, targeting the given bitrate in bits per second.
+func NewEncoderWithBitrate(width, height, keyFrameInterval, bitrate int, logger logging.Logger) (ourcodec.VideoEncoder, error) {
 	// Check to make sure dimensions are even.
 	if width%2 != 0 || height%2 != 0 {
 		return nil, errors.New("x264 encoder does not support odd dimensions. " +
@@ -38,7 +44,7 @@ func NewEncoder(width, height, keyFrameInterval int, logger logging.Logger) (our
 	}
 	builder = &params
 	params.KeyFrameInterval = keyFrameInterval
-	params.BitRate = calcBitrateFromResolution(width, height, float32(params.KeyFrameInterval))
+	params.BitRate = clampBitrate(bitrate)
 
 	codec, err := builder.BuildVideoEncoder(enc, prop.Media{
 		Video: prop.Video{
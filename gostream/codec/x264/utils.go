@@ -26,7 +26,7 @@ func init() {
 	DefaultStreamConfig.VideoEncoderFactory = NewEncoderFactory()
 }
 
-// NewEncoderFactory returns an x264 encoder factory.
+// NewEncoderFactory returns an x264 encoder factory, whose encoders can target a given bitrate.
 func NewEncoderFactory() codec.VideoEncoderFactory {
 	return &factory{}
 }
@@ -37,6 +37,10 @@ func (f *factory) New(width, height, keyFrameInterval int, logger logging.Logger
 	return NewEncoder(width, height, keyFrameInterval, logger)
 }
 
+func (f *factory) NewWithBitrate(width, height, keyFrameInterval, bitrate int, logger logging.Logger) (codec.VideoEncoder, error) {
+	return NewEncoderWithBitrate(width, height, keyFrameInterval, bitrate, logger)
+}
+
 func (f *factory) MIMEType() string {
 	return "video/H264"
 }
@@ -46,6 +50,11 @@ func calcBitrateFromResolution(width, height int, framerate float32) int {
 	bitrate := float32(width) * float32(height) * framerate * encodeCompressionRatio
 	// Round up to the nearest integer value.
 	bitrate = float32(math.Ceil(float64(bitrate)))
+	return clampBitrate(int(bitrate))
+}
+
+// clampBitrate returns the bitrate within the range supported by the encoder.
+func clampBitrate(bitrate int) int {
 	// This accounts for zero bitrates too.
 	if bitrate < minBitrate {
 		return minBitrate
@@ -53,5 +62,5 @@ func calcBitrateFromResolution(width, height int, framerate float32) int {
 	if bitrate > maxBitrate {
 		return maxBitrate
 	}
-	return int(bitrate)
+	return bitrate
 }
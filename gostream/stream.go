@@ -9,9 +9,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/disintegration/imaging"
 	"github.com/google/uuid"
 	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/mediadevices/pkg/wave"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/viamrobotics/webrtc/v3"
 	"go.viam.com/utils"
@@ -46,6 +48,12 @@ type Stream interface {
 
 	InputAudioChunks(props prop.Audio) (chan<- MediaReleasePair[wave.Audio], error)
 
+	// HandleRTCP adapts the bitrate, frame rate and size of the video to the RTCP feedback of a peer receiving it.
+	HandleRTCP(peer string, pkts []rtcp.Packet)
+
+	// Stats returns statistics of the video sent.
+	Stats() StreamStats
+
 	// Stop stops further processing of frames.
 	Stop()
 }
@@ -105,6 +113,7 @@ func NewStream(config StreamConfig, logger logging.Logger) (Stream, error) {
 		videoTrackLocal: trackLocal,
 		inputImageChan:  make(chan MediaReleasePair[image.Image]),
 		outputVideoChan: make(chan []byte),
+		rate:            newRateController(float64(config.TargetFrameRate)),
 
 		audioTrackLocal: audioTrackLocal,
 		inputAudioChan:  make(chan MediaReleasePair[wave.Audio]),
@@ -129,6 +138,7 @@ type basicStream struct {
 	inputImageChan  chan MediaReleasePair[image.Image]
 	outputVideoChan chan []byte
 	videoEncoder    codec.VideoEncoder
+	rate            *rateController
 
 	audioTrackLocal *trackLocalStaticSample
 	inputAudioChan  chan MediaReleasePair[wave.Audio]
@@ -191,6 +201,7 @@ func (bs *basicStream) Stop() {
 		if err := bs.videoEncoder.Close(); err != nil {
 			bs.logger.Error(err)
 		}
+		bs.videoEncoder = nil
 	}
 
 	// reset
@@ -229,6 +240,14 @@ func (bs *basicStream) InputAudioChunks(props prop.Audio) (chan<- MediaReleasePa
 	return bs.inputAudioChan, nil
 }
 
+func (bs *basicStream) HandleRTCP(peer string, pkts []rtcp.Packet) {
+	bs.rate.handleRTCP(peer, pkts, time.Now())
+}
+
+func (bs *basicStream) Stats() StreamStats {
+	return bs.rate.stats(time.Now())
+}
+
 func (bs *basicStream) VideoTrackLocal() (webrtc.TrackLocal, bool) {
 	return bs.videoTrackLocal, bs.videoTrackLocal != nil
 }
@@ -241,6 +260,9 @@ func (bs *basicStream) processInputFrames() {
 	frameLimiterDur := time.Second / time.Duration(bs.config.TargetFrameRate)
 	defer close(bs.outputVideoChan)
 	var dx, dy int
+	// the encoding and size of the frames of the current encoder, adapted to the feedback of the peers
+	var encoding videoEncoding
+	var encodedDx, encodedDy int
 	ticker := time.NewTicker(frameLimiterDur)
 	defer ticker.Stop()
 	for {
@@ -300,20 +322,38 @@ func (bs *basicStream) processInputFrames() {
 				}
 
 				newDx, newDy := bounds.Dx(), bounds.Dy()
-				if bs.videoEncoder == nil || dx != newDx || dy != newDy {
+				if dx != newDx || dy != newDy {
 					dx, dy = newDx, newDy
 					bs.logger.Infow("detected new image bounds", "width", dx, "height", dy)
-
-					if err := bs.initVideoCodec(dx, dy); err != nil {
+				}
+				adapted := bs.rate.adapt(dx, dy, time.Now())
+				if adapted.frameRate != encoding.frameRate {
+					ticker.Reset(time.Duration(float64(time.Second) / adapted.frameRate))
+				}
+				if adapted != encoding && encoding.bitrate != 0 {
+					bs.logger.Debugw("adapting video to the connection of the peers", "bitrate", adapted.bitrate,
+						"frame_rate", adapted.frameRate, "downscale", adapted.downscale)
+				}
+				width, height := downscaledSize(dx, dy, adapted.downscale)
+				if bs.videoEncoder == nil || width != encodedDx || height != encodedDy || adapted.bitrate != encoding.bitrate {
+					if err := bs.initVideoCodec(width, height, adapted.bitrate); err != nil {
 						bs.logger.Error(err)
 						initErr = true
 						return
 					}
+					encodedDx, encodedDy = width, height
+				}
+				encoding = adapted
+
+				frame := framePair.Media
+				if width != dx || height != dy {
+					frame = imaging.Resize(frame, width, height, imaging.NearestNeighbor)
 				}
+				bs.rate.encoded(width, height)
 
 				// thread-safe because the size is static
 				var err error
-				encodedFrame, err = bs.videoEncoder.Encode(bs.shutdownCtx, framePair.Media)
+				encodedFrame, err = bs.videoEncoder.Encode(bs.shutdownCtx, frame)
 				if err != nil {
 					bs.logger.Error(err)
 					return
@@ -403,6 +443,7 @@ func (bs *basicStream) processOutputFrames() {
 		if err := bs.videoTrackLocal.WriteData(outputFrame); err != nil {
 			bs.logger.Errorw("error writing frame", "error", err)
 		}
+		bs.rate.sent(len(outputFrame), now)
 		framesSent++
 		if Debug {
 			bs.logger.Debugw("wrote sample", "frames_sent", framesSent, "write_time", time.Since(now))
@@ -429,9 +470,21 @@ func (bs *basicStream) processOutputAudioChunks() {
 	}
 }
 
-func (bs *basicStream) initVideoCodec(width, height int) error {
+// initVideoCodec creates an encoder for frames of the given size, targeting the given bitrate when the encoders of the
+// factory can.
+func (bs *basicStream) initVideoCodec(width, height, bitrate int) error {
+	if bs.videoEncoder != nil {
+		if err := bs.videoEncoder.Close(); err != nil {
+			bs.logger.Error(err)
+		}
+		bs.videoEncoder = nil
+	}
 	var err error
-	bs.videoEncoder, err = bs.config.VideoEncoderFactory.New(width, height, bs.config.TargetFrameRate, bs.logger)
+	if factory, ok := bs.config.VideoEncoderFactory.(codec.BitrateVideoEncoderFactory); ok {
+		bs.videoEncoder, err = factory.NewWithBitrate(width, height, bs.config.TargetFrameRate, bitrate, bs.logger)
+	} else {
+		bs.videoEncoder, err = bs.config.VideoEncoderFactory.New(width, height, bs.config.TargetFrameRate, bs.logger)
+	}
 	return err
 }
 
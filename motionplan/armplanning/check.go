@@ -0,0 +1,97 @@
+package armplanning
+
+import (
+	"context"
+	"errors"
+
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/referenceframe"
+)
+
+// SegmentCheck is the result of collision checking the segment between two consecutive configurations of a trajectory.
+type SegmentCheck struct {
+	// Configurations are the interpolated configurations along the segment which were checked, at the resolution of the planner.
+	Configurations []*referenceframe.LinearInputs
+	// FailedIndex is the index of the first configuration which is in collision, or -1 if the segment is collision free.
+	FailedIndex int
+	// Err describes the collision at FailedIndex.
+	Err error
+}
+
+// CheckTrajectoryCollisions checks every segment of a trajectory for collisions in the same manner as the planner, including the
+// collision specifications of the request. Other constraints are not checked, as they depend on which goal a segment belongs to.
+func CheckTrajectoryCollisions(ctx context.Context, req *PlanRequest, traj motionplan.Trajectory) ([]SegmentCheck, error) {
+	if len(traj) == 0 {
+		return nil, errors.New("cannot check an empty trajectory")
+	}
+	opts := req.PlannerOptions
+	if opts == nil {
+		opts = NewBasicPlannerOptions()
+	}
+	constraints := &motionplan.Constraints{}
+	if req.Constraints != nil {
+		constraints.CollisionSpecification = req.Constraints.CollisionSpecification
+	}
+
+	// the frames which move are those which the planner moved towards any of the goals
+	goals := referenceframe.FrameSystemPoses{}
+	for _, goal := range req.Goals {
+		poses, err := goal.ComputePoses(ctx, req.FrameSystem)
+		if err != nil {
+			return nil, err
+		}
+		for name, pif := range poses {
+			goals[name] = pif
+		}
+	}
+	chains, err := motionChainsFromPlanState(req.FrameSystem, goals)
+	if err != nil {
+		return nil, err
+	}
+
+	start := traj[0].ToLinearInputs()
+	frameSystemGeometries, err := referenceframe.FrameSystemGeometries(req.FrameSystem, traj[0])
+	if err != nil {
+		return nil, err
+	}
+	movingRobotGeometries, staticRobotGeometries := chains.geometries(req.FrameSystem, frameSystemGeometries)
+	startPoses, err := start.ComputePoses(req.FrameSystem)
+	if err != nil {
+		return nil, err
+	}
+	checker, err := motionplan.NewConstraintChecker(
+		opts.CollisionBufferMM,
+		constraints,
+		startPoses,
+		goals,
+		req.FrameSystem,
+		movingRobotGeometries, staticRobotGeometries,
+		start,
+		req.WorldState,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]SegmentCheck, 0, len(traj)-1)
+	for i := 1; i < len(traj); i++ {
+		configurations, err := motionplan.InterpolateSegmentFS(&motionplan.SegmentFS{
+			StartConfiguration: traj[i-1].ToLinearInputs(),
+			EndConfiguration:   traj[i].ToLinearInputs(),
+			FS:                 req.FrameSystem,
+		}, opts.Resolution)
+		if err != nil {
+			return nil, err
+		}
+		check := SegmentCheck{Configurations: configurations, FailedIndex: -1}
+		for j, configuration := range configurations {
+			err := checker.CheckStateFSConstraints(ctx, &motionplan.StateFS{FS: req.FrameSystem, Configuration: configuration})
+			if err != nil {
+				check.FailedIndex, check.Err = j, err
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
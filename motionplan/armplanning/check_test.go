@@ -0,0 +1,78 @@
+package armplanning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// makeSliderRequest returns a request to move a box on a rail along X past a wall at X=500.
+func makeSliderRequest(t *testing.T) *PlanRequest {
+	t.Helper()
+	fs := referenceframe.NewEmptyFrameSystem("test")
+	box, err := spatialmath.NewBox(spatialmath.NewZeroPose(), r3.Vector{X: 20, Y: 20, Z: 20}, "slider")
+	test.That(t, err, test.ShouldBeNil)
+	limit := referenceframe.Limit{Min: -2000, Max: 2000}
+	slider, err := referenceframe.NewTranslationalFrameWithGeometry("slider", r3.Vector{X: 1}, limit, box)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fs.AddFrame(slider, fs.World()), test.ShouldBeNil)
+
+	wall, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: 500}), r3.Vector{X: 10, Y: 1000, Z: 1000}, "wall")
+	test.That(t, err, test.ShouldBeNil)
+	worldState, err := referenceframe.NewWorldState(
+		[]*referenceframe.GeometriesInFrame{referenceframe.NewGeometriesInFrame(referenceframe.World, []spatialmath.Geometry{wall})},
+		nil,
+	)
+	test.That(t, err, test.ShouldBeNil)
+	goal := referenceframe.NewPoseInFrame(referenceframe.World, spatialmath.NewPoseFromPoint(r3.Vector{X: 1000}))
+	return &PlanRequest{
+		FrameSystem: fs,
+		Goals:       []*PlanState{NewPlanState(referenceframe.FrameSystemPoses{"slider": goal}, nil)},
+		StartState:  NewPlanState(nil, referenceframe.FrameSystemInputs{"slider": {0}}),
+		WorldState:  worldState,
+	}
+}
+
+func TestCheckTrajectoryCollisions(t *testing.T) {
+	ctx := context.Background()
+	req := makeSliderRequest(t)
+	trajectory := motionplan.Trajectory{
+		{"slider": {0}},
+		{"slider": {250}},
+		{"slider": {1000}},
+	}
+
+	checks, err := CheckTrajectoryCollisions(ctx, req, trajectory)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(checks), test.ShouldEqual, 2)
+
+	test.That(t, checks[0].FailedIndex, test.ShouldEqual, -1)
+	test.That(t, checks[0].Err, test.ShouldBeNil)
+	test.That(t, len(checks[0].Configurations), test.ShouldBeGreaterThan, 1)
+
+	// the box reaches the wall partway through the second segment, where checking stops
+	test.That(t, checks[1].Err, test.ShouldNotBeNil)
+	test.That(t, checks[1].FailedIndex, test.ShouldBeGreaterThan, 0)
+	test.That(t, checks[1].FailedIndex, test.ShouldBeLessThan, len(checks[1].Configurations)-1)
+	failed := checks[1].Configurations[checks[1].FailedIndex].Get("slider")
+	test.That(t, failed[0], test.ShouldBeBetween, 450, 550)
+
+	// allowing the collision makes the trajectory collision free
+	req.Constraints = &motionplan.Constraints{
+		CollisionSpecification: []motionplan.CollisionSpecification{
+			{Allows: []motionplan.CollisionSpecificationAllowedFrameCollisions{{Frame1: "slider", Frame2: "wall"}}},
+		},
+	}
+	checks, err = CheckTrajectoryCollisions(ctx, req, trajectory)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, checks[1].FailedIndex, test.ShouldEqual, -1)
+
+	_, err = CheckTrajectoryCollisions(ctx, req, nil)
+	test.That(t, err, test.ShouldNotBeNil)
+}
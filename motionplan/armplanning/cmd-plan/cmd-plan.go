@@ -45,6 +45,7 @@ func realMain() error {
 	loop := flag.Int("loop", 1, "loop")
 	cpu := flag.String("cpu", "", "cpu profiling")
 	interactive := flag.Bool("i", false, "interactive")
+	htmlPath := flag.String("html", "", "write the plan, geometries and collision checks to an html file")
 
 	flag.Parse()
 
@@ -128,6 +129,12 @@ func realMain() error {
 
 	plan, _, err := armplanning.PlanMotion(ctx, logger, req)
 	exporter.Stop()
+	if *htmlPath != "" {
+		logger.Infof("writing plan visualization to [%s]", *htmlPath)
+		if htmlErr := writeHTML(ctx, req, plan, err, flag.Arg(0), *htmlPath); htmlErr != nil {
+			logger.Errorf("couldn't write plan visualization: %v", htmlErr)
+		}
+	}
 	if *interactive {
 		if interactiveErr := doInteractive(req, plan, err, mylog); interactiveErr != nil {
 			logger.Fatal("Interactive mode failed:", interactiveErr)
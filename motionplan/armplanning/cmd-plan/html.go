@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/motionplan/armplanning"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// vizShape describes a geometry centered at its own origin, in mm.
+type vizShape struct {
+	Type      spatialmath.GeometryType `json:"type"`
+	X         float64                  `json:"x,omitempty"`
+	Y         float64                  `json:"y,omitempty"`
+	Z         float64                  `json:"z,omitempty"`
+	R         float64                  `json:"r,omitempty"`
+	L         float64                  `json:"l,omitempty"`
+	Triangles [][9]float64             `json:"triangles,omitempty"`
+	// Points are the points of geometries which have no shape, such as point clouds
+	Points [][3]float64 `json:"points,omitempty"`
+}
+
+// pointsShape is the type of the shapes of geometries drawn as their points.
+const pointsShape = spatialmath.GeometryType("points")
+
+// maxVizPoints is the most points a geometry is drawn with, larger point clouds being subsampled.
+const maxVizPoints = 20000
+
+type vizGeometry struct {
+	Name  string   `json:"name"`
+	Frame string   `json:"frame"`
+	Shape vizShape `json:"shape"`
+	// Pose is x, y, z, qx, qy, qz, qw of the geometry in the world frame
+	Pose [7]float64 `json:"pose"`
+}
+
+type vizStep struct {
+	Segment int    `json:"segment"`
+	Failed  bool   `json:"failed"`
+	Inputs  string `json:"inputs"`
+	// Poses are the world poses of the robot geometries, in the same order as vizData.Robot
+	Poses [][7]float64 `json:"poses"`
+}
+
+type vizSegment struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Error string `json:"error,omitempty"`
+}
+
+type vizData struct {
+	Title     string        `json:"title"`
+	PlanError string        `json:"planError,omitempty"`
+	Robot     []vizGeometry `json:"robot"`
+	Obstacles []vizGeometry `json:"obstacles"`
+	Goals     [][7]float64  `json:"goals"`
+	Steps     []vizStep     `json:"steps"`
+	Segments  []vizSegment  `json:"segments"`
+}
+
+// viewerJS draws the exported data, and is inlined in the HTML so that it can be viewed offline.
+//
+//go:embed viewer.js
+var viewerJS string
+
+// writeHTML exports the plan, the frame system geometries along it and the world state obstacles to a single HTML file
+// which draws them with the inlined viewer, without loading anything. Every segment of the plan is collision checked at
+// the resolution of the planner, and segments in collision are highlighted on the timeline.
+func writeHTML(ctx context.Context, req *armplanning.PlanRequest, plan motionplan.Plan, planErr error, title, path string) error {
+	data := vizData{Title: title, Obstacles: []vizGeometry{}, Goals: [][7]float64{}, Segments: []vizSegment{}}
+	if planErr != nil {
+		data.PlanError = planErr.Error()
+	}
+
+	startInputs := req.StartState.Configuration()
+	obstacles, err := req.WorldState.ObstaclesInWorldFrame(req.FrameSystem, startInputs)
+	if err != nil {
+		return err
+	}
+	for _, g := range obstacles.Geometries() {
+		geometry, err := newVizGeometry(referenceframe.World, g)
+		if err != nil {
+			return err
+		}
+		data.Obstacles = append(data.Obstacles, geometry)
+	}
+
+	for _, goal := range req.Goals {
+		poses, err := goal.ComputePoses(ctx, req.FrameSystem)
+		if err != nil {
+			return err
+		}
+		for _, pif := range poses {
+			tf, err := req.FrameSystem.Transform(startInputs.ToLinearInputs(), pif, referenceframe.World)
+			if err != nil {
+				return err
+			}
+			data.Goals = append(data.Goals, vizPose(tf.(*referenceframe.PoseInFrame).Pose()))
+		}
+	}
+
+	var trajectory motionplan.Trajectory
+	if plan != nil {
+		trajectory = plan.Trajectory()
+	}
+	if len(trajectory) == 0 {
+		trajectory = motionplan.Trajectory{startInputs}
+	}
+
+	robot, err := robotGeometries(req.FrameSystem, trajectory[0].ToLinearInputs())
+	if err != nil {
+		return err
+	}
+	data.Robot = robot
+
+	addStep := func(segment int, failed bool, inputs *referenceframe.LinearInputs) error {
+		geometries, err := robotGeometries(req.FrameSystem, inputs)
+		if err != nil {
+			return err
+		}
+		if len(geometries) != len(data.Robot) {
+			return fmt.Errorf("number of geometries changed along the trajectory, %d vs %d", len(geometries), len(data.Robot))
+		}
+		poses := make([][7]float64, 0, len(geometries))
+		for _, g := range geometries {
+			poses = append(poses, g.Pose)
+		}
+		data.Steps = append(data.Steps, vizStep{
+			Segment: segment,
+			Failed:  failed,
+			Inputs:  fmt.Sprint(inputs.ToFrameSystemInputs()),
+			Poses:   poses,
+		})
+		return nil
+	}
+
+	if len(trajectory) == 1 {
+		if err := addStep(-1, false, trajectory[0].ToLinearInputs()); err != nil {
+			return err
+		}
+	} else {
+		checks, err := armplanning.CheckTrajectoryCollisions(ctx, req, trajectory)
+		if err != nil {
+			return err
+		}
+		for i, check := range checks {
+			segment := vizSegment{Start: len(data.Steps)}
+			if check.Err != nil {
+				segment.Error = check.Err.Error()
+			}
+			for j, configuration := range check.Configurations {
+				if err := addStep(i, j == check.FailedIndex, configuration); err != nil {
+					return err
+				}
+			}
+			segment.End = len(data.Steps) - 1
+			data.Segments = append(data.Segments, segment)
+		}
+	}
+
+	f, err := os.Create(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Data   vizData
+		Viewer template.JS
+	}{data, template.JS(viewerJS)} //nolint:gosec
+	return errors.Join(vizTemplate.Execute(f, page), f.Close())
+}
+
+// robotGeometries returns the geometries of every frame of the frame system in the world frame, in a stable order.
+func robotGeometries(fs *referenceframe.FrameSystem, inputs *referenceframe.LinearInputs) ([]vizGeometry, error) {
+	frameGeometries, err := referenceframe.FrameSystemGeometriesLinearInputs(fs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	geometries := []vizGeometry{}
+	for _, name := range fs.FrameNames() {
+		gif, ok := frameGeometries[name]
+		if !ok {
+			continue
+		}
+		for _, g := range gif.Geometries() {
+			geometry, err := newVizGeometry(name, g)
+			if err != nil {
+				return nil, err
+			}
+			geometries = append(geometries, geometry)
+		}
+	}
+	return geometries, nil
+}
+
+func newVizGeometry(frame string, g spatialmath.Geometry) (vizGeometry, error) {
+	geometry := vizGeometry{Name: g.Label(), Frame: frame, Pose: vizPose(g.Pose())}
+	if mesh, ok := g.(*spatialmath.Mesh); ok {
+		geometry.Shape.Type = spatialmath.MeshType
+		for _, t := range mesh.Triangles() {
+			pts := t.Points()
+			geometry.Shape.Triangles = append(geometry.Shape.Triangles, [9]float64{
+				pts[0].X, pts[0].Y, pts[0].Z,
+				pts[1].X, pts[1].Y, pts[1].Z,
+				pts[2].X, pts[2].Y, pts[2].Z,
+			})
+		}
+		return geometry, nil
+	}
+	cfg, err := spatialmath.NewGeometryConfig(g)
+	if err != nil {
+		// geometries without a shape, such as point clouds and octrees, are drawn as their points
+		geometry.Shape.Type = pointsShape
+		inverse := spatialmath.PoseInverse(g.Pose())
+		points := g.ToPoints(1)
+		stride := max(1, (len(points)+maxVizPoints-1)/maxVizPoints)
+		for i := 0; i < len(points); i += stride {
+			pt := spatialmath.Compose(inverse, spatialmath.NewPoseFromPoint(points[i])).Point()
+			geometry.Shape.Points = append(geometry.Shape.Points, [3]float64{pt.X, pt.Y, pt.Z})
+		}
+		return geometry, nil
+	}
+	geometry.Shape = vizShape{Type: cfg.Type, X: cfg.X, Y: cfg.Y, Z: cfg.Z, R: cfg.R, L: cfg.L}
+	return geometry, nil
+}
+
+func vizPose(p spatialmath.Pose) [7]float64 {
+	pt := p.Point()
+	q := p.Orientation().Quaternion()
+	return [7]float64{pt.X, pt.Y, pt.Z, q.Imag, q.Jmag, q.Kmag, q.Real}
+}
+
+var vizTemplate = template.Must(template.New("plan").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Data.Title}}</title>
+<style>
+  body { margin: 0; font-family: sans-serif; overflow: hidden; }
+  #view { display: block; }
+  #panel { position: absolute; bottom: 0; left: 0; right: 0; padding: 8px 12px; background: rgba(255, 255, 255, 0.9); }
+  #timeline { position: relative; height: 8px; margin: 4px 0; }
+  #timeline div { position: absolute; top: 0; height: 8px; }
+  #slider { width: 100%; }
+  #info { font-size: 12px; white-space: pre-wrap; max-height: 120px; overflow: auto; }
+</style>
+</head>
+<body>
+<canvas id="view"></canvas>
+<div id="panel">
+  <button id="play">play</button>
+  <label><input type="checkbox" id="ghosts"> show checked configurations of segment</label>
+  <div id="timeline"></div>
+  <input type="range" id="slider" min="0" value="0">
+  <div id="info"></div>
+</div>
+<script>
+{{.Viewer}}
+</script>
+<script>
+showPlan({{.Data}});
+</script>
+</body>
+</html>
+`))
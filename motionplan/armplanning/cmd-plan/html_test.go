@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/motionplan"
+	"go.viam.com/rdk/motionplan/armplanning"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/referenceframe"
+	"go.viam.com/rdk/spatialmath"
+)
+
+func TestWriteHTML(t *testing.T) {
+	ctx := context.Background()
+	fs := referenceframe.NewEmptyFrameSystem("test")
+	box, err := spatialmath.NewBox(spatialmath.NewZeroPose(), r3.Vector{X: 20, Y: 20, Z: 20}, "slider")
+	test.That(t, err, test.ShouldBeNil)
+	limit := referenceframe.Limit{Min: -2000, Max: 2000}
+	slider, err := referenceframe.NewTranslationalFrameWithGeometry("slider", r3.Vector{X: 1}, limit, box)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fs.AddFrame(slider, fs.World()), test.ShouldBeNil)
+
+	// a wall in the way of the slider, and a point cloud out of its way
+	wall, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: 500}), r3.Vector{X: 10, Y: 1000, Z: 1000}, "wall")
+	test.That(t, err, test.ShouldBeNil)
+	cloud := pointcloud.NewBasicEmpty()
+	for i := 0; i < 10; i++ {
+		test.That(t, cloud.Set(r3.Vector{X: float64(i) * 10, Y: 800}, pointcloud.NewBasicData()), test.ShouldBeNil)
+	}
+	octree, err := pointcloud.ToBasicOctree(cloud, 50)
+	test.That(t, err, test.ShouldBeNil)
+	worldState, err := referenceframe.NewWorldState([]*referenceframe.GeometriesInFrame{
+		referenceframe.NewGeometriesInFrame(referenceframe.World, []spatialmath.Geometry{wall, octree}),
+	}, nil)
+	test.That(t, err, test.ShouldBeNil)
+	goal := referenceframe.NewPoseInFrame(referenceframe.World, spatialmath.NewPoseFromPoint(r3.Vector{X: 1000}))
+	req := &armplanning.PlanRequest{
+		FrameSystem: fs,
+		Goals:       []*armplanning.PlanState{armplanning.NewPlanState(referenceframe.FrameSystemPoses{"slider": goal}, nil)},
+		StartState:  armplanning.NewPlanState(nil, referenceframe.FrameSystemInputs{"slider": {0}}),
+		WorldState:  worldState,
+	}
+	plan := motionplan.NewSimplePlan(nil, motionplan.Trajectory{{"slider": {0}}, {"slider": {250}}, {"slider": {1000}}})
+
+	path := filepath.Join(t.TempDir(), "plan.html")
+	test.That(t, writeHTML(ctx, req, plan, nil, "slider plan", path), test.ShouldBeNil)
+	page, err := os.ReadFile(path)
+	test.That(t, err, test.ShouldBeNil)
+	html := string(page)
+
+	// the page must work offline
+	test.That(t, html, test.ShouldNotContainSubstring, "http://")
+	test.That(t, html, test.ShouldNotContainSubstring, "https://")
+	test.That(t, html, test.ShouldContainSubstring, "function showPlan(data)")
+
+	match := regexp.MustCompile(`showPlan\((.*)\);`).FindStringSubmatch(html)
+	test.That(t, match, test.ShouldHaveLength, 2)
+	var data vizData
+	test.That(t, json.Unmarshal([]byte(match[1]), &data), test.ShouldBeNil)
+	test.That(t, data.Title, test.ShouldEqual, "slider plan")
+	test.That(t, data.Robot, test.ShouldHaveLength, 1)
+	test.That(t, data.Goals, test.ShouldHaveLength, 1)
+
+	// the point cloud is drawn as its points
+	test.That(t, data.Obstacles, test.ShouldHaveLength, 2)
+	shapes := map[spatialmath.GeometryType]vizShape{}
+	for _, o := range data.Obstacles {
+		shapes[o.Shape.Type] = o.Shape
+	}
+	test.That(t, shapes[spatialmath.BoxType].X, test.ShouldEqual, 10)
+	test.That(t, shapes[pointsShape].Points, test.ShouldHaveLength, 10)
+
+	// the second segment runs into the wall
+	test.That(t, data.Segments, test.ShouldHaveLength, 2)
+	test.That(t, data.Segments[0].Error, test.ShouldBeEmpty)
+	test.That(t, data.Segments[1].Error, test.ShouldContainSubstring, "wall")
+	failed := 0
+	for _, step := range data.Steps[data.Segments[1].Start : data.Segments[1].End+1] {
+		if step.Failed {
+			failed++
+			test.That(t, step.Poses[0][0], test.ShouldBeBetween, 450, 550)
+		}
+	}
+	test.That(t, failed, test.ShouldEqual, 1)
+	test.That(t, strings.Count(html, "<script>"), test.ShouldEqual, 2)
+}
@@ -0,0 +1,346 @@
+// The viewer of exported plans, which draws their geometries on a canvas without any dependency so that exported
+// plans can be viewed offline.
+"use strict";
+
+function showPlan(data) {
+  const add = (a, b) => [a[0] + b[0], a[1] + b[1], a[2] + b[2]];
+  const sub = (a, b) => [a[0] - b[0], a[1] - b[1], a[2] - b[2]];
+  const scale = (a, s) => [a[0] * s, a[1] * s, a[2] * s];
+  const dot = (a, b) => a[0] * b[0] + a[1] * b[1] + a[2] * b[2];
+  const cross = (a, b) => [a[1] * b[2] - a[2] * b[1], a[2] * b[0] - a[0] * b[2], a[0] * b[1] - a[1] * b[0]];
+  const normalize = (a) => {
+    const n = Math.sqrt(dot(a, a));
+    return n > 0 ? scale(a, 1 / n) : a;
+  };
+
+  // poses are x, y, z, qx, qy, qz, qw
+  function rotate(pose, v) {
+    const q = [pose[3], pose[4], pose[5]];
+    const t = scale(cross(q, v), 2);
+    return add(add(v, scale(t, pose[6])), cross(q, t));
+  }
+  const applyPose = (pose, v) => add(rotate(pose, v), [pose[0], pose[1], pose[2]]);
+
+  // shapes are tessellated into triangles in their own frames
+  function ringsToTriangles(rings) {
+    const triangles = [];
+    for (let i = 0; i < rings.length - 1; i++) {
+      const n = rings[i].length;
+      for (let j = 0; j < n; j++) {
+        const a = rings[i][j], b = rings[i][(j + 1) % n], c = rings[i + 1][j], d = rings[i + 1][(j + 1) % n];
+        triangles.push([a, c, b], [b, c, d]);
+      }
+    }
+    return triangles;
+  }
+
+  // sphereRings returns the rings of a sphere whose upper and lower halves are offset along z, making a capsule
+  function sphereRings(r, top, bottom) {
+    const numRings = 8, numSegments = 16;
+    const ring = (radius, z) => {
+      const pts = [];
+      for (let j = 0; j < numSegments; j++) {
+        const phi = 2 * Math.PI * j / numSegments;
+        pts.push([radius * Math.cos(phi), radius * Math.sin(phi), z]);
+      }
+      return pts;
+    };
+    const rings = [];
+    for (let i = 0; i <= numRings; i++) {
+      const theta = Math.PI * i / numRings;
+      const z = r * Math.cos(theta), radius = r * Math.sin(theta);
+      rings.push(ring(radius, z + (2 * i <= numRings ? top : bottom)));
+      if (2 * i === numRings && top !== bottom) {
+        rings.push(ring(radius, z + bottom));
+      }
+    }
+    return rings;
+  }
+
+  function boxTriangles(x, y, z) {
+    const c = (i) => [(i & 1 ? 0.5 : -0.5) * x, (i & 2 ? 0.5 : -0.5) * y, (i & 4 ? 0.5 : -0.5) * z];
+    const faces = [[0, 1, 3, 2], [4, 6, 7, 5], [0, 4, 5, 1], [2, 3, 7, 6], [0, 2, 6, 4], [1, 5, 7, 3]];
+    const triangles = [];
+    for (const f of faces) {
+      triangles.push([c(f[0]), c(f[1]), c(f[2])], [c(f[0]), c(f[2]), c(f[3])]);
+    }
+    return triangles;
+  }
+
+  function makeShape(shape) {
+    switch (shape.type) {
+      case "box":
+        return { triangles: boxTriangles(shape.x, shape.y, shape.z) };
+      case "sphere":
+        return { triangles: ringsToTriangles(sphereRings(shape.r, 0, 0)) };
+      case "capsule": {
+        // capsules are along the z axis, and their length includes their caps
+        const h = Math.max(shape.l / 2 - shape.r, 0);
+        return { triangles: ringsToTriangles(sphereRings(shape.r, h, -h)) };
+      }
+      case "mesh":
+        return { triangles: shape.triangles.map((t) => [t.slice(0, 3), t.slice(3, 6), t.slice(6, 9)]) };
+      case "points":
+        return { points: shape.points };
+      default:
+        return { triangles: boxTriangles(10, 10, 10) };
+    }
+  }
+
+  const canvas = document.getElementById("view");
+  const ctx = canvas.getContext("2d");
+  const view = { target: [0, 0, 300], yaw: -Math.PI / 4, pitch: 0.45, distance: 3000, fov: 50 * Math.PI / 180 };
+  const objects = [];
+  const axes = [{ pose: [0, 0, 0, 0, 0, 0, 1], size: 200 }];
+  const lightDirection = normalize([0.4, -0.4, 0.8]);
+
+  function rgba(color, shade, alpha) {
+    const r = Math.round(((color >> 16) & 255) * shade);
+    const g = Math.round(((color >> 8) & 255) * shade);
+    const b = Math.round((color & 255) * shade);
+    return "rgba(" + r + "," + g + "," + b + "," + alpha + ")";
+  }
+
+  function render() {
+    const width = canvas.width = window.innerWidth;
+    const height = canvas.height = window.innerHeight;
+    const eye = add(view.target, scale([
+      Math.cos(view.pitch) * Math.cos(view.yaw),
+      Math.cos(view.pitch) * Math.sin(view.yaw),
+      Math.sin(view.pitch),
+    ], view.distance));
+    const forward = normalize(sub(view.target, eye));
+    const right = normalize(cross(forward, [0, 0, 1]));
+    const up = cross(right, forward);
+    const focal = height / 2 / Math.tan(view.fov / 2);
+    view.right = right;
+    view.up = up;
+    view.focal = focal;
+    const project = (p) => {
+      const d = sub(p, eye);
+      const z = dot(d, forward);
+      if (z < 1) {
+        return null;
+      }
+      return [width / 2 + focal * dot(d, right) / z, height / 2 - focal * dot(d, up) / z, z];
+    };
+    const line = (a, b, style) => {
+      const pa = project(a), pb = project(b);
+      if (!pa || !pb) {
+        return;
+      }
+      ctx.strokeStyle = style;
+      ctx.beginPath();
+      ctx.moveTo(pa[0], pa[1]);
+      ctx.lineTo(pb[0], pb[1]);
+      ctx.stroke();
+    };
+
+    ctx.fillStyle = "#f0f0f0";
+    ctx.fillRect(0, 0, width, height);
+    ctx.lineWidth = 1;
+    for (let i = -20; i <= 20; i++) {
+      line([i * 100, -2000, 0], [i * 100, 2000, 0], "#d0d0d0");
+      line([-2000, i * 100, 0], [2000, i * 100, 0], "#d0d0d0");
+    }
+    ctx.lineWidth = 2;
+    for (const a of axes) {
+      const origin = applyPose(a.pose, [0, 0, 0]);
+      line(origin, applyPose(a.pose, [a.size, 0, 0]), "#dd2222");
+      line(origin, applyPose(a.pose, [0, a.size, 0]), "#22aa22");
+      line(origin, applyPose(a.pose, [0, 0, a.size]), "#2222dd");
+    }
+
+    // triangles are drawn from the farthest to the nearest
+    const faces = [];
+    for (const obj of objects) {
+      if (!obj.visible) {
+        continue;
+      }
+      if (obj.shape.points) {
+        ctx.fillStyle = rgba(obj.color, 1, obj.alpha);
+        for (const p of obj.shape.points) {
+          const pp = project(applyPose(obj.pose, p));
+          if (pp) {
+            ctx.fillRect(pp[0] - 1, pp[1] - 1, 2, 2);
+          }
+        }
+        continue;
+      }
+      for (const t of obj.shape.triangles) {
+        const world = t.map((p) => applyPose(obj.pose, p));
+        const projected = world.map(project);
+        if (projected.some((p) => !p)) {
+          continue;
+        }
+        const normal = normalize(cross(sub(world[1], world[0]), sub(world[2], world[0])));
+        faces.push({
+          points: projected,
+          depth: (projected[0][2] + projected[1][2] + projected[2][2]) / 3,
+          style: rgba(obj.color, 0.5 + 0.5 * Math.abs(dot(normal, lightDirection)), obj.alpha),
+          wireframe: obj.wireframe,
+        });
+      }
+    }
+    faces.sort((a, b) => b.depth - a.depth);
+    ctx.lineWidth = 1;
+    for (const f of faces) {
+      ctx.beginPath();
+      ctx.moveTo(f.points[0][0], f.points[0][1]);
+      ctx.lineTo(f.points[1][0], f.points[1][1]);
+      ctx.lineTo(f.points[2][0], f.points[2][1]);
+      ctx.closePath();
+      if (f.wireframe) {
+        ctx.strokeStyle = f.style;
+        ctx.stroke();
+      } else {
+        ctx.fillStyle = f.style;
+        ctx.fill();
+      }
+    }
+  }
+
+  let renderPending = false;
+  function requestRender() {
+    if (!renderPending) {
+      renderPending = true;
+      window.requestAnimationFrame(() => {
+        renderPending = false;
+        render();
+      });
+    }
+  }
+
+  // dragging orbits the view, dragging with the right button or shift pans it and scrolling zooms it
+  let drag = null;
+  canvas.addEventListener("contextmenu", (e) => e.preventDefault());
+  canvas.addEventListener("mousedown", (e) => {
+    drag = { x: e.clientX, y: e.clientY, pan: e.button === 2 || e.shiftKey };
+  });
+  window.addEventListener("mouseup", () => {
+    drag = null;
+  });
+  window.addEventListener("mousemove", (e) => {
+    if (!drag) {
+      return;
+    }
+    const dx = e.clientX - drag.x, dy = e.clientY - drag.y;
+    drag.x = e.clientX;
+    drag.y = e.clientY;
+    if (drag.pan) {
+      const s = view.distance / view.focal;
+      view.target = add(view.target, add(scale(view.right, -dx * s), scale(view.up, dy * s)));
+    } else {
+      view.yaw -= dx * 0.01;
+      view.pitch = Math.max(-1.55, Math.min(1.55, view.pitch + dy * 0.01));
+    }
+    requestRender();
+  });
+  canvas.addEventListener("wheel", (e) => {
+    e.preventDefault();
+    view.distance *= Math.exp(e.deltaY * 0.001);
+    requestRender();
+  }, { passive: false });
+  window.addEventListener("resize", requestRender);
+
+  const robotColor = 0x4477cc;
+  const failedColor = 0xdd2222;
+  for (const g of data.obstacles) {
+    objects.push({ shape: makeShape(g.shape), pose: g.pose, color: 0x888888, alpha: 0.6, visible: true });
+  }
+  for (const pose of data.goals) {
+    axes.push({ pose: pose, size: 100 });
+  }
+  const shapes = data.robot.map((g) => makeShape(g.shape));
+  const robot = data.robot.map((g, i) => {
+    const obj = { shape: shapes[i], pose: g.pose, color: robotColor, alpha: 1, visible: true };
+    objects.push(obj);
+    return obj;
+  });
+
+  let ghosts = [];
+  let ghostSegment = null;
+  function drawGhosts(segment) {
+    if (ghostSegment === segment) {
+      return;
+    }
+    ghostSegment = segment;
+    for (const g of ghosts) {
+      objects.splice(objects.indexOf(g), 1);
+    }
+    ghosts = [];
+    if (segment < 0 || !document.getElementById("ghosts").checked) {
+      return;
+    }
+    const s = data.segments[segment];
+    for (let i = s.start; i <= s.end; i++) {
+      data.steps[i].poses.forEach((pose, j) => {
+        const failed = data.steps[i].failed;
+        const ghost = {
+          shape: shapes[j], pose: pose, color: failed ? failedColor : robotColor, alpha: failed ? 1 : 0.15,
+          wireframe: true, visible: true,
+        };
+        ghosts.push(ghost);
+        objects.push(ghost);
+      });
+    }
+  }
+
+  const slider = document.getElementById("slider");
+  const info = document.getElementById("info");
+  slider.max = data.steps.length - 1;
+  const timeline = document.getElementById("timeline");
+  for (const s of data.segments) {
+    const bar = document.createElement("div");
+    const n = Math.max(data.steps.length - 1, 1);
+    bar.style.left = (100 * s.start / n) + "%";
+    bar.style.width = Math.max(100 * (s.end - s.start) / n, 0.2) + "%";
+    bar.style.background = s.error ? "#dd2222" : "#22aa44";
+    bar.title = s.error || "collision free";
+    timeline.appendChild(bar);
+  }
+
+  function show(idx) {
+    const step = data.steps[idx];
+    step.poses.forEach((pose, i) => {
+      robot[i].pose = pose;
+      robot[i].color = step.failed ? failedColor : robotColor;
+    });
+    drawGhosts(step.segment);
+    let text = "step " + idx + " of " + (data.steps.length - 1);
+    if (step.segment >= 0) {
+      text += ", segment " + step.segment + " of " + (data.segments.length - 1);
+      const err = data.segments[step.segment].error;
+      if (err) {
+        text += (step.failed ? "\nIN COLLISION: " : "\nsegment in collision: ") + err;
+      }
+    }
+    if (data.planError) {
+      text += "\nplan error: " + data.planError;
+    }
+    info.textContent = text + "\n" + step.inputs;
+    requestRender();
+  }
+
+  slider.addEventListener("input", () => show(Number(slider.value)));
+  document.getElementById("ghosts").addEventListener("change", () => {
+    ghostSegment = null;
+    show(Number(slider.value));
+  });
+  let playing = null;
+  document.getElementById("play").addEventListener("click", (e) => {
+    if (playing) {
+      clearInterval(playing);
+      playing = null;
+      e.target.textContent = "play";
+      return;
+    }
+    e.target.textContent = "pause";
+    playing = setInterval(() => {
+      slider.value = (Number(slider.value) + 1) % data.steps.length;
+      show(Number(slider.value));
+    }, 20);
+  });
+
+  show(0);
+}
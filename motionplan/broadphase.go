@@ -0,0 +1,71 @@
+package motionplan
+
+import (
+	"sort"
+
+	spatial "go.viam.com/rdk/spatialmath"
+)
+
+// broadPhaseEntry is a named geometry along with its world-frame axis-aligned bounding box.
+type broadPhaseEntry struct {
+	name   string
+	bounds spatial.AABB
+}
+
+// broadPhase is a sweep-and-prune structure over a set of named geometries. It is used to quickly discard pairs of geometries
+// whose bounding boxes are too far apart to possibly be in collision, so that the more expensive narrow-phase checks only
+// need to be run on the remaining candidate pairs.
+type broadPhase struct {
+	// entries are sorted by the minimum X coordinate of their bounding box
+	entries []broadPhaseEntry
+
+	// unbounded holds the names of geometries for which a bounding box could not be computed. These are always candidates.
+	unbounded []string
+}
+
+// newBroadPhase builds a broadPhase over the given geometries.
+func newBroadPhase(geometries map[string]spatial.Geometry) *broadPhase {
+	bp := &broadPhase{entries: make([]broadPhaseEntry, 0, len(geometries))}
+	for name, geom := range geometries {
+		bounds, ok := spatial.GeometryAABB(geom)
+		if !ok {
+			bp.unbounded = append(bp.unbounded, name)
+			continue
+		}
+		bp.entries = append(bp.entries, broadPhaseEntry{name: name, bounds: bounds})
+	}
+	sort.Slice(bp.entries, func(i, j int) bool {
+		return bp.entries[i].bounds.Min.X < bp.entries[j].bounds.Min.X
+	})
+	return bp
+}
+
+// candidates returns the names of all geometries which may be within collisionBufferMM of the given geometry.
+func (bp *broadPhase) candidates(geom spatial.Geometry, collisionBufferMM float64) []string {
+	bounds, ok := spatial.GeometryAABB(geom)
+	if !ok {
+		return bp.all()
+	}
+	names := make([]string, 0, len(bp.unbounded))
+	names = append(names, bp.unbounded...)
+	// Entries are sorted by their minimum X, so once one begins past the end of the query box no later one can overlap it
+	maxX := bounds.Max.X + collisionBufferMM
+	for _, entry := range bp.entries {
+		if entry.bounds.Min.X > maxX {
+			break
+		}
+		if entry.bounds.Intersects(bounds, collisionBufferMM) {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// all returns the names of every geometry in the broadPhase.
+func (bp *broadPhase) all() []string {
+	names := make([]string, 0, len(bp.entries)+len(bp.unbounded))
+	for _, entry := range bp.entries {
+		names = append(names, entry.name)
+	}
+	return append(names, bp.unbounded...)
+}
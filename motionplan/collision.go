@@ -223,9 +223,16 @@ func newCollisionGraph(x, y []spatial.Geometry,
 		reportDistances: reportDistances,
 	}
 
+	bp := newBroadPhase(cg.y)
 	var distance float64
 	for xName, xGeometry := range cg.x {
-		for yName, yGeometry := range cg.y {
+		// When distances are not being reported, pairs which the broad phase rules out cannot be in collision and need not be checked
+		yNames := bp.all()
+		if !reportDistances {
+			yNames = bp.candidates(xGeometry, collisionBufferMM)
+		}
+		for _, yName := range yNames {
+			yGeometry := cg.y[yName]
 			if _, ok := cg.getDistance(xName, yName); ok || xGeometry == yGeometry {
 				// geometry pair already has distance information associated with it, or is comparing with itself - skip to next pair
 				continue
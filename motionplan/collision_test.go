@@ -1,6 +1,7 @@
 package motionplan
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -8,6 +9,7 @@ import (
 
 	"go.viam.com/rdk/referenceframe"
 	spatial "go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/testutils"
 	"go.viam.com/rdk/utils"
 )
 
@@ -141,3 +143,131 @@ func TestUniqueCollisions(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, collisionListsAlmostEqual(cg.collisions(defaultCollisionBufferMM), expectedCollisions[:1]), test.ShouldBeTrue)
 }
+
+// makeCollisionBenchmarkScene returns a set of moving robot geometries and a grid of static mesh and box obstacles.
+func makeCollisionBenchmarkScene(t testing.TB, gridSize int) (robot, obstacles []spatial.Geometry) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		pose := spatial.NewPoseFromPoint(r3.Vector{X: float64(i) * 90, Y: 45, Z: 45})
+		mesh := testutils.MakeSphereMesh(pose, 40, 10, 12, fmt.Sprintf("link%d", i))
+		robot = append(robot, mesh)
+	}
+	for i := 0; i < gridSize; i++ {
+		for j := 0; j < gridSize; j++ {
+			pose := spatial.NewPoseFromPoint(r3.Vector{X: float64(i) * 200, Y: float64(j) * 200, Z: 200})
+			if (i+j)%2 == 0 {
+				obstacles = append(obstacles, testutils.MakeSphereMesh(pose, 60, 10, 12, fmt.Sprintf("mesh%d_%d", i, j)))
+			} else {
+				box, err := spatial.NewBox(pose, r3.Vector{X: 100, Y: 100, Z: 100}, fmt.Sprintf("box%d_%d", i, j))
+				test.That(t, err, test.ShouldBeNil)
+				obstacles = append(obstacles, box)
+			}
+		}
+	}
+	return robot, obstacles
+}
+
+// naiveCollisionCheck checks every pair of geometries, comparing every pair of triangles for mesh pairs.
+// It is the baseline that the broad phase and mesh bounding volume hierarchies are measured against.
+func naiveCollisionCheck(robot, obstacles []spatial.Geometry, collisionBufferMM float64) (bool, error) {
+	for _, x := range robot {
+		for _, y := range obstacles {
+			xMesh, xOK := x.(*spatial.Mesh)
+			yMesh, yOK := y.(*spatial.Mesh)
+			if xOK && yOK {
+				if naiveMeshCollision(xMesh, yMesh, collisionBufferMM) {
+					return true, nil
+				}
+				continue
+			}
+			collides, err := x.CollidesWith(y, collisionBufferMM)
+			if err != nil {
+				return false, err
+			}
+			if collides {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func naiveMeshCollision(m1, m2 *spatial.Mesh, collisionBufferMM float64) bool {
+	for _, t1 := range m1.Triangles() {
+		w1 := t1.Transform(m1.Pose())
+		for _, t2 := range m2.Triangles() {
+			w2 := t2.Transform(m2.Pose())
+			for _, pair := range [][2]*spatial.Triangle{{w1, w2}, {w2, w1}} {
+				pts := pair[0].Points()
+				for i := 0; i < 3; i++ {
+					segPt, triPt := spatial.ClosestPointsSegmentTriangle(pts[i], pts[(i+1)%3], pair[1])
+					if segPt.Sub(triPt).Norm() <= collisionBufferMM {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestBroadPhaseCollisions(t *testing.T) {
+	robot, obstacles := makeCollisionBenchmarkScene(t, 4)
+	// move one obstacle into the robot so that there is exactly one collision to find
+	obstacles[1] = obstacles[1].Transform(spatial.NewPoseFromPoint(r3.Vector{X: -20, Y: -180, Z: -150}))
+
+	cg, err := newCollisionGraph(robot, obstacles, nil, false, defaultCollisionBufferMM)
+	test.That(t, err, test.ShouldBeNil)
+	collisions := cg.collisions(defaultCollisionBufferMM)
+	test.That(t, len(collisions), test.ShouldEqual, 1)
+
+	// checking every pair and reporting distances must find the same collision
+	reference, err := newCollisionGraph(robot, obstacles, nil, true, defaultCollisionBufferMM)
+	test.That(t, err, test.ShouldBeNil)
+	referenceCollisions := reference.collisions(defaultCollisionBufferMM)
+	test.That(t, len(referenceCollisions), test.ShouldEqual, 1)
+	test.That(t, referenceCollisions[0].name1, test.ShouldEqual, collisions[0].name1)
+	test.That(t, referenceCollisions[0].name2, test.ShouldEqual, collisions[0].name2)
+
+	collides, err := naiveCollisionCheck(robot, obstacles, defaultCollisionBufferMM)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, collides, test.ShouldBeTrue)
+
+	// the broad phase only returns candidates whose bounds overlap the query
+	bp := newBroadPhase(cg.y)
+	test.That(t, len(bp.all()), test.ShouldEqual, len(obstacles))
+	test.That(t, bp.candidates(robot[2], defaultCollisionBufferMM), test.ShouldBeEmpty)
+	test.That(t, bp.candidates(robot[0], defaultCollisionBufferMM), test.ShouldResemble, []string{obstacles[1].Label()})
+}
+
+func BenchmarkCollisionGraph(b *testing.B) {
+	robot, obstacles := makeCollisionBenchmarkScene(b, 4)
+	b.Run("broad phase and bvh", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := newCollisionGraph(robot, obstacles, nil, false, defaultCollisionBufferMM)
+			test.That(b, err, test.ShouldBeNil)
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := naiveCollisionCheck(robot, obstacles, defaultCollisionBufferMM)
+			test.That(b, err, test.ShouldBeNil)
+		}
+	})
+}
+
+func BenchmarkMeshCollision(b *testing.B) {
+	m1 := testutils.MakeSphereMesh(spatial.NewZeroPose(), 50, 16, 24, "a")
+	m2 := testutils.MakeSphereMesh(spatial.NewPoseFromPoint(r3.Vector{X: 101}), 50, 16, 24, "b")
+	b.Run("bvh", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := m1.CollidesWith(m2, defaultCollisionBufferMM)
+			test.That(b, err, test.ShouldBeNil)
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			naiveMeshCollision(m1, m2, defaultCollisionBufferMM)
+		}
+	})
+}
@@ -62,3 +62,22 @@ func TestUR5NloptIKinematics(t *testing.T) {
 	_, _, err = DoSolve(context.Background(), ik, solveFunc, home, [][]frame.Limit{m.DoF()})
 	test.That(t, err, test.ShouldBeNil)
 }
+
+func TestMimicJointIKinematics(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	m, err := frame.ParseModelJSONFile(utils.ResolveFile("referenceframe/testfiles/parallelogram.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	ik, err := CreateCombinedIKSolver(logger, nCPU, defaultGoalThreshold)
+	test.That(t, err, test.ShouldBeNil)
+
+	// the mimic joint is not an independent DoF, so the solver only searches over the shoulder and the slide
+	goal, err := m.Transform([]frame.Input{utils.DegToRad(40), 60})
+	test.That(t, err, test.ShouldBeNil)
+	solveFunc := NewMetricMinFunc(motionplan.NewSquaredNormMetric(goal), m, logger)
+	solution, _, err := DoSolve(context.Background(), ik, solveFunc, [][]float64{{0, 0}}, [][]frame.Limit{m.DoF()})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(solution[0]), test.ShouldEqual, 2)
+	pose, err := m.Transform(solution[0])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, spatial.PoseAlmostCoincidentEps(pose, goal, 0.1), test.ShouldBeTrue)
+}
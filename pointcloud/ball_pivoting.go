@@ -0,0 +1,290 @@
+package pointcloud
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// ReconstructMesh reconstructs a triangle mesh of the surface sampled by a point cloud with the ball pivoting algorithm of
+// Bernardini et al. A ball of each of the radii in turn, in mm, is rolled over the points, adding a triangle for every three
+// points it touches without containing any other. The radii should be a little larger than the spacing of the points, with
+// larger radii closing holes left by smaller ones. The cloud must have normals, see EstimateNormals, which determine the
+// side of the surface the ball rolls on and the orientation of the triangles.
+func ReconstructMesh(cloud PointCloud, radii []float64, label string) (*spatialmath.Mesh, error) {
+	if len(radii) == 0 {
+		return nil, errors.New("at least one ball radius is needed to reconstruct a mesh")
+	}
+	for _, r := range radii {
+		if r <= 0 {
+			return nil, errors.Errorf("ball radii must be positive, got %v", r)
+		}
+	}
+	if cloud.Size() < 3 {
+		return nil, errors.Errorf("at least 3 points are needed to reconstruct a mesh, got %d", cloud.Size())
+	}
+	if !cloud.MetaData().HasNormal {
+		return nil, errors.New("point cloud must have normals to reconstruct a mesh")
+	}
+
+	bp := newBallPivoter(cloud)
+	sorted := append([]float64{}, radii...)
+	sort.Float64s(sorted)
+	for i, r := range sorted {
+		bp.radius = r
+		if i > 0 {
+			bp.reactivateBoundary()
+		}
+		for {
+			bp.expandFront()
+			if !bp.findSeed() {
+				break
+			}
+		}
+	}
+
+	triangles := make([]*spatialmath.Triangle, 0, len(bp.triangles))
+	for _, t := range bp.triangles {
+		triangles = append(triangles, spatialmath.NewTriangle(bp.points[t[0]], bp.points[t[1]], bp.points[t[2]]))
+	}
+	return spatialmath.NewMesh(spatialmath.NewZeroPose(), triangles, label), nil
+}
+
+// bpaEdge is a directed edge a→b of the front of the mesh. It belongs to the triangle (a, b, opposite), which was made by
+// a ball at center, and the ball pivots around it to find the triangle on its other side.
+type bpaEdge struct {
+	a, b     int
+	opposite int
+	center   r3.Vector
+	boundary bool
+}
+
+type ballPivoter struct {
+	points  []r3.Vector
+	normals []r3.Vector
+	index   map[r3.Vector]int
+	kd      *KDTree
+	radius  float64
+
+	used        []bool
+	frontDegree []int
+	front       map[[2]int]*bpaEdge
+	active      []*bpaEdge
+	// the number of triangles on each undirected edge, and the directed edges of the triangles
+	edgeTriangles map[[2]int]int
+	directed      map[[2]int]bool
+	triangles     [][3]int
+	seedCursor    int
+}
+
+func newBallPivoter(cloud PointCloud) *ballPivoter {
+	bp := &ballPivoter{
+		index:         map[r3.Vector]int{},
+		kd:            ToKDTree(cloud),
+		front:         map[[2]int]*bpaEdge{},
+		edgeTriangles: map[[2]int]int{},
+		directed:      map[[2]int]bool{},
+	}
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		bp.index[p] = len(bp.points)
+		bp.points = append(bp.points, p)
+		var n r3.Vector
+		if d != nil {
+			n = d.Normal()
+		}
+		bp.normals = append(bp.normals, n)
+		return true
+	})
+	bp.used = make([]bool, len(bp.points))
+	bp.frontDegree = make([]int, len(bp.points))
+	return bp
+}
+
+func undirected(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// ballCenter returns the center of the ball of the current radius which touches the triangle (a, b, c) on the side its
+// normal points to, if the ball is large enough and the normal agrees with the normals of its vertices.
+func (bp *ballPivoter) ballCenter(a, b, c int) (r3.Vector, bool) {
+	pa, pb, pc := bp.points[a], bp.points[b], bp.points[c]
+	ab, ac := pb.Sub(pa), pc.Sub(pa)
+	w := ab.Cross(ac)
+	w2 := w.Norm2()
+	if w2 < 1e-12 {
+		return r3.Vector{}, false
+	}
+	if w.Dot(bp.normals[a].Add(bp.normals[b]).Add(bp.normals[c])) <= 0 {
+		return r3.Vector{}, false
+	}
+	circumcenter := pa.Add(w.Cross(ab).Mul(ac.Norm2()).Add(ac.Cross(w).Mul(ab.Norm2())).Mul(1 / (2 * w2)))
+	h2 := bp.radius*bp.radius - circumcenter.Sub(pa).Norm2()
+	if h2 < 0 {
+		return r3.Vector{}, false
+	}
+	return circumcenter.Add(w.Mul(math.Sqrt(h2 / w2))), true
+}
+
+// emptyBall returns whether the ball at center contains no points other than those of the triangle (a, b, c).
+func (bp *ballPivoter) emptyBall(center r3.Vector, a, b, c int) bool {
+	for _, neighbor := range bp.kd.RadiusNearestNeighbors(center, bp.radius*(1-1e-6), true) {
+		if i := bp.index[neighbor.P]; i != a && i != b && i != c {
+			return false
+		}
+	}
+	return true
+}
+
+// canAddTriangle returns whether the triangle (a, b, c) would keep the mesh manifold and consistently oriented.
+func (bp *ballPivoter) canAddTriangle(a, b, c int) bool {
+	for _, e := range [][2]int{{a, b}, {b, c}, {c, a}} {
+		if bp.edgeTriangles[undirected(e[0], e[1])] >= 2 || bp.directed[e] {
+			return false
+		}
+	}
+	return true
+}
+
+func (bp *ballPivoter) addTriangle(a, b, c int) {
+	bp.triangles = append(bp.triangles, [3]int{a, b, c})
+	for _, e := range [][2]int{{a, b}, {b, c}, {c, a}} {
+		bp.directed[e] = true
+		bp.edgeTriangles[undirected(e[0], e[1])]++
+	}
+	bp.used[a], bp.used[b], bp.used[c] = true, true, true
+}
+
+// addFrontEdge adds the edge a→b of the triangle (a, b, opposite) to the front, unless the front already has the edge
+// b→a, in which case the triangles on both of its sides are known and it leaves the front.
+func (bp *ballPivoter) addFrontEdge(a, b, opposite int, center r3.Vector) {
+	if reverse, ok := bp.front[[2]int{b, a}]; ok {
+		bp.removeFrontEdge(reverse)
+		return
+	}
+	e := &bpaEdge{a: a, b: b, opposite: opposite, center: center}
+	bp.front[[2]int{a, b}] = e
+	bp.frontDegree[a]++
+	bp.frontDegree[b]++
+	bp.active = append(bp.active, e)
+}
+
+func (bp *ballPivoter) removeFrontEdge(e *bpaEdge) {
+	delete(bp.front, [2]int{e.a, e.b})
+	bp.frontDegree[e.a]--
+	bp.frontDegree[e.b]--
+}
+
+// pivot rolls the ball around an edge of the front, returning the first point it touches and the center of the ball there.
+func (bp *ballPivoter) pivot(e *bpaEdge) (int, r3.Vector, bool) {
+	pa, pb := bp.points[e.a], bp.points[e.b]
+	mid := pa.Add(pb).Mul(0.5)
+	axis := pb.Sub(pa).Normalize()
+	from := e.center.Sub(mid).Normalize()
+
+	best, bestAngle, bestCenter := -1, math.Inf(1), r3.Vector{}
+	for _, neighbor := range bp.kd.RadiusNearestNeighbors(mid, 2*bp.radius, false) {
+		k := bp.index[neighbor.P]
+		if k == e.a || k == e.b || k == e.opposite {
+			continue
+		}
+		// the triangle on the other side of a→b contains b→a
+		center, ok := bp.ballCenter(e.b, e.a, k)
+		if !ok {
+			continue
+		}
+		to := center.Sub(mid).Normalize()
+		angle := math.Atan2(axis.Dot(from.Cross(to)), from.Dot(to))
+		if angle < 0 {
+			angle += 2 * math.Pi
+		}
+		if angle < bestAngle {
+			best, bestAngle, bestCenter = k, angle, center
+		}
+	}
+	if best < 0 || !bp.emptyBall(bestCenter, e.a, e.b, best) {
+		return -1, r3.Vector{}, false
+	}
+	return best, bestCenter, true
+}
+
+// expandFront pivots the ball around every active edge of the front until none is left.
+func (bp *ballPivoter) expandFront() {
+	for len(bp.active) > 0 {
+		e := bp.active[len(bp.active)-1]
+		bp.active = bp.active[:len(bp.active)-1]
+		if bp.front[[2]int{e.a, e.b}] != e || e.boundary {
+			continue
+		}
+		k, center, ok := bp.pivot(e)
+		// the ball may only reach points which are unused or still on the front
+		if !ok || (bp.used[k] && bp.frontDegree[k] == 0) || !bp.canAddTriangle(e.b, e.a, k) {
+			e.boundary = true
+			continue
+		}
+		bp.addTriangle(e.b, e.a, k)
+		bp.removeFrontEdge(e)
+		bp.addFrontEdge(e.a, k, e.b, center)
+		bp.addFrontEdge(k, e.b, e.a, center)
+	}
+}
+
+// findSeed looks for a triangle of unused points which the ball touches without containing any other point, and starts a
+// new front from it.
+func (bp *ballPivoter) findSeed() bool {
+	for ; bp.seedCursor < len(bp.points); bp.seedCursor++ {
+		i := bp.seedCursor
+		if bp.used[i] {
+			continue
+		}
+		neighbors := bp.kd.RadiusNearestNeighbors(bp.points[i], 2*bp.radius, false)
+		for x := 0; x < len(neighbors); x++ {
+			j := bp.index[neighbors[x].P]
+			if bp.used[j] {
+				continue
+			}
+			for y := x + 1; y < len(neighbors); y++ {
+				k := bp.index[neighbors[y].P]
+				if bp.used[k] {
+					continue
+				}
+				for _, t := range [][3]int{{i, j, k}, {i, k, j}} {
+					center, ok := bp.ballCenter(t[0], t[1], t[2])
+					if !ok || !bp.emptyBall(center, t[0], t[1], t[2]) || !bp.canAddTriangle(t[0], t[1], t[2]) {
+						continue
+					}
+					bp.addTriangle(t[0], t[1], t[2])
+					bp.addFrontEdge(t[0], t[1], t[2], center)
+					bp.addFrontEdge(t[1], t[2], t[0], center)
+					bp.addFrontEdge(t[2], t[0], t[1], center)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// reactivateBoundary makes the edges of the front which a smaller ball could not pivot around active again for a larger
+// ball, and restarts the search for seeds.
+func (bp *ballPivoter) reactivateBoundary() {
+	for _, e := range bp.front {
+		if !e.boundary {
+			continue
+		}
+		center, ok := bp.ballCenter(e.a, e.b, e.opposite)
+		if !ok {
+			continue
+		}
+		e.center = center
+		e.boundary = false
+		bp.active = append(bp.active, e)
+	}
+	bp.seedCursor = 0
+}
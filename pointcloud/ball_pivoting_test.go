@@ -0,0 +1,93 @@
+package pointcloud
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// makeSphereCloud samples a sphere of the given radius evenly with a Fibonacci lattice, with outward normals.
+func makeSphereCloud(t *testing.T, n int, radius float64) PointCloud {
+	t.Helper()
+	pc := NewBasicPointCloud(n)
+	golden := math.Pi * (3 - math.Sqrt(5))
+	for i := 0; i < n; i++ {
+		z := 1 - 2*(float64(i)+0.5)/float64(n)
+		r := math.Sqrt(1 - z*z)
+		normal := r3.Vector{X: r * math.Cos(golden*float64(i)), Y: r * math.Sin(golden*float64(i)), Z: z}
+		test.That(t, pc.Set(normal.Mul(radius), NewBasicData().SetNormal(normal)), test.ShouldBeNil)
+	}
+	return pc
+}
+
+func TestReconstructMesh(t *testing.T) {
+	t.Run("plane", func(t *testing.T) {
+		pc := NewBasicPointCloud(0)
+		for x := 0.; x < 100; x += 10 {
+			for y := 0.; y < 100; y += 10 {
+				test.That(t, pc.Set(r3.Vector{X: x, Y: y + x/20}, NewBasicData().SetNormal(r3.Vector{Z: 1})), test.ShouldBeNil)
+			}
+		}
+		mesh, err := ReconstructMesh(pc, []float64{9}, "plane")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, mesh.Label(), test.ShouldEqual, "plane")
+		area := 0.
+		for _, tri := range mesh.Triangles() {
+			test.That(t, tri.Normal().Z, test.ShouldAlmostEqual, 1)
+			area += tri.Area()
+		}
+		test.That(t, len(mesh.Triangles()), test.ShouldEqual, 2*9*9)
+		test.That(t, area, test.ShouldAlmostEqual, 90*90)
+	})
+
+	t.Run("sphere", func(t *testing.T) {
+		const radius = 100.
+		pc := makeSphereCloud(t, 1000, radius)
+		// the points are about 11mm apart, so a small ball leaves holes which a larger one closes
+		mesh, err := ReconstructMesh(pc, []float64{8, 12, 16}, "")
+		test.That(t, err, test.ShouldBeNil)
+
+		edges := map[[2]r3.Vector]int{}
+		area := 0.
+		for _, tri := range mesh.Triangles() {
+			pts := tri.Points()
+			// triangles face outwards
+			test.That(t, tri.Normal().Dot(tri.Centroid()), test.ShouldBeGreaterThan, 0)
+			area += tri.Area()
+			for i := range pts {
+				a, b := pts[i], pts[(i+1)%3]
+				if a.Cmp(b) > 0 {
+					a, b = b, a
+				}
+				edges[[2]r3.Vector{a, b}]++
+			}
+		}
+		boundary := 0
+		for _, count := range edges {
+			test.That(t, count, test.ShouldBeLessThanOrEqualTo, 2)
+			if count == 1 {
+				boundary++
+			}
+		}
+		// a closed surface has 2V - 4 triangles
+		test.That(t, boundary, test.ShouldEqual, 0)
+		test.That(t, len(mesh.Triangles()), test.ShouldEqual, 2*pc.Size()-4)
+		test.That(t, area, test.ShouldAlmostEqual, 4*math.Pi*radius*radius, 0.02*4*math.Pi*radius*radius)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		pc := makeSphereCloud(t, 10, 100)
+		_, err := ReconstructMesh(pc, nil, "")
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = ReconstructMesh(pc, []float64{-1}, "")
+		test.That(t, err, test.ShouldNotBeNil)
+		noNormals := NewBasicPointCloud(0)
+		for _, p := range []r3.Vector{{}, {X: 1}, {Y: 1}} {
+			test.That(t, noNormals.Set(p, nil), test.ShouldBeNil)
+		}
+		_, err = ReconstructMesh(noNormals, []float64{1}, "")
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
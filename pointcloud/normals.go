@@ -0,0 +1,59 @@
+package pointcloud
+
+import (
+	"image/color"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// EstimateNormals estimates the surface normal at every point of a cloud by principal component analysis of its k nearest
+// neighbors, and writes the points with their normals into out. The sign of a normal is ambiguous, so each is oriented
+// towards the viewpoint, which is usually the origin of the camera that captured the cloud. Points with fewer than
+// 3 neighbors are written without a normal.
+func EstimateNormals(in, out PointCloud, k int, viewpoint r3.Vector) error {
+	if k < 3 {
+		return errors.Errorf("at least 3 neighbors are needed to estimate a normal, got %d", k)
+	}
+	kd := ToKDTree(in)
+	var err error
+	kd.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		d = copyData(d)
+		neighbors := kd.KNearestNeighbors(p, k, true)
+		if len(neighbors) >= 3 {
+			positions := make([]r3.Vector, 0, len(neighbors))
+			for _, neighbor := range neighbors {
+				positions = append(positions, neighbor.P)
+			}
+			normal := estimatePlaneNormalFromPoints(positions)
+			if normal.Norm2() > 0 {
+				if normal.Dot(viewpoint.Sub(p)) < 0 {
+					normal = normal.Mul(-1)
+				}
+				d.SetNormal(normal)
+			}
+		}
+		err = out.Set(p, d)
+		return err == nil
+	})
+	return err
+}
+
+// copyData returns a copy of the data of a point, so that it can be changed without changing the original.
+func copyData(d Data) Data {
+	c := NewBasicData()
+	if d == nil {
+		return c
+	}
+	if d.HasColor() {
+		r, g, b := d.RGB255()
+		c.SetColor(color.NRGBA{r, g, b, 255})
+	}
+	if d.HasValue() {
+		c.SetValue(d.Value())
+	}
+	if d.HasNormal() {
+		c.SetNormal(d.Normal())
+	}
+	return c.SetIntensity(d.Intensity())
+}
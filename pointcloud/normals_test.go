@@ -0,0 +1,53 @@
+package pointcloud
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestEstimateNormals(t *testing.T) {
+	t.Run("plane", func(t *testing.T) {
+		in := NewBasicPointCloud(0)
+		for x := 0.; x < 100; x += 10 {
+			for y := 0.; y < 100; y += 10 {
+				test.That(t, in.Set(r3.Vector{X: x, Y: y, Z: 500}, NewColoredData(color.NRGBA{255, 0, 0, 255})), test.ShouldBeNil)
+			}
+		}
+		out := NewBasicPointCloud(0)
+		test.That(t, EstimateNormals(in, out, 8, r3.Vector{}), test.ShouldBeNil)
+		test.That(t, out.Size(), test.ShouldEqual, in.Size())
+		test.That(t, out.MetaData().HasNormal, test.ShouldBeTrue)
+		out.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			test.That(t, d.HasNormal(), test.ShouldBeTrue)
+			// oriented towards the camera at the origin
+			test.That(t, d.Normal().Z, test.ShouldAlmostEqual, -1)
+			test.That(t, d.HasColor(), test.ShouldBeTrue)
+			return true
+		})
+		// the input is left unchanged
+		test.That(t, in.MetaData().HasNormal, test.ShouldBeFalse)
+	})
+
+	t.Run("sphere", func(t *testing.T) {
+		sphere := makeSphereCloud(t, 500, 100)
+		in := NewBasicPointCloud(0)
+		sphere.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			test.That(t, in.Set(p, nil), test.ShouldBeNil)
+			return true
+		})
+		out := NewBasicPointCloud(0)
+		test.That(t, EstimateNormals(in, out, 10, r3.Vector{}), test.ShouldBeNil)
+		out.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			// the viewpoint is inside the sphere, so the normals point inwards
+			test.That(t, d.Normal().Dot(p.Normalize()), test.ShouldBeLessThan, -0.95)
+			return true
+		})
+	})
+
+	t.Run("too few neighbors", func(t *testing.T) {
+		test.That(t, EstimateNormals(NewBasicPointCloud(0), NewBasicPointCloud(0), 2, r3.Vector{}), test.ShouldNotBeNil)
+	})
+}
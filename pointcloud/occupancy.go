@@ -0,0 +1,313 @@
+package pointcloud
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+const (
+	defaultOccupancyHitProbability  = 0.7
+	defaultOccupancyMissProbability = 0.4
+	defaultOccupancyMinProbability  = 0.12
+	defaultOccupancyMaxProbability  = 0.97
+)
+
+// OccupancyMapConfig configures an OccupancyMap. Zero values are replaced by defaults.
+type OccupancyMapConfig struct {
+	// VoxelSize is the side length of a voxel of the map in mm.
+	VoxelSize float64
+	// HitProbability is the probability that a voxel is occupied given that a ray ends in it, and MissProbability that
+	// it is occupied given that a ray passes through it. They default to 0.7 and 0.4.
+	HitProbability  float64
+	MissProbability float64
+	// MinProbability and MaxProbability clamp the probability of a voxel, so that the map can change its mind about a
+	// voxel after many observations of it. They default to 0.12 and 0.97.
+	MinProbability float64
+	MaxProbability float64
+	// MaxRange is the distance in mm from the sensor beyond which points only clear the space up to that distance,
+	// without marking the voxel they end in as occupied. Zero means unlimited.
+	MaxRange float64
+	// ConfidenceThreshold is the probability as a value between 0-100 at which a voxel is considered occupied, defaulting
+	// to 50 when nil. It is the confidence threshold of the exported octree.
+	ConfidenceThreshold *int
+}
+
+func (cfg *OccupancyMapConfig) setDefaults() error {
+	if cfg.VoxelSize <= 0 {
+		return errors.Errorf("occupancy map voxel size must be positive, got %v", cfg.VoxelSize)
+	}
+	if cfg.HitProbability == 0 {
+		cfg.HitProbability = defaultOccupancyHitProbability
+	}
+	if cfg.MissProbability == 0 {
+		cfg.MissProbability = defaultOccupancyMissProbability
+	}
+	if cfg.MinProbability == 0 {
+		cfg.MinProbability = defaultOccupancyMinProbability
+	}
+	if cfg.MaxProbability == 0 {
+		cfg.MaxProbability = defaultOccupancyMaxProbability
+	}
+	if cfg.ConfidenceThreshold == nil {
+		threshold := defaultConfidenceThreshold
+		cfg.ConfidenceThreshold = &threshold
+	}
+	if cfg.HitProbability <= 0.5 || cfg.HitProbability >= 1 {
+		return errors.Errorf("hit probability must be between 0.5 and 1, got %v", cfg.HitProbability)
+	}
+	if cfg.MissProbability <= 0 || cfg.MissProbability >= 0.5 {
+		return errors.Errorf("miss probability must be between 0 and 0.5, got %v", cfg.MissProbability)
+	}
+	if cfg.MinProbability <= 0 || cfg.MaxProbability >= 1 || cfg.MinProbability >= cfg.MaxProbability {
+		return errors.Errorf("probability clamps must satisfy 0 < min < max < 1, got %v and %v", cfg.MinProbability, cfg.MaxProbability)
+	}
+	if *cfg.ConfidenceThreshold < 0 || *cfg.ConfidenceThreshold > 100 {
+		return errors.Errorf("confidence threshold must be between 0-100, got %d", *cfg.ConfidenceThreshold)
+	}
+	if cfg.MaxRange < 0 {
+		return errors.Errorf("max range cannot be negative, got %v", cfg.MaxRange)
+	}
+	return nil
+}
+
+// OccupancyMap is a probabilistic occupancy map of voxels, built by integrating point clouds from depth sensors. Every
+// point of a cloud is a ray from the sensor: the voxel it ends in becomes more likely to be occupied and the voxels it
+// passes through less likely, so that obstacles which move away are cleared. Probabilities are stored as log odds, and
+// the occupied voxels can be exported as boxes for collision checking. An OccupancyMap is not safe for concurrent use.
+type OccupancyMap struct {
+	cfg      OccupancyMapConfig
+	hit      float64
+	miss     float64
+	minLog   float64
+	maxLog   float64
+	logOdds  map[VoxelCoords]float64
+	occupied float64
+}
+
+// NewOccupancyMap returns an empty occupancy map.
+func NewOccupancyMap(cfg OccupancyMapConfig) (*OccupancyMap, error) {
+	if err := cfg.setDefaults(); err != nil {
+		return nil, err
+	}
+	return &OccupancyMap{
+		cfg:      cfg,
+		hit:      logOdds(cfg.HitProbability),
+		miss:     logOdds(cfg.MissProbability),
+		minLog:   logOdds(cfg.MinProbability),
+		maxLog:   logOdds(cfg.MaxProbability),
+		logOdds:  map[VoxelCoords]float64{},
+		occupied: logOdds(float64(*cfg.ConfidenceThreshold) / 100),
+	}, nil
+}
+
+func logOdds(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
+
+func probability(l float64) float64 {
+	return 1 - 1/(1+math.Exp(l))
+}
+
+// Config returns the configuration of the map, with defaults filled in.
+func (m *OccupancyMap) Config() OccupancyMapConfig {
+	return m.cfg
+}
+
+func (m *OccupancyMap) voxelOf(p r3.Vector) VoxelCoords {
+	return VoxelCoords{
+		I: int64(math.Floor(p.X / m.cfg.VoxelSize)),
+		J: int64(math.Floor(p.Y / m.cfg.VoxelSize)),
+		K: int64(math.Floor(p.Z / m.cfg.VoxelSize)),
+	}
+}
+
+func (m *OccupancyMap) voxelCenter(c VoxelCoords) r3.Vector {
+	return r3.Vector{
+		X: (float64(c.I) + 0.5) * m.cfg.VoxelSize,
+		Y: (float64(c.J) + 0.5) * m.cfg.VoxelSize,
+		Z: (float64(c.K) + 0.5) * m.cfg.VoxelSize,
+	}
+}
+
+// Insert integrates a point cloud captured by a sensor at origin, with both in the frame of the map.
+func (m *OccupancyMap) Insert(cloud PointCloud, origin r3.Vector) {
+	hits := map[VoxelCoords]bool{}
+	misses := map[VoxelCoords]bool{}
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		end := p
+		hit := true
+		if ray := p.Sub(origin); m.cfg.MaxRange > 0 && ray.Norm() > m.cfg.MaxRange {
+			end = origin.Add(ray.Normalize().Mul(m.cfg.MaxRange))
+			hit = false
+		}
+		m.traverse(origin, end, func(c VoxelCoords) {
+			misses[c] = true
+		})
+		if hit {
+			hits[m.voxelOf(end)] = true
+		}
+		return true
+	})
+	// a voxel which any ray ends in is occupied even if other rays pass through it
+	for c := range misses {
+		if !hits[c] {
+			m.update(c, m.miss)
+		}
+	}
+	for c := range hits {
+		m.update(c, m.hit)
+	}
+}
+
+// InsertFromPose integrates a point cloud in the frame of a sensor, given the pose of the sensor in the frame of the map.
+func (m *OccupancyMap) InsertFromPose(cloud PointCloud, sensorPose spatialmath.Pose) error {
+	transformed := NewBasicPointCloud(cloud.Size())
+	var err error
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		err = transformed.Set(spatialmath.Compose(sensorPose, spatialmath.NewPoseFromPoint(p)).Point(), d)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	m.Insert(transformed, sensorPose.Point())
+	return nil
+}
+
+func (m *OccupancyMap) update(c VoxelCoords, delta float64) {
+	l := math.Max(m.minLog, math.Min(m.maxLog, m.logOdds[c]+delta))
+	m.logOdds[c] = l
+}
+
+// traverse calls fn for every voxel the segment from start to end passes through, except the one it ends in, using the
+// voxel traversal of Amanatides and Woo.
+func (m *OccupancyMap) traverse(start, end r3.Vector, fn func(VoxelCoords)) {
+	current, last := m.voxelOf(start), m.voxelOf(end)
+	dir := end.Sub(start)
+	length := dir.Norm()
+	if length == 0 {
+		return
+	}
+	dir = dir.Mul(1 / length)
+
+	coords := [3]*int64{&current.I, &current.J, &current.K}
+	lasts := [3]int64{last.I, last.J, last.K}
+	starts := [3]float64{start.X, start.Y, start.Z}
+	dirs := [3]float64{dir.X, dir.Y, dir.Z}
+	var step [3]int64
+	var tMax, tDelta [3]float64
+	for axis := 0; axis < 3; axis++ {
+		switch {
+		case dirs[axis] > 0:
+			step[axis] = 1
+			boundary := float64(*coords[axis]+1) * m.cfg.VoxelSize
+			tMax[axis] = (boundary - starts[axis]) / dirs[axis]
+			tDelta[axis] = m.cfg.VoxelSize / dirs[axis]
+		case dirs[axis] < 0:
+			step[axis] = -1
+			boundary := float64(*coords[axis]) * m.cfg.VoxelSize
+			tMax[axis] = (boundary - starts[axis]) / dirs[axis]
+			tDelta[axis] = -m.cfg.VoxelSize / dirs[axis]
+		default:
+			tMax[axis] = math.Inf(1)
+			tDelta[axis] = math.Inf(1)
+		}
+	}
+
+	for current != last {
+		fn(current)
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		if tMax[axis] > length {
+			// rounding can leave the end voxel just out of reach
+			break
+		}
+		*coords[axis] += step[axis]
+		tMax[axis] += tDelta[axis]
+		// never step past the end voxel along an axis
+		if (step[axis] > 0 && *coords[axis] > lasts[axis]) || (step[axis] < 0 && *coords[axis] < lasts[axis]) {
+			break
+		}
+	}
+}
+
+// Probability returns the probability that the voxel containing p is occupied, and whether the voxel has been observed.
+func (m *OccupancyMap) Probability(p r3.Vector) (float64, bool) {
+	l, ok := m.logOdds[m.voxelOf(p)]
+	if !ok {
+		return 0.5, false
+	}
+	return probability(l), true
+}
+
+// Occupied returns whether the voxel containing p is at least as likely to be occupied as the confidence threshold.
+func (m *OccupancyMap) Occupied(p r3.Vector) bool {
+	l, ok := m.logOdds[m.voxelOf(p)]
+	return ok && l >= m.occupied
+}
+
+// Size returns the number of voxels which have been observed.
+func (m *OccupancyMap) Size() int {
+	return len(m.logOdds)
+}
+
+// Clear forgets every observation.
+func (m *OccupancyMap) Clear() {
+	m.logOdds = map[VoxelCoords]float64{}
+}
+
+// ToOctree returns the centers of the occupied voxels as a BasicOctree, each with its probability of being occupied as
+// a value between 0-100, and with the confidence threshold of the map. Collisions with the octree are only checked
+// against the voxel centers, so use ToBoxes to check collisions with the whole voxels.
+func (m *OccupancyMap) ToOctree() (*BasicOctree, error) {
+	occupied := NewBasicPointCloud(0)
+	for _, c := range m.occupiedVoxels() {
+		value := int(math.Round(probability(m.logOdds[c]) * 100))
+		if err := occupied.Set(m.voxelCenter(c), NewValueData(value)); err != nil {
+			return nil, err
+		}
+	}
+	return ToBasicOctree(occupied, *m.cfg.ConfidenceThreshold)
+}
+
+// ToBoxes returns a box the size of a voxel for every occupied voxel, labeled by suffixing the label with the index of
+// the box.
+func (m *OccupancyMap) ToBoxes(label string) ([]spatialmath.Geometry, error) {
+	voxels := m.occupiedVoxels()
+	dims := r3.Vector{X: m.cfg.VoxelSize, Y: m.cfg.VoxelSize, Z: m.cfg.VoxelSize}
+	boxes := make([]spatialmath.Geometry, 0, len(voxels))
+	for i, c := range voxels {
+		box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(m.voxelCenter(c)), dims, fmt.Sprintf("%s_%d", label, i))
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, box)
+	}
+	return boxes, nil
+}
+
+// occupiedVoxels returns the occupied voxels, sorted so that exports are stable.
+func (m *OccupancyMap) occupiedVoxels() []VoxelCoords {
+	voxels := []VoxelCoords{}
+	for c, l := range m.logOdds {
+		if l >= m.occupied {
+			voxels = append(voxels, c)
+		}
+	}
+	slices.SortFunc(voxels, func(a, b VoxelCoords) int {
+		return cmp.Or(cmp.Compare(a.I, b.I), cmp.Compare(a.J, b.J), cmp.Compare(a.K, b.K))
+	})
+	return voxels
+}
@@ -0,0 +1,155 @@
+package pointcloud
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// makeWallCloud returns a square wall of points at the given depth along z, as seen by a sensor at the origin.
+func makeWallCloud(t *testing.T, depth float64) PointCloud {
+	t.Helper()
+	pc := NewBasicPointCloud(0)
+	for x := -100.; x <= 100; x += 10 {
+		for y := -100.; y <= 100; y += 10 {
+			test.That(t, pc.Set(r3.Vector{X: x, Y: y, Z: depth}, nil), test.ShouldBeNil)
+		}
+	}
+	return pc
+}
+
+func TestOccupancyMap(t *testing.T) {
+	t.Run("config", func(t *testing.T) {
+		_, err := NewOccupancyMap(OccupancyMapConfig{})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10, HitProbability: 0.3})
+		test.That(t, err, test.ShouldNotBeNil)
+		threshold := 120
+		_, err = NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10, ConfidenceThreshold: &threshold})
+		test.That(t, err, test.ShouldNotBeNil)
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, m.Config().HitProbability, test.ShouldEqual, defaultOccupancyHitProbability)
+		test.That(t, *m.Config().ConfidenceThreshold, test.ShouldEqual, defaultConfidenceThreshold)
+
+		// a zero threshold is kept, making every observed voxel occupied
+		threshold = 0
+		m, err = NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10, ConfidenceThreshold: &threshold})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, *m.Config().ConfidenceThreshold, test.ShouldEqual, 0)
+		m.Insert(makeWallCloud(t, 505), r3.Vector{})
+		test.That(t, m.Occupied(r3.Vector{Z: 255}), test.ShouldBeTrue)
+	})
+
+	t.Run("hits and clearing", func(t *testing.T) {
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10})
+		test.That(t, err, test.ShouldBeNil)
+		m.Insert(makeWallCloud(t, 505), r3.Vector{})
+		test.That(t, m.Occupied(r3.Vector{Z: 505}), test.ShouldBeTrue)
+		test.That(t, m.Occupied(r3.Vector{Z: 255}), test.ShouldBeFalse)
+		p, observed := m.Probability(r3.Vector{Z: 255})
+		test.That(t, observed, test.ShouldBeTrue)
+		test.That(t, p, test.ShouldAlmostEqual, defaultOccupancyMissProbability)
+		_, observed = m.Probability(r3.Vector{Z: -255})
+		test.That(t, observed, test.ShouldBeFalse)
+
+		octree, err := m.ToOctree()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, octree.Size(), test.ShouldEqual, 21*21)
+		octree.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			test.That(t, p.Z, test.ShouldAlmostEqual, 505)
+			test.That(t, d.Value(), test.ShouldEqual, 70)
+			return true
+		})
+		box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{Z: 500}), r3.Vector{X: 50, Y: 50, Z: 50}, "")
+		test.That(t, err, test.ShouldBeNil)
+		collides, err := octree.CollidesWith(box, 0)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, collides, test.ShouldBeTrue)
+
+		// the wall moves back, and rays through where it was clear it after repeated observations
+		for i := 0; i < 3; i++ {
+			m.Insert(makeWallCloud(t, 805), r3.Vector{})
+		}
+		test.That(t, m.Occupied(r3.Vector{Z: 505}), test.ShouldBeFalse)
+		test.That(t, m.Occupied(r3.Vector{Z: 805}), test.ShouldBeTrue)
+		p, _ = m.Probability(r3.Vector{Z: 805})
+		test.That(t, p, test.ShouldBeLessThanOrEqualTo, defaultOccupancyMaxProbability+1e-9)
+
+		m.Clear()
+		test.That(t, m.Size(), test.ShouldEqual, 0)
+	})
+
+	t.Run("boxes", func(t *testing.T) {
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10})
+		test.That(t, err, test.ShouldBeNil)
+		m.Insert(makeWallCloud(t, 505), r3.Vector{})
+		boxes, err := m.ToBoxes("wall")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(boxes), test.ShouldEqual, 21*21)
+		test.That(t, boxes[0].Label(), test.ShouldEqual, "wall_0")
+		test.That(t, boxes[0].Pose().Point(), test.ShouldResemble, r3.Vector{X: -95, Y: -95, Z: 505})
+
+		// a sphere reaching into the voxel from z=500 to z=510 without reaching its center misses the octree but not
+		// the boxes
+		sphere, err := spatialmath.NewSphere(spatialmath.NewPoseFromPoint(r3.Vector{X: 5, Y: 5, Z: 501}), 3, "")
+		test.That(t, err, test.ShouldBeNil)
+		octree, err := m.ToOctree()
+		test.That(t, err, test.ShouldBeNil)
+		collides, err := octree.CollidesWith(sphere, 0)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, collides, test.ShouldBeFalse)
+		collides = false
+		for _, box := range boxes {
+			hit, err := box.CollidesWith(sphere, 0)
+			test.That(t, err, test.ShouldBeNil)
+			collides = collides || hit
+		}
+		test.That(t, collides, test.ShouldBeTrue)
+	})
+
+	t.Run("max range", func(t *testing.T) {
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10, MaxRange: 300})
+		test.That(t, err, test.ShouldBeNil)
+		m.Insert(makeWallCloud(t, 505), r3.Vector{})
+		test.That(t, m.Occupied(r3.Vector{Z: 505}), test.ShouldBeFalse)
+		_, observed := m.Probability(r3.Vector{Z: 505})
+		test.That(t, observed, test.ShouldBeFalse)
+		_, observed = m.Probability(r3.Vector{Z: 255})
+		test.That(t, observed, test.ShouldBeTrue)
+	})
+
+	t.Run("sensor pose", func(t *testing.T) {
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10})
+		test.That(t, err, test.ShouldBeNil)
+		// a sensor at x=1000 looking along -x
+		pose := spatialmath.NewPose(r3.Vector{X: 1000}, &spatialmath.OrientationVectorDegrees{OX: -1})
+		test.That(t, m.InsertFromPose(makeWallCloud(t, 505), pose), test.ShouldBeNil)
+		test.That(t, m.Occupied(r3.Vector{X: 495}), test.ShouldBeTrue)
+		test.That(t, m.Occupied(r3.Vector{X: 750}), test.ShouldBeFalse)
+	})
+
+	t.Run("diagonal rays", func(t *testing.T) {
+		m, err := NewOccupancyMap(OccupancyMapConfig{VoxelSize: 10})
+		test.That(t, err, test.ShouldBeNil)
+		var visited []VoxelCoords
+		m.traverse(r3.Vector{X: 1, Y: 1, Z: 1}, r3.Vector{X: 95, Y: -43, Z: 27}, func(c VoxelCoords) {
+			visited = append(visited, c)
+		})
+		test.That(t, visited[0], test.ShouldResemble, VoxelCoords{})
+		// consecutive voxels are face neighbors
+		for i := 1; i < len(visited); i++ {
+			a, b := visited[i-1], visited[i]
+			steps := math.Abs(float64(a.I-b.I)) + math.Abs(float64(a.J-b.J)) + math.Abs(float64(a.K-b.K))
+			test.That(t, steps, test.ShouldEqual, 1)
+		}
+		last := visited[len(visited)-1]
+		end := VoxelCoords{I: 9, J: -5, K: 2}
+		steps := math.Abs(float64(last.I-end.I)) + math.Abs(float64(last.J-end.J)) + math.Abs(float64(last.K-end.K))
+		test.That(t, steps, test.ShouldEqual, 1)
+	})
+}
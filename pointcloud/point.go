@@ -67,6 +67,15 @@ type Data interface {
 
 	// SetIntensity sets the intensity on the point.
 	SetIntensity(v uint16) Data
+
+	// HasNormal returns whether or not this point has a surface normal.
+	HasNormal() bool
+
+	// Normal returns the unit surface normal at the point, if it exists.
+	Normal() r3.Vector
+
+	// SetNormal sets the surface normal of the point, which is normalized.
+	SetNormal(n r3.Vector) Data
 }
 
 type basicData struct {
@@ -77,6 +86,9 @@ type basicData struct {
 	value    int
 
 	intensity uint16
+
+	hasNormal bool
+	normal    r3.Vector
 }
 
 // NewBasicData returns a point that is solely positionally based.
@@ -134,3 +146,17 @@ func (bp *basicData) SetIntensity(v uint16) Data {
 func (bp *basicData) Intensity() uint16 {
 	return bp.intensity
 }
+
+func (bp *basicData) SetNormal(n r3.Vector) Data {
+	bp.hasNormal = true
+	bp.normal = n.Normalize()
+	return bp
+}
+
+func (bp *basicData) HasNormal() bool {
+	return bp.hasNormal
+}
+
+func (bp *basicData) Normal() r3.Vector {
+	return bp.normal
+}
@@ -23,8 +23,9 @@ const numThreadsPointCloud = 8
 
 // MetaData is data about what's stored in the point cloud.
 type MetaData struct {
-	HasColor bool
-	HasValue bool
+	HasColor  bool
+	HasValue  bool
+	HasNormal bool
 
 	MinX, MaxX             float64
 	MinY, MaxY             float64
@@ -108,6 +109,9 @@ func (meta *MetaData) Merge(v r3.Vector, data Data) {
 		if data.HasValue() {
 			meta.HasValue = true
 		}
+		if data.HasNormal() {
+			meta.HasNormal = true
+		}
 	}
 
 	if v.X > meta.MaxX {
@@ -0,0 +1,572 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image/color"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// E57 files (ASTM E2807) are divided into pages whose last 4 bytes are a CRC-32C checksum of the rest of the page.
+// Offsets into the file are either physical, counting the checksums, or logical, skipping them.
+const (
+	e57PageSize        = 1024
+	e57LogicalPageSize = e57PageSize - 4
+	e57HeaderSize      = 48
+	e57SignatureString = "ASTM-E57"
+	e57Namespace       = "http://www.astm.org/COMMIT/E57/2010-e57-v1.0"
+
+	e57CompressedVectorSectionID = 1
+	e57IndexPacket               = 0
+	e57DataPacket                = 1
+	e57EmptyPacket               = 2
+	e57MaxPacketSize             = 64 * 1024
+	e57PointsPerPacket           = 2000
+)
+
+var e57CRC = crc32.MakeTable(crc32.Castagnoli)
+
+func e57LogicalToPhysical(offset uint64) uint64 {
+	return offset/e57LogicalPageSize*e57PageSize + offset%e57LogicalPageSize
+}
+
+// e57File is the logical content of an e57 file, with the page checksums removed.
+type e57File struct {
+	data []byte
+}
+
+func newE57File(raw []byte) (*e57File, error) {
+	if len(raw) < e57HeaderSize || string(raw[:8]) != e57SignatureString {
+		return nil, errors.New("not an e57 file")
+	}
+	pageSize := binary.LittleEndian.Uint64(raw[40:48])
+	if pageSize != e57PageSize {
+		return nil, fmt.Errorf("unsupported e57 page size %d", pageSize)
+	}
+	if len(raw)%e57PageSize != 0 {
+		return nil, fmt.Errorf("e57 file length %d is not a multiple of the page size", len(raw))
+	}
+	f := &e57File{data: make([]byte, 0, len(raw)/e57PageSize*e57LogicalPageSize)}
+	for page := 0; page < len(raw); page += e57PageSize {
+		logical := raw[page : page+e57LogicalPageSize]
+		checksum := crc32.Checksum(logical, e57CRC)
+		stored := raw[page+e57LogicalPageSize : page+e57PageSize]
+		// the standard stores the checksum big endian, but accept either byte order
+		if binary.BigEndian.Uint32(stored) != checksum && binary.LittleEndian.Uint32(stored) != checksum {
+			return nil, fmt.Errorf("e57 checksum mismatch on page %d", page/e57PageSize)
+		}
+		f.data = append(f.data, logical...)
+	}
+	return f, nil
+}
+
+// read returns length bytes of the file starting at a physical offset.
+func (f *e57File) read(physicalOffset, length uint64) ([]byte, error) {
+	if physicalOffset%e57PageSize >= e57LogicalPageSize {
+		return nil, fmt.Errorf("e57 offset %d is inside a checksum", physicalOffset)
+	}
+	start := physicalOffset/e57PageSize*e57LogicalPageSize + physicalOffset%e57PageSize
+	if start+length > uint64(len(f.data)) {
+		return nil, fmt.Errorf("e57 read of %d bytes at offset %d is past the end of the file", length, physicalOffset)
+	}
+	return f.data[start : start+length], nil
+}
+
+// e57Node is an element of the xml section of an e57 file.
+type e57Node struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []e57Node  `xml:",any"`
+}
+
+func (n *e57Node) attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *e57Node) child(name string) *e57Node {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == name {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+func (n *e57Node) float(name string, def float64) float64 {
+	c := n.child(name)
+	if c == nil {
+		return def
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(c.Content), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// e57Field is a field of the records of a compressed vector, and how to decode it from its bytestream.
+type e57Field struct {
+	name      string
+	valType   string
+	precision int // bytes of a Float
+	bits      int // bits of an Integer or ScaledInteger
+	minimum   int64
+	scale     float64
+	offset    float64
+	// the normalized range of the field, to map colors and intensities into their Data ranges
+	lower, upper float64
+}
+
+func newE57Field(n *e57Node) (*e57Field, error) {
+	f := &e57Field{name: n.XMLName.Local, scale: 1, precision: 8}
+	f.valType, _ = n.attr("type")
+	parseAttr := func(name string, def float64) float64 {
+		s, ok := n.attr(name)
+		if !ok {
+			return def
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return def
+		}
+		return v
+	}
+	switch f.valType {
+	case "Float":
+		if precision, _ := n.attr("precision"); precision == "single" {
+			f.precision = 4
+		}
+		f.lower = parseAttr("minimum", -math.MaxFloat64)
+		f.upper = parseAttr("maximum", math.MaxFloat64)
+	case "Integer", "ScaledInteger":
+		minimum, maximum := int64(math.MinInt64), int64(math.MaxInt64)
+		if s, ok := n.attr("minimum"); ok {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid minimum of e57 field %s", f.name)
+			}
+			minimum = v
+		}
+		if s, ok := n.attr("maximum"); ok {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid maximum of e57 field %s", f.name)
+			}
+			maximum = v
+		}
+		if maximum < minimum {
+			return nil, fmt.Errorf("e57 field %s has maximum %d below minimum %d", f.name, maximum, minimum)
+		}
+		f.minimum = minimum
+		f.bits = bits.Len64(uint64(maximum - minimum))
+		if f.valType == "ScaledInteger" {
+			f.scale = parseAttr("scale", 1)
+			f.offset = parseAttr("offset", 0)
+		}
+		f.lower = float64(minimum)*f.scale + f.offset
+		f.upper = float64(maximum)*f.scale + f.offset
+	default:
+		return nil, fmt.Errorf("unsupported e57 field type %q of %s", f.valType, f.name)
+	}
+	return f, nil
+}
+
+// decode decodes count values of the field from its bytestream.
+func (f *e57Field) decode(stream []byte, count int) ([]float64, error) {
+	values := make([]float64, count)
+	if f.valType == "Float" {
+		if len(stream) < count*f.precision {
+			return nil, fmt.Errorf("e57 field %s has %d bytes, need %d", f.name, len(stream), count*f.precision)
+		}
+		for i := range values {
+			if f.precision == 4 {
+				values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(stream[4*i:])))
+			} else {
+				values[i] = math.Float64frombits(binary.LittleEndian.Uint64(stream[8*i:]))
+			}
+		}
+		return values, nil
+	}
+
+	// integers are packed into the bytestream least significant bit first, without padding
+	if len(stream)*8 < count*f.bits {
+		return nil, fmt.Errorf("e57 field %s has %d bytes, need %d bits", f.name, len(stream), count*f.bits)
+	}
+	bit := 0
+	for i := range values {
+		var raw uint64
+		for b := 0; b < f.bits; b++ {
+			if stream[bit/8]&(1<<(bit%8)) != 0 {
+				raw |= 1 << b
+			}
+			bit++
+		}
+		values[i] = float64(int64(raw)+f.minimum)*f.scale + f.offset
+	}
+	return values, nil
+}
+
+// normalized maps a value of the field into [0, 1] using the bounds of the field.
+func (f *e57Field) normalized(v float64) float64 {
+	if f.upper <= f.lower || math.IsInf(f.upper-f.lower, 0) {
+		return v
+	}
+	return (v - f.lower) / (f.upper - f.lower)
+}
+
+// ReadE57 reads the scans of an e57 file into a single point cloud, transforming each by its pose. Cartesian or spherical
+// coordinates in meters are read along with colors and intensities.
+func ReadE57(inRaw io.Reader, pcStructureType string) (PointCloud, error) {
+	cfg, err := Find(pcStructureType)
+	if err != nil {
+		return nil, err
+	}
+	return readE57(inRaw, cfg)
+}
+
+func readE57(inRaw io.Reader, cfg TypeConfig) (PointCloud, error) {
+	raw, err := io.ReadAll(inRaw)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newE57File(raw)
+	if err != nil {
+		return nil, err
+	}
+	xmlOffset := binary.LittleEndian.Uint64(raw[24:32])
+	xmlLength := binary.LittleEndian.Uint64(raw[32:40])
+	xmlData, err := f.read(xmlOffset, xmlLength)
+	if err != nil {
+		return nil, err
+	}
+	var root e57Node
+	if err := xml.Unmarshal(xmlData, &root); err != nil {
+		return nil, errors.Wrap(err, "invalid e57 xml section")
+	}
+
+	scans := root.child("data3D")
+	if scans == nil {
+		return nil, errors.New("e57 file has no data3D")
+	}
+	total := 0
+	for _, scan := range scans.Children {
+		if points := scan.child("points"); points != nil {
+			count, _ := points.attr("recordCount")
+			n, _ := strconv.Atoi(count)
+			total += n
+		}
+	}
+	pc := cfg.NewWithParams(total)
+	for i := range scans.Children {
+		if err := readE57Scan(f, &scans.Children[i], pc); err != nil {
+			return nil, errors.Wrapf(err, "error reading e57 scan %d", i)
+		}
+	}
+	return pc.FinalizeAfterReading()
+}
+
+func readE57Scan(f *e57File, scan *e57Node, pc PointCloud) error {
+	points := scan.child("points")
+	if points == nil {
+		return errors.New("scan has no points")
+	}
+	if t, _ := points.attr("type"); t != "CompressedVector" {
+		return fmt.Errorf("unsupported points type %q", t)
+	}
+	offsetAttr, _ := points.attr("fileOffset")
+	sectionOffset, err := strconv.ParseUint(offsetAttr, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid fileOffset")
+	}
+	countAttr, _ := points.attr("recordCount")
+	count, err := strconv.Atoi(countAttr)
+	if err != nil {
+		return errors.Wrap(err, "invalid recordCount")
+	}
+	prototype := points.child("prototype")
+	if prototype == nil {
+		return errors.New("points have no prototype")
+	}
+	fields := make([]*e57Field, 0, len(prototype.Children))
+	byName := map[string]*e57Field{}
+	for i := range prototype.Children {
+		field, err := newE57Field(&prototype.Children[i])
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+		byName[field.name] = field
+	}
+
+	streams, err := readE57Bytestreams(f, sectionOffset, len(fields))
+	if err != nil {
+		return err
+	}
+	values := map[string][]float64{}
+	for i, field := range fields {
+		if values[field.name], err = field.decode(streams[i], count); err != nil {
+			return err
+		}
+	}
+
+	pose := spatialmath.NewZeroPose()
+	if p := scan.child("pose"); p != nil {
+		var translation r3.Vector
+		if t := p.child("translation"); t != nil {
+			translation = r3.Vector{X: t.float("x", 0), Y: t.float("y", 0), Z: t.float("z", 0)}.Mul(1000)
+		}
+		orientation := spatialmath.NewZeroOrientation()
+		if r := p.child("rotation"); r != nil {
+			orientation = &spatialmath.Quaternion{Real: r.float("w", 1), Imag: r.float("x", 0), Jmag: r.float("y", 0), Kmag: r.float("z", 0)}
+		}
+		pose = spatialmath.NewPose(translation, orientation)
+	}
+
+	_, cartesian := values["cartesianX"]
+	_, spherical := values["sphericalRange"]
+	if !cartesian && !spherical {
+		return errors.New("points have neither cartesian nor spherical coordinates")
+	}
+	hasColor := values["colorRed"] != nil && values["colorGreen"] != nil && values["colorBlue"] != nil
+	colorChannel := func(name string, i int) uint8 {
+		return uint8(math.Max(0, math.Min(255, math.Round(255*byName[name].normalized(values[name][i])))))
+	}
+
+	point := spatialmath.NewZeroPose()
+	for i := 0; i < count; i++ {
+		var p r3.Vector
+		if cartesian {
+			if invalid, ok := values["cartesianInvalidState"]; ok && invalid[i] != 0 {
+				continue
+			}
+			p = r3.Vector{X: values["cartesianX"][i], Y: values["cartesianY"][i], Z: values["cartesianZ"][i]}
+		} else {
+			if invalid, ok := values["sphericalInvalidState"]; ok && invalid[i] != 0 {
+				continue
+			}
+			r, azimuth, elevation := values["sphericalRange"][i], values["sphericalAzimuth"][i], values["sphericalElevation"][i]
+			p = r3.Vector{
+				X: r * math.Cos(elevation) * math.Cos(azimuth),
+				Y: r * math.Cos(elevation) * math.Sin(azimuth),
+				Z: r * math.Sin(elevation),
+			}
+		}
+		spatialmath.ResetPoseDQTranslation(point, p.Mul(1000))
+		p = spatialmath.Compose(pose, point).Point()
+		p = r3.Vector{X: math.Round(p.X*1e3) / 1e3, Y: math.Round(p.Y*1e3) / 1e3, Z: math.Round(p.Z*1e3) / 1e3}
+
+		d := NewBasicData()
+		if hasColor {
+			d.SetColor(color.NRGBA{colorChannel("colorRed", i), colorChannel("colorGreen", i), colorChannel("colorBlue", i), 255})
+		}
+		if intensity, ok := values["intensity"]; ok {
+			d.SetIntensity(uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(math.MaxUint16*byName["intensity"].normalized(intensity[i]))))))
+		}
+		if err := pc.Set(p, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readE57Bytestreams concatenates the buffers of each bytestream of the data packets of a compressed vector section.
+func readE57Bytestreams(f *e57File, sectionOffset uint64, numStreams int) ([][]byte, error) {
+	sectionHeader, err := f.read(sectionOffset, 32)
+	if err != nil {
+		return nil, err
+	}
+	if sectionHeader[0] != e57CompressedVectorSectionID {
+		return nil, fmt.Errorf("unexpected e57 section id %d", sectionHeader[0])
+	}
+	sectionLength := binary.LittleEndian.Uint64(sectionHeader[8:16])
+	dataOffset := binary.LittleEndian.Uint64(sectionHeader[16:24])
+
+	// packets are contiguous in the logical file, so track logical offsets and convert them to physical ones
+	sectionStart := sectionOffset/e57PageSize*e57LogicalPageSize + sectionOffset%e57PageSize
+	offset := dataOffset/e57PageSize*e57LogicalPageSize + dataOffset%e57PageSize
+	streams := make([][]byte, numStreams)
+	for offset < sectionStart+sectionLength {
+		packetHeader, err := f.read(e57LogicalToPhysical(offset), 4)
+		if err != nil {
+			return nil, err
+		}
+		packetLength := uint64(binary.LittleEndian.Uint16(packetHeader[2:4])) + 1
+		packet, err := f.read(e57LogicalToPhysical(offset), packetLength)
+		if err != nil {
+			return nil, err
+		}
+		offset += packetLength
+		switch packet[0] {
+		case e57IndexPacket, e57EmptyPacket:
+			continue
+		case e57DataPacket:
+		default:
+			return nil, fmt.Errorf("unknown e57 packet type %d", packet[0])
+		}
+		count := int(binary.LittleEndian.Uint16(packet[4:6]))
+		if count != numStreams {
+			return nil, fmt.Errorf("e57 packet has %d bytestreams, expected %d", count, numStreams)
+		}
+		pos := 6 + 2*count
+		for i := 0; i < count; i++ {
+			length := int(binary.LittleEndian.Uint16(packet[6+2*i:]))
+			if pos+length > len(packet) {
+				return nil, errors.New("e57 bytestream overruns its packet")
+			}
+			streams[i] = append(streams[i], packet[pos:pos+length]...)
+			pos += length
+		}
+	}
+	return streams, nil
+}
+
+// ToE57 writes out a point cloud to an e57 file as a single scan with cartesian coordinates in meters, and colors if any
+// point of the cloud has them.
+func ToE57(cloud PointCloud, out io.Writer) error {
+	meta := cloud.MetaData()
+	var xs, ys, zs []float64
+	var colors [3][]byte
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		xs = append(xs, p.X/1000.)
+		ys = append(ys, p.Y/1000.)
+		zs = append(zs, p.Z/1000.)
+		if meta.HasColor {
+			r, g, b := uint8(255), uint8(255), uint8(255)
+			if d != nil && d.HasColor() {
+				r, g, b = d.RGB255()
+			}
+			colors[0] = append(colors[0], r)
+			colors[1] = append(colors[1], g)
+			colors[2] = append(colors[2], b)
+		}
+		return true
+	})
+
+	var prototype strings.Builder
+	for i, coords := range [][]float64{xs, ys, zs} {
+		lo, hi := 0., 0.
+		for j, v := range coords {
+			if j == 0 || v < lo {
+				lo = v
+			}
+			if j == 0 || v > hi {
+				hi = v
+			}
+		}
+		fmt.Fprintf(&prototype, "<cartesian%c type=\"Float\" minimum=\"%s\" maximum=\"%s\"/>\n", 'X'+i,
+			strconv.FormatFloat(lo, 'g', -1, 64), strconv.FormatFloat(hi, 'g', -1, 64))
+	}
+	if meta.HasColor {
+		for _, channel := range []string{"Red", "Green", "Blue"} {
+			fmt.Fprintf(&prototype, "<color%s type=\"Integer\" minimum=\"0\" maximum=\"255\"/>\n", channel)
+		}
+	}
+
+	var packets [][][]byte
+	for start := 0; start < len(xs); start += e57PointsPerPacket {
+		end := min(start+e57PointsPerPacket, len(xs))
+		var streams [][]byte
+		for _, coords := range [][]float64{xs[start:end], ys[start:end], zs[start:end]} {
+			stream := make([]byte, 0, 8*len(coords))
+			for _, v := range coords {
+				stream = binary.LittleEndian.AppendUint64(stream, math.Float64bits(v))
+			}
+			streams = append(streams, stream)
+		}
+		if meta.HasColor {
+			// 8 bit integers pack into one byte each
+			streams = append(streams, colors[0][start:end], colors[1][start:end], colors[2][start:end])
+		}
+		packets = append(packets, streams)
+	}
+	return writeE57(out, len(xs), prototype.String(), "", packets)
+}
+
+// writeE57 writes an e57 file of a single scan. The prototype and pose are the xml of the fields of the points and of the
+// pose of the scan, and the packets are the bytestreams of each field, split into data packets.
+func writeE57(out io.Writer, count int, prototype, pose string, packets [][][]byte) error {
+	// the logical file is the header, then the binary section of the points, then the xml
+	logical := make([]byte, e57HeaderSize)
+	sectionStart := uint64(len(logical))
+	logical = append(logical, make([]byte, 32)...)
+	dataStart := uint64(len(logical))
+	for _, streams := range packets {
+		packet := []byte{e57DataPacket, 0, 0, 0}
+		packet = binary.LittleEndian.AppendUint16(packet, uint16(len(streams)))
+		for _, stream := range streams {
+			packet = binary.LittleEndian.AppendUint16(packet, uint16(len(stream)))
+		}
+		for _, stream := range streams {
+			packet = append(packet, stream...)
+		}
+		for len(packet)%4 != 0 {
+			packet = append(packet, 0)
+		}
+		if len(packet) > e57MaxPacketSize {
+			return fmt.Errorf("e57 packet of %d bytes is too large", len(packet))
+		}
+		binary.LittleEndian.PutUint16(packet[2:4], uint16(len(packet)-1))
+		logical = append(logical, packet...)
+	}
+	sectionHeader := logical[sectionStart : sectionStart+32]
+	sectionHeader[0] = e57CompressedVectorSectionID
+	binary.LittleEndian.PutUint64(sectionHeader[8:16], uint64(len(logical))-sectionStart)
+	binary.LittleEndian.PutUint64(sectionHeader[16:24], e57LogicalToPhysical(dataStart))
+
+	var xmlData bytes.Buffer
+	xmlData.WriteString(xml.Header)
+	fmt.Fprintf(&xmlData, "<e57Root type=\"Structure\" xmlns=\"%s\">\n", e57Namespace)
+	xmlData.WriteString("<formatName type=\"String\"><![CDATA[ASTM E57 3D Imaging Data File]]></formatName>\n")
+	fmt.Fprintf(&xmlData, "<guid type=\"String\"><![CDATA[{%s}]]></guid>\n", uuid.NewString())
+	xmlData.WriteString("<versionMajor type=\"Integer\">1</versionMajor>\n<versionMinor type=\"Integer\">0</versionMinor>\n")
+	xmlData.WriteString("<coordinateMetadata type=\"String\"/>\n")
+	xmlData.WriteString("<data3D type=\"Vector\" allowHeterogeneousChildren=\"1\">\n<vectorChild type=\"Structure\">\n")
+	fmt.Fprintf(&xmlData, "<guid type=\"String\"><![CDATA[{%s}]]></guid>\n", uuid.NewString())
+	xmlData.WriteString(pose)
+	fmt.Fprintf(&xmlData, "<points type=\"CompressedVector\" fileOffset=\"%d\" recordCount=\"%d\">\n<prototype type=\"Structure\">\n",
+		e57LogicalToPhysical(sectionStart), count)
+	xmlData.WriteString(prototype)
+	xmlData.WriteString("</prototype>\n<codecs type=\"Vector\" allowHeterogeneousChildren=\"1\"/>\n</points>\n")
+	xmlData.WriteString("</vectorChild>\n</data3D>\n<images2D type=\"Vector\" allowHeterogeneousChildren=\"1\"/>\n</e57Root>\n")
+	xmlStart := uint64(len(logical))
+	logical = append(logical, xmlData.Bytes()...)
+
+	numPages := (len(logical) + e57LogicalPageSize - 1) / e57LogicalPageSize
+	logical = append(logical, make([]byte, numPages*e57LogicalPageSize-len(logical))...)
+	header := logical[:e57HeaderSize]
+	copy(header, e57SignatureString)
+	binary.LittleEndian.PutUint32(header[8:12], 1)
+	binary.LittleEndian.PutUint32(header[12:16], 0)
+	binary.LittleEndian.PutUint64(header[16:24], uint64(numPages*e57PageSize))
+	binary.LittleEndian.PutUint64(header[24:32], e57LogicalToPhysical(xmlStart))
+	binary.LittleEndian.PutUint64(header[32:40], uint64(xmlData.Len()))
+	binary.LittleEndian.PutUint64(header[40:48], e57PageSize)
+
+	physical := make([]byte, 0, numPages*e57PageSize)
+	for page := 0; page < numPages; page++ {
+		logicalPage := logical[page*e57LogicalPageSize : (page+1)*e57LogicalPageSize]
+		physical = append(physical, logicalPage...)
+		physical = binary.BigEndian.AppendUint32(physical, crc32.Checksum(logicalPage, e57CRC))
+	}
+	_, err := out.Write(physical)
+	return err
+}
@@ -0,0 +1,82 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestE57(t *testing.T) {
+	t.Run("round trip across pages and packets", func(t *testing.T) {
+		cloud := newBigPC()
+		var buf bytes.Buffer
+		test.That(t, ToE57(cloud, &buf), test.ShouldBeNil)
+		test.That(t, buf.Len()%e57PageSize, test.ShouldEqual, 0)
+		test.That(t, cloud.Size(), test.ShouldBeGreaterThan, 2*e57PointsPerPacket)
+
+		cloud2, err := ReadE57(bytes.NewReader(buf.Bytes()), "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cloud2.Size(), test.ShouldEqual, cloud.Size())
+		d, ok := cloud2.At(50, 10, 33)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 1, 2})
+
+		// corrupting a byte fails the page checksum
+		raw := buf.Bytes()
+		raw[3000]++
+		_, err = ReadE57(bytes.NewReader(raw), "")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "checksum")
+	})
+
+	t.Run("scaled integers, spherical coordinates and pose", func(t *testing.T) {
+		// two points at a range of 1m and 2m, on the x and y axes, with 12 bit intensities
+		prototype := `<sphericalRange type="ScaledInteger" minimum="0" maximum="4000" scale="0.001" offset="0"/>
+<sphericalAzimuth type="Float" precision="single"/>
+<sphericalElevation type="Float"/>
+<intensity type="Integer" minimum="0" maximum="4095"/>
+`
+		pose := `<pose type="Structure">
+<rotation type="Structure"><w type="Float">1</w><x type="Float">0</x><y type="Float">0</y><z type="Float">0</z></rotation>
+<translation type="Structure"><x type="Float">0</x><y type="Float">0</y><z type="Float">0.5</z></translation>
+</pose>
+`
+		// 12 bit ranges of 1000 and 2000, packed least significant bit first into 3 bytes
+		packBits := func(a, b uint32) []byte {
+			v := a | b<<12
+			return []byte{byte(v), byte(v >> 8), byte(v >> 16)}
+		}
+		azimuth := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0))
+		azimuth = binary.LittleEndian.AppendUint32(azimuth, math.Float32bits(math.Pi/2))
+		elevation := make([]byte, 16)
+		streams := [][]byte{packBits(1000, 2000), azimuth, elevation, packBits(4095, 0)}
+
+		var buf bytes.Buffer
+		test.That(t, writeE57(&buf, 2, prototype, pose, [][][]byte{streams}), test.ShouldBeNil)
+		pc, err := ReadE57(&buf, KDTreeType)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		d, ok := pc.At(1000, 0, 500)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, math.MaxUint16)
+		d, ok = pc.At(0, 2000, 500)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 0)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := ReadE57(bytes.NewReader([]byte("ASTM-E58")), "")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		var buf bytes.Buffer
+		prototype := `<cartesianX type="Float"/><cartesianY type="Float"/><cartesianZ type="Float"/>`
+		test.That(t, writeE57(&buf, 1, prototype, "", [][][]byte{{make([]byte, 8), make([]byte, 8)}}), test.ShouldBeNil)
+		_, err = ReadE57(&buf, "")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "bytestreams")
+	})
+}
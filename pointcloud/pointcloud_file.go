@@ -16,6 +16,8 @@ import (
 	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
 	lzf "github.com/zhuyie/golzf"
+	"go.uber.org/multierr"
+	"go.viam.com/utils"
 	"gonum.org/v1/gonum/num/quat"
 
 	"go.viam.com/rdk/spatialmath"
@@ -40,18 +42,57 @@ func NewFromFile(filename, pcStructureType string) (PointCloud, error) {
 		return nil, err
 	}
 
-	switch filepath.Ext(filename) {
+	var read func(io.Reader, TypeConfig) (PointCloud, error)
+	switch strings.ToLower(filepath.Ext(filename)) {
 	case ".las":
 		return newFromLASFile(filename, cfg)
 	case ".pcd":
-		f, err := os.Open(filepath.Clean(filename))
-		if err != nil {
-			return nil, err
-		}
-		return readPCD(f, cfg)
+		read = readPCD
+	case ".ply":
+		read = readPLY
+	case ".xyz", ".csv", ".pts", ".txt":
+		read = readXYZ
+	case ".e57":
+		read = readE57
 	default:
 		return nil, errors.Errorf("do not know how to read file %q", filename)
 	}
+	f, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer utils.UncheckedErrorFunc(f.Close)
+	return read(f, cfg)
+}
+
+// WriteToFile writes a point cloud to a file in the format given by its extension. PCD and PLY files are written in
+// binary and CSV files are comma separated.
+func WriteToFile(cloud PointCloud, filename string) (err error) {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".las":
+		return writeToLASFile(cloud, filename)
+	case ".pcd":
+		write = func(out io.Writer) error { return ToPCD(cloud, out, PCDBinary) }
+	case ".ply":
+		write = func(out io.Writer) error { return ToPLY(cloud, out, PLYBinary) }
+	case ".xyz", ".pts", ".txt":
+		write = func(out io.Writer) error { return ToXYZ(cloud, out, ' ') }
+	case ".csv":
+		write = func(out io.Writer) error { return ToXYZ(cloud, out, ',') }
+	case ".e57":
+		write = func(out io.Writer) error { return ToE57(cloud, out) }
+	default:
+		return errors.Errorf("do not know how to write file %q", filename)
+	}
+	f, err := os.Create(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Combine(err, f.Close())
+	}()
+	return write(f)
 }
 
 func _colorToPCDInt(pt Data) int {
@@ -638,3 +638,42 @@ func BenchmarkPCDCompressedRead(b *testing.B) {
 		test.That(b, err, test.ShouldBeNil)
 	}
 }
+
+func TestFileFormatsRoundTrip(t *testing.T) {
+	cloud := NewBasicPointCloud(0)
+	test.That(t, cloud.Set(NewVector(-1, -2, 5), NewColoredData(color.NRGBA{255, 1, 2, 255}).SetValue(5).SetNormal(r3.Vector{Z: 1})),
+		test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(582, 12, 0), NewColoredData(color.NRGBA{0, 128, 2, 255}).SetValue(-1).SetNormal(r3.Vector{X: 1})),
+		test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(7.5, 6, 1), NewColoredData(color.NRGBA{3, 4, 255, 255}).SetValue(1).SetNormal(r3.Vector{Y: -1})),
+		test.ShouldBeNil)
+
+	for _, ext := range []string{".pcd", ".ply", ".xyz", ".csv", ".e57"} {
+		for _, pcType := range []string{BasicType, KDTreeType, BasicOctreeType} {
+			t.Run(ext+" "+pcType, func(t *testing.T) {
+				filename := t.TempDir() + "/cloud" + ext
+				test.That(t, WriteToFile(cloud, filename), test.ShouldBeNil)
+				cloud2, err := NewFromFile(filename, pcType)
+				test.That(t, err, test.ShouldBeNil)
+				test.That(t, cloud2.Size(), test.ShouldEqual, cloud.Size())
+				test.That(t, cloud2.MetaData().HasColor, test.ShouldBeTrue)
+
+				cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+					d2, ok := cloud2.At(p.X, p.Y, p.Z)
+					test.That(t, ok, test.ShouldBeTrue)
+					test.That(t, d2.Color(), test.ShouldResemble, d.Color())
+					// only some formats have normals and values
+					if ext == ".ply" || ext == ".xyz" || ext == ".csv" {
+						test.That(t, d2.Normal(), test.ShouldResemble, d.Normal())
+						test.That(t, d2.Value(), test.ShouldEqual, d.Value())
+					}
+					return true
+				})
+			})
+		}
+	}
+
+	_, err := NewFromFile(t.TempDir()+"/cloud.obj", "")
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, WriteToFile(cloud, t.TempDir()+"/cloud.obj"), test.ShouldNotBeNil)
+}
@@ -0,0 +1,374 @@
+package pointcloud
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// PLYType is the format of a ply file.
+type PLYType int
+
+const (
+	// PLYAscii ascii format for ply.
+	PLYAscii PLYType = 0
+	// PLYBinary little endian binary format for ply.
+	PLYBinary PLYType = 1
+)
+
+type plyProperty struct {
+	name      string
+	valType   string
+	countType string // only set for list properties
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+type plyHeader struct {
+	format   string
+	order    binary.ByteOrder
+	elements []plyElement
+}
+
+// plyTypeSizes are the sizes in bytes of the scalar types of the ply format, by both of their names.
+var plyTypeSizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4,
+	"float": 4, "float32": 4, "double": 8, "float64": 8,
+}
+
+func parsePLYHeader(in *bufio.Reader) (*plyHeader, error) {
+	line, err := in.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(line) != "ply" {
+		return nil, errors.New("ply file must start with \"ply\"")
+	}
+	header := &plyHeader{}
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrap(err, "ply header must end with \"end_header\"")
+		}
+		tokens := strings.Fields(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		switch tokens[0] {
+		case "format":
+			if len(tokens) != 3 {
+				return nil, fmt.Errorf("invalid ply format line %q", line)
+			}
+			header.format = tokens[1]
+			switch header.format {
+			case "ascii":
+			case "binary_little_endian":
+				header.order = binary.LittleEndian
+			case "binary_big_endian":
+				header.order = binary.BigEndian
+			default:
+				return nil, fmt.Errorf("unsupported ply format %q", header.format)
+			}
+		case "comment", "obj_info":
+		case "element":
+			if len(tokens) != 3 {
+				return nil, fmt.Errorf("invalid ply element line %q", line)
+			}
+			count, err := strconv.Atoi(tokens[2])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("invalid ply element count %q", tokens[2])
+			}
+			header.elements = append(header.elements, plyElement{name: tokens[1], count: count})
+		case "property":
+			if len(header.elements) == 0 {
+				return nil, errors.New("ply property must belong to an element")
+			}
+			var prop plyProperty
+			switch {
+			case len(tokens) == 3:
+				prop = plyProperty{name: tokens[2], valType: tokens[1]}
+			case len(tokens) == 5 && tokens[1] == "list":
+				prop = plyProperty{name: tokens[4], valType: tokens[3], countType: tokens[2]}
+				if _, ok := plyTypeSizes[prop.countType]; !ok {
+					return nil, fmt.Errorf("unsupported ply type %q", prop.countType)
+				}
+			default:
+				return nil, fmt.Errorf("invalid ply property line %q", line)
+			}
+			if _, ok := plyTypeSizes[prop.valType]; !ok {
+				return nil, fmt.Errorf("unsupported ply type %q", prop.valType)
+			}
+			element := &header.elements[len(header.elements)-1]
+			element.properties = append(element.properties, prop)
+		case "end_header":
+			if header.format == "" {
+				return nil, errors.New("ply header is missing its format")
+			}
+			return header, nil
+		default:
+			return nil, fmt.Errorf("unknown ply header line %q", line)
+		}
+	}
+}
+
+// plyValueReader reads the values of elements one at a time from the body of a ply file.
+type plyValueReader struct {
+	in     *bufio.Reader
+	order  binary.ByteOrder // nil for ascii
+	tokens []string
+	buf    [8]byte
+}
+
+func (r *plyValueReader) next(valType string) (float64, error) {
+	if r.order == nil {
+		for len(r.tokens) == 0 {
+			line, err := r.in.ReadString('\n')
+			if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+				return 0, err
+			}
+			r.tokens = strings.Fields(line)
+		}
+		token := r.tokens[0]
+		r.tokens = r.tokens[1:]
+		return strconv.ParseFloat(token, 64)
+	}
+
+	buf := r.buf[:plyTypeSizes[valType]]
+	if _, err := io.ReadFull(r.in, buf); err != nil {
+		return 0, err
+	}
+	switch valType {
+	case "char", "int8":
+		return float64(int8(buf[0])), nil
+	case "uchar", "uint8":
+		return float64(buf[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(buf))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(buf)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(buf))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(buf)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(buf))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(buf)), nil
+	}
+}
+
+// ReadPLY reads an ascii or binary ply file. Only the vertices are read, whose positions are in meters, along with their
+// normals, colors, intensities and values if present.
+func ReadPLY(inRaw io.Reader, pcStructureType string) (PointCloud, error) {
+	cfg, err := Find(pcStructureType)
+	if err != nil {
+		return nil, err
+	}
+	return readPLY(inRaw, cfg)
+}
+
+func readPLY(inRaw io.Reader, cfg TypeConfig) (PointCloud, error) {
+	in := bufio.NewReader(inRaw)
+	header, err := parsePLYHeader(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var pc PointCloud
+	reader := &plyValueReader{in: in, order: header.order}
+	for _, element := range header.elements {
+		if element.name == "vertex" {
+			pc = cfg.NewWithParams(element.count)
+		}
+		values := map[string]float64{}
+		for i := 0; i < element.count; i++ {
+			for _, prop := range element.properties {
+				if prop.countType != "" {
+					count, err := reader.next(prop.countType)
+					if err != nil {
+						return nil, errors.Wrapf(err, "error reading ply %s %d", element.name, i)
+					}
+					for j := 0; j < int(count); j++ {
+						if _, err := reader.next(prop.valType); err != nil {
+							return nil, errors.Wrapf(err, "error reading ply %s %d", element.name, i)
+						}
+					}
+					continue
+				}
+				v, err := reader.next(prop.valType)
+				if err != nil {
+					return nil, errors.Wrapf(err, "error reading ply %s %d", element.name, i)
+				}
+				values[prop.name] = v
+			}
+			if element.name != "vertex" {
+				continue
+			}
+			p, d, err := plyVertexToPoint(element, values)
+			if err != nil {
+				return nil, err
+			}
+			if err := pc.Set(p, d); err != nil {
+				return nil, err
+			}
+		}
+		// only the vertices are needed, so the rest of the file is left unread
+		if element.name == "vertex" {
+			break
+		}
+	}
+	if pc == nil {
+		return nil, errors.New("ply file has no vertex element")
+	}
+	return pc.FinalizeAfterReading()
+}
+
+func plyVertexToPoint(element plyElement, values map[string]float64) (r3.Vector, Data, error) {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := values[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	if !has("x", "y", "z") {
+		return r3.Vector{}, nil, errors.New("ply vertices must have x, y and z properties")
+	}
+	d := NewBasicData()
+	if has("nx", "ny", "nz") {
+		d.SetNormal(r3.Vector{X: values["nx"], Y: values["ny"], Z: values["nz"]})
+	}
+	for _, names := range [][]string{{"red", "green", "blue"}, {"r", "g", "b"}, {"diffuse_red", "diffuse_green", "diffuse_blue"}} {
+		if !has(names...) {
+			continue
+		}
+		var rgb [3]uint8
+		for i, name := range names {
+			v := values[name]
+			for _, prop := range element.properties {
+				// floating point colors are in [0, 1]
+				if prop.name == name && (prop.valType == "float" || prop.valType == "float32" ||
+					prop.valType == "double" || prop.valType == "float64") {
+					v *= 255
+				}
+			}
+			rgb[i] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+		}
+		d.SetColor(color.NRGBA{rgb[0], rgb[1], rgb[2], 255})
+		break
+	}
+	for _, name := range []string{"intensity", "scalar_intensity"} {
+		if v, ok := values[name]; ok {
+			d.SetIntensity(uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(v)))))
+			break
+		}
+	}
+	if v, ok := values["value"]; ok {
+		d.SetValue(int(v))
+	}
+	return r3.Vector{X: metersToMM(values["x"]), Y: metersToMM(values["y"]), Z: metersToMM(values["z"])}, d, nil
+}
+
+// metersToMM converts a coordinate from meters in a file to millimeters, rounded to a micrometer so that coordinates
+// round trip exactly.
+func metersToMM(v float64) float64 {
+	return math.Round(v*1e6) / 1e3
+}
+
+// ToPLY writes out a point cloud to a PLY file of the specified type, with positions in meters. Normals, colors and values
+// are written if any point of the cloud has them.
+func ToPLY(cloud PointCloud, out io.Writer, outputType PLYType) error {
+	meta := cloud.MetaData()
+	var header strings.Builder
+	header.WriteString("ply\n")
+	switch outputType {
+	case PLYAscii:
+		header.WriteString("format ascii 1.0\n")
+	case PLYBinary:
+		header.WriteString("format binary_little_endian 1.0\n")
+	default:
+		return fmt.Errorf("unsupported ply type %v", outputType)
+	}
+	fmt.Fprintf(&header, "element vertex %d\n", cloud.Size())
+	header.WriteString("property double x\nproperty double y\nproperty double z\n")
+	if meta.HasNormal {
+		header.WriteString("property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	if meta.HasColor {
+		header.WriteString("property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	if meta.HasValue {
+		header.WriteString("property int value\n")
+	}
+	header.WriteString("end_header\n")
+
+	w := bufio.NewWriter(out)
+	if _, err := w.WriteString(header.String()); err != nil {
+		return err
+	}
+	var err error
+	buf := make([]byte, 0, 3*8+3*4+3+4)
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		var n r3.Vector
+		r, g, b := uint8(255), uint8(255), uint8(255)
+		value := 0
+		if d != nil {
+			n = d.Normal()
+			if d.HasColor() {
+				r, g, b = d.RGB255()
+			}
+			value = d.Value()
+		}
+		if outputType == PLYAscii {
+			line := fmt.Sprintf("%f %f %f", p.X/1000., p.Y/1000., p.Z/1000.)
+			if meta.HasNormal {
+				line += fmt.Sprintf(" %f %f %f", n.X, n.Y, n.Z)
+			}
+			if meta.HasColor {
+				line += fmt.Sprintf(" %d %d %d", r, g, b)
+			}
+			if meta.HasValue {
+				line += fmt.Sprintf(" %d", value)
+			}
+			_, err = w.WriteString(line + "\n")
+			return err == nil
+		}
+
+		buf = buf[:0]
+		for _, v := range []float64{p.X / 1000., p.Y / 1000., p.Z / 1000.} {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+		if meta.HasNormal {
+			for _, v := range []float64{n.X, n.Y, n.Z} {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+			}
+		}
+		if meta.HasColor {
+			buf = append(buf, r, g, b)
+		}
+		if meta.HasValue {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(int32(value)))
+		}
+		_, err = w.Write(buf)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,113 @@
+package pointcloud
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestReadPLY(t *testing.T) {
+	t.Run("ascii with normals and faces", func(t *testing.T) {
+		ply := "ply\n" +
+			"format ascii 1.0\n" +
+			"comment made by hand\n" +
+			"element vertex 3\n" +
+			"property float x\nproperty float y\nproperty float z\n" +
+			"property float nx\nproperty float ny\nproperty float nz\n" +
+			"property uchar intensity\n" +
+			"element face 1\n" +
+			"property list uchar int vertex_indices\n" +
+			"end_header\n" +
+			"0 0 0 0 0 2 7\n" +
+			"0.001 0 0 0 0 1 8\n" +
+			"0 0.002 0 0 0 1 9\n" +
+			"3 0 1 2\n"
+		pc, err := ReadPLY(strings.NewReader(ply), "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 3)
+		test.That(t, pc.MetaData().HasNormal, test.ShouldBeTrue)
+		test.That(t, pc.MetaData().HasColor, test.ShouldBeFalse)
+		d, ok := pc.At(0, 2, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 9)
+		d, ok = pc.At(0, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		// normals are normalized
+		test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+	})
+
+	t.Run("big endian with float colors", func(t *testing.T) {
+		var buf bytes.Buffer
+		buf.WriteString("ply\nformat binary_big_endian 1.0\nelement vertex 2\n" +
+			"property double x\nproperty double y\nproperty double z\n" +
+			"property float red\nproperty float green\nproperty float blue\n" +
+			"property short value\nend_header\n")
+		for _, v := range [][7]float64{{0.5, 0, 0, 1, 0, 0, -3}, {0, 0.25, 0, 0, 0.5, 1, 4}} {
+			for _, f := range v[:3] {
+				test.That(t, binary.Write(&buf, binary.BigEndian, f), test.ShouldBeNil)
+			}
+			for _, f := range v[3:6] {
+				test.That(t, binary.Write(&buf, binary.BigEndian, float32(f)), test.ShouldBeNil)
+			}
+			test.That(t, binary.Write(&buf, binary.BigEndian, int16(v[6])), test.ShouldBeNil)
+		}
+		pc, err := ReadPLY(&buf, KDTreeType)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		d, ok := pc.At(500, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{255, 0, 0})
+		test.That(t, d.Value(), test.ShouldEqual, -3)
+		d, ok = pc.At(0, 250, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b = d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{0, 128, 255})
+		test.That(t, d.Value(), test.ShouldEqual, 4)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, ply := range []string{
+			"pcd\n",
+			"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\n",
+			"ply\nformat binary_middle_endian 1.0\nend_header\n",
+			"ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\nend_header\n",
+			"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n1\n",
+			"ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n1 2 3\n",
+			"ply\nformat ascii 1.0\nelement face 0\nproperty list uchar int vertex_indices\nend_header\n",
+		} {
+			_, err := ReadPLY(strings.NewReader(ply), "")
+			test.That(t, err, test.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestToPLY(t *testing.T) {
+	cloud := NewBasicPointCloud(0)
+	test.That(t, cloud.Set(NewVector(-1, -2, 5), NewBasicData().SetNormal(r3.Vector{X: 3, Y: 4})), test.ShouldBeNil)
+	test.That(t, cloud.Set(NewVector(582, 12, 0), NewBasicData()), test.ShouldBeNil)
+
+	var buf bytes.Buffer
+	test.That(t, ToPLY(cloud, &buf, PLYAscii), test.ShouldBeNil)
+	got := buf.String()
+	test.That(t, got, test.ShouldStartWith, "ply\nformat ascii 1.0\nelement vertex 2\n")
+	test.That(t, got, test.ShouldContainSubstring, "property float nx\n")
+	test.That(t, got, test.ShouldNotContainSubstring, "property uchar red\n")
+	test.That(t, got, test.ShouldContainSubstring, "-0.001000 -0.002000 0.005000 0.600000 0.800000 0.000000\n")
+
+	for _, plyType := range []PLYType{PLYAscii, PLYBinary} {
+		buf.Reset()
+		test.That(t, ToPLY(cloud, &buf, plyType), test.ShouldBeNil)
+		cloud2, err := ReadPLY(&buf, "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cloud2.Size(), test.ShouldEqual, 2)
+		d, ok := cloud2.At(-1, -2, 5)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Normal().Distance(r3.Vector{X: 0.6, Y: 0.8}), test.ShouldBeLessThan, 1e-6)
+		test.That(t, CloudContains(cloud2, 582, 12, 0), test.ShouldBeTrue)
+	}
+}
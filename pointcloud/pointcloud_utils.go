@@ -90,3 +90,64 @@ func StatisticalOutlierFilter(meanK int, stdDevThresh float64) (func(in, out Poi
 	}
 	return filterFunc, nil
 }
+
+// CropBoxFilter returns a function that keeps only the points of a point cloud which are within the axis aligned box
+// from minPt to maxPt, inclusive.
+func CropBoxFilter(minPt, maxPt r3.Vector) (func(in, out PointCloud) error, error) {
+	if minPt.X > maxPt.X || minPt.Y > maxPt.Y || minPt.Z > maxPt.Z {
+		return nil, errors.Errorf("crop box minimum %v must not be greater than its maximum %v", minPt, maxPt)
+	}
+	filterFunc := func(pc, filteredCloud PointCloud) error {
+		var err error
+		pc.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			if p.X < minPt.X || p.Y < minPt.Y || p.Z < minPt.Z || p.X > maxPt.X || p.Y > maxPt.Y || p.Z > maxPt.Z {
+				return true
+			}
+			err = filteredCloud.Set(p, d)
+			return err == nil
+		})
+		return err
+	}
+	return filterFunc, nil
+}
+
+// VoxelDownsampleFilter returns a function that replaces the points of a point cloud which fall in the same voxel of
+// side length voxelSize with their centroid, which keeps the data of the first point in the voxel.
+func VoxelDownsampleFilter(voxelSize float64) (func(in, out PointCloud) error, error) {
+	if voxelSize <= 0 {
+		return nil, errors.Errorf("argument voxelSize must be a positive float, got %.2f", voxelSize)
+	}
+	type voxelSum struct {
+		sum r3.Vector
+		n   float64
+		d   Data
+	}
+	filterFunc := func(pc, filteredCloud PointCloud) error {
+		voxels := map[VoxelCoords]*voxelSum{}
+		order := make([]VoxelCoords, 0)
+		pc.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+			c := VoxelCoords{
+				I: int64(math.Floor(p.X / voxelSize)),
+				J: int64(math.Floor(p.Y / voxelSize)),
+				K: int64(math.Floor(p.Z / voxelSize)),
+			}
+			v, ok := voxels[c]
+			if !ok {
+				v = &voxelSum{d: d}
+				voxels[c] = v
+				order = append(order, c)
+			}
+			v.sum = v.sum.Add(p)
+			v.n++
+			return true
+		})
+		for _, c := range order {
+			v := voxels[c]
+			if err := filteredCloud.Set(v.sum.Mul(1/v.n), v.d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return filterFunc, nil
+}
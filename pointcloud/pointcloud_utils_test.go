@@ -93,3 +93,34 @@ func TestToOctree(t *testing.T) {
 		return true
 	})
 }
+
+func TestCropBoxFilter(t *testing.T) {
+	_, err := CropBoxFilter(r3.Vector{X: 1}, r3.Vector{})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	filter, err := CropBoxFilter(r3.Vector{X: 20, Y: 20, Z: 20}, r3.Vector{X: 29, Y: 29, Z: 29})
+	test.That(t, err, test.ShouldBeNil)
+	filtered := NewBasicEmpty()
+	test.That(t, filter(newBigPC(), filtered), test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 10*10*10)
+	d, ok := filtered.At(20, 29, 25)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 5)
+	_, ok = filtered.At(30, 25, 25)
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestVoxelDownsampleFilter(t *testing.T) {
+	_, err := VoxelDownsampleFilter(0)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	filter, err := VoxelDownsampleFilter(10)
+	test.That(t, err, test.ShouldBeNil)
+	filtered := NewBasicEmpty()
+	// the points from 10 to 50 fall in voxels 1 to 4 with 10 points per axis, and voxel 5 with one
+	test.That(t, filter(newBigPC(), filtered), test.ShouldBeNil)
+	test.That(t, filtered.Size(), test.ShouldEqual, 5*5*5)
+	d, ok := filtered.At(14.5, 14.5, 50)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.Value(), test.ShouldEqual, 5)
+}
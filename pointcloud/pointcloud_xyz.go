@@ -0,0 +1,210 @@
+package pointcloud
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+)
+
+// xyzColumns are the names an xyz header may use for each column, mapped to the canonical name.
+var xyzColumns = map[string]string{
+	"x": "x", "y": "y", "z": "z",
+	"r": "r", "g": "g", "b": "b", "red": "r", "green": "g", "blue": "b",
+	"nx": "nx", "ny": "ny", "nz": "nz",
+	"i": "i", "intensity": "i",
+	"value": "value",
+}
+
+// xyzDefaultColumns are the columns of an xyz file without a header, by number of columns.
+var xyzDefaultColumns = map[int][]string{
+	3: {"x", "y", "z"},
+	4: {"x", "y", "z", "i"},
+	6: {"x", "y", "z", "r", "g", "b"},
+	9: {"x", "y", "z", "r", "g", "b", "nx", "ny", "nz"},
+}
+
+func splitXYZLine(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == ';'
+	})
+}
+
+// parseXYZHeader returns the canonical names of the columns of a header or comment line, or nil if it does not name
+// columns.
+func parseXYZHeader(line string) []string {
+	tokens := splitXYZLine(strings.TrimLeft(line, "#/"))
+	if len(tokens) < 3 {
+		return nil
+	}
+	columns := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		column, ok := xyzColumns[strings.ToLower(token)]
+		if !ok {
+			return nil
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// ReadXYZ reads a text file with a point per line, with positions in meters. Columns are separated by spaces, tabs,
+// commas or semicolons. A header line such as "x,y,z,red,green,blue" or "# x y z nx ny nz" names the columns, otherwise
+// lines with 3, 4, 6 or 9 columns are read as "x y z", "x y z intensity", "x y z r g b" or "x y z r g b nx ny nz".
+func ReadXYZ(inRaw io.Reader, pcStructureType string) (PointCloud, error) {
+	cfg, err := Find(pcStructureType)
+	if err != nil {
+		return nil, err
+	}
+	return readXYZ(inRaw, cfg)
+}
+
+func readXYZ(inRaw io.Reader, cfg TypeConfig) (PointCloud, error) {
+	scanner := bufio.NewScanner(inRaw)
+	pc := cfg.NewWithParams(0)
+	var columns []string
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			if header := parseXYZHeader(line); header != nil {
+				columns = header
+			}
+			continue
+		}
+		tokens := splitXYZLine(line)
+		if columns == nil {
+			if header := parseXYZHeader(line); header != nil {
+				columns = header
+				continue
+			}
+		}
+		// a line with a single number is the point count of some formats, like pts
+		if len(tokens) == 1 {
+			continue
+		}
+		lineColumns := columns
+		if lineColumns == nil {
+			lineColumns = xyzDefaultColumns[len(tokens)]
+			if lineColumns == nil {
+				return nil, fmt.Errorf("line %d has an unsupported number of columns %d", lineNum, len(tokens))
+			}
+		}
+		if len(tokens) != len(lineColumns) {
+			return nil, fmt.Errorf("line %d has %d columns, expected %d", lineNum, len(tokens), len(lineColumns))
+		}
+		values := make(map[string]float64, len(tokens))
+		for i, token := range tokens {
+			v, err := strconv.ParseFloat(token, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid value on line %d", lineNum)
+			}
+			values[lineColumns[i]] = v
+		}
+		p, d, err := xyzValuesToPoint(values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid point on line %d", lineNum)
+		}
+		if err := pc.Set(p, d); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pc.FinalizeAfterReading()
+}
+
+func xyzValuesToPoint(values map[string]float64) (r3.Vector, Data, error) {
+	x, okX := values["x"]
+	y, okY := values["y"]
+	z, okZ := values["z"]
+	if !okX || !okY || !okZ {
+		return r3.Vector{}, nil, errors.New("x, y and z are required")
+	}
+	d := NewBasicData()
+	if r, ok := values["r"]; ok {
+		toUint8 := func(v float64) uint8 { return uint8(math.Max(0, math.Min(255, math.Round(v)))) }
+		d.SetColor(color.NRGBA{toUint8(r), toUint8(values["g"]), toUint8(values["b"]), 255})
+	}
+	if nx, ok := values["nx"]; ok {
+		d.SetNormal(r3.Vector{X: nx, Y: values["ny"], Z: values["nz"]})
+	}
+	if i, ok := values["i"]; ok {
+		d.SetIntensity(uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(i)))))
+	}
+	if v, ok := values["value"]; ok {
+		d.SetValue(int(v))
+	}
+	return r3.Vector{X: metersToMM(x), Y: metersToMM(y), Z: metersToMM(z)}, d, nil
+}
+
+// ToXYZ writes out a point cloud as text with a point per line, with positions in meters. The columns are separated by
+// the given delimiter and named by a commented header line. Colors, normals and values are written if any point of the
+// cloud has them.
+func ToXYZ(cloud PointCloud, out io.Writer, delimiter rune) error {
+	meta := cloud.MetaData()
+	sep := string(delimiter)
+	columns := []string{"x", "y", "z"}
+	if meta.HasColor {
+		columns = append(columns, "r", "g", "b")
+	}
+	if meta.HasNormal {
+		columns = append(columns, "nx", "ny", "nz")
+	}
+	if meta.HasValue {
+		columns = append(columns, "value")
+	}
+
+	w := bufio.NewWriter(out)
+	if _, err := w.WriteString("# " + strings.Join(columns, sep) + "\n"); err != nil {
+		return err
+	}
+	var err error
+	cloud.Iterate(0, 0, func(p r3.Vector, d Data) bool {
+		fields := []string{
+			strconv.FormatFloat(p.X/1000., 'f', 6, 64),
+			strconv.FormatFloat(p.Y/1000., 'f', 6, 64),
+			strconv.FormatFloat(p.Z/1000., 'f', 6, 64),
+		}
+		if meta.HasColor {
+			r, g, b := uint8(255), uint8(255), uint8(255)
+			if d != nil && d.HasColor() {
+				r, g, b = d.RGB255()
+			}
+			fields = append(fields, strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b)))
+		}
+		if meta.HasNormal {
+			var n r3.Vector
+			if d != nil {
+				n = d.Normal()
+			}
+			for _, v := range []float64{n.X, n.Y, n.Z} {
+				fields = append(fields, strconv.FormatFloat(v, 'f', 6, 64))
+			}
+		}
+		if meta.HasValue {
+			value := 0
+			if d != nil {
+				value = d.Value()
+			}
+			fields = append(fields, strconv.Itoa(value))
+		}
+		_, err = w.WriteString(strings.Join(fields, sep) + "\n")
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,69 @@
+package pointcloud
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+func TestReadXYZ(t *testing.T) {
+	t.Run("csv with header", func(t *testing.T) {
+		pc, err := ReadXYZ(strings.NewReader("X,Y,Z,Red,Green,Blue,Intensity\n0.001,0.002,0.003,10,20,30,400\n1,2,3,0,0,0,0\n"), "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 2)
+		d, ok := pc.At(1, 2, 3)
+		test.That(t, ok, test.ShouldBeTrue)
+		r, g, b := d.RGB255()
+		test.That(t, []uint8{r, g, b}, test.ShouldResemble, []uint8{10, 20, 30})
+		test.That(t, d.Intensity(), test.ShouldEqual, 400)
+		test.That(t, CloudContains(pc, 1000, 2000, 3000), test.ShouldBeTrue)
+	})
+
+	t.Run("columns by count", func(t *testing.T) {
+		pc, err := ReadXYZ(strings.NewReader("3\n0 0 0\n0.001\t0 0 7\n0 0.001 0 255 0 0\n0 0 0.001 0 255 0 0 0 -2\n"), BasicOctreeType)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pc.Size(), test.ShouldEqual, 4)
+		d, ok := pc.At(1, 0, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Intensity(), test.ShouldEqual, 7)
+		d, ok = pc.At(0, 1, 0)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.HasColor(), test.ShouldBeTrue)
+		test.That(t, d.HasNormal(), test.ShouldBeFalse)
+		d, ok = pc.At(0, 0, 1)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: -1})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, xyz := range []string{
+			"0 0\n",
+			"0 0 0 0 0\n",
+			"# x y z\n0 0 0 0\n",
+			"0 0 zero\n",
+			"# nx ny nz\n0 0 1\n",
+		} {
+			_, err := ReadXYZ(strings.NewReader(xyz), "")
+			test.That(t, err, test.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestToXYZ(t *testing.T) {
+	cloud := NewBasicPointCloud(0)
+	test.That(t, cloud.Set(NewVector(-1, -2, 5), NewBasicData().SetNormal(r3.Vector{Z: 1})), test.ShouldBeNil)
+
+	var buf bytes.Buffer
+	test.That(t, ToXYZ(cloud, &buf, ','), test.ShouldBeNil)
+	test.That(t, buf.String(), test.ShouldEqual, "# x,y,z,nx,ny,nz\n-0.001000,-0.002000,0.005000,0.000000,0.000000,1.000000\n")
+
+	cloud2, err := ReadXYZ(&buf, "")
+	test.That(t, err, test.ShouldBeNil)
+	d, ok := cloud2.At(-1, -2, 5)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, d.HasColor(), test.ShouldBeFalse)
+	test.That(t, d.Normal(), test.ShouldResemble, r3.Vector{Z: 1})
+}
@@ -0,0 +1,393 @@
+package pointcloud
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/r3"
+	"gonum.org/v1/gonum/mat"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// RegistrationMethod is the algorithm used to register one point cloud to another.
+type RegistrationMethod string
+
+// The set of registration methods supported by RegisterPointClouds.
+const (
+	// ICPPointToPoint minimizes the distances between source points and their nearest target points.
+	ICPPointToPoint = RegistrationMethod("icp_point_to_point")
+	// ICPPointToPlane minimizes the distances between source points and the planes tangent to the target at their
+	// nearest target points. It converges faster than point to point ICP on surfaces.
+	ICPPointToPlane = RegistrationMethod("icp_point_to_plane")
+	// NDT minimizes the Mahalanobis distances between source points and the normal distributions of the target points
+	// in the cells of a voxel grid. It does not need nearest neighbor searches.
+	NDT = RegistrationMethod("ndt")
+)
+
+const (
+	defaultRegistrationMaxIterations   = 50
+	defaultRegistrationTranslationTol  = 1e-3 // mm
+	defaultRegistrationRotationTol     = 1e-5 // rad
+	defaultRegistrationNormalNeighbors = 10
+	// NDT cells with fewer points than this do not have a meaningful distribution.
+	ndtMinCellPoints = 6
+)
+
+// RegistrationConfig are the parameters of a point cloud registration.
+type RegistrationConfig struct {
+	Method RegistrationMethod
+	// InitialGuess of the pose of the source cloud in the frame of the target cloud. Defaults to the zero pose.
+	InitialGuess spatialmath.Pose
+	// MaxIterations defaults to 50.
+	MaxIterations int
+	// MaxCorrespondenceDistance in mm beyond which a source point is considered to have no corresponding target point.
+	// Zero means every source point has a correspondence.
+	MaxCorrespondenceDistance float64
+	// The registration has converged when an iteration moves the source cloud by less than both of these tolerances.
+	// Defaults to 1e-3 mm and 1e-5 radians.
+	TranslationTolerance float64
+	RotationTolerance    float64
+	// NormalNeighbors is the number of target points used to estimate the normal of point to plane ICP. Defaults to 10.
+	NormalNeighbors int
+	// VoxelSize is the side length in mm of the NDT cells. Required for NDT.
+	VoxelSize float64
+}
+
+// RegistrationResult is the outcome of a point cloud registration.
+type RegistrationResult struct {
+	// Pose of the source cloud in the frame of the target cloud, i.e. ApplyOffset(source, Pose, ...) aligns source with target.
+	Pose spatialmath.Pose
+	// Fitness is the fraction of source points which have a corresponding target point at the final pose.
+	Fitness float64
+	// InlierRMSE is the root mean square distance in mm between corresponding points at the final pose.
+	InlierRMSE float64
+	// Converged is true if the last iteration moved the source cloud by less than the tolerances.
+	Converged  bool
+	Iterations int
+	// TranslationChange and RotationChange are how far the last iteration moved the source cloud, in mm and radians.
+	TranslationChange float64
+	RotationChange    float64
+}
+
+// correspondence is a source point, transformed by the current estimate, matched with a target point and the weight of
+// their difference.
+type correspondence struct {
+	source r3.Vector
+	target r3.Vector
+	info   [3][3]float64
+}
+
+// registrationTarget finds the correspondences of source points for a registration method.
+type registrationTarget interface {
+	correspondence(p r3.Vector) (correspondence, bool)
+}
+
+// RegisterPointClouds estimates the pose of the source cloud in the frame of the target cloud.
+func RegisterPointClouds(source, target PointCloud, cfg RegistrationConfig) (*RegistrationResult, error) {
+	if source == nil || source.Size() == 0 || target == nil || target.Size() == 0 {
+		return nil, errors.New("cannot register empty point clouds")
+	}
+	if cfg.MaxIterations <= 0 {
+		cfg.MaxIterations = defaultRegistrationMaxIterations
+	}
+	if cfg.TranslationTolerance <= 0 {
+		cfg.TranslationTolerance = defaultRegistrationTranslationTol
+	}
+	if cfg.RotationTolerance <= 0 {
+		cfg.RotationTolerance = defaultRegistrationRotationTol
+	}
+	if cfg.NormalNeighbors <= 0 {
+		cfg.NormalNeighbors = defaultRegistrationNormalNeighbors
+	}
+	maxDist := cfg.MaxCorrespondenceDistance
+	if maxDist <= 0 {
+		maxDist = math.Inf(1)
+	}
+
+	var tgt registrationTarget
+	switch cfg.Method {
+	case ICPPointToPoint, ICPPointToPlane:
+		tgt = &icpTarget{
+			tree:      ToKDTree(target),
+			maxDist:   maxDist,
+			toPlane:   cfg.Method == ICPPointToPlane,
+			neighbors: cfg.NormalNeighbors,
+			normals:   map[r3.Vector]r3.Vector{},
+		}
+	case NDT:
+		if cfg.VoxelSize <= 0 {
+			return nil, errors.New("ndt registration needs a positive voxel size")
+		}
+		ndt, err := newNDTTarget(target, cfg.VoxelSize, maxDist)
+		if err != nil {
+			return nil, err
+		}
+		tgt = ndt
+	default:
+		return nil, fmt.Errorf("unknown registration method %q", cfg.Method)
+	}
+
+	points := CloudToPoints(source)
+	pose := cfg.InitialGuess
+	if pose == nil {
+		pose = spatialmath.NewZeroPose()
+	}
+
+	result := &RegistrationResult{}
+	for result.Iterations < cfg.MaxIterations {
+		result.Iterations++
+		correspondences := findCorrespondences(tgt, points, pose)
+		if len(correspondences) < 3 {
+			return nil, fmt.Errorf("registration found only %d correspondences, need at least 3", len(correspondences))
+		}
+		omega, translation, err := solveRegistrationStep(correspondences)
+		if err != nil {
+			return nil, err
+		}
+		delta := spatialmath.NewPoseFromPoint(translation)
+		if omega.Norm() > 0 {
+			delta = spatialmath.NewPose(translation, spatialmath.R3ToR4(omega))
+		}
+		pose = spatialmath.Compose(delta, pose)
+		result.TranslationChange = translation.Norm()
+		result.RotationChange = omega.Norm()
+		if result.TranslationChange < cfg.TranslationTolerance && result.RotationChange < cfg.RotationTolerance {
+			result.Converged = true
+			break
+		}
+	}
+
+	result.Pose = pose
+	correspondences := findCorrespondences(tgt, points, pose)
+	result.Fitness = float64(len(correspondences)) / float64(len(points))
+	if len(correspondences) > 0 {
+		sum := 0.
+		for _, c := range correspondences {
+			sum += c.source.Sub(c.target).Norm2()
+		}
+		result.InlierRMSE = math.Sqrt(sum / float64(len(correspondences)))
+	}
+	return result, nil
+}
+
+func findCorrespondences(tgt registrationTarget, points []r3.Vector, pose spatialmath.Pose) []correspondence {
+	// transform the unit vectors once rather than composing a pose for every point
+	origin := pose.Point()
+	rx := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{X: 1})).Point().Sub(origin)
+	ry := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{Y: 1})).Point().Sub(origin)
+	rz := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(r3.Vector{Z: 1})).Point().Sub(origin)
+
+	correspondences := make([]correspondence, 0, len(points))
+	for _, p := range points {
+		transformed := rx.Mul(p.X).Add(ry.Mul(p.Y)).Add(rz.Mul(p.Z)).Add(origin)
+		if c, ok := tgt.correspondence(transformed); ok {
+			correspondences = append(correspondences, c)
+		}
+	}
+	return correspondences
+}
+
+// solveRegistrationStep solves for the small rotation and translation which minimize the weighted distances between
+// corresponding points with one Gauss-Newton step. The source points are moved by x' = x + omega × x + translation.
+func solveRegistrationStep(correspondences []correspondence) (r3.Vector, r3.Vector, error) {
+	hessian := mat.NewSymDense(6, nil)
+	gradient := mat.NewVecDense(6, nil)
+	for _, c := range correspondences {
+		x := c.source
+		// jacobian of the moved point with respect to (omega, translation)
+		jac := [3][6]float64{
+			{0, x.Z, -x.Y, 1, 0, 0},
+			{-x.Z, 0, x.X, 0, 1, 0},
+			{x.Y, -x.X, 0, 0, 0, 1},
+		}
+		e := c.target.Sub(x)
+		res := [3]float64{e.X, e.Y, e.Z}
+		// weighted jacobian, info * jac
+		var wj [3][6]float64
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 6; j++ {
+				for k := 0; k < 3; k++ {
+					wj[i][j] += c.info[i][k] * jac[k][j]
+				}
+			}
+		}
+		for i := 0; i < 6; i++ {
+			g := 0.
+			for k := 0; k < 3; k++ {
+				g += wj[k][i] * res[k]
+			}
+			gradient.SetVec(i, gradient.AtVec(i)+g)
+			for j := i; j < 6; j++ {
+				h := 0.
+				for k := 0; k < 3; k++ {
+					h += jac[k][i] * wj[k][j]
+				}
+				hessian.SetSym(i, j, hessian.At(i, j)+h)
+			}
+		}
+	}
+
+	// a little damping keeps the step finite when the correspondences do not constrain every direction
+	damping := 1e-9 * (mat.Trace(hessian) + 1)
+	for i := 0; i < 6; i++ {
+		hessian.SetSym(i, i, hessian.At(i, i)+damping)
+	}
+	var step mat.VecDense
+	if err := step.SolveVec(hessian, gradient); err != nil {
+		return r3.Vector{}, r3.Vector{}, fmt.Errorf("registration step could not be solved: %w", err)
+	}
+	return r3.Vector{X: step.AtVec(0), Y: step.AtVec(1), Z: step.AtVec(2)},
+		r3.Vector{X: step.AtVec(3), Y: step.AtVec(4), Z: step.AtVec(5)}, nil
+}
+
+// icpTarget matches source points with their nearest neighbor in the target cloud.
+type icpTarget struct {
+	tree      *KDTree
+	maxDist   float64
+	toPlane   bool
+	neighbors int
+	normals   map[r3.Vector]r3.Vector
+}
+
+func (icp *icpTarget) correspondence(p r3.Vector) (correspondence, bool) {
+	nearest, _, dist, ok := icp.tree.NearestNeighbor(p)
+	if !ok || dist > icp.maxDist {
+		return correspondence{}, false
+	}
+	c := correspondence{source: p, target: nearest}
+	if !icp.toPlane {
+		c.info = [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+		return c, true
+	}
+	n := icp.normal(nearest)
+	if n.Norm2() == 0 {
+		return correspondence{}, false
+	}
+	// only the distance along the normal is penalized
+	nv := [3]float64{n.X, n.Y, n.Z}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			c.info[i][j] = nv[i] * nv[j]
+		}
+	}
+	return c, true
+}
+
+// normal estimates the normal of the target at one of its points from its neighbors, caching the result.
+func (icp *icpTarget) normal(p r3.Vector) r3.Vector {
+	if n, ok := icp.normals[p]; ok {
+		return n
+	}
+	neighbors := icp.tree.KNearestNeighbors(p, icp.neighbors, true)
+	n := r3.Vector{}
+	if len(neighbors) >= 3 {
+		positions := make([]r3.Vector, 0, len(neighbors))
+		for _, neighbor := range neighbors {
+			positions = append(positions, neighbor.P)
+		}
+		n = estimatePlaneNormalFromPoints(positions)
+	}
+	icp.normals[p] = n
+	return n
+}
+
+// ndtCell is the normal distribution of the target points in one voxel.
+type ndtCell struct {
+	mean r3.Vector
+	info [3][3]float64
+}
+
+// ndtTarget matches source points with the distribution of the voxel which is nearest to them in Mahalanobis distance
+// among the voxel containing them and its neighbors.
+type ndtTarget struct {
+	grid    *VoxelGrid
+	cells   map[VoxelCoords]*ndtCell
+	ptMin   r3.Vector
+	maxDist float64
+}
+
+func newNDTTarget(target PointCloud, voxelSize, maxDist float64) (*ndtTarget, error) {
+	grid := NewVoxelGridFromPointCloud(target, voxelSize, 1.0)
+	meta := target.MetaData()
+	ndt := &ndtTarget{
+		grid:    grid,
+		cells:   map[VoxelCoords]*ndtCell{},
+		ptMin:   r3.Vector{X: meta.MinX, Y: meta.MinY, Z: meta.MinZ},
+		maxDist: maxDist,
+	}
+	for coords, vox := range grid.Voxels {
+		if len(vox.Points) < ndtMinCellPoints {
+			continue
+		}
+		cell, ok := newNDTCell(vox.Positions(), vox.Center)
+		if ok {
+			ndt.cells[coords] = cell
+		}
+	}
+	if len(ndt.cells) == 0 {
+		return nil, fmt.Errorf("no voxel of size %v contains at least %d target points", voxelSize, ndtMinCellPoints)
+	}
+	return ndt, nil
+}
+
+func newNDTCell(points []r3.Vector, mean r3.Vector) (*ndtCell, bool) {
+	cov := mat.NewSymDense(3, nil)
+	for _, p := range points {
+		d := [3]float64{p.X - mean.X, p.Y - mean.Y, p.Z - mean.Z}
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				cov.SetSym(i, j, cov.At(i, j)+d[i]*d[j]/float64(len(points)-1))
+			}
+		}
+	}
+	// points on a plane or a line have a singular covariance, so small eigenvalues are inflated as in Magnusson's NDT
+	var eig mat.EigenSym
+	if !eig.Factorize(cov, true) {
+		return nil, false
+	}
+	values := eig.Values(nil)
+	maxValue := values[len(values)-1]
+	if maxValue <= 0 {
+		return nil, false
+	}
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+	cell := &ndtCell{mean: mean}
+	for k, v := range values {
+		inverse := 1 / math.Max(v, 0.01*maxValue)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				cell.info[i][j] += inverse * vectors.At(i, k) * vectors.At(j, k)
+			}
+		}
+	}
+	return cell, true
+}
+
+func (ndt *ndtTarget) correspondence(p r3.Vector) (correspondence, bool) {
+	key := GetVoxelCoordinates(p, ndt.ptMin, ndt.grid.VoxelSize())
+	candidates := append(ndt.grid.GetAdjacentVoxels(&Voxel{Key: key}), key)
+	best := correspondence{}
+	bestDist := math.Inf(1)
+	for _, coords := range candidates {
+		cell, ok := ndt.cells[coords]
+		if !ok {
+			continue
+		}
+		d := p.Sub(cell.mean)
+		dv := [3]float64{d.X, d.Y, d.Z}
+		mahalanobis := 0.
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				mahalanobis += dv[i] * cell.info[i][j] * dv[j]
+			}
+		}
+		if mahalanobis < bestDist && d.Norm() <= ndt.maxDist {
+			bestDist = mahalanobis
+			best = correspondence{source: p, target: cell.mean, info: cell.info}
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
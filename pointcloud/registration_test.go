@@ -0,0 +1,75 @@
+package pointcloud
+
+import (
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// makeCornerCloud makes a cloud of three orthogonal walls, which constrains all six degrees of freedom of a registration.
+func makeCornerCloud(t *testing.T) PointCloud {
+	t.Helper()
+	pc := NewBasicPointCloud(0)
+	for i := 0.; i <= 500; i += 20 {
+		for j := 0.; j <= 500; j += 20 {
+			for _, p := range []r3.Vector{{i, j, 0}, {0, i, j}, {i, 0, j}} {
+				test.That(t, pc.Set(p, nil), test.ShouldBeNil)
+			}
+		}
+	}
+	return pc
+}
+
+func TestRegisterPointClouds(t *testing.T) {
+	target := makeCornerCloud(t)
+	truth := spatialmath.NewPose(r3.Vector{X: 15, Y: -10, Z: 20}, &spatialmath.OrientationVectorDegrees{OX: 0.05, OY: 0.05, OZ: 1, Theta: 4})
+	// the source is the target seen from the inverse of the true pose, so registering it recovers the true pose
+	source := NewBasicPointCloud(0)
+	test.That(t, ApplyOffset(target, spatialmath.PoseInverse(truth), source), test.ShouldBeNil)
+
+	for _, cfg := range []RegistrationConfig{
+		{Method: ICPPointToPoint, MaxIterations: 200},
+		{Method: ICPPointToPlane},
+		{Method: NDT, VoxelSize: 100, MaxIterations: 100},
+	} {
+		t.Run(string(cfg.Method), func(t *testing.T) {
+			result, err := RegisterPointClouds(source, target, cfg)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, result.Converged, test.ShouldBeTrue)
+			test.That(t, spatialmath.PoseAlmostEqualEps(result.Pose, truth, 0.5), test.ShouldBeTrue)
+			test.That(t, result.Fitness, test.ShouldAlmostEqual, 1)
+		})
+	}
+
+	t.Run("max correspondence distance", func(t *testing.T) {
+		result, err := RegisterPointClouds(source, target, RegistrationConfig{
+			Method:                    ICPPointToPlane,
+			InitialGuess:              truth,
+			MaxCorrespondenceDistance: 1,
+		})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, result.Converged, test.ShouldBeTrue)
+		test.That(t, result.Fitness, test.ShouldAlmostEqual, 1)
+		test.That(t, result.InlierRMSE, test.ShouldBeLessThan, 1e-3)
+
+		// far from the truth, most points have no correspondence
+		_, err = RegisterPointClouds(source, target, RegistrationConfig{
+			Method:                    ICPPointToPoint,
+			InitialGuess:              spatialmath.NewPoseFromPoint(r3.Vector{X: 1000}),
+			MaxCorrespondenceDistance: 1,
+		})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := RegisterPointClouds(NewBasicPointCloud(0), target, RegistrationConfig{Method: ICPPointToPoint})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = RegisterPointClouds(source, target, RegistrationConfig{Method: NDT})
+		test.That(t, err, test.ShouldNotBeNil)
+		_, err = RegisterPointClouds(source, target, RegistrationConfig{Method: "gicp"})
+		test.That(t, err, test.ShouldNotBeNil)
+	})
+}
@@ -809,6 +809,16 @@ func framesAlmostEqual(frame1, frame2 Frame, epsilon float64) (bool, error) {
 		default:
 			return framesAlmostEqual(f1.staticFrame, f2.staticFrame, epsilon)
 		}
+	case *mimicFrame:
+		f2 := frame2.(*mimicFrame)
+		switch {
+		case f1.source != f2.source || f1.sourceIndex != f2.sourceIndex:
+			return false, nil
+		case math.Abs(f1.multiplier-f2.multiplier) > epsilon || math.Abs(f1.offset-f2.offset) > epsilon:
+			return false, nil
+		default:
+			return framesAlmostEqual(f1.Frame, f2.Frame, epsilon)
+		}
 	case *SimpleModel:
 		f2 := frame2.(*SimpleModel)
 		ordTransforms1 := f1.OrdTransforms()
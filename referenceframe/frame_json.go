@@ -37,6 +37,7 @@ type JointConfig struct {
 	Max      float64                 `json:"max"`                // in mm or degs
 	Min      float64                 `json:"min"`                // in mm or degs
 	Geometry *spatial.GeometryConfig `json:"geometry,omitempty"` // only valid for prismatic/translational joints
+	Mimic    *MimicConfig            `json:"mimic,omitempty"`
 }
 
 // DHParamConfig is a revolute and static frame combined in a set of Denavit Hartenberg parameters.
@@ -127,16 +128,34 @@ func (cfg *LinkConfig) Pose() (spatial.Pose, error) {
 
 // ToFrame converts a JointConfig into a joint frame.
 func (cfg *JointConfig) ToFrame() (Frame, error) {
+	var frame Frame
+	var err error
+	offset := 0.
 	switch cfg.Type {
 	case RevoluteJoint:
-		return NewRotationalFrame(cfg.ID, cfg.Axis.ParseConfig(),
+		frame, err = NewRotationalFrame(cfg.ID, cfg.Axis.ParseConfig(),
 			Limit{Min: utils.DegToRad(cfg.Min), Max: utils.DegToRad(cfg.Max)})
+		if cfg.Mimic != nil {
+			offset = utils.DegToRad(cfg.Mimic.Offset)
+		}
 	case PrismaticJoint:
-		return NewTranslationalFrame(cfg.ID, r3.Vector(cfg.Axis),
+		frame, err = NewTranslationalFrame(cfg.ID, r3.Vector(cfg.Axis),
 			Limit{Min: cfg.Min, Max: cfg.Max})
+		if cfg.Mimic != nil {
+			offset = cfg.Mimic.Offset
+		}
 	default:
 		return nil, NewUnsupportedJointTypeError(cfg.Type)
 	}
+	if err != nil || cfg.Mimic == nil {
+		return frame, err
+	}
+
+	multiplier := 1.
+	if cfg.Mimic.Multiplier != nil {
+		multiplier = *cfg.Mimic.Multiplier
+	}
+	return newMimicFrame(frame, cfg.Mimic.Joint, multiplier, offset)
 }
 
 // ToDHFrames converts a DHParamConfig into a joint frame and a link frame.
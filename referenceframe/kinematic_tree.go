@@ -0,0 +1,163 @@
+package referenceframe
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// treeLink is a rigid body of a kinematic tree read from a robot description format such as SDF or MJCF.
+type treeLink struct {
+	name string
+	// pose is the pose of the link in the model's frame when all inputs are zero
+	pose spatialmath.Pose
+	// geometry is the collision geometry of the link, expressed in the link's own frame
+	geometry *spatialmath.GeometryConfig
+}
+
+// treeJoint connects a parent link to a child link in a kinematic tree.
+type treeJoint struct {
+	name      string
+	jointType string
+	parent    string
+	child     string
+	// pose is the pose of the joint in the model's frame when all inputs are zero
+	pose spatialmath.Pose
+	// axis is the axis of motion, expressed in the joint's frame
+	axis r3.Vector
+	// min and max are in degrees for revolute joints and mm for prismatic joints
+	min, max float64
+}
+
+// newModelConfigFromTree converts links and joints whose poses are known in the model frame into the serial chain of links
+// and joints used by ModelConfigJSON. In the same fashion as URDF, each link's translation holds the offset to its child
+// joint, and each joint's child link geometry is expressed in the frame of the joint.
+func newModelConfigFromTree(name string, links []treeLink, joints []treeJoint) (*ModelConfigJSON, error) {
+	linkNames := make(map[string]bool, len(links))
+	for _, link := range links {
+		if link.name == World {
+			return nil, NewReservedWordError("link", World)
+		}
+		linkNames[link.name] = true
+	}
+
+	parentJoint := map[string]treeJoint{}
+	childJoints := map[string][]treeJoint{}
+	for _, joint := range joints {
+		if !linkNames[joint.child] {
+			return nil, NewFrameNotInListOfTransformsError(joint.child)
+		}
+		if _, ok := parentJoint[joint.child]; ok {
+			return nil, fmt.Errorf("link %q is the child of more than one joint", joint.child)
+		}
+		parentJoint[joint.child] = joint
+		childJoints[joint.parent] = append(childJoints[joint.parent], joint)
+	}
+
+	// inputFrame returns the model-frame pose of the frame that a link's geometry and offset are expressed in,
+	// which is the frame of the joint that moves it, or the model frame for root links.
+	inputFrame := func(linkName string) spatialmath.Pose {
+		if joint, ok := parentJoint[linkName]; ok {
+			return joint.pose
+		}
+		return spatialmath.NewZeroPose()
+	}
+	offsetLinkConfig := func(id, parent string, offset spatialmath.Pose) (LinkConfig, error) {
+		orient, err := spatialmath.NewOrientationConfig(offset.Orientation())
+		if err != nil {
+			return LinkConfig{}, err
+		}
+		return LinkConfig{ID: id, Translation: offset.Point(), Orientation: orient, Parent: parent}, nil
+	}
+
+	linkConfigs := make([]LinkConfig, 0, len(links)+len(joints))
+	jointConfigs := make([]JointConfig, 0, len(joints))
+	for _, link := range links {
+		children := childJoints[link.name]
+		if len(children) > 1 {
+			return nil, fmt.Errorf("link %q has %d child joints but only serial chains are supported", link.name, len(children))
+		}
+		offset := spatialmath.NewZeroPose()
+		if len(children) == 1 && children[0].jointType != FixedJoint {
+			offset = spatialmath.PoseBetween(inputFrame(link.name), children[0].pose)
+		}
+		cfg, err := offsetLinkConfig(link.name, "", offset)
+		if err != nil {
+			return nil, err
+		}
+		if joint, ok := parentJoint[link.name]; ok {
+			cfg.Parent = joint.name
+		}
+		if link.geometry != nil {
+			geometry := *link.geometry
+			geomPose, err := geometryConfigPose(&geometry)
+			if err != nil {
+				return nil, err
+			}
+			geomPose = spatialmath.Compose(spatialmath.PoseBetween(inputFrame(link.name), link.pose), geomPose)
+			orient, err := spatialmath.NewOrientationConfig(geomPose.Orientation())
+			if err != nil {
+				return nil, err
+			}
+			geometry.TranslationOffset = geomPose.Point()
+			geometry.OrientationOffset = *orient
+			cfg.Geometry = &geometry
+		}
+		linkConfigs = append(linkConfigs, cfg)
+	}
+
+	for _, joint := range joints {
+		parentFrame := spatialmath.NewZeroPose()
+		parent := joint.parent
+		if linkNames[parent] {
+			parentFrame = inputFrame(parent)
+		} else {
+			parent = World
+		}
+
+		if joint.jointType == FixedJoint {
+			// Fixed joints become links, which carry the offset from their parent
+			cfg, err := offsetLinkConfig(joint.name, parent, spatialmath.PoseBetween(parentFrame, joint.pose))
+			if err != nil {
+				return nil, err
+			}
+			linkConfigs = append(linkConfigs, cfg)
+			continue
+		}
+		if parent == World {
+			// There is no parent link to carry the offset to this joint, so one is added
+			cfg, err := offsetLinkConfig(joint.name+"_offset", "", joint.pose)
+			if err != nil {
+				return nil, err
+			}
+			linkConfigs = append(linkConfigs, cfg)
+			parent = cfg.ID
+		}
+		jointConfigs = append(jointConfigs, JointConfig{
+			ID:     joint.name,
+			Type:   joint.jointType,
+			Parent: parent,
+			Axis:   spatialmath.AxisConfig{X: joint.axis.X, Y: joint.axis.Y, Z: joint.axis.Z},
+			Min:    joint.min,
+			Max:    joint.max,
+		})
+	}
+
+	return &ModelConfigJSON{
+		Name:         name,
+		KinParamType: "SVA",
+		Links:        linkConfigs,
+		Joints:       jointConfigs,
+	}, nil
+}
+
+// geometryConfigPose returns the offset of a geometry config as a pose.
+func geometryConfigPose(cfg *spatialmath.GeometryConfig) (spatialmath.Pose, error) {
+	orient, err := cfg.OrientationOffset.ParseConfig()
+	if err != nil {
+		return nil, err
+	}
+	return spatialmath.NewPose(cfg.TranslationOffset, orient), nil
+}
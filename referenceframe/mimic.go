@@ -0,0 +1,110 @@
+package referenceframe
+
+import (
+	"fmt"
+
+	pb "go.viam.com/api/component/arm/v1"
+
+	spatial "go.viam.com/rdk/spatialmath"
+)
+
+// MimicConfig describes a joint whose position is not independent, but follows that of another joint of the same model as
+// multiplier * position + offset. Mimic joints can be used to describe mechanisms such as parallel grippers, or closed
+// kinematic loops like parallelograms in which several joints are driven by a single input.
+type MimicConfig struct {
+	Joint string `json:"joint"`
+	// Multiplier defaults to 1 if unset
+	Multiplier *float64 `json:"multiplier,omitempty"`
+	Offset     float64  `json:"offset,omitempty"` // in mm or degs
+}
+
+// mimicFrame is a joint which has no degrees of freedom of its own. Its input is computed from the input of the joint it mimics,
+// so it can only be transformed by the model which contains both.
+type mimicFrame struct {
+	Frame
+	source      string
+	sourceIndex int
+	multiplier  float64
+	offset      float64 // in the input units of the wrapped joint
+}
+
+func newMimicFrame(joint Frame, source string, multiplier, offset float64) (*mimicFrame, error) {
+	if len(joint.DoF()) != 1 {
+		return nil, fmt.Errorf("mimic joint %q must have exactly one degree of freedom, has %d", joint.Name(), len(joint.DoF()))
+	}
+	if source == joint.Name() {
+		return nil, fmt.Errorf("joint %q cannot mimic itself", source)
+	}
+	return &mimicFrame{Frame: joint, source: source, sourceIndex: -1, multiplier: multiplier, offset: offset}, nil
+}
+
+// jointInputs computes the input of the mimic joint from the inputs of the model which contains it.
+func (mf *mimicFrame) jointInputs(modelInputs []Input) ([]Input, error) {
+	if mf.sourceIndex < 0 || mf.sourceIndex >= len(modelInputs) {
+		return nil, fmt.Errorf("mimic joint %q is not linked to joint %q of its model", mf.Name(), mf.source)
+	}
+	return []Input{mf.multiplier*modelInputs[mf.sourceIndex] + mf.offset}, nil
+}
+
+// DoF returns no limits, as the mimic joint is not independently controllable.
+func (mf *mimicFrame) DoF() []Limit {
+	return []Limit{}
+}
+
+// Transform returns an error, as the input of a mimic joint depends on the rest of its model.
+func (mf *mimicFrame) Transform(inputs []Input) (spatial.Pose, error) {
+	return nil, fmt.Errorf("mimic joint %q must be transformed by the model which contains it", mf.Name())
+}
+
+// Interpolate returns an empty slice, as there are no independent inputs to interpolate.
+func (mf *mimicFrame) Interpolate(from, to []Input, by float64) ([]Input, error) {
+	if len(from) != 0 || len(to) != 0 {
+		return nil, NewIncorrectDoFError(max(len(from), len(to)), 0)
+	}
+	return []Input{}, nil
+}
+
+// Geometries returns an error, as the input of a mimic joint depends on the rest of its model.
+func (mf *mimicFrame) Geometries(inputs []Input) (*GeometriesInFrame, error) {
+	return nil, fmt.Errorf("mimic joint %q must be transformed by the model which contains it", mf.Name())
+}
+
+// InputFromProtobuf converts pb.JointPosition to inputs.
+func (mf *mimicFrame) InputFromProtobuf(jp *pb.JointPositions) []Input {
+	return []Input{}
+}
+
+// ProtobufFromInput converts inputs to pb.JointPosition.
+func (mf *mimicFrame) ProtobufFromInput(input []Input) *pb.JointPositions {
+	return &pb.JointPositions{}
+}
+
+// Hash returns a hash value for this mimic frame.
+func (mf *mimicFrame) Hash() int {
+	return mf.Frame.Hash() + 13*mf.sourceIndex + int(1000*mf.multiplier) + int(1000*mf.offset)
+}
+
+// linkMimicJoints points every mimic joint in an ordered list of transforms to the index of the model input of the joint it
+// mimics. Mimicked joints must be independent joints with a single degree of freedom.
+func linkMimicJoints(transforms []Frame) error {
+	inputIndices := map[string]int{}
+	posIdx := 0
+	for _, transform := range transforms {
+		if len(transform.DoF()) == 1 {
+			inputIndices[transform.Name()] = posIdx
+		}
+		posIdx += len(transform.DoF())
+	}
+	for _, transform := range transforms {
+		mimic, ok := transform.(*mimicFrame)
+		if !ok {
+			continue
+		}
+		idx, ok := inputIndices[mimic.source]
+		if !ok {
+			return fmt.Errorf("joint %q mimics %q, which is not an independent joint of the same chain", mimic.Name(), mimic.source)
+		}
+		mimic.sourceIndex = idx
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package referenceframe
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+func TestMimicJoints(t *testing.T) {
+	m, err := ParseModelJSONFile(utils.ResolveFile("referenceframe/testfiles/parallelogram.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+
+	// the wrist follows the shoulder, so only the shoulder and the slide are independent
+	test.That(t, len(m.DoF()), test.ShouldEqual, 2)
+	for _, theta := range []float64{-60, 0, 30, 90} {
+		pose, err := m.Transform([]Input{utils.DegToRad(theta), 20})
+		test.That(t, err, test.ShouldBeNil)
+		// a parallelogram keeps the orientation of the tool constant
+		test.That(t, spatialmath.OrientationAlmostEqual(pose.Orientation(), spatialmath.NewZeroOrientation()), test.ShouldBeTrue)
+		rad := utils.DegToRad(theta)
+		expected := r3.Vector{X: 300*math.Cos(rad) + 70, Z: 100 - 300*math.Sin(rad)}
+		test.That(t, spatialmath.R3VectorAlmostEqual(pose.Point(), expected, 1e-6), test.ShouldBeTrue)
+	}
+
+	inputs := []Input{utils.DegToRad(45), 20}
+	geoms, err := m.Geometries(inputs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(geoms.Geometries()), test.ShouldEqual, 1)
+	interp, err := m.Interpolate([]Input{0, 0}, inputs, 0.5)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, interp, test.ShouldResemble, []Input{utils.DegToRad(22.5), 10})
+	test.That(t, len(m.ProtobufFromInput(inputs).Values), test.ShouldEqual, 2)
+
+	// mimic joints survive serialization
+	data, err := json.Marshal(m)
+	test.That(t, err, test.ShouldBeNil)
+	m2 := &SimpleModel{}
+	test.That(t, m2.UnmarshalJSON(data), test.ShouldBeNil)
+	equal, err := framesAlmostEqual(m, m2, 1e-6)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, equal, test.ShouldBeTrue)
+
+	t.Run("invalid sources", func(t *testing.T) {
+		multiplier := 2.
+		cfg := &ModelConfigJSON{
+			Name:  "bad",
+			Links: []LinkConfig{{ID: "base", Parent: World}, {ID: "tip", Parent: "j2"}},
+			Joints: []JointConfig{
+				{ID: "j1", Type: RevoluteJoint, Parent: "base", Axis: spatialmath.AxisConfig{Z: 1}, Min: -90, Max: 90},
+				{ID: "j2", Type: RevoluteJoint, Parent: "j1", Axis: spatialmath.AxisConfig{Z: 1}, Min: -90, Max: 90},
+			},
+		}
+		cfg.Joints[1].Mimic = &MimicConfig{Joint: "missing", Multiplier: &multiplier}
+		_, err := cfg.ParseConfig("")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		cfg.Joints[1].Mimic = &MimicConfig{Joint: "j2"}
+		_, err = cfg.ParseConfig("")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		cfg.Joints[1].Mimic = &MimicConfig{Joint: "j1", Multiplier: &multiplier, Offset: 10}
+		m, err := cfg.ParseConfig("")
+		test.That(t, err, test.ShouldBeNil)
+		pose, err := m.Transform([]Input{utils.DegToRad(10)})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, pose.Orientation().OrientationVectorDegrees().Theta, test.ShouldAlmostEqual, 40)
+	})
+}
+
+func TestMimicJointsURDF(t *testing.T) {
+	urdf := `<robot name="gripper">
+		<link name="base"/>
+		<link name="left_finger"/>
+		<link name="right_finger"/>
+		<joint name="left" type="prismatic">
+			<parent link="base"/><child link="left_finger"/>
+			<origin xyz="0 0 0" rpy="0 0 0"/>
+			<axis xyz="0 1 0"/>
+			<limit lower="0" upper="0.04" effort="10" velocity="0.1"/>
+		</joint>
+		<joint name="right" type="prismatic">
+			<parent link="left_finger"/><child link="right_finger"/>
+			<origin xyz="0 0 0" rpy="0 0 0"/>
+			<axis xyz="0 1 0"/>
+			<limit lower="-0.08" upper="0" effort="10" velocity="0.1"/>
+			<mimic joint="left" multiplier="-2" offset="0.01"/>
+		</joint>
+	</robot>`
+	cfg, err := UnmarshalModelXML([]byte(urdf), "")
+	test.That(t, err, test.ShouldBeNil)
+	m, err := cfg.ParseConfig("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(m.DoF()), test.ShouldEqual, 1)
+
+	// the right finger moves back twice as far as the left moves forward, offset by 10mm
+	pose, err := m.Transform([]Input{30})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, spatialmath.R3VectorAlmostEqual(pose.Point(), r3.Vector{Y: 30 - 60 + 10}, 1e-6), test.ShouldBeTrue)
+
+	// and the mimic relationship is exported with the rest of the frame system
+	fs := NewEmptyFrameSystem("test")
+	test.That(t, fs.AddFrame(m, fs.World()), test.ShouldBeNil)
+	exported, err := NewModelFromFrameSystem(fs, "test")
+	test.That(t, err, test.ShouldBeNil)
+	bytes, err := xml.Marshal(exported)
+	test.That(t, err, test.ShouldBeNil)
+	reparsed := &ModelConfigURDF{}
+	test.That(t, xml.Unmarshal(bytes, reparsed), test.ShouldBeNil)
+	mimics := 0
+	for _, joint := range reparsed.Joints {
+		if joint.Mimic != nil {
+			mimics++
+			test.That(t, joint.Mimic.Joint, test.ShouldEqual, "gripper:left_joint")
+			test.That(t, *joint.Mimic.Multiplier, test.ShouldAlmostEqual, -2)
+			test.That(t, joint.Mimic.Offset, test.ShouldAlmostEqual, 0.01)
+		}
+	}
+	test.That(t, mimics, test.ShouldEqual, 1)
+}
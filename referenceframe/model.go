@@ -3,9 +3,11 @@ package referenceframe
 import (
 	"encoding/binary"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
 	"strings"
 	"sync"
 
@@ -77,14 +79,55 @@ func KinematicModelToProtobuf(model Model) *commonpb.GetKinematicsResponse {
 }
 
 // KinematicModelFromFile returns a model frame from a file that defines the kinematics.
+// Files ending in .xml may contain URDF, SDF or MJCF data, which is determined from their root element.
 func KinematicModelFromFile(modelPath, name string) (Model, error) {
 	switch {
 	case strings.HasSuffix(modelPath, ".urdf"):
 		return ParseModelXMLFile(modelPath, name)
 	case strings.HasSuffix(modelPath, ".json"):
 		return ParseModelJSONFile(modelPath, name)
+	case strings.HasSuffix(modelPath, ".sdf"):
+		return ParseModelSDFFile(modelPath, name)
+	case strings.HasSuffix(modelPath, ".mjcf"):
+		return ParseModelMJCFFile(modelPath, name)
+	case strings.HasSuffix(modelPath, ".xml"):
+		rootElement, err := xmlRootElement(modelPath)
+		if err != nil {
+			return nil, err
+		}
+		switch rootElement {
+		case "robot":
+			return ParseModelXMLFile(modelPath, name)
+		case "sdf":
+			return ParseModelSDFFile(modelPath, name)
+		case "mujoco":
+			return ParseModelMJCFFile(modelPath, name)
+		default:
+			return nil, fmt.Errorf("unrecognized root element %q in %s, expected robot, sdf or mujoco", rootElement, modelPath)
+		}
 	default:
-		return nil, errors.New("only files with .json and .urdf file extensions are supported")
+		return nil, errors.New("only files with .json, .urdf, .sdf, .mjcf and .xml file extensions are supported")
+	}
+}
+
+// xmlRootElement returns the name of the first element in an XML file.
+func xmlRootElement(path string) (string, error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	//nolint:errcheck
+	defer f.Close()
+	decoder := xml.NewDecoder(f)
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read root element of %s", path)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
 	}
 }
 
@@ -331,6 +374,14 @@ func (m *SimpleModel) inputsToFrames(inputs []Input, collectAll bool) ([]*static
 		input := inputs[posIdx:dof]
 		posIdx = dof
 
+		if mimic, ok := transform.(*mimicFrame); ok {
+			var err error
+			if input, err = mimic.jointInputs(inputs); err != nil {
+				return nil, err
+			}
+			transform = mimic.Frame
+		}
+
 		pose, err := transform.Transform(input)
 		// Fail if inputs are incorrect and pose is nil, but allow querying out-of-bounds positions
 		if pose == nil || err != nil {
@@ -438,6 +489,13 @@ func (m *SimpleModel) InputsToTransformOpt(inputs []Input) (spatialmath.Pose, er
 			posIdx = dof
 
 			var err error
+			if mimic, ok := transform.(*mimicFrame); ok {
+				// mimic joints consume no inputs of their own, but follow the input of another joint
+				if input, err = mimic.jointInputs(inputs); err != nil {
+					return nil, err
+				}
+				transform = mimic.Frame
+			}
 			pose, err = transform.Transform(input)
 			if err != nil {
 				if strings.Contains(err.Error(), OOBErrString) {
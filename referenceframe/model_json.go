@@ -7,6 +7,8 @@ import (
 
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
+
+	spatial "go.viam.com/rdk/spatialmath"
 )
 
 // ErrNoModelInformation is used when there is no model information.
@@ -19,7 +21,7 @@ type ModelConfigJSON struct {
 	Links        []LinkConfig    `json:"links,omitempty"`
 	Joints       []JointConfig   `json:"joints,omitempty"`
 	DHParams     []DHParamConfig `json:"dhParams,omitempty"`
-	OriginalFile *ModelFile
+	OriginalFile *ModelFile      `json:",omitempty"`
 }
 
 // ModelFile is a struct that stores the raw bytes of the file used to create the model as well as its extension,
@@ -29,10 +31,38 @@ type ModelFile struct {
 	Extension string
 }
 
+// setOriginalFileToJSON sets the original file of a model config converted from another format to the config as
+// JSON, since only JSON and URDF models can be sent to clients. The meshes the config refers to are embedded in the
+// JSON, as clients cannot read their files.
+func (cfg *ModelConfigJSON) setOriginalFileToJSON() error {
+	converted := *cfg
+	converted.OriginalFile = nil
+	converted.Links = make([]LinkConfig, len(cfg.Links))
+	for i, link := range cfg.Links {
+		if link.Geometry != nil && link.Geometry.Type == spatial.MeshType && link.Geometry.MeshFilePath != "" {
+			mesh, err := spatial.NewMeshFromFile(link.Geometry.MeshFilePath)
+			if err != nil {
+				return err
+			}
+			geometry := *link.Geometry
+			geometry.MeshFilePath = ""
+			geometry.MeshPLY = mesh.ToProtobuf().GetMesh().GetMesh()
+			link.Geometry = &geometry
+		}
+		converted.Links[i] = link
+	}
+	data, err := json.Marshal(&converted)
+	if err != nil {
+		return err
+	}
+	cfg.OriginalFile = &ModelFile{Bytes: data, Extension: "json"}
+	return nil
+}
+
 // UnmarshalModelJSON will parse the given JSON data into a kinematics model. modelName sets the name of the model,
 // will use the name from the JSON if string is empty.
 func UnmarshalModelJSON(jsonData []byte, modelName string) (Model, error) {
-	m := &ModelConfigJSON{OriginalFile: &ModelFile{Bytes: jsonData, Extension: "json"}}
+	m := &ModelConfigJSON{}
 
 	// empty data probably means that the robot component has no model information
 	if len(jsonData) == 0 {
@@ -43,6 +73,10 @@ func UnmarshalModelJSON(jsonData []byte, modelName string) (Model, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal json file")
 	}
+	// configs serialized with their model keep the file the model was read from
+	if m.OriginalFile == nil {
+		m.OriginalFile = &ModelFile{Bytes: jsonData, Extension: "json"}
+	}
 
 	return m.ParseConfig(modelName)
 }
@@ -122,6 +156,9 @@ func (cfg *ModelConfigJSON) ParseConfig(modelName string) (Model, error) {
 		return nil, err
 	}
 
+	if err := linkMimicJoints(ot); err != nil {
+		return nil, err
+	}
 	model.SetOrdTransforms(ot)
 
 	return model, nil
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	commonpb "go.viam.com/api/common/v1"
 	"go.viam.com/test"
 
 	"go.viam.com/rdk/utils"
@@ -35,6 +36,32 @@ func TestLimitsParsing(t *testing.T) {
 	test.That(t, simpleModelDeserialized.DoF()[0], test.ShouldResemble, Limit{0, 1})
 }
 
+func TestModelJSONRoundTripKeepsOriginalFile(t *testing.T) {
+	for _, path := range []string{
+		"components/arm/fake/kinematics/xarm6.json",
+		"referenceframe/testfiles/ur5e.urdf",
+		"referenceframe/testfiles/two_link.sdf",
+		"referenceframe/testfiles/two_link_mjcf.xml",
+	} {
+		t.Run(path, func(t *testing.T) {
+			model, err := KinematicModelFromFile(utils.ResolveFile(path), "")
+			test.That(t, err, test.ShouldBeNil)
+			expected := KinematicModelToProtobuf(model)
+			test.That(t, expected.Format, test.ShouldNotEqual, commonpb.KinematicsFileFormat_KINEMATICS_FILE_FORMAT_UNSPECIFIED)
+
+			data, err := json.Marshal(model)
+			test.That(t, err, test.ShouldBeNil)
+			deserialized := &SimpleModel{}
+			test.That(t, deserialized.UnmarshalJSON(data), test.ShouldBeNil)
+			resp := KinematicModelToProtobuf(deserialized)
+			test.That(t, resp.Format, test.ShouldEqual, expected.Format)
+			test.That(t, resp.KinematicsData, test.ShouldResemble, expected.KinematicsData)
+			// the file sent to clients does not hold itself
+			test.That(t, string(resp.KinematicsData), test.ShouldNotContainSubstring, "OriginalFile")
+		})
+	}
+}
+
 // Tests that yml files are properly parsed and correctly loaded into the model
 // Should not need to actually test the contained rotation/translation values
 // since that will be caught by tests to the actual kinematics
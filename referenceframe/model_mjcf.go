@@ -0,0 +1,427 @@
+package referenceframe
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/num/quat"
+
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// mjcfDefaultClass is the name of the implicit top level default class in MJCF.
+const mjcfDefaultClass = "main"
+
+// mjcfElement is a generic MJCF XML element. MJCF attributes may be inherited from default classes, so elements are read
+// generically and their attributes are resolved once the defaults are known.
+type mjcfElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Children []mjcfElement `xml:",any"`
+}
+
+func (e *mjcfElement) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (e *mjcfElement) children(tag string) []mjcfElement {
+	var found []mjcfElement
+	for _, c := range e.Children {
+		if c.XMLName.Local == tag {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// mjcfParser holds the state needed to convert the bodies of an MJCF file into a kinematic tree.
+type mjcfParser struct {
+	// defaults maps class name -> element tag -> attribute -> value, with inheritance already applied
+	defaults map[string]map[string]map[string]string
+	meshes   map[string]mjcfMesh
+	angleDeg bool
+	eulerSeq string
+
+	links  []treeLink
+	joints []treeJoint
+}
+
+type mjcfMesh struct {
+	path  string
+	scale string
+}
+
+// UnmarshalModelMJCF will transfer the given MuJoCo MJCF XML data into an equivalent ModelConfig. The bodies must form a serial
+// chain, and each body may have at most one hinge or slide joint. Relative mesh files are resolved against meshDir.
+func UnmarshalModelMJCF(xmlData []byte, modelName, meshDir string) (*ModelConfigJSON, error) {
+	root := &mjcfElement{}
+	if err := xml.Unmarshal(xmlData, root); err != nil {
+		return nil, errors.Wrap(err, "failed to convert MJCF data to equivalent MJCF element tree")
+	}
+	if root.XMLName.Local != "mujoco" {
+		return nil, fmt.Errorf("expected root element mujoco, got %s", root.XMLName.Local)
+	}
+	if modelName == "" {
+		modelName = root.attr("model")
+	}
+
+	p := &mjcfParser{
+		defaults: map[string]map[string]map[string]string{},
+		meshes:   map[string]mjcfMesh{},
+		angleDeg: true,
+		eulerSeq: "xyz",
+	}
+	for _, compiler := range root.children("compiler") {
+		if angle := compiler.attr("angle"); angle != "" {
+			p.angleDeg = angle != "radian"
+		}
+		if seq := compiler.attr("eulerseq"); seq != "" {
+			p.eulerSeq = seq
+		}
+		if dir := compiler.attr("meshdir"); dir != "" {
+			meshDir = joinIfRelative(meshDir, dir)
+		} else if dir := compiler.attr("assetdir"); dir != "" {
+			meshDir = joinIfRelative(meshDir, dir)
+		}
+	}
+	for _, def := range root.children("default") {
+		p.readDefaults(def, mjcfDefaultClass, nil)
+	}
+	for _, asset := range root.children("asset") {
+		for _, mesh := range asset.children("mesh") {
+			attrs := p.resolveAttrs(&mesh, "mesh", mjcfDefaultClass)
+			name := attrs["name"]
+			if name == "" {
+				// unnamed meshes are referred to by their file name without extension
+				name = strings.TrimSuffix(filepath.Base(attrs["file"]), filepath.Ext(attrs["file"]))
+			}
+			p.meshes[name] = mjcfMesh{path: joinIfRelative(meshDir, attrs["file"]), scale: attrs["scale"]}
+		}
+	}
+
+	for _, worldbody := range root.children("worldbody") {
+		for _, body := range worldbody.children("body") {
+			if err := p.readBody(&body, World, spatialmath.NewZeroPose(), mjcfDefaultClass); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	mc, err := newModelConfigFromTree(modelName, p.links, p.joints)
+	if err != nil {
+		return nil, err
+	}
+	if err := mc.setOriginalFileToJSON(); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// ParseModelMJCFFile will read a given file and parse the contained MJCF XML data into an equivalent Model.
+func ParseModelMJCFFile(filename, modelName string) (Model, error) {
+	//nolint:gosec
+	xmlData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read MJCF file")
+	}
+
+	mc, err := UnmarshalModelMJCF(xmlData, modelName, filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return mc.ParseConfig(modelName)
+}
+
+// readDefaults records the attributes of a default class, inheriting from its parent class.
+func (p *mjcfParser) readDefaults(def mjcfElement, class string, parent map[string]map[string]string) {
+	if c := def.attr("class"); c != "" {
+		class = c
+	}
+	classDefaults := map[string]map[string]string{}
+	for tag, attrs := range parent {
+		classDefaults[tag] = map[string]string{}
+		for k, v := range attrs {
+			classDefaults[tag][k] = v
+		}
+	}
+	for _, child := range def.Children {
+		if child.XMLName.Local == "default" {
+			continue
+		}
+		if _, ok := classDefaults[child.XMLName.Local]; !ok {
+			classDefaults[child.XMLName.Local] = map[string]string{}
+		}
+		for _, a := range child.Attrs {
+			classDefaults[child.XMLName.Local][a.Name.Local] = a.Value
+		}
+	}
+	p.defaults[class] = classDefaults
+	for _, child := range def.children("default") {
+		p.readDefaults(child, class, classDefaults)
+	}
+}
+
+// resolveAttrs returns the attributes of an element after applying the defaults of its class.
+func (p *mjcfParser) resolveAttrs(e *mjcfElement, tag, childClass string) map[string]string {
+	class := childClass
+	if c := e.attr("class"); c != "" {
+		class = c
+	}
+	attrs := map[string]string{}
+	for k, v := range p.defaults[class][tag] {
+		attrs[k] = v
+	}
+	for _, a := range e.Attrs {
+		attrs[a.Name.Local] = a.Value
+	}
+	return attrs
+}
+
+// readBody adds a body, the joint connecting it to its parent and all of its descendants to the kinematic tree.
+func (p *mjcfParser) readBody(body *mjcfElement, parent string, parentPose spatialmath.Pose, childClass string) error {
+	if c := body.attr("childclass"); c != "" {
+		childClass = c
+	}
+	attrs := map[string]string{}
+	for _, a := range body.Attrs {
+		attrs[a.Name.Local] = a.Value
+	}
+	name := attrs["name"]
+	if name == "" {
+		return errors.New("MJCF bodies must be named")
+	}
+	local, err := p.pose(attrs)
+	if err != nil {
+		return fmt.Errorf("body %q: %w", name, err)
+	}
+	pose := spatialmath.Compose(parentPose, local)
+
+	link := treeLink{name: name, pose: pose}
+	for _, geom := range body.children("geom") {
+		geomAttrs := p.resolveAttrs(&geom, "geom", childClass)
+		// geoms which collide with nothing are purely visual
+		if geomAttrs["contype"] == "0" && geomAttrs["conaffinity"] == "0" {
+			continue
+		}
+		if link.geometry, err = p.geometry(geomAttrs); err != nil {
+			return fmt.Errorf("failed to convert collision geometry %v to geometry config: %w", name, err)
+		}
+		break
+	}
+	p.links = append(p.links, link)
+
+	joint := treeJoint{name: name + "_joint", jointType: FixedJoint, parent: parent, child: name, pose: pose}
+	// bodies without joints are welded to their parent
+	bodyJoints := body.children("joint")
+	if len(bodyJoints) > 1 {
+		return fmt.Errorf("body %q has %d joints but at most one is supported", name, len(bodyJoints))
+	}
+	for _, j := range bodyJoints {
+		if err := p.joint(p.resolveAttrs(&j, "joint", childClass), &joint); err != nil {
+			return fmt.Errorf("body %q: %w", name, err)
+		}
+	}
+	p.joints = append(p.joints, joint)
+
+	for _, child := range body.children("body") {
+		if err := p.readBody(&child, name, pose, childClass); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joint fills in the details of a body's joint from its MJCF attributes.
+func (p *mjcfParser) joint(attrs map[string]string, joint *treeJoint) error {
+	if attrs["name"] != "" {
+		joint.name = attrs["name"]
+	}
+	pos, err := mjcfVector(attrs["pos"], r3.Vector{})
+	if err != nil {
+		return err
+	}
+	joint.pose = spatialmath.Compose(joint.pose, spatialmath.NewPoseFromPoint(pos.Mul(1000)))
+	if joint.axis, err = mjcfVector(attrs["axis"], r3.Vector{Z: 1}); err != nil {
+		return err
+	}
+
+	limited := attrs["limited"] == "true" || (attrs["limited"] != "false" && attrs["range"] != "")
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if limited {
+		vals := spaceDelimitedStringToFloatSlice(attrs["range"])
+		if len(vals) != 2 {
+			return fmt.Errorf("joint %q range must have two values", joint.name)
+		}
+		lower, upper = vals[0], vals[1]
+	}
+
+	switch jointType := attrs["type"]; jointType {
+	case "hinge", "":
+		joint.jointType = RevoluteJoint
+		joint.min, joint.max = lower, upper
+		if !p.angleDeg {
+			joint.min, joint.max = utils.RadToDeg(lower), utils.RadToDeg(upper)
+		}
+	case "slide":
+		joint.jointType = PrismaticJoint
+		joint.min, joint.max = utils.MetersToMM(lower), utils.MetersToMM(upper)
+	default:
+		return NewUnsupportedJointTypeError(jointType)
+	}
+	return nil
+}
+
+// geometry converts MJCF geom attributes into a geometry config in the frame of its body.
+func (p *mjcfParser) geometry(attrs map[string]string) (*spatialmath.GeometryConfig, error) {
+	pose, err := p.pose(attrs)
+	if err != nil {
+		return nil, err
+	}
+	size := spaceDelimitedStringToFloatSlice(attrs["size"])
+	sizeAt := func(i int) float64 {
+		if i < len(size) {
+			return size[i]
+		}
+		return 0
+	}
+	geomType := attrs["type"]
+	if geomType == "" {
+		geomType = "sphere"
+	}
+
+	// Capsules, cylinders and boxes may instead be defined by the segment between two points
+	var halfLength float64
+	if fromto := attrs["fromto"]; fromto != "" && geomType != "sphere" && geomType != "mesh" {
+		pts := spaceDelimitedStringToFloatSlice(fromto)
+		if len(pts) != 6 {
+			return nil, fmt.Errorf("fromto %q must have six values", fromto)
+		}
+		from := r3.Vector{X: utils.MetersToMM(pts[0]), Y: utils.MetersToMM(pts[1]), Z: utils.MetersToMM(pts[2])}
+		to := r3.Vector{X: utils.MetersToMM(pts[3]), Y: utils.MetersToMM(pts[4]), Z: utils.MetersToMM(pts[5])}
+		dir := to.Sub(from)
+		pose = spatialmath.NewPose(from.Add(to).Mul(0.5), &spatialmath.OrientationVector{OX: dir.X, OY: dir.Y, OZ: dir.Z})
+		halfLength = dir.Norm() / 2
+	} else {
+		halfLength = utils.MetersToMM(sizeAt(1))
+		if geomType == "box" || geomType == "ellipsoid" {
+			halfLength = utils.MetersToMM(sizeAt(2))
+		}
+	}
+
+	var geom spatialmath.Geometry
+	switch geomType {
+	case "sphere":
+		geom, err = spatialmath.NewSphere(pose, utils.MetersToMM(sizeAt(0)), "")
+	case "capsule", "cylinder":
+		// There is no cylinder geometry, so it is approximated by the smallest capsule which encloses it
+		r := utils.MetersToMM(sizeAt(0))
+		geom, err = spatialmath.NewCapsule(pose, r, 2*(halfLength+r), "")
+	case "box", "ellipsoid":
+		// ellipsoids are approximated by their bounding box
+		x, y := utils.MetersToMM(sizeAt(0)), utils.MetersToMM(sizeAt(1))
+		if attrs["fromto"] != "" {
+			y = x
+		}
+		geom, err = spatialmath.NewBox(pose, r3.Vector{X: 2 * x, Y: 2 * y, Z: 2 * halfLength}, "")
+	case "mesh":
+		mesh, ok := p.meshes[attrs["mesh"]]
+		if !ok {
+			return nil, fmt.Errorf("geom refers to unknown mesh %q", attrs["mesh"])
+		}
+		return newMeshGeometryConfig(pose, mesh.path, mesh.scale)
+	default:
+		return nil, fmt.Errorf("%w %s", errGeometryTypeUnsupported, geomType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spatialmath.NewGeometryConfig(geom)
+}
+
+// pose reads the position and any of the supported MJCF orientation attributes into a pose.
+func (p *mjcfParser) pose(attrs map[string]string) (spatialmath.Pose, error) {
+	pos, err := mjcfVector(attrs["pos"], r3.Vector{})
+	if err != nil {
+		return nil, err
+	}
+	pos = pos.Mul(1000)
+	toRad := func(angle float64) float64 {
+		if p.angleDeg {
+			return utils.DegToRad(angle)
+		}
+		return angle
+	}
+
+	var orientation spatialmath.Orientation = spatialmath.NewZeroOrientation()
+	switch {
+	case attrs["quat"] != "":
+		q := spaceDelimitedStringToFloatSlice(attrs["quat"])
+		if len(q) != 4 {
+			return nil, fmt.Errorf("quat %q must have four values", attrs["quat"])
+		}
+		// MJCF quaternions are ordered w x y z
+		normalized := spatialmath.Quaternion(spatialmath.Normalize(quat.Number{Real: q[0], Imag: q[1], Jmag: q[2], Kmag: q[3]}))
+		orientation = &normalized
+	case attrs["axisangle"] != "":
+		aa := spaceDelimitedStringToFloatSlice(attrs["axisangle"])
+		if len(aa) != 4 {
+			return nil, fmt.Errorf("axisangle %q must have four values", attrs["axisangle"])
+		}
+		r4 := &spatialmath.R4AA{Theta: toRad(aa[3]), RX: aa[0], RY: aa[1], RZ: aa[2]}
+		r4.Normalize()
+		orientation = r4
+	case attrs["euler"] != "":
+		angles := spaceDelimitedStringToFloatSlice(attrs["euler"])
+		if len(angles) != 3 || len(p.eulerSeq) != 3 {
+			return nil, fmt.Errorf("euler %q with sequence %q must have three values", attrs["euler"], p.eulerSeq)
+		}
+		rotation := spatialmath.NewZeroPose()
+		for i, axisName := range p.eulerSeq {
+			axis := map[rune]r3.Vector{'x': {X: 1}, 'y': {Y: 1}, 'z': {Z: 1}}[[]rune(strings.ToLower(string(axisName)))[0]]
+			step := spatialmath.NewPoseFromOrientation(&spatialmath.R4AA{Theta: toRad(angles[i]), RX: axis.X, RY: axis.Y, RZ: axis.Z})
+			// lower case sequences rotate about the moving axes, upper case about the fixed axes
+			if axisName >= 'a' {
+				rotation = spatialmath.Compose(rotation, step)
+			} else {
+				rotation = spatialmath.Compose(step, rotation)
+			}
+		}
+		orientation = rotation.Orientation()
+	case attrs["xyaxes"] != "" || attrs["zaxis"] != "":
+		return nil, errors.New("xyaxes and zaxis orientations are not supported")
+	}
+	return spatialmath.NewPose(pos, orientation), nil
+}
+
+// mjcfVector parses a space delimited vector, returning the default if the string is empty.
+func mjcfVector(s string, def r3.Vector) (r3.Vector, error) {
+	if s == "" {
+		return def, nil
+	}
+	vals := spaceDelimitedStringToFloatSlice(s)
+	if len(vals) != 3 {
+		return r3.Vector{}, fmt.Errorf("vector %q must have three values", s)
+	}
+	return r3.Vector{X: vals[0], Y: vals[1], Z: vals[2]}, nil
+}
+
+// joinIfRelative joins path onto dir unless path is already absolute.
+func joinIfRelative(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,58 @@
+package referenceframe
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/utils"
+)
+
+func TestParseMJCFFile(t *testing.T) {
+	m, err := ParseModelMJCFFile(utils.ResolveFile("referenceframe/testfiles/two_link_mjcf.xml"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testTwoLinkModel(t, m)
+
+	// .xml files are routed by their root element
+	m, err = KinematicModelFromFile(utils.ResolveFile("referenceframe/testfiles/two_link_mjcf.xml"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testTwoLinkModel(t, m)
+	m, err = KinematicModelFromFile(utils.ResolveFile("referenceframe/testfiles/example_gantry.xml"), "")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(m.DoF()), test.ShouldEqual, 2)
+
+	t.Run("radians and orientations", func(t *testing.T) {
+		mjcf := `<mujoco model="m"><compiler angle="radian"/><worldbody>
+			<body name="a" quat="0.7071068 0 0 0.7071068">
+				<joint name="j" type="slide" axis="1 0 0" range="0 0.25"/>
+				<body name="b" pos="0.1 0 0"/>
+			</body></worldbody></mujoco>`
+		cfg, err := UnmarshalModelMJCF([]byte(mjcf), "", "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cfg.Name, test.ShouldEqual, "m")
+		test.That(t, len(cfg.Joints), test.ShouldEqual, 1)
+		test.That(t, cfg.Joints[0].Type, test.ShouldEqual, PrismaticJoint)
+		test.That(t, cfg.Joints[0].Max, test.ShouldAlmostEqual, 250)
+
+		m, err := cfg.ParseConfig("")
+		test.That(t, err, test.ShouldBeNil)
+		pose, err := m.Transform([]Input{100})
+		test.That(t, err, test.ShouldBeNil)
+		// body a is rotated 90 degrees about Z, so its X axis points along the model's Y axis
+		test.That(t, pose.Point().X, test.ShouldAlmostEqual, 0, 1e-3)
+		test.That(t, pose.Point().Y, test.ShouldAlmostEqual, 200, 1e-3)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := UnmarshalModelMJCF([]byte(`<mujoco><worldbody><body/></worldbody></mujoco>`), "", "")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		twoJoints := `<mujoco><worldbody><body name="a"><joint name="j1"/><joint name="j2"/></body></worldbody></mujoco>`
+		_, err = UnmarshalModelMJCF([]byte(twoJoints), "", "")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		ball := `<mujoco><worldbody><body name="a"><joint name="j" type="ball"/></body></worldbody></mujoco>`
+		_, err = UnmarshalModelMJCF([]byte(ball), "", "")
+		test.That(t, err.Error(), test.ShouldContainSubstring, NewUnsupportedJointTypeError("ball").Error())
+	})
+}
@@ -0,0 +1,348 @@
+package referenceframe
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// sdfModelFrame is the name SDF uses to refer to the frame of the enclosing model.
+const sdfModelFrame = "__model__"
+
+// ModelConfigSDF represents the supported fields of a Simulation Description Format (SDF) file.
+type ModelConfigSDF struct {
+	XMLName xml.Name   `xml:"sdf"`
+	Version string     `xml:"version,attr"`
+	Models  []sdfModel `xml:"model"`
+}
+
+type sdfModel struct {
+	Name   string     `xml:"name,attr"`
+	Links  []sdfLink  `xml:"link"`
+	Joints []sdfJoint `xml:"joint"`
+}
+
+type sdfPose struct {
+	RelativeTo string `xml:"relative_to,attr"`
+	Degrees    bool   `xml:"degrees,attr"`
+	Value      string `xml:",chardata"` // "x y z roll pitch yaw" format, in meters and radians unless degrees is set
+}
+
+type sdfLink struct {
+	Name      string         `xml:"name,attr"`
+	Pose      *sdfPose       `xml:"pose"`
+	Collision []sdfCollision `xml:"collision"`
+}
+
+type sdfCollision struct {
+	Name     string      `xml:"name,attr"`
+	Pose     *sdfPose    `xml:"pose"`
+	Geometry sdfGeometry `xml:"geometry"`
+}
+
+type sdfGeometry struct {
+	Box *struct {
+		Size string `xml:"size"`
+	} `xml:"box"`
+	Sphere *struct {
+		Radius float64 `xml:"radius"`
+	} `xml:"sphere"`
+	Cylinder *struct {
+		Radius float64 `xml:"radius"`
+		Length float64 `xml:"length"`
+	} `xml:"cylinder"`
+	Capsule *struct {
+		Radius float64 `xml:"radius"`
+		Length float64 `xml:"length"`
+	} `xml:"capsule"`
+	Mesh *struct {
+		URI   string `xml:"uri"`
+		Scale string `xml:"scale"`
+	} `xml:"mesh"`
+}
+
+type sdfJoint struct {
+	Name   string   `xml:"name,attr"`
+	Type   string   `xml:"type,attr"`
+	Parent string   `xml:"parent"`
+	Child  string   `xml:"child"`
+	Pose   *sdfPose `xml:"pose"`
+	Axis   *struct {
+		XYZ struct {
+			ExpressedIn string `xml:"expressed_in,attr"`
+			Value       string `xml:",chardata"`
+		} `xml:"xyz"`
+		UseParentModelFrame bool `xml:"use_parent_model_frame"`
+		Limit               *struct {
+			Lower float64 `xml:"lower"`
+			Upper float64 `xml:"upper"`
+		} `xml:"limit"`
+	} `xml:"axis"`
+}
+
+// UnmarshalModelSDF will transfer the given SDF XML data into an equivalent ModelConfig. Only the first model in the file is
+// read, and it must describe a serial chain. Relative mesh URIs are resolved against meshDir. Joint axes are interpreted in the
+// joint frame as of SDF 1.6, unless expressed in the model frame.
+func UnmarshalModelSDF(xmlData []byte, modelName, meshDir string) (*ModelConfigJSON, error) {
+	sdf := &ModelConfigSDF{}
+	if err := xml.Unmarshal(xmlData, sdf); err != nil {
+		return nil, errors.Wrap(err, "failed to convert SDF data to equivalent SDFConfig struct")
+	}
+	if len(sdf.Models) == 0 {
+		return nil, errors.New("SDF file does not contain a model")
+	}
+	model := sdf.Models[0]
+	if modelName == "" {
+		modelName = model.Name
+	}
+
+	linksByName := make(map[string]sdfLink, len(model.Links))
+	for _, link := range model.Links {
+		linksByName[link.Name] = link
+	}
+	jointsByName := make(map[string]sdfJoint, len(model.Joints))
+	for _, joint := range model.Joints {
+		jointsByName[joint.Name] = joint
+	}
+
+	// Poses may be relative to any other named frame, so frames are resolved into the model frame recursively.
+	resolved := map[string]spatialmath.Pose{}
+	resolving := map[string]bool{}
+	var frameInModel func(name string) (spatialmath.Pose, error)
+	poseInModel := func(p *sdfPose, defaultFrame string) (spatialmath.Pose, error) {
+		local, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		relativeTo := defaultFrame
+		if p != nil && p.RelativeTo != "" {
+			relativeTo = p.RelativeTo
+		}
+		base, err := frameInModel(relativeTo)
+		if err != nil {
+			return nil, err
+		}
+		return spatialmath.Compose(base, local), nil
+	}
+	frameInModel = func(name string) (spatialmath.Pose, error) {
+		if name == sdfModelFrame || name == World || name == "" {
+			return spatialmath.NewZeroPose(), nil
+		}
+		if p, ok := resolved[name]; ok {
+			return p, nil
+		}
+		if resolving[name] {
+			return nil, ErrCircularReference
+		}
+		resolving[name] = true
+		var p spatialmath.Pose
+		var err error
+		if link, ok := linksByName[name]; ok {
+			p, err = poseInModel(link.Pose, sdfModelFrame)
+		} else if joint, ok := jointsByName[name]; ok {
+			p, err = poseInModel(joint.Pose, joint.Child)
+		} else {
+			err = NewFrameNotInListOfTransformsError(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = p
+		return p, nil
+	}
+
+	links := make([]treeLink, 0, len(model.Links))
+	for _, link := range model.Links {
+		pose, err := frameInModel(link.Name)
+		if err != nil {
+			return nil, err
+		}
+		tl := treeLink{name: link.Name, pose: pose}
+		if len(link.Collision) > 0 {
+			geomPose, err := link.Collision[0].Pose.parse()
+			if err != nil {
+				return nil, err
+			}
+			if link.Collision[0].Pose != nil && link.Collision[0].Pose.RelativeTo != "" {
+				base, err := frameInModel(link.Collision[0].Pose.RelativeTo)
+				if err != nil {
+					return nil, err
+				}
+				geomPose = spatialmath.PoseBetween(pose, spatialmath.Compose(base, geomPose))
+			}
+			tl.geometry, err = link.Collision[0].Geometry.toGeometryConfig(geomPose, meshDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert collision geometry %v to geometry config: %w", link.Name, err)
+			}
+		}
+		links = append(links, tl)
+	}
+
+	joints := make([]treeJoint, 0, len(model.Joints))
+	for _, joint := range model.Joints {
+		pose, err := frameInModel(joint.Name)
+		if err != nil {
+			return nil, err
+		}
+		tj := treeJoint{name: joint.Name, parent: joint.Parent, child: joint.Child, pose: pose}
+		switch joint.Type {
+		case FixedJoint:
+			tj.jointType = FixedJoint
+		case RevoluteJoint, ContinuousJoint, PrismaticJoint:
+			if joint.Axis == nil {
+				return nil, fmt.Errorf("joint %q has no axis", joint.Name)
+			}
+			xyz := spaceDelimitedStringToFloatSlice(joint.Axis.XYZ.Value)
+			if len(xyz) != 3 {
+				return nil, fmt.Errorf("joint %q axis must have three values", joint.Name)
+			}
+			tj.axis = r3.Vector{X: xyz[0], Y: xyz[1], Z: xyz[2]}
+			if joint.Axis.UseParentModelFrame || joint.Axis.XYZ.ExpressedIn == sdfModelFrame {
+				// rotate the axis from the model frame into the joint frame
+				rotation := spatialmath.NewPoseFromOrientation(pose.Orientation())
+				tj.axis = spatialmath.PoseBetween(rotation, spatialmath.NewPoseFromPoint(tj.axis)).Point()
+			}
+
+			lower, upper := math.Inf(-1), math.Inf(1)
+			if joint.Axis.Limit != nil && joint.Type != ContinuousJoint {
+				lower, upper = joint.Axis.Limit.Lower, joint.Axis.Limit.Upper
+			}
+			if joint.Type == PrismaticJoint {
+				tj.jointType = PrismaticJoint
+				tj.min, tj.max = utils.MetersToMM(lower), utils.MetersToMM(upper)
+			} else {
+				tj.jointType = RevoluteJoint
+				tj.min, tj.max = utils.RadToDeg(lower), utils.RadToDeg(upper)
+			}
+		default:
+			return nil, NewUnsupportedJointTypeError(joint.Type)
+		}
+		joints = append(joints, tj)
+	}
+
+	mc, err := newModelConfigFromTree(modelName, links, joints)
+	if err != nil {
+		return nil, err
+	}
+	if err := mc.setOriginalFileToJSON(); err != nil {
+		return nil, err
+	}
+	return mc, nil
+}
+
+// ParseModelSDFFile will read a given file and parse the contained SDF XML data into an equivalent Model.
+func ParseModelSDFFile(filename, modelName string) (Model, error) {
+	//nolint:gosec
+	xmlData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read SDF file")
+	}
+
+	mc, err := UnmarshalModelSDF(xmlData, modelName, filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return mc.ParseConfig(modelName)
+}
+
+func (p *sdfPose) parse() (spatialmath.Pose, error) {
+	if p == nil || strings.TrimSpace(p.Value) == "" {
+		return spatialmath.NewZeroPose(), nil
+	}
+	vals := spaceDelimitedStringToFloatSlice(p.Value)
+	if len(vals) != 6 {
+		return nil, fmt.Errorf("pose %q must have six values", p.Value)
+	}
+	if p.Degrees {
+		for i := 3; i < 6; i++ {
+			vals[i] = utils.DegToRad(vals[i])
+		}
+	}
+	return spatialmath.NewPose(
+		r3.Vector{X: utils.MetersToMM(vals[0]), Y: utils.MetersToMM(vals[1]), Z: utils.MetersToMM(vals[2])},
+		&spatialmath.EulerAngles{Roll: vals[3], Pitch: vals[4], Yaw: vals[5]},
+	), nil
+}
+
+func (g *sdfGeometry) toGeometryConfig(pose spatialmath.Pose, meshDir string) (*spatialmath.GeometryConfig, error) {
+	var geom spatialmath.Geometry
+	var err error
+	switch {
+	case g.Box != nil:
+		dims := spaceDelimitedStringToFloatSlice(g.Box.Size)
+		if len(dims) != 3 {
+			return nil, fmt.Errorf("box size %q must have three values", g.Box.Size)
+		}
+		geom, err = spatialmath.NewBox(pose, r3.Vector{
+			X: utils.MetersToMM(dims[0]), Y: utils.MetersToMM(dims[1]), Z: utils.MetersToMM(dims[2]),
+		}, "")
+	case g.Sphere != nil:
+		geom, err = spatialmath.NewSphere(pose, utils.MetersToMM(g.Sphere.Radius), "")
+	case g.Capsule != nil:
+		// SDF capsule lengths do not include the hemispherical caps
+		geom, err = spatialmath.NewCapsule(pose, utils.MetersToMM(g.Capsule.Radius),
+			utils.MetersToMM(g.Capsule.Length+2*g.Capsule.Radius), "")
+	case g.Cylinder != nil:
+		// There is no cylinder geometry, so it is approximated by the smallest capsule which encloses it
+		geom, err = spatialmath.NewCapsule(pose, utils.MetersToMM(g.Cylinder.Radius),
+			utils.MetersToMM(g.Cylinder.Length+2*g.Cylinder.Radius), "")
+	case g.Mesh != nil:
+		return newMeshGeometryConfig(pose, resolveSDFMeshURI(g.Mesh.URI, meshDir), g.Mesh.Scale)
+	default:
+		return nil, errors.New("couldn't parse xml: no supported geometry defined")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spatialmath.NewGeometryConfig(geom)
+}
+
+// resolveSDFMeshURI converts an SDF mesh URI into a path on disk. model:// URIs are taken to be relative to the directory of the
+// model, and any other scheme is stripped.
+func resolveSDFMeshURI(uri, meshDir string) string {
+	path := uri
+	switch {
+	case strings.HasPrefix(uri, "model://"):
+		// model://<model name>/<path within the model>
+		parts := strings.SplitN(strings.TrimPrefix(uri, "model://"), "/", 2)
+		path = parts[len(parts)-1]
+	case strings.HasPrefix(uri, "file://"):
+		path = strings.TrimPrefix(uri, "file://")
+	case strings.HasPrefix(uri, "package://"):
+		path = strings.TrimPrefix(uri, "package://")
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(meshDir, path)
+}
+
+// newMeshGeometryConfig creates a geometry config which refers to a mesh file. Meshes are loaded without scaling, so any scale
+// other than one is rejected.
+func newMeshGeometryConfig(pose spatialmath.Pose, path, scale string) (*spatialmath.GeometryConfig, error) {
+	for _, s := range spaceDelimitedStringToFloatSlice(scale) {
+		if s != 1 {
+			return nil, fmt.Errorf("mesh %s has scale %q but scaled meshes are not supported", path, scale)
+		}
+	}
+	orient, err := spatialmath.NewOrientationConfig(pose.Orientation())
+	if err != nil {
+		return nil, err
+	}
+	return &spatialmath.GeometryConfig{
+		Type:              spatialmath.MeshType,
+		MeshFilePath:      path,
+		TranslationOffset: pose.Point(),
+		OrientationOffset: *orient,
+	}, nil
+}
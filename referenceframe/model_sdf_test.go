@@ -0,0 +1,232 @@
+package referenceframe
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	commonpb "go.viam.com/api/common/v1"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/spatialmath"
+	"go.viam.com/rdk/utils"
+)
+
+// testTwoLinkModel checks a model parsed from one of the two_link test files, which all describe the same arm.
+func testTwoLinkModel(t *testing.T, m Model) {
+	t.Helper()
+	test.That(t, m.Name(), test.ShouldEqual, "two_link")
+	limits := m.DoF()
+	test.That(t, len(limits), test.ShouldEqual, 2)
+	test.That(t, limits[0].Max, test.ShouldAlmostEqual, math.Pi, 1e-4)
+	test.That(t, limits[1].Min, test.ShouldAlmostEqual, -math.Pi/2, 1e-4)
+
+	expected := []struct {
+		inputs []float64
+		point  r3.Vector
+	}{
+		{[]float64{0, 0}, r3.Vector{X: 400, Y: 0, Z: 100}},
+		{[]float64{90, 0}, r3.Vector{X: 0, Y: 400, Z: 100}},
+		{[]float64{0, 90}, r3.Vector{X: 300, Y: 0, Z: 0}},
+	}
+	for _, e := range expected {
+		pose, err := m.Transform([]Input{utils.DegToRad(e.inputs[0]), utils.DegToRad(e.inputs[1])})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.R3VectorAlmostEqual(pose.Point(), e.point, 1e-3), test.ShouldBeTrue)
+	}
+
+	geoms, err := m.Geometries(make([]Input, len(limits)))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(geoms.Geometries()), test.ShouldEqual, 3)
+	for _, g := range geoms.Geometries() {
+		bounds, ok := spatialmath.GeometryAABB(g)
+		test.That(t, ok, test.ShouldBeTrue)
+		switch g.Label() {
+		case "two_link:base":
+			test.That(t, spatialmath.R3VectorAlmostEqual(bounds.Center(), r3.Vector{Z: 50}, 1e-3), test.ShouldBeTrue)
+		case "two_link:upper_arm":
+			// the arm along X should span from the shoulder to the elbow, plus its radius
+			test.That(t, bounds.Min.X, test.ShouldAlmostEqual, -20, 1e-2)
+			test.That(t, bounds.Max.X, test.ShouldAlmostEqual, 320, 1e-2)
+		case "two_link:forearm":
+			test.That(t, spatialmath.R3VectorAlmostEqual(bounds.Center(), r3.Vector{X: 400, Z: 100}, 1e-3), test.ShouldBeTrue)
+		default:
+			t.Fatalf("unexpected geometry %q", g.Label())
+		}
+	}
+}
+
+func TestParseSDFFile(t *testing.T) {
+	m, err := ParseModelSDFFile(utils.ResolveFile("referenceframe/testfiles/two_link.sdf"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testTwoLinkModel(t, m)
+
+	m, err = KinematicModelFromFile(utils.ResolveFile("referenceframe/testfiles/two_link.sdf"), "foo")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, m.Name(), test.ShouldEqual, "foo")
+
+	t.Run("axis in model frame", func(t *testing.T) {
+		sdf := `<sdf version="1.6"><model name="m">
+			<link name="a"/>
+			<link name="b"><pose>0 0 0 0 0 1.5707963</pose></link>
+			<joint name="j" type="prismatic"><parent>a</parent><child>b</child>
+				<axis><xyz expressed_in="__model__">1 0 0</xyz><limit><lower>0</lower><upper>0.5</upper></limit></axis>
+			</joint></model></sdf>`
+		cfg, err := UnmarshalModelSDF([]byte(sdf), "", "")
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(cfg.Joints), test.ShouldEqual, 1)
+		test.That(t, cfg.Joints[0].Max, test.ShouldAlmostEqual, 500)
+		// the joint frame is rotated 90 degrees about Z, so the model X axis is its -Y axis
+		test.That(t, cfg.Joints[0].Axis.Y, test.ShouldAlmostEqual, -1, 1e-6)
+
+		m, err := cfg.ParseConfig("")
+		test.That(t, err, test.ShouldBeNil)
+		pose, err := m.Transform([]Input{100})
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.R3VectorAlmostEqual(pose.Point(), r3.Vector{X: 100}, 1e-3), test.ShouldBeTrue)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := UnmarshalModelSDF([]byte(`<sdf version="1.8"></sdf>`), "", "")
+		test.That(t, err, test.ShouldNotBeNil)
+
+		branching := `<sdf version="1.8"><model name="m">
+			<link name="a"/><link name="b"/><link name="c"/>
+			<joint name="j1" type="fixed"><parent>a</parent><child>b</child></joint>
+			<joint name="j2" type="fixed"><parent>a</parent><child>c</child></joint>
+			</model></sdf>`
+		_, err = UnmarshalModelSDF([]byte(branching), "", "")
+		test.That(t, err, test.ShouldNotBeNil)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "serial chains")
+
+		circular := `<sdf version="1.8"><model name="m">
+			<link name="a"><pose relative_to="b"/></link><link name="b"><pose relative_to="a"/></link>
+			</model></sdf>`
+		_, err = UnmarshalModelSDF([]byte(circular), "", "")
+		test.That(t, err, test.ShouldBeError, ErrCircularReference)
+	})
+}
+
+// writeTetrahedronSTL writes an ASCII STL tetrahedron with 0.1m edges along the axes and returns its path.
+func writeTetrahedronSTL(t *testing.T, dir string) string {
+	t.Helper()
+	stl := `solid tetra
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0.1 0 0
+vertex 0 0.1 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 0.1 0
+vertex 0 0 0.1
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0 0 0
+vertex 0 0 0.1
+vertex 0.1 0 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 0.1 0 0
+vertex 0 0 0.1
+vertex 0 0.1 0
+endloop
+endfacet
+endsolid tetra
+`
+	path := filepath.Join(dir, "tetra.stl")
+	test.That(t, os.WriteFile(path, []byte(stl), 0o600), test.ShouldBeNil)
+	return path
+}
+
+// testModelProtobufRoundTrip checks that a model converted from another format can be rebuilt from its protobuf, as
+// remote clients do, and that its mesh, when it has one, is sent along with it.
+func testModelProtobufRoundTrip(t *testing.T, m Model) {
+	t.Helper()
+	resp := KinematicModelToProtobuf(m)
+	test.That(t, resp.Format, test.ShouldEqual, commonpb.KinematicsFileFormat_KINEMATICS_FILE_FORMAT_SVA)
+	rebuilt, err := KinematicModelFromProtobuf(m.Name(), resp)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rebuilt.DoF(), test.ShouldResemble, m.DoF())
+
+	inputs := make([]Input, len(m.DoF()))
+	for i, limit := range m.DoF() {
+		inputs[i] = (limit.Min + limit.Max) / 4
+	}
+	pose, err := m.Transform(inputs)
+	test.That(t, err, test.ShouldBeNil)
+	rebuiltPose, err := rebuilt.Transform(inputs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, spatialmath.PoseAlmostEqual(pose, rebuiltPose), test.ShouldBeTrue)
+
+	geoms, err := m.Geometries(inputs)
+	test.That(t, err, test.ShouldBeNil)
+	rebuiltGeoms, err := rebuilt.Geometries(inputs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(rebuiltGeoms.Geometries()), test.ShouldEqual, len(geoms.Geometries()))
+	for _, g := range geoms.Geometries() {
+		rebuiltGeom := rebuiltGeoms.GeometryByName(g.Label())
+		test.That(t, rebuiltGeom, test.ShouldNotBeNil)
+		bounds, _ := spatialmath.GeometryAABB(g)
+		rebuiltBounds, _ := spatialmath.GeometryAABB(rebuiltGeom)
+		test.That(t, spatialmath.R3VectorAlmostEqual(rebuiltBounds.Min, bounds.Min, 1e-3), test.ShouldBeTrue)
+		test.That(t, spatialmath.R3VectorAlmostEqual(rebuiltBounds.Max, bounds.Max, 1e-3), test.ShouldBeTrue)
+	}
+}
+
+func TestConvertedModelProtobuf(t *testing.T) {
+	m, err := ParseModelSDFFile(utils.ResolveFile("referenceframe/testfiles/two_link.sdf"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testModelProtobufRoundTrip(t, m)
+	m, err = ParseModelMJCFFile(utils.ResolveFile("referenceframe/testfiles/two_link_mjcf.xml"), "")
+	test.That(t, err, test.ShouldBeNil)
+	testModelProtobufRoundTrip(t, m)
+
+	// mesh paths are relative to the model file, which clients do not have
+	dir := t.TempDir()
+	writeTetrahedronSTL(t, dir)
+	sdf := `<sdf version="1.8"><model name="m">
+		<link name="a"><collision name="c"><pose>0.1 0 0 0 0 0</pose>
+			<geometry><mesh><uri>model://m/tetra.stl</uri></mesh></geometry></collision></link>
+		<link name="b"/>
+		<joint name="j" type="revolute"><parent>a</parent><child>b</child>
+			<axis><xyz>0 0 1</xyz><limit><lower>-1</lower><upper>1</upper></limit></axis></joint>
+		</model></sdf>`
+	sdfPath := filepath.Join(dir, "mesh.sdf")
+	test.That(t, os.WriteFile(sdfPath, []byte(sdf), 0o600), test.ShouldBeNil)
+	m, err = ParseModelSDFFile(sdfPath, "")
+	test.That(t, err, test.ShouldBeNil)
+	geoms, err := m.Geometries([]Input{0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(geoms.Geometries()), test.ShouldEqual, 1)
+	bounds, _ := spatialmath.GeometryAABB(geoms.Geometries()[0])
+	test.That(t, spatialmath.R3VectorAlmostEqual(bounds.Min, r3.Vector{X: 100}, 1e-3), test.ShouldBeTrue)
+	test.That(t, spatialmath.R3VectorAlmostEqual(bounds.Max, r3.Vector{X: 200, Y: 100, Z: 100}, 1e-3), test.ShouldBeTrue)
+	testModelProtobufRoundTrip(t, m)
+
+	mjcf := `<mujoco model="m"><asset><mesh file="tetra.stl"/></asset><worldbody>
+		<body name="a"><joint name="j" axis="0 0 1" range="-90 90"/><geom type="mesh" mesh="tetra" pos="0 0 0.1"/></body>
+		</worldbody></mujoco>`
+	mjcfPath := filepath.Join(dir, "mesh.xml")
+	test.That(t, os.WriteFile(mjcfPath, []byte(mjcf), 0o600), test.ShouldBeNil)
+	m, err = KinematicModelFromFile(mjcfPath, "")
+	test.That(t, err, test.ShouldBeNil)
+	geoms, err = m.Geometries([]Input{0})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(geoms.Geometries()), test.ShouldEqual, 1)
+	bounds, _ = spatialmath.GeometryAABB(geoms.Geometries()[0])
+	test.That(t, spatialmath.R3VectorAlmostEqual(bounds.Max, r3.Vector{X: 100, Y: 100, Z: 200}, 1e-3), test.ShouldBeTrue)
+	testModelProtobufRoundTrip(t, m)
+
+	// the mesh is embedded rather than referred to by its path
+	test.That(t, string(KinematicModelToProtobuf(m).KinematicsData), test.ShouldNotContainSubstring, dir)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 
 	"github.com/golang/geo/r3"
 	"github.com/pkg/errors"
@@ -38,6 +39,7 @@ type jointXML struct {
 	Origin  *pose    `xml:"origin,omitempty"`
 	Axis    *axis    `xml:"axis,omitempty"`
 	Limit   *limit   `xml:"limit,omitempty"`
+	Mimic   *mimic   `xml:"mimic,omitempty"`
 }
 
 // NewModelFromWorldState creates a ModelConfigURDF struct which can be marshalled into xml and will be a
@@ -52,13 +54,13 @@ func NewModelFromWorldState(ws *WorldState, name string) (*ModelConfigURDF, erro
 		return nil, err
 	}
 	for _, g := range gf.Geometries() {
-		coll, err := newCollision(g)
+		colls, err := newCollisions(g)
 		if err != nil {
 			return nil, err
 		}
 		links = append(links, linkXML{
 			Name:      g.Label(),
-			Collision: []collision{*coll},
+			Collision: colls,
 		})
 		joints = append(joints, jointXML{
 			Name:   g.Label() + "_joint",
@@ -74,6 +76,121 @@ func NewModelFromWorldState(ws *WorldState, name string) (*ModelConfigURDF, erro
 	}, nil
 }
 
+// NewModelFromFrameSystem creates a ModelConfigURDF struct which can be marshalled into xml and will be a valid .urdf file
+// representing every frame of the given frame system. Models are expanded into a link per kinematic piece, named
+// "<frame>:<piece>", followed by a link with the name of the frame itself so that its children attach to its end.
+// Geometries are exported at zero inputs, and meshes and points, which URDF cannot represent, are left out.
+func NewModelFromFrameSystem(fs *FrameSystem, name string) (*ModelConfigURDF, error) {
+	urdf := &ModelConfigURDF{Name: name, Links: []linkXML{{Name: World}}}
+
+	children := map[string][]string{}
+	for _, frameName := range fs.FrameNames() {
+		parent := fs.parents[frameName]
+		children[parent] = append(children[parent], frameName)
+	}
+	// walk the tree from the world frame so that parents are always written before their children
+	queue := []string{World}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		sort.Strings(children[parent])
+		for _, frameName := range children[parent] {
+			if err := urdf.addFrame(frameName, fs.Frame(frameName), parent); err != nil {
+				return nil, err
+			}
+			queue = append(queue, frameName)
+		}
+	}
+	return urdf, nil
+}
+
+// addFrame adds a link with the given name and the joint connecting it to its parent link to the URDF.
+func (urdf *ModelConfigURDF) addFrame(name string, f Frame, parent string) error {
+	for unwrapped := false; !unwrapped; {
+		switch wrapper := f.(type) {
+		case *namedFrame:
+			f = wrapper.Frame
+		case *mimicFrame:
+			f = wrapper.Frame
+		default:
+			unwrapped = true
+		}
+	}
+
+	joint := jointXML{Name: name + "_joint", Type: FixedJoint, Parent: frame{parent}, Child: frame{name}}
+	zeroInputs := make([]Input, len(f.DoF()))
+	switch typedFrame := f.(type) {
+	case *SimpleModel:
+		for _, transform := range typedFrame.OrdTransforms() {
+			pieceName := name + ":" + transform.Name()
+			if err := urdf.addFrame(pieceName, transform, parent); err != nil {
+				return err
+			}
+			if mimic, ok := transform.(*mimicFrame); ok {
+				joint := &urdf.Joints[len(urdf.Joints)-1]
+				joint.Mimic = newMimic(name+":"+mimic.source+"_joint", joint.Type, mimic)
+			}
+			parent = pieceName
+		}
+		joint.Parent = frame{parent}
+		joint.Origin = newPose(spatialmath.NewZeroPose())
+		urdf.Links = append(urdf.Links, linkXML{Name: name})
+		urdf.Joints = append(urdf.Joints, joint)
+		return nil
+	case *rotationalFrame:
+		joint.Axis = &axis{XYZ: fmt.Sprintf("%f %f %f", typedFrame.rotAxis.X, typedFrame.rotAxis.Y, typedFrame.rotAxis.Z)}
+		joint.Type = ContinuousJoint
+		if dof := typedFrame.DoF()[0]; !math.IsInf(dof.Min, -1) || !math.IsInf(dof.Max, 1) {
+			joint.Type = RevoluteJoint
+			joint.Limit = &limit{Lower: dof.Min, Upper: dof.Max, Effort: defaultURDFEffort, Velocity: defaultURDFRevoluteVelocity}
+		}
+	case *translationalFrame:
+		joint.Type = PrismaticJoint
+		joint.Axis = &axis{XYZ: fmt.Sprintf("%f %f %f", typedFrame.transAxis.X, typedFrame.transAxis.Y, typedFrame.transAxis.Z)}
+		dof := typedFrame.DoF()[0]
+		joint.Limit = &limit{
+			Lower:    utils.MMToMeters(dof.Min),
+			Upper:    utils.MMToMeters(dof.Max),
+			Effort:   defaultURDFEffort,
+			Velocity: defaultURDFPrismaticVelocity,
+		}
+	case *poseFrame:
+		joint.Type = "floating"
+		zeroInputs = PoseToInputs(spatialmath.NewZeroPose())
+	default:
+		if len(f.DoF()) > 0 {
+			return fmt.Errorf("cannot convert frame %q of type %T with %d degrees of freedom to URDF", name, f, len(f.DoF()))
+		}
+	}
+
+	pose, err := f.Transform(zeroInputs)
+	if err != nil {
+		return err
+	}
+	// the pose of a moving frame at zero inputs is the identity, as required of a URDF joint origin
+	joint.Origin = newPose(pose)
+
+	// geometries are returned in the frame's parent frame, so they are moved into the frame of the new link
+	gif, err := f.Geometries(zeroInputs)
+	if err != nil {
+		return err
+	}
+	link := linkXML{Name: name}
+	for _, g := range gif.Geometries() {
+		colls, err := newCollisions(g.Transform(spatialmath.PoseInverse(pose)))
+		if errors.Is(err, errGeometryTypeUnsupported) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		link.Collision = append(link.Collision, colls...)
+	}
+	urdf.Links = append(urdf.Links, link)
+	urdf.Joints = append(urdf.Joints, joint)
+	return nil
+}
+
 // UnmarshalModelXML will transfer the given URDF XML data into an equivalent ModelConfig. Direct unmarshaling in the
 // same fashion as ModelJSON is not possible, as URDF data will need to be evaluated to accommodate differences
 // between the two kinematics encoding schemes.
@@ -127,6 +244,9 @@ func UnmarshalModelXML(xmlData []byte, modelName string) (*ModelConfigJSON, erro
 			if jointElem.Axis != nil {
 				thisJoint.Axis = jointElem.Axis.Parse()
 			}
+			if jointElem.Mimic != nil {
+				thisJoint.Mimic = jointElem.Mimic.toConfig(jointElem.Type)
+			}
 
 			// Slightly different limits handling for continuous, revolute, and prismatic joints
 			switch jointElem.Type {
@@ -51,3 +51,176 @@ func TestWorldStateConversion(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	test.That(t, bytes, test.ShouldNotBeNil)
 }
+
+func TestFrameSystemToURDF(t *testing.T) {
+	arm, err := ParseModelJSONFile(utils.ResolveFile("referenceframe/testfiles/ur5e.json"), "")
+	test.That(t, err, test.ShouldBeNil)
+	gantry, err := ParseModelXMLFile(utils.ResolveFile("referenceframe/testfiles/example_gantry.xml"), "")
+	test.That(t, err, test.ShouldBeNil)
+	cameraBox, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: 10}), r3.Vector{X: 20, Y: 20, Z: 20}, "")
+	test.That(t, err, test.ShouldBeNil)
+
+	armPose := spatialmath.NewPose(r3.Vector{X: 100, Y: 200}, &spatialmath.OrientationVectorDegrees{OZ: 1, Theta: 90})
+	parts := []*FrameSystemPart{
+		{FrameConfig: NewLinkInFrame(World, armPose, "arm", nil), ModelFrame: arm},
+		{FrameConfig: NewLinkInFrame("arm", spatialmath.NewPoseFromPoint(r3.Vector{Z: 50}), "camera", cameraBox)},
+		{FrameConfig: NewLinkInFrame(World, spatialmath.NewPoseFromPoint(r3.Vector{Y: -500}), "gantry", nil), ModelFrame: gantry},
+	}
+	fs, err := NewFrameSystem("test", parts, nil)
+	test.That(t, err, test.ShouldBeNil)
+
+	cfg, err := NewModelFromFrameSystem(fs, "test")
+	test.That(t, err, test.ShouldBeNil)
+	bytes, err := xml.MarshalIndent(cfg, "", "  ")
+	test.That(t, err, test.ShouldBeNil)
+	urdf := &ModelConfigURDF{}
+	test.That(t, xml.Unmarshal(bytes, urdf), test.ShouldBeNil)
+	checkURDF(t, bytes)
+
+	jointTypes := map[string]int{}
+	for _, joint := range urdf.Joints {
+		jointTypes[joint.Type]++
+	}
+	test.That(t, jointTypes[RevoluteJoint], test.ShouldEqual, 6)
+	test.That(t, jointTypes[PrismaticJoint], test.ShouldEqual, 2)
+	test.That(t, len(urdf.Joints), test.ShouldEqual, len(urdf.Links)-1)
+
+	// chaining the joint origins at zero inputs must place every frame where the frame system does
+	linkPoses := map[string]spatialmath.Pose{World: spatialmath.NewZeroPose()}
+	for _, joint := range urdf.Joints {
+		parentPose, ok := linkPoses[joint.Parent.Link]
+		test.That(t, ok, test.ShouldBeTrue)
+		linkPoses[joint.Child.Link] = spatialmath.Compose(parentPose, joint.Origin.Parse())
+	}
+	zeroInputs := NewZeroInputs(fs).ToLinearInputs()
+	for _, name := range fs.FrameNames() {
+		tf, err := fs.Transform(zeroInputs, NewPoseInFrame(name, spatialmath.NewZeroPose()), World)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, spatialmath.PoseAlmostCoincidentEps(linkPoses[name], tf.(*PoseInFrame).Pose(), 1e-3), test.ShouldBeTrue)
+	}
+
+	// as must placing every collision geometry relative to its link
+	geoms, err := FrameSystemGeometries(fs, NewZeroInputs(fs))
+	test.That(t, err, test.ShouldBeNil)
+	expected := []r3.Vector{}
+	for _, gif := range geoms {
+		for _, g := range gif.Geometries() {
+			expected = append(expected, g.Pose().Point())
+		}
+	}
+	actual := []r3.Vector{}
+	for _, link := range urdf.Links {
+		for _, coll := range link.Collision {
+			actual = append(actual, spatialmath.Compose(linkPoses[link.Name], coll.Origin.Parse()).Point())
+		}
+	}
+	// capsules are written with a sphere capping each end
+	capsules := 0
+	for _, gif := range geoms {
+		for _, g := range gif.Geometries() {
+			if cfg, err := spatialmath.NewGeometryConfig(g); err == nil && cfg.Type == spatialmath.CapsuleType {
+				capsules++
+			}
+		}
+	}
+	test.That(t, len(actual), test.ShouldEqual, len(expected)+2*capsules)
+	for _, pt := range expected {
+		found := false
+		for _, other := range actual {
+			found = found || spatialmath.R3VectorAlmostEqual(pt, other, 1e-3)
+		}
+		test.That(t, found, test.ShouldBeTrue)
+	}
+}
+
+// checkURDF checks the URDF the way urdfdom, which MoveIt and ROS load URDF files with, does: links and joints are
+// named, joints connect existing links, revolute and prismatic joints have limits with an effort and a velocity, and
+// collision geometries have the attributes of their shape.
+func checkURDF(t *testing.T, data []byte) {
+	t.Helper()
+	type strictGeometry struct {
+		Box *struct {
+			Size *string `xml:"size,attr"`
+		} `xml:"box"`
+		Sphere *struct {
+			Radius *float64 `xml:"radius,attr"`
+		} `xml:"sphere"`
+		Cylinder *struct {
+			Radius *float64 `xml:"radius,attr"`
+			Length *float64 `xml:"length,attr"`
+		} `xml:"cylinder"`
+		Mesh *struct{} `xml:"mesh"`
+	}
+	var robot struct {
+		Name  *string `xml:"name,attr"`
+		Links []struct {
+			Name      *string `xml:"name,attr"`
+			Collision []struct {
+				Geometry *strictGeometry `xml:"geometry"`
+			} `xml:"collision"`
+		} `xml:"link"`
+		Joints []struct {
+			Name   *string `xml:"name,attr"`
+			Type   string  `xml:"type,attr"`
+			Parent *frame  `xml:"parent"`
+			Child  *frame  `xml:"child"`
+			Limit  *struct {
+				Lower    *float64 `xml:"lower,attr"`
+				Upper    *float64 `xml:"upper,attr"`
+				Effort   *float64 `xml:"effort,attr"`
+				Velocity *float64 `xml:"velocity,attr"`
+			} `xml:"limit"`
+		} `xml:"joint"`
+	}
+	test.That(t, xml.Unmarshal(data, &robot), test.ShouldBeNil)
+	test.That(t, robot.Name, test.ShouldNotBeNil)
+
+	links := map[string]bool{}
+	for _, link := range robot.Links {
+		test.That(t, link.Name, test.ShouldNotBeNil)
+		test.That(t, links[*link.Name], test.ShouldBeFalse)
+		links[*link.Name] = true
+		for _, coll := range link.Collision {
+			g := coll.Geometry
+			test.That(t, g, test.ShouldNotBeNil)
+			shapes := 0
+			if g.Box != nil {
+				shapes++
+				test.That(t, g.Box.Size, test.ShouldNotBeNil)
+				test.That(t, len(spaceDelimitedStringToFloatSlice(*g.Box.Size)), test.ShouldEqual, 3)
+			}
+			if g.Sphere != nil {
+				shapes++
+				test.That(t, g.Sphere.Radius, test.ShouldNotBeNil)
+			}
+			if g.Cylinder != nil {
+				shapes++
+				test.That(t, g.Cylinder.Radius, test.ShouldNotBeNil)
+				test.That(t, g.Cylinder.Length, test.ShouldNotBeNil)
+			}
+			if g.Mesh != nil {
+				shapes++
+			}
+			test.That(t, shapes, test.ShouldEqual, 1)
+		}
+	}
+	for _, joint := range robot.Joints {
+		test.That(t, joint.Name, test.ShouldNotBeNil)
+		test.That(t, []string{FixedJoint, RevoluteJoint, ContinuousJoint, PrismaticJoint, "floating", "planar"},
+			test.ShouldContain, joint.Type)
+		test.That(t, joint.Parent, test.ShouldNotBeNil)
+		test.That(t, joint.Child, test.ShouldNotBeNil)
+		test.That(t, links[joint.Parent.Link], test.ShouldBeTrue)
+		test.That(t, links[joint.Child.Link], test.ShouldBeTrue)
+		if joint.Type == RevoluteJoint || joint.Type == PrismaticJoint {
+			test.That(t, joint.Limit, test.ShouldNotBeNil)
+			test.That(t, joint.Limit.Lower, test.ShouldNotBeNil)
+			test.That(t, joint.Limit.Upper, test.ShouldNotBeNil)
+			// a zero effort or velocity makes the joint immovable
+			test.That(t, joint.Limit.Effort, test.ShouldNotBeNil)
+			test.That(t, *joint.Limit.Effort, test.ShouldBeGreaterThan, 0)
+			test.That(t, joint.Limit.Velocity, test.ShouldNotBeNil)
+			test.That(t, *joint.Limit.Velocity, test.ShouldBeGreaterThan, 0)
+		}
+	}
+}
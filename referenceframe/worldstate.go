@@ -8,6 +8,7 @@ import (
 	commonpb "go.viam.com/api/common/v1"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/spatialmath"
 )
 
@@ -196,3 +197,14 @@ func (ws *WorldState) ObstaclesInWorldFrame(fs *FrameSystem, inputs FrameSystemI
 	}
 	return NewGeometriesInFrame(World, allGeometries), nil
 }
+
+// NewWorldStateFromOccupancyMap returns a WorldState whose obstacles are boxes filling the occupied voxels of an
+// occupancy map, where the map is built in the given frame. The boxes are labeled by suffixing the label with their
+// index.
+func NewWorldStateFromOccupancyMap(m *pointcloud.OccupancyMap, frame, label string) (*WorldState, error) {
+	boxes, err := m.ToBoxes(label)
+	if err != nil {
+		return nil, err
+	}
+	return NewWorldState([]*GeometriesInFrame{NewGeometriesInFrame(frame, boxes)}, nil)
+}
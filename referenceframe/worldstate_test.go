@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/golang/geo/r3"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"go.viam.com/test"
 
+	"go.viam.com/rdk/pointcloud"
 	"go.viam.com/rdk/spatialmath"
 )
 
@@ -44,6 +46,27 @@ func TestWorldStateConstruction(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 }
 
+func TestWorldStateFromOccupancyMap(t *testing.T) {
+	m, err := pointcloud.NewOccupancyMap(pointcloud.OccupancyMapConfig{VoxelSize: 10})
+	test.That(t, err, test.ShouldBeNil)
+	cloud := pointcloud.NewBasicPointCloud(0)
+	test.That(t, cloud.Set(r3.Vector{Z: 505}, nil), test.ShouldBeNil)
+	m.Insert(cloud, r3.Vector{})
+
+	ws, err := NewWorldStateFromOccupancyMap(m, World, "occupancy")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ws.ObstacleNames(), test.ShouldResemble, map[string]bool{"occupancy_0": true})
+	test.That(t, len(ws.Obstacles()), test.ShouldEqual, 1)
+	test.That(t, ws.Obstacles()[0].Parent(), test.ShouldEqual, World)
+
+	// the box overlaps the occupied voxel, from z=500 to z=510, without reaching its center
+	box, err := spatialmath.NewBox(spatialmath.NewPoseFromPoint(r3.Vector{X: 5, Y: 5, Z: 498}), r3.Vector{X: 4, Y: 4, Z: 6}, "")
+	test.That(t, err, test.ShouldBeNil)
+	collides, err := ws.Obstacles()[0].Geometries()[0].CollidesWith(box, 0)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, collides, test.ShouldBeTrue)
+}
+
 func TestString(t *testing.T) {
 	foo, err := spatialmath.NewSphere(spatialmath.NewZeroPose(), 10, "foo")
 	test.That(t, err, test.ShouldBeNil)
@@ -21,9 +21,10 @@ type collision struct {
 	XMLName  xml.Name `xml:"collision"`
 	Origin   *pose    `xml:"origin"`
 	Geometry struct {
-		XMLName xml.Name `xml:"geometry"`
-		Box     *box     `xml:"box,omitempty"`
-		Sphere  *sphere  `xml:"sphere,omitempty"`
+		XMLName  xml.Name  `xml:"geometry"`
+		Box      *box      `xml:"box,omitempty"`
+		Sphere   *sphere   `xml:"sphere,omitempty"`
+		Cylinder *cylinder `xml:"cylinder,omitempty"`
 	} `xml:"geometry"`
 }
 
@@ -37,12 +38,22 @@ type sphere struct {
 	Radius  float64  `xml:"radius,attr"` // in meters
 }
 
-func newCollision(g spatialmath.Geometry) (*collision, error) {
+type cylinder struct {
+	XMLName xml.Name `xml:"cylinder"`
+	Radius  float64  `xml:"radius,attr"` // in meters
+	Length  float64  `xml:"length,attr"` // in meters
+}
+
+// newCollisions returns the URDF collision elements making up the geometry.
+func newCollisions(g spatialmath.Geometry) ([]collision, error) {
+	if _, ok := g.(*spatialmath.Mesh); ok {
+		return nil, fmt.Errorf("%w %T", errGeometryTypeUnsupported, g)
+	}
 	cfg, err := spatialmath.NewGeometryConfig(g)
 	if err != nil {
 		return nil, err
 	}
-	urdf := &collision{
+	urdf := collision{
 		Origin: newPose(g.Pose()),
 	}
 	//nolint:exhaustive
@@ -51,10 +62,21 @@ func newCollision(g spatialmath.Geometry) (*collision, error) {
 		urdf.Geometry.Box = &box{Size: fmt.Sprintf("%f %f %f", utils.MMToMeters(cfg.X), utils.MMToMeters(cfg.Y), utils.MMToMeters(cfg.Z))}
 	case spatialmath.SphereType:
 		urdf.Geometry.Sphere = &sphere{Radius: utils.MMToMeters(cfg.R)}
+	case spatialmath.CapsuleType:
+		// URDF has no capsules, so the capsule is written as the cylinder of its straight section followed by the spheres
+		// capping its ends, which make up the same volume. toGeometry reads the cylinder back as the same capsule.
+		urdf.Geometry.Cylinder = &cylinder{Radius: utils.MMToMeters(cfg.R), Length: utils.MMToMeters(cfg.L - 2*cfg.R)}
+		collisions := []collision{urdf}
+		for _, z := range []float64{cfg.L/2 - cfg.R, cfg.R - cfg.L/2} {
+			end := collision{Origin: newPose(spatialmath.Compose(g.Pose(), spatialmath.NewPoseFromPoint(r3.Vector{Z: z})))}
+			end.Geometry.Sphere = &sphere{Radius: utils.MMToMeters(cfg.R)}
+			collisions = append(collisions, end)
+		}
+		return collisions, nil
 	default:
 		return nil, fmt.Errorf("%w %s", errGeometryTypeUnsupported, fmt.Sprintf("%T", cfg.Type))
 	}
-	return urdf, nil
+	return []collision{urdf}, nil
 }
 
 func (c *collision) toGeometry() (spatialmath.Geometry, error) {
@@ -68,6 +90,10 @@ func (c *collision) toGeometry() (spatialmath.Geometry, error) {
 		)
 	case c.Geometry.Sphere != nil:
 		return spatialmath.NewSphere(c.Origin.Parse(), utils.MetersToMM(c.Geometry.Sphere.Radius), "")
+	case c.Geometry.Cylinder != nil:
+		// a cylinder is represented by the capsule which encloses it
+		r := utils.MetersToMM(c.Geometry.Cylinder.Radius)
+		return spatialmath.NewCapsule(c.Origin.Parse(), r, utils.MetersToMM(c.Geometry.Cylinder.Length)+2*r, "")
 	default:
 		return nil, errors.New("couldn't parse xml: no geometry defined")
 	}
@@ -77,10 +103,48 @@ type frame struct {
 	Link string `xml:"link,attr"`
 }
 
+const (
+	// defaultURDFEffort is the effort limit written for exported joints, in N for prismatic joints and in N m for
+	// revolute joints. Models do not track effort, but URDF parsers require it and take zero as an immovable joint.
+	defaultURDFEffort = 1000.
+	// defaultURDFRevoluteVelocity and defaultURDFPrismaticVelocity are the velocity limits written for exported joints,
+	// in rad/s and m/s, which models do not track either.
+	defaultURDFRevoluteVelocity  = math.Pi
+	defaultURDFPrismaticVelocity = 1.
+)
+
 type limit struct {
-	XMLName xml.Name `xml:"limit"`
-	Lower   float64  `xml:"lower,attr"` // translation limits are in meters, revolute limits are in radians
-	Upper   float64  `xml:"upper,attr"` // translation limits are in meters, revolute limits are in radians
+	XMLName  xml.Name `xml:"limit"`
+	Lower    float64  `xml:"lower,attr"` // translation limits are in meters, revolute limits are in radians
+	Upper    float64  `xml:"upper,attr"` // translation limits are in meters, revolute limits are in radians
+	Effort   float64  `xml:"effort,attr"`
+	Velocity float64  `xml:"velocity,attr"`
+}
+
+type mimic struct {
+	XMLName    xml.Name `xml:"mimic"`
+	Joint      string   `xml:"joint,attr"`
+	Multiplier *float64 `xml:"multiplier,attr"`
+	Offset     float64  `xml:"offset,attr"` // translation offsets are in meters, revolute offsets are in radians
+}
+
+func newMimic(joint, jointType string, mf *mimicFrame) *mimic {
+	multiplier := mf.multiplier
+	offset := mf.offset
+	if jointType == PrismaticJoint {
+		offset = utils.MMToMeters(offset)
+	}
+	return &mimic{Joint: joint, Multiplier: &multiplier, Offset: offset}
+}
+
+func (m *mimic) toConfig(jointType string) *MimicConfig {
+	cfg := &MimicConfig{Joint: m.Joint, Multiplier: m.Multiplier}
+	if jointType == PrismaticJoint {
+		cfg.Offset = utils.MetersToMM(m.Offset)
+	} else {
+		cfg.Offset = utils.RadToDeg(m.Offset)
+	}
+	return cfg
 }
 
 type axis struct {
@@ -21,6 +21,7 @@ func TestGeometrySerialization(t *testing.T) {
 	test.That(t, err, test.ShouldBeNil)
 	capsule, err := spatialmath.NewCapsule(spatialmath.NewZeroPose(), 1, 10, "")
 	test.That(t, err, test.ShouldBeNil)
+	point := spatialmath.NewPoint(r3.Vector{X: 1}, "")
 
 	testCases := []struct {
 		name    string
@@ -29,18 +30,19 @@ func TestGeometrySerialization(t *testing.T) {
 	}{
 		{"box", box, true},
 		{"sphere", sphere, true},
-		{"capsule", capsule, false},
+		{"capsule", capsule, true},
+		{"point", point, false},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			urdf, err := newCollision(tc.g)
+			urdf, err := newCollisions(tc.g)
 			if !tc.success {
 				test.That(t, err.Error(), test.ShouldContainSubstring, errGeometryTypeUnsupported.Error())
 				return
 			}
 			test.That(t, err, test.ShouldBeNil)
-			bytes, err := xml.MarshalIndent(urdf, "", "  ")
+			bytes, err := xml.MarshalIndent(urdf[0], "", "  ")
 			test.That(t, err, test.ShouldBeNil)
 			var urdf2 collision
 			xml.Unmarshal(bytes, &urdf2)
@@ -49,4 +51,47 @@ func TestGeometrySerialization(t *testing.T) {
 			test.That(t, spatialmath.GeometriesAlmostEqual(tc.g, g2), test.ShouldBeTrue)
 		})
 	}
+
+	t.Run("capsule", func(t *testing.T) {
+		// capsules are written as the cylinders of their straight sections and the spheres capping their ends
+		tilted, err := spatialmath.NewCapsule(
+			spatialmath.NewPose(r3.Vector{X: 5}, &spatialmath.OrientationVectorDegrees{OX: 1, Theta: 40}), 1, 10, "")
+		test.That(t, err, test.ShouldBeNil)
+		urdf, err := newCollisions(tilted)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(urdf), test.ShouldEqual, 3)
+		test.That(t, urdf[0].Geometry.Cylinder, test.ShouldNotBeNil)
+		test.That(t, urdf[0].Geometry.Cylinder.Radius, test.ShouldAlmostEqual, 0.001)
+		test.That(t, urdf[0].Geometry.Cylinder.Length, test.ShouldAlmostEqual, 0.008)
+		ends := []r3.Vector{}
+		for _, end := range urdf[1:] {
+			test.That(t, end.Geometry.Sphere, test.ShouldNotBeNil)
+			test.That(t, end.Geometry.Sphere.Radius, test.ShouldAlmostEqual, 0.001)
+			ends = append(ends, end.Origin.Parse().Point())
+		}
+		// the spheres are centered on the ends of the segment of the capsule
+		for _, z := range []float64{4, -4} {
+			pt := spatialmath.Compose(tilted.Pose(), spatialmath.NewPoseFromPoint(r3.Vector{Z: z})).Point()
+			test.That(t, spatialmath.R3VectorAlmostEqual(pt, ends[0], 1e-3) || spatialmath.R3VectorAlmostEqual(pt, ends[1], 1e-3),
+				test.ShouldBeTrue)
+		}
+
+		// and a round trip through URDF leaves them unchanged
+		g := spatialmath.Geometry(capsule)
+		for i := 0; i < 3; i++ {
+			urdf, err := newCollisions(g)
+			test.That(t, err, test.ShouldBeNil)
+			bytes, err := xml.Marshal(urdf[0])
+			test.That(t, err, test.ShouldBeNil)
+			var urdf2 collision
+			test.That(t, xml.Unmarshal(bytes, &urdf2), test.ShouldBeNil)
+			g, err = urdf2.toGeometry()
+			test.That(t, err, test.ShouldBeNil)
+		}
+		cfg, err := spatialmath.NewGeometryConfig(g)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cfg.Type, test.ShouldEqual, spatialmath.CapsuleType)
+		test.That(t, cfg.R, test.ShouldAlmostEqual, 1)
+		test.That(t, cfg.L, test.ShouldAlmostEqual, 10)
+	})
 }
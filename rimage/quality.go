@@ -0,0 +1,157 @@
+package rimage
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// HistogramBins is the number of bins of the luma histograms of image qualities.
+const HistogramBins = 16
+
+const (
+	// underexposedLuma and overexposedLuma are the luma at or below which pixels are underexposed and at or above
+	// which they are overexposed.
+	underexposedLuma = 8
+	overexposedLuma  = 247
+	// saturatedValue is the value at or above which channels are clipped, leaving room for the rounding of YCbCr images.
+	saturatedValue = 250
+)
+
+// ImageQuality are quality metrics of an image, for telling useful frames apart from blurry, badly exposed or black
+// ones. Luma is that of Rec. 601, from 0 to 255.
+type ImageQuality struct {
+	// Focus is the variance of the Laplacian of the luma, which is low for blurry images and featureless scenes.
+	Focus float64
+	// MeanLuma is the mean luma, from 0 to 255.
+	MeanLuma float64
+	// Contrast is the standard deviation of the luma.
+	Contrast float64
+	// UnderexposedFraction and OverexposedFraction are the fractions of pixels which are nearly black and nearly white.
+	UnderexposedFraction float64
+	OverexposedFraction  float64
+	// SaturatedFraction is the fraction of pixels with a clipped color channel.
+	SaturatedFraction float64
+	// Histogram is the fraction of pixels in each of HistogramBins even bins of luma.
+	Histogram []float64
+}
+
+// luma returns the luma of the pixels of the image, row by row, and whether each pixel has a saturated channel.
+func luma(img image.Image) ([]uint8, []bool) {
+	b := img.Bounds()
+	lumas := make([]uint8, b.Dx()*b.Dy())
+	saturated := make([]bool, len(lumas))
+	i := 0
+	switch im := img.(type) {
+	case *image.YCbCr:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				lumas[i] = im.Y[im.YOffset(x, y)]
+				c := im.YCbCrAt(x, y)
+				r, g, bl := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+				saturated[i] = max(r, g, bl) >= saturatedValue
+				i++
+			}
+		}
+	case *image.Gray:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				lumas[i] = im.Pix[im.PixOffset(x, y)]
+				saturated[i] = lumas[i] >= saturatedValue
+				i++
+			}
+		}
+	default:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(bl>>8)
+				lumas[i], _, _ = color.RGBToYCbCr(r8, g8, b8)
+				saturated[i] = max(r8, g8, b8) >= saturatedValue
+				i++
+			}
+		}
+	}
+	return lumas, saturated
+}
+
+// LumaImage returns the luma of the image as a gray image.
+func LumaImage(img image.Image) *image.Gray {
+	lumas, _ := luma(img)
+	b := img.Bounds()
+	return &image.Gray{Pix: lumas, Stride: b.Dx(), Rect: image.Rect(0, 0, b.Dx(), b.Dy())}
+}
+
+// MeasureImageQuality returns the quality metrics of the image.
+func MeasureImageQuality(img image.Image) ImageQuality {
+	quality := ImageQuality{Histogram: make([]float64, HistogramBins)}
+	lumas, saturated := luma(img)
+	if len(lumas) == 0 {
+		return quality
+	}
+	n := float64(len(lumas))
+	var sum, sumSquares float64
+	for i, l := range lumas {
+		sum += float64(l)
+		sumSquares += float64(l) * float64(l)
+		quality.Histogram[int(l)*HistogramBins/256]++
+		switch {
+		case l <= underexposedLuma:
+			quality.UnderexposedFraction++
+		case l >= overexposedLuma:
+			quality.OverexposedFraction++
+		}
+		if saturated[i] {
+			quality.SaturatedFraction++
+		}
+	}
+	quality.MeanLuma = sum / n
+	quality.Contrast = math.Sqrt(math.Max(0, sumSquares/n-quality.MeanLuma*quality.MeanLuma))
+	quality.UnderexposedFraction /= n
+	quality.OverexposedFraction /= n
+	quality.SaturatedFraction /= n
+	for i := range quality.Histogram {
+		quality.Histogram[i] /= n
+	}
+	quality.Focus = laplacianVariance(lumas, img.Bounds().Dx(), img.Bounds().Dy())
+	return quality
+}
+
+// laplacianVariance returns the variance of the 4-neighbor Laplacian of the interior pixels of the luma.
+func laplacianVariance(lumas []uint8, width, height int) float64 {
+	if width < 3 || height < 3 {
+		return 0
+	}
+	var sum, sumSquares float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			l := float64(lumas[i-1]) + float64(lumas[i+1]) + float64(lumas[i-width]) + float64(lumas[i+width]) - 4*float64(lumas[i])
+			sum += l
+			sumSquares += l * l
+		}
+	}
+	n := float64((width - 2) * (height - 2))
+	mean := sum / n
+	return sumSquares/n - mean*mean
+}
+
+// ImageChange returns how much an image changed from the previous one, as the mean absolute difference of their luma
+// from 0 to 255. Images must have the same size.
+func ImageChange(previous, current image.Image) (float64, error) {
+	if previous.Bounds().Size() != current.Bounds().Size() {
+		return 0, errors.Errorf("cannot compare images of sizes %v and %v", previous.Bounds().Size(), current.Bounds().Size())
+	}
+	previousLumas, _ := luma(previous)
+	currentLumas, _ := luma(current)
+	if len(currentLumas) == 0 {
+		return 0, nil
+	}
+	var sum float64
+	for i, l := range currentLumas {
+		sum += math.Abs(float64(l) - float64(previousLumas[i]))
+	}
+	return sum / float64(len(currentLumas)), nil
+}
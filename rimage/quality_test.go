@@ -0,0 +1,82 @@
+package rimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+// checkerboard returns an image of squares of the given size alternating between the two gray levels.
+func checkerboard(size int, dark, light uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			v := dark
+			if (x/size+y/size)%2 == 1 {
+				v = light
+			}
+			img.Set(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestMeasureImageQuality(t *testing.T) {
+	sharp := MeasureImageQuality(checkerboard(4, 50, 200))
+	test.That(t, sharp.MeanLuma, test.ShouldAlmostEqual, 125, 0.5)
+	test.That(t, sharp.Contrast, test.ShouldAlmostEqual, 75, 0.5)
+	test.That(t, sharp.UnderexposedFraction, test.ShouldEqual, 0)
+	test.That(t, sharp.OverexposedFraction, test.ShouldEqual, 0)
+	test.That(t, sharp.SaturatedFraction, test.ShouldEqual, 0)
+	test.That(t, sharp.Histogram, test.ShouldHaveLength, HistogramBins)
+	test.That(t, sharp.Histogram[50*HistogramBins/256], test.ShouldAlmostEqual, 0.5)
+	test.That(t, sharp.Histogram[200*HistogramBins/256], test.ShouldAlmostEqual, 0.5)
+
+	// larger squares have fewer edges, and lower contrast ones weaker edges
+	test.That(t, MeasureImageQuality(checkerboard(16, 50, 200)).Focus, test.ShouldBeLessThan, sharp.Focus/2)
+	test.That(t, MeasureImageQuality(checkerboard(4, 100, 150)).Focus, test.ShouldBeLessThan, sharp.Focus/2)
+
+	black := MeasureImageQuality(image.NewGray(image.Rect(0, 0, 10, 10)))
+	test.That(t, black.MeanLuma, test.ShouldEqual, 0)
+	test.That(t, black.Focus, test.ShouldEqual, 0)
+	test.That(t, black.UnderexposedFraction, test.ShouldEqual, 1)
+	test.That(t, black.Histogram[0], test.ShouldEqual, 1)
+
+	// a red image is saturated without being overexposed
+	red := image.NewYCbCr(image.Rect(0, 0, 10, 10), image.YCbCrSubsampleRatio444)
+	y, cb, cr := color.RGBToYCbCr(255, 0, 0)
+	for i := range red.Y {
+		red.Y[i], red.Cb[i], red.Cr[i] = y, cb, cr
+	}
+	redQuality := MeasureImageQuality(red)
+	test.That(t, redQuality.MeanLuma, test.ShouldEqual, float64(y))
+	test.That(t, redQuality.SaturatedFraction, test.ShouldEqual, 1)
+	test.That(t, redQuality.OverexposedFraction, test.ShouldEqual, 0)
+
+	empty := MeasureImageQuality(image.NewGray(image.Rect(0, 0, 0, 0)))
+	test.That(t, empty.MeanLuma, test.ShouldEqual, 0)
+}
+
+func TestImageChange(t *testing.T) {
+	img := checkerboard(4, 50, 200)
+	change, err := ImageChange(img, img)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, change, test.ShouldEqual, 0)
+
+	// half of the pixels change by 150
+	change, err = ImageChange(img, checkerboard(4, 200, 200))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, change, test.ShouldAlmostEqual, 75, 0.5)
+
+	// images are compared by size rather than bounds
+	lum := LumaImage(img.SubImage(image.Rect(10, 10, 20, 20)))
+	test.That(t, lum.Bounds(), test.ShouldResemble, image.Rect(0, 0, 10, 10))
+	change, err = ImageChange(lum, img.SubImage(image.Rect(10, 10, 20, 20)))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, change, test.ShouldEqual, 0)
+
+	_, err = ImageChange(img, lum)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,400 @@
+package transform
+
+import (
+	"image"
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// IntrinsicCalibration is the result of an intrinsic calibration. Marshalled to JSON, it is the block of
+// intrinsic_parameters and distortion_parameters that camera configs expect.
+type IntrinsicCalibration struct {
+	Intrinsics *PinholeCameraIntrinsics `json:"intrinsic_parameters"`
+	Distortion *BrownConrady            `json:"distortion_parameters"`
+	// RMSError is the root mean square reprojection error in pixels over the corners of all images.
+	RMSError float64 `json:"-"`
+	// ImageErrors are the root mean square reprojection errors in pixels of every image the board was found in.
+	ImageErrors []float64 `json:"-"`
+	// BoardPoses are the poses of the board in the camera frame for every image the board was found in.
+	BoardPoses []spatialmath.Pose `json:"-"`
+	// Images are the indices, among the images given, of the images the errors and poses are of, which differ from
+	// their positions once images without the board are skipped.
+	Images []int `json:"-"`
+}
+
+// number of camera parameters (fx, fy, ppx, ppy, rk1, rk2, rk3, tp1, tp2) and of pose parameters per image
+// (rotation vector and translation) solved for.
+const (
+	numCameraParams = 9
+	numPoseParams   = 6
+)
+
+// RunPinholeIntrinsicCalibration finds the checkerboard in every image and solves for the intrinsics and the
+// distortion of the camera that took them. Images in which the board can't be found are skipped, and at least
+// three images with the board seen from different angles are needed.
+func RunPinholeIntrinsicCalibration(imgs []image.Image, board *Checkerboard, logger logging.Logger) (*IntrinsicCalibration, error) {
+	if err := board.CheckValid(); err != nil {
+		return nil, err
+	}
+	if len(imgs) == 0 {
+		return nil, errors.New("no images to calibrate with")
+	}
+	width, height := imgs[0].Bounds().Dx(), imgs[0].Bounds().Dy()
+	corners := make([][]r2.Point, 0, len(imgs))
+	found := make([]int, 0, len(imgs))
+	for i, img := range imgs {
+		if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+			return nil, errors.Errorf("image %d has size (%d, %d), expected (%d, %d)",
+				i, img.Bounds().Dx(), img.Bounds().Dy(), width, height)
+		}
+		pts, err := FindCheckerboardCorners(img, board.Cols, board.Rows)
+		if err != nil {
+			logger.Warnw("skipping image without checkerboard", "image", i, "error", err)
+			continue
+		}
+		corners = append(corners, pts)
+		found = append(found, i)
+	}
+	logger.Infof("found the checkerboard in %d of %d images", len(corners), len(imgs))
+	calib, err := CalibrateIntrinsicsFromCorners(corners, board, width, height)
+	if err != nil {
+		return nil, err
+	}
+	calib.Images = found
+	return calib, nil
+}
+
+// CalibrateIntrinsicsFromCorners solves for the intrinsics and the distortion of a camera from the corners of the
+// board found in images of the given size. The intrinsics are initialized in closed form from the homographies of
+// the board to every image, then all parameters are refined by minimizing the reprojection error.
+// Z. Zhang, A Flexible New Technique for Camera Calibration, 2000.
+func CalibrateIntrinsicsFromCorners(corners [][]r2.Point, board *Checkerboard, width, height int) (*IntrinsicCalibration, error) {
+	if err := board.CheckValid(); err != nil {
+		return nil, err
+	}
+	if len(corners) < 3 {
+		return nil, errors.Errorf("need the checkerboard in at least 3 images to calibrate, have %d", len(corners))
+	}
+	objPts := board.ObjectPoints()
+	for i, pts := range corners {
+		if len(pts) != len(objPts) {
+			return nil, errors.Errorf("image %d has %d corners, expected %d", i, len(pts), len(objPts))
+		}
+	}
+	homographies := make([]*Homography, len(corners))
+	for i, pts := range corners {
+		h, err := fitHomography(objPts, pts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot fit the board to image %d", i)
+		}
+		homographies[i] = h
+	}
+	k, err := initialIntrinsics(homographies, width, height)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]float64, numCameraParams+numPoseParams*len(corners))
+	params[0], params[1], params[2], params[3] = k.Fx, k.Fy, k.Ppx, k.Ppy
+	for i, h := range homographies {
+		rvec, t := initialBoardPose(k, h)
+		copy(params[numCameraParams+numPoseParams*i:], []float64{rvec.X, rvec.Y, rvec.Z, t.X, t.Y, t.Z})
+	}
+	residuals := func(p []float64, out []float64) {
+		reprojectionResiduals(p, objPts, corners, out)
+	}
+	params = levenbergMarquardt(residuals, params, 2*len(objPts)*len(corners), 100)
+
+	res := make([]float64, 2*len(objPts)*len(corners))
+	reprojectionResiduals(params, objPts, corners, res)
+	calib := &IntrinsicCalibration{
+		Intrinsics: &PinholeCameraIntrinsics{
+			Width: width, Height: height, Fx: params[0], Fy: params[1], Ppx: params[2], Ppy: params[3],
+		},
+		Distortion: &BrownConrady{
+			RadialK1: params[4], RadialK2: params[5], RadialK3: params[6], TangentialP1: params[7], TangentialP2: params[8],
+		},
+		ImageErrors: make([]float64, len(corners)),
+		BoardPoses:  make([]spatialmath.Pose, len(corners)),
+		Images:      make([]int, len(corners)),
+	}
+	total := 0.
+	for i := range corners {
+		calib.Images[i] = i
+		sq := 0.
+		for _, r := range res[2*len(objPts)*i : 2*len(objPts)*(i+1)] {
+			sq += r * r
+		}
+		total += sq
+		calib.ImageErrors[i] = math.Sqrt(sq / float64(len(objPts)))
+		p := params[numCameraParams+numPoseParams*i:]
+		rotation := rotationVectorToR4AA(r3.Vector{X: p[0], Y: p[1], Z: p[2]})
+		calib.BoardPoses[i] = spatialmath.NewPose(r3.Vector{X: p[3], Y: p[4], Z: p[5]}, rotation)
+	}
+	calib.RMSError = math.Sqrt(total / float64(len(objPts)*len(corners)))
+	if err := calib.Intrinsics.CheckValid(); err != nil {
+		return nil, errors.Wrap(err, "calibration did not converge")
+	}
+	return calib, nil
+}
+
+// fitHomography estimates the homography taking the points src to dst with the normalized direct linear transform.
+func fitHomography(src, dst []r2.Point) (*Homography, error) {
+	if len(src) != len(dst) || len(src) < 4 {
+		return nil, errors.Errorf("need at least 4 pairs of points to fit a homography, have %d and %d", len(src), len(dst))
+	}
+	normSrc, normDst := ComputeNormalizationMatFromSliceVecs(src), ComputeNormalizationMatFromSliceVecs(dst)
+	s, d := ApplyNormalizationMat(normSrc, src), ApplyNormalizationMat(normDst, dst)
+	a := mat.NewDense(2*len(src), 9, nil)
+	for i := range s {
+		x, y, u, v := s[i].X, s[i].Y, d[i].X, d[i].Y
+		a.SetRow(2*i, []float64{x, y, 1, 0, 0, 0, -u * x, -u * y, -u})
+		a.SetRow(2*i+1, []float64{0, 0, 0, x, y, 1, -v * x, -v * y, -v})
+	}
+	h, err := nullVector(a)
+	if err != nil {
+		return nil, err
+	}
+	// undo the normalizations
+	var invDst, tmp, out mat.Dense
+	if err := invDst.Inverse(normDst); err != nil {
+		return nil, err
+	}
+	tmp.Mul(&invDst, mat.NewDense(3, 3, h))
+	out.Mul(&tmp, normSrc)
+	if out.At(2, 2) == 0 {
+		return nil, errors.New("degenerate homography")
+	}
+	out.Scale(1/out.At(2, 2), &out)
+	return &Homography{&out}, nil
+}
+
+// nullVector returns the right singular vector of the smallest singular value of a.
+func nullVector(a *mat.Dense) ([]float64, error) {
+	var svd mat.SVD
+	if ok := svd.Factorize(a, mat.SVDFull); !ok {
+		return nil, errors.New("failed to factorize matrix")
+	}
+	var v mat.Dense
+	svd.VTo(&v)
+	_, c := v.Dims()
+	return mat.Col(nil, c-1, &v), nil
+}
+
+// initialIntrinsics solves for the intrinsics, without skew, in closed form from the homographies of the board to the
+// images. The images are normalized first to keep the system well conditioned.
+func initialIntrinsics(homographies []*Homography, width, height int) (*PinholeCameraIntrinsics, error) {
+	scale := float64(width+height) / 2
+	cx, cy := float64(width)/2, float64(height)/2
+	norm := mat.NewDense(3, 3, []float64{1 / scale, 0, -cx / scale, 0, 1 / scale, -cy / scale, 0, 0, 1})
+	v := mat.NewDense(2*len(homographies)+1, 6, nil)
+	for i, h := range homographies {
+		var hn mat.Dense
+		hn.Mul(norm, h.matrix)
+		vij := func(a, b int) []float64 {
+			return []float64{
+				hn.At(0, a) * hn.At(0, b),
+				hn.At(0, a)*hn.At(1, b) + hn.At(1, a)*hn.At(0, b),
+				hn.At(1, a) * hn.At(1, b),
+				hn.At(2, a)*hn.At(0, b) + hn.At(0, a)*hn.At(2, b),
+				hn.At(2, a)*hn.At(1, b) + hn.At(1, a)*hn.At(2, b),
+				hn.At(2, a) * hn.At(2, b),
+			}
+		}
+		v11, v12, v22 := vij(0, 0), vij(0, 1), vij(1, 1)
+		diff := make([]float64, 6)
+		for j := range diff {
+			diff[j] = v11[j] - v22[j]
+		}
+		v.SetRow(2*i, v12)
+		v.SetRow(2*i+1, diff)
+	}
+	// the pixels are square to the sensor, so there is no skew
+	v.SetRow(2*len(homographies), []float64{0, 1, 0, 0, 0, 0})
+	b, err := nullVector(v)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] < 0 {
+		for i := range b {
+			b[i] = -b[i]
+		}
+	}
+	b11, b12, b22, b13, b23, b33 := b[0], b[1], b[2], b[3], b[4], b[5]
+	den := b11*b22 - b12*b12
+	if b11 <= 0 || den <= 0 {
+		return nil, errors.New("cannot solve for the intrinsics, the board needs to be seen from different angles")
+	}
+	v0 := (b12*b13 - b11*b23) / den
+	lambda := b33 - (b13*b13+v0*(b12*b13-b11*b23))/b11
+	if lambda <= 0 {
+		return nil, errors.New("cannot solve for the intrinsics, the board needs to be seen from different angles")
+	}
+	alpha := math.Sqrt(lambda / b11)
+	beta := math.Sqrt(lambda * b11 / den)
+	u0 := -b13 * alpha * alpha / lambda
+	return &PinholeCameraIntrinsics{
+		Width:  width,
+		Height: height,
+		Fx:     alpha * scale,
+		Fy:     beta * scale,
+		Ppx:    u0*scale + cx,
+		Ppy:    v0*scale + cy,
+	}, nil
+}
+
+// initialBoardPose recovers the rotation vector and translation of the board in the camera frame from its homography.
+func initialBoardPose(k *PinholeCameraIntrinsics, h *Homography) (r3.Vector, r3.Vector) {
+	kInv := func(c int) r3.Vector {
+		x, y, z := h.At(0, c), h.At(1, c), h.At(2, c)
+		return r3.Vector{X: (x - k.Ppx*z) / k.Fx, Y: (y - k.Ppy*z) / k.Fy, Z: z}
+	}
+	h1, h2, h3 := kInv(0), kInv(1), kInv(2)
+	lambda := 1 / h1.Norm()
+	// the board is in front of the camera
+	if h3.Z < 0 {
+		lambda = -lambda
+	}
+	r1, r2, t := h1.Mul(lambda), h2.Mul(lambda), h3.Mul(lambda)
+	r3v := r1.Cross(r2)
+	// the columns are only approximately orthonormal, so use the closest rotation
+	rot := mat.NewDense(3, 3, []float64{r1.X, r2.X, r3v.X, r1.Y, r2.Y, r3v.Y, r1.Z, r2.Z, r3v.Z})
+	var svd mat.SVD
+	if ok := svd.Factorize(rot, mat.SVDFull); ok {
+		var u, v mat.Dense
+		svd.UTo(&u)
+		svd.VTo(&v)
+		rot.Mul(&u, v.T())
+	}
+	return rotationMatrixToVector(rot), t
+}
+
+// rotationMatrixToVector returns the rotation vector, whose direction is the axis and norm is the angle, of the
+// rotation matrix.
+func rotationMatrixToVector(rot mat.Matrix) r3.Vector {
+	cos := math.Max(-1, math.Min(1, (rot.At(0, 0)+rot.At(1, 1)+rot.At(2, 2)-1)/2))
+	theta := math.Acos(cos)
+	axis := r3.Vector{X: rot.At(2, 1) - rot.At(1, 2), Y: rot.At(0, 2) - rot.At(2, 0), Z: rot.At(1, 0) - rot.At(0, 1)}
+	if math.Sin(theta) < 1e-9 {
+		return axis.Mul(0.5)
+	}
+	return axis.Mul(theta / (2 * math.Sin(theta)))
+}
+
+// rotationVectorToR4AA converts a rotation vector, whose direction is the axis and norm is the angle, to an axis angle.
+func rotationVectorToR4AA(rvec r3.Vector) *spatialmath.R4AA {
+	theta := rvec.Norm()
+	if theta < 1e-12 {
+		return spatialmath.NewR4AA()
+	}
+	return &spatialmath.R4AA{Theta: theta, RX: rvec.X / theta, RY: rvec.Y / theta, RZ: rvec.Z / theta}
+}
+
+// rotateByVector rotates the point by the rotation vector with the Rodrigues formula.
+func rotateByVector(rvec, pt r3.Vector) r3.Vector {
+	theta := rvec.Norm()
+	if theta < 1e-12 {
+		return pt.Add(rvec.Cross(pt))
+	}
+	axis := rvec.Mul(1 / theta)
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	return pt.Mul(cos).Add(axis.Cross(pt).Mul(sin)).Add(axis.Mul(axis.Dot(pt) * (1 - cos)))
+}
+
+// reprojectionResiduals writes to out the differences between the corners and the board points projected with the
+// camera and pose parameters p, two per corner.
+func reprojectionResiduals(p []float64, objPts []r2.Point, corners [][]r2.Point, out []float64) {
+	dist := &BrownConrady{RadialK1: p[4], RadialK2: p[5], RadialK3: p[6], TangentialP1: p[7], TangentialP2: p[8]}
+	idx := 0
+	for i, pts := range corners {
+		pose := p[numCameraParams+numPoseParams*i:]
+		rvec, t := r3.Vector{X: pose[0], Y: pose[1], Z: pose[2]}, r3.Vector{X: pose[3], Y: pose[4], Z: pose[5]}
+		for j, obj := range objPts {
+			c := rotateByVector(rvec, r3.Vector{X: obj.X, Y: obj.Y}).Add(t)
+			x, y := dist.Transform(c.X/c.Z, c.Y/c.Z)
+			out[idx] = x*p[0] + p[2] - pts[j].X
+			out[idx+1] = y*p[1] + p[3] - pts[j].Y
+			idx += 2
+		}
+	}
+}
+
+// levenbergMarquardt minimizes the sum of squares of the m residuals computed by fn, starting from x0, using
+// forward differences for the Jacobian.
+func levenbergMarquardt(fn func(x, out []float64), x0 []float64, m, maxIterations int) []float64 {
+	n := len(x0)
+	x := append([]float64{}, x0...)
+	res := make([]float64, m)
+	fn(x, res)
+	cost := sumSquares(res)
+	lambda := 1e-3
+	jac := mat.NewDense(m, n, nil)
+	step := make([]float64, m)
+	trial := make([]float64, n)
+	trialRes := make([]float64, m)
+	for iter := 0; iter < maxIterations; iter++ {
+		for j := 0; j < n; j++ {
+			h := 1e-6 * math.Max(math.Abs(x[j]), 1e-2)
+			orig := x[j]
+			x[j] = orig + h
+			fn(x, step)
+			x[j] = orig
+			for i := 0; i < m; i++ {
+				jac.Set(i, j, (step[i]-res[i])/h)
+			}
+		}
+		var jtj mat.SymDense
+		jtj.SymOuterK(1, jac.T())
+		var jtr mat.VecDense
+		jtr.MulVec(jac.T(), mat.NewVecDense(m, res))
+		improved := false
+		for !improved && lambda < 1e12 {
+			damped := mat.NewSymDense(n, nil)
+			damped.CopySym(&jtj)
+			for j := 0; j < n; j++ {
+				damped.SetSym(j, j, jtj.At(j, j)*(1+lambda))
+			}
+			var delta mat.VecDense
+			if err := delta.SolveVec(damped, &jtr); err != nil {
+				lambda *= 10
+				continue
+			}
+			for j := range trial {
+				trial[j] = x[j] - delta.AtVec(j)
+			}
+			fn(trial, trialRes)
+			trialCost := sumSquares(trialRes)
+			if trialCost < cost && !math.IsNaN(trialCost) {
+				improved = true
+				relative := (cost - trialCost) / cost
+				copy(x, trial)
+				copy(res, trialRes)
+				cost = trialCost
+				lambda = math.Max(lambda/10, 1e-12)
+				if relative < 1e-12 {
+					return x
+				}
+			} else {
+				lambda *= 10
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return x
+}
+
+func sumSquares(v []float64) float64 {
+	s := 0.
+	for _, x := range v {
+		s += x * x
+	}
+	return s
+}
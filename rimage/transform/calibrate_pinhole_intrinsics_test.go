@@ -0,0 +1,157 @@
+package transform
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/testutils"
+)
+
+// testBoardPoses are rotation vectors of the board in the camera frame, seen from different angles.
+var testBoardPoses = []r3.Vector{
+	{X: 0.3, Y: 0.1, Z: 0.05},
+	{X: -0.3, Y: 0.2, Z: -0.1},
+	{X: 0.1, Y: -0.35, Z: 0.2},
+	{X: -0.2, Y: -0.3, Z: -0.05},
+	{X: 0.35, Y: 0.3, Z: 0.1},
+}
+
+// boardTranslation returns the translation which puts the center of the board at the given distance in front of
+// the camera.
+func boardTranslation(board *Checkerboard, rvec r3.Vector, dist float64) r3.Vector {
+	center := r3.Vector{X: float64(board.Cols-1) * board.SquareSizeMm / 2, Y: float64(board.Rows-1) * board.SquareSizeMm / 2}
+	return r3.Vector{Z: dist}.Sub(rotateByVector(rvec, center))
+}
+
+// projectBoard projects the corners of the board in the given pose with the camera.
+func projectBoard(board *Checkerboard, k *PinholeCameraIntrinsics, dist *BrownConrady, rvec, t r3.Vector) []r2.Point {
+	pts := []r2.Point{}
+	for _, obj := range board.ObjectPoints() {
+		c := rotateByVector(rvec, r3.Vector{X: obj.X, Y: obj.Y}).Add(t)
+		x, y := dist.Transform(c.X/c.Z, c.Y/c.Z)
+		pts = append(pts, r2.Point{X: x*k.Fx + k.Ppx, Y: y*k.Fy + k.Ppy})
+	}
+	return pts
+}
+
+// renderBoard renders the board in the given pose with the camera.
+func renderBoard(board *Checkerboard, k *PinholeCameraIntrinsics, dist *BrownConrady, rvec, t r3.Vector) image.Image {
+	normal := rotateByVector(rvec, r3.Vector{Z: 1})
+	toBoard := func(u, v float64) r2.Point {
+		xd, yd := (u-k.Ppx)/k.Fx, (v-k.Ppy)/k.Fy
+		// undistort by fixed point iteration
+		x, y := xd, yd
+		for i := 0; i < 10; i++ {
+			dx, dy := dist.Transform(x, y)
+			x, y = x+xd-dx, y+yd-dy
+		}
+		ray := r3.Vector{X: x, Y: y, Z: 1}
+		p := ray.Mul(normal.Dot(t) / normal.Dot(ray)).Sub(t)
+		// back to the frame of the board
+		b := rotateByVector(rvec.Mul(-1), p)
+		return r2.Point{X: b.X, Y: b.Y}
+	}
+	return testutils.RenderCheckerboard(k.Width, k.Height, board.Cols, board.Rows, board.SquareSizeMm, toBoard)
+}
+
+func TestFindCheckerboardCorners(t *testing.T) {
+	board := &Checkerboard{Cols: 7, Rows: 5, SquareSizeMm: 30}
+	k := &PinholeCameraIntrinsics{Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 160, Ppy: 120}
+	rvec := testBoardPoses[0]
+	tr := boardTranslation(board, rvec, 500)
+	img := renderBoard(board, k, nil, rvec, tr)
+
+	corners, err := FindCheckerboardCorners(img, board.Cols, board.Rows)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(corners), test.ShouldEqual, board.Cols*board.Rows)
+	expected := projectBoard(board, k, nil, rvec, tr)
+	// the board is only known up to a half turn
+	if corners[0].Sub(expected[0]).Norm() > corners[0].Sub(expected[len(expected)-1]).Norm() {
+		for i, j := 0, len(corners)-1; i < j; i, j = i+1, j-1 {
+			corners[i], corners[j] = corners[j], corners[i]
+		}
+	}
+	for i, c := range corners {
+		test.That(t, c.Sub(expected[i]).Norm(), test.ShouldBeLessThan, 0.2)
+	}
+
+	// a blank image has no board
+	_, err = FindCheckerboardCorners(image.NewGray(image.Rect(0, 0, 100, 100)), 7, 5)
+	test.That(t, err, test.ShouldNotBeNil)
+	// more corners than there are on the board
+	_, err = FindCheckerboardCorners(img, 9, 7)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestCalibrateIntrinsicsFromCorners(t *testing.T) {
+	board := &Checkerboard{Cols: 8, Rows: 6, SquareSizeMm: 25}
+	k := &PinholeCameraIntrinsics{Width: 640, Height: 480, Fx: 610, Fy: 605, Ppx: 322, Ppy: 236}
+	dist := &BrownConrady{RadialK1: -0.12, RadialK2: 0.05, TangentialP1: 0.001, TangentialP2: -0.002}
+	corners := [][]r2.Point{}
+	for _, rvec := range testBoardPoses {
+		corners = append(corners, projectBoard(board, k, dist, rvec, boardTranslation(board, rvec, 600)))
+	}
+
+	_, err := CalibrateIntrinsicsFromCorners(corners[:2], board, 640, 480)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = CalibrateIntrinsicsFromCorners([][]r2.Point{corners[0], corners[1], corners[2][1:]}, board, 640, 480)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = CalibrateIntrinsicsFromCorners(corners, &Checkerboard{Cols: 8, Rows: 6}, 640, 480)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	calib, err := CalibrateIntrinsicsFromCorners(corners, board, 640, 480)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, calib.RMSError, test.ShouldBeLessThan, 1e-3)
+	test.That(t, len(calib.ImageErrors), test.ShouldEqual, len(corners))
+	test.That(t, calib.Images, test.ShouldResemble, []int{0, 1, 2, 3, 4})
+	test.That(t, calib.Intrinsics.Width, test.ShouldEqual, 640)
+	test.That(t, calib.Intrinsics.Fx, test.ShouldAlmostEqual, k.Fx, 0.1)
+	test.That(t, calib.Intrinsics.Fy, test.ShouldAlmostEqual, k.Fy, 0.1)
+	test.That(t, calib.Intrinsics.Ppx, test.ShouldAlmostEqual, k.Ppx, 0.1)
+	test.That(t, calib.Intrinsics.Ppy, test.ShouldAlmostEqual, k.Ppy, 0.1)
+	test.That(t, calib.Distortion.RadialK1, test.ShouldAlmostEqual, dist.RadialK1, 1e-3)
+	test.That(t, calib.Distortion.TangentialP1, test.ShouldAlmostEqual, dist.TangentialP1, 1e-4)
+	test.That(t, calib.Distortion.TangentialP2, test.ShouldAlmostEqual, dist.TangentialP2, 1e-4)
+	test.That(t, calib.BoardPoses[0].Point().Z, test.ShouldAlmostEqual, boardTranslation(board, testBoardPoses[0], 600).Z, 0.1)
+
+	// the JSON is the block the camera configs expect
+	b, err := json.Marshal(calib)
+	test.That(t, err, test.ShouldBeNil)
+	var block map[string]map[string]interface{}
+	test.That(t, json.Unmarshal(b, &block), test.ShouldBeNil)
+	test.That(t, len(block), test.ShouldEqual, 2)
+	test.That(t, block["intrinsic_parameters"]["width_px"], test.ShouldEqual, 640)
+	test.That(t, block["distortion_parameters"], test.ShouldContainKey, "rk1")
+}
+
+func TestRunPinholeIntrinsicCalibration(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	board := &Checkerboard{Cols: 7, Rows: 5, SquareSizeMm: 30}
+	k := &PinholeCameraIntrinsics{Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 160, Ppy: 120}
+	dist := &BrownConrady{RadialK1: -0.1}
+	imgs := []image.Image{image.NewGray(image.Rect(0, 0, 320, 240))}
+	for _, rvec := range testBoardPoses {
+		imgs = append(imgs, renderBoard(board, k, dist, rvec, boardTranslation(board, rvec, 500)))
+	}
+
+	_, err := RunPinholeIntrinsicCalibration([]image.Image{image.NewGray(image.Rect(0, 0, 10, 10)), imgs[1]}, board, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// the blank image is skipped
+	calib, err := RunPinholeIntrinsicCalibration(imgs, board, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(calib.ImageErrors), test.ShouldEqual, len(testBoardPoses))
+	test.That(t, calib.Images, test.ShouldResemble, []int{1, 2, 3, 4, 5})
+	test.That(t, calib.RMSError, test.ShouldBeLessThan, 0.2)
+	test.That(t, calib.Intrinsics.Fx, test.ShouldAlmostEqual, k.Fx, 6)
+	test.That(t, calib.Intrinsics.Fy, test.ShouldAlmostEqual, k.Fy, 6)
+	test.That(t, calib.Intrinsics.Ppx, test.ShouldAlmostEqual, k.Ppx, 6)
+	test.That(t, calib.Intrinsics.Ppy, test.ShouldAlmostEqual, k.Ppy, 6)
+	test.That(t, calib.Distortion.RadialK1, test.ShouldAlmostEqual, dist.RadialK1, 0.05)
+}
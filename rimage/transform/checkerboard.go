@@ -0,0 +1,376 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+)
+
+// Checkerboard describes a calibration checkerboard by the number of inner corners along each side,
+// i.e. one less than the number of squares, and the side length of its squares.
+type Checkerboard struct {
+	Cols         int     `json:"cols"`
+	Rows         int     `json:"rows"`
+	SquareSizeMm float64 `json:"square_size_mm"`
+}
+
+// CheckValid checks that the board has enough corners to calibrate with.
+func (cb *Checkerboard) CheckValid() error {
+	if cb == nil {
+		return errors.New("no checkerboard provided")
+	}
+	if cb.Cols < 2 || cb.Rows < 2 {
+		return errors.Errorf("checkerboard needs at least 2x2 inner corners, got %dx%d", cb.Cols, cb.Rows)
+	}
+	if cb.SquareSizeMm <= 0 {
+		return errors.Errorf("checkerboard square size must be positive, got %v", cb.SquareSizeMm)
+	}
+	return nil
+}
+
+// ObjectPoints returns the inner corners of the board in mm on the plane of the board, row by row,
+// in the same order as the corners returned by FindCheckerboardCorners.
+func (cb *Checkerboard) ObjectPoints() []r2.Point {
+	pts := make([]r2.Point, 0, cb.Cols*cb.Rows)
+	for j := 0; j < cb.Rows; j++ {
+		for i := 0; i < cb.Cols; i++ {
+			pts = append(pts, r2.Point{X: float64(i) * cb.SquareSizeMm, Y: float64(j) * cb.SquareSizeMm})
+		}
+	}
+	return pts
+}
+
+const (
+	// chessRadius is the radius in pixels of the ring sampled around every pixel to find the corners. The squares of
+	// the board need to be at least twice as large in the image.
+	chessRadius = 5.
+	// chessThreshold is the fraction of the strongest corner response a candidate corner needs to have.
+	chessThreshold = 0.2
+	// subPixWindow is the half size of the window used to refine the corners to sub pixel precision.
+	subPixWindow = 4
+)
+
+// grayFloat is a grayscale image with float intensities.
+type grayFloat struct {
+	width, height int
+	pix           []float64
+}
+
+func newGrayFloat(img image.Image) *grayFloat {
+	b := img.Bounds()
+	g := &grayFloat{width: b.Dx(), height: b.Dy(), pix: make([]float64, b.Dx()*b.Dy())}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			gray := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray16)
+			g.pix[y*g.width+x] = float64(gray.Y) / 65535.
+		}
+	}
+	return g
+}
+
+func (g *grayFloat) at(x, y int) float64 {
+	x = int(math.Max(0, math.Min(float64(x), float64(g.width-1))))
+	y = int(math.Max(0, math.Min(float64(y), float64(g.height-1))))
+	return g.pix[y*g.width+x]
+}
+
+// bilinear returns the intensity at the sub pixel location (x, y).
+func (g *grayFloat) bilinear(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	dx, dy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	return (1-dx)*(1-dy)*g.at(ix, iy) + dx*(1-dy)*g.at(ix+1, iy) +
+		(1-dx)*dy*g.at(ix, iy+1) + dx*dy*g.at(ix+1, iy+1)
+}
+
+// blur smooths the image with a 3x3 binomial kernel.
+func (g *grayFloat) blur() *grayFloat {
+	out := &grayFloat{width: g.width, height: g.height, pix: make([]float64, len(g.pix))}
+	k := []float64{0.25, 0.5, 0.25}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			v := 0.
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					v += k[i+1] * k[j+1] * g.at(x+i, y+j)
+				}
+			}
+			out.pix[y*g.width+x] = v
+		}
+	}
+	return out
+}
+
+// chessResponse computes the ChESS corner response of the pixel (x, y), which is large at the saddle points
+// where two dark and two bright squares meet, and close to zero on edges and ordinary corners.
+// S. Bennett and J. Lasenby, ChESS - Quick and Robust Detection of Chess-board Features, 2013.
+func (g *grayFloat) chessResponse(x, y int) float64 {
+	var ring [16]float64
+	mean := 0.
+	for n := range ring {
+		theta := 2 * math.Pi * float64(n) / 16
+		ring[n] = g.bilinear(float64(x)+chessRadius*math.Cos(theta), float64(y)+chessRadius*math.Sin(theta))
+		mean += ring[n]
+	}
+	mean /= 16
+	sum, diff := 0., 0.
+	for n := 0; n < 4; n++ {
+		sum += math.Abs(ring[n] + ring[n+8] - ring[n+4] - ring[n+12])
+	}
+	for n := 0; n < 8; n++ {
+		diff += math.Abs(ring[n] - ring[n+8])
+	}
+	local := (g.at(x, y) + g.at(x-1, y) + g.at(x+1, y) + g.at(x, y-1) + g.at(x, y+1)) / 5
+	return sum - diff - 16*math.Abs(mean-local)
+}
+
+type cornerCandidate struct {
+	pt       r2.Point
+	response float64
+}
+
+// FindCheckerboardCorners finds the cols x rows inner corners of a checkerboard in the image, refined to sub
+// pixel precision. The corners are returned row by row, matching the order of Checkerboard.ObjectPoints.
+// The board should be fully visible and the strongest checkered pattern in the image.
+func FindCheckerboardCorners(img image.Image, cols, rows int) ([]r2.Point, error) {
+	if cols < 2 || rows < 2 {
+		return nil, errors.Errorf("checkerboard needs at least 2x2 inner corners, got %dx%d", cols, rows)
+	}
+	gray := newGrayFloat(img).blur()
+	margin := int(chessRadius) + 1
+	if gray.width <= 2*margin || gray.height <= 2*margin {
+		return nil, errors.Errorf("image of size (%d, %d) is too small to find a checkerboard in", gray.width, gray.height)
+	}
+	response := make([]float64, len(gray.pix))
+	maxResponse := 0.
+	for y := margin; y < gray.height-margin; y++ {
+		for x := margin; x < gray.width-margin; x++ {
+			r := gray.chessResponse(x, y)
+			response[y*gray.width+x] = r
+			maxResponse = math.Max(maxResponse, r)
+		}
+	}
+	if maxResponse <= 0 {
+		return nil, errors.New("no checkerboard corners found in image")
+	}
+	// keep the local maxima of the response as candidates
+	candidates := []cornerCandidate{}
+	nms := int(chessRadius)
+	for y := margin; y < gray.height-margin; y++ {
+		for x := margin; x < gray.width-margin; x++ {
+			r := response[y*gray.width+x]
+			if r < chessThreshold*maxResponse {
+				continue
+			}
+			isMax := true
+			for j := -nms; j <= nms && isMax; j++ {
+				for i := -nms; i <= nms; i++ {
+					xx, yy := x+i, y+j
+					if xx < 0 || yy < 0 || xx >= gray.width || yy >= gray.height || (i == 0 && j == 0) {
+						continue
+					}
+					// break ties on plateaus towards the first pixel
+					other := response[yy*gray.width+xx]
+					if other > r || (other == r && (j < 0 || (j == 0 && i < 0))) {
+						isMax = false
+						break
+					}
+				}
+			}
+			if isMax {
+				candidates = append(candidates, cornerCandidate{r2.Point{X: float64(x), Y: float64(y)}, r})
+			}
+		}
+	}
+	n := cols * rows
+	if len(candidates) < n {
+		return nil, errors.Errorf("found %d checkerboard corner candidates, need %d", len(candidates), n)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].response > candidates[j].response })
+	pts := make([]r2.Point, n)
+	for i := range pts {
+		pts[i] = candidates[i].pt
+	}
+	ordered, err := orderCheckerboardCorners(pts, cols, rows)
+	if err != nil {
+		return nil, err
+	}
+	gx, gy := gray.gradients()
+	for i, pt := range ordered {
+		ordered[i] = refineCorner(gx, gy, pt)
+	}
+	return ordered, nil
+}
+
+// gradients returns the horizontal and vertical central difference gradients of the image.
+func (g *grayFloat) gradients() (*grayFloat, *grayFloat) {
+	gx := &grayFloat{width: g.width, height: g.height, pix: make([]float64, len(g.pix))}
+	gy := &grayFloat{width: g.width, height: g.height, pix: make([]float64, len(g.pix))}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			gx.pix[y*g.width+x] = (g.at(x+1, y) - g.at(x-1, y)) / 2
+			gy.pix[y*g.width+x] = (g.at(x, y+1) - g.at(x, y-1)) / 2
+		}
+	}
+	return gx, gy
+}
+
+// refineCorner moves the corner to the point where the gradients in the window around it are all orthogonal to the
+// vectors from the point, which is the saddle point at sub pixel precision, like cornerSubPix of OpenCV.
+func refineCorner(gx, gy *grayFloat, pt r2.Point) r2.Point {
+	sigma := float64(subPixWindow) / 2
+	for iter := 0; iter < 20; iter++ {
+		var a00, a01, a11, b0, b1 float64
+		for j := -subPixWindow; j <= subPixWindow; j++ {
+			for i := -subPixWindow; i <= subPixWindow; i++ {
+				px, py := pt.X+float64(i), pt.Y+float64(j)
+				w := math.Exp(-float64(i*i+j*j) / (2 * sigma * sigma))
+				dx, dy := gx.bilinear(px, py), gy.bilinear(px, py)
+				xx, xy, yy := w*dx*dx, w*dx*dy, w*dy*dy
+				a00 += xx
+				a01 += xy
+				a11 += yy
+				b0 += xx*px + xy*py
+				b1 += xy*px + yy*py
+			}
+		}
+		det := a00*a11 - a01*a01
+		if math.Abs(det) < 1e-12 {
+			return pt
+		}
+		next := r2.Point{X: (a11*b0 - a01*b1) / det, Y: (a00*b1 - a01*b0) / det}
+		// the refinement should not leave the window
+		if next.Sub(pt).Norm() > subPixWindow {
+			return pt
+		}
+		moved := next.Sub(pt).Norm()
+		pt = next
+		if moved < 1e-3 {
+			break
+		}
+	}
+	return pt
+}
+
+// orderCheckerboardCorners labels the corners with their position on the board. The outer corners of the board are
+// found as the largest quadrilateral on the convex hull of the corners, and every labeling of them which keeps the
+// orientation of the board is checked by projecting the grid with the homography it defines.
+func orderCheckerboardCorners(pts []r2.Point, cols, rows int) ([]r2.Point, error) {
+	hull := convexHull(pts)
+	if len(hull) < 4 {
+		return nil, errors.New("checkerboard corners are collinear")
+	}
+	// the largest quadrilateral on the hull
+	var quad [4]r2.Point
+	bestArea := 0.
+	for a := 0; a < len(hull); a++ {
+		for b := a + 1; b < len(hull); b++ {
+			for c := b + 1; c < len(hull); c++ {
+				for d := c + 1; d < len(hull); d++ {
+					area := polygonArea([]r2.Point{hull[a], hull[b], hull[c], hull[d]})
+					if area > bestArea {
+						bestArea = area
+						quad = [4]r2.Point{hull[a], hull[b], hull[c], hull[d]}
+					}
+				}
+			}
+		}
+	}
+	grid := (&Checkerboard{Cols: cols, Rows: rows, SquareSizeMm: 1}).ObjectPoints()
+	gridCorners := []r2.Point{grid[0], grid[cols-1], grid[cols*rows-1], grid[(rows-1)*cols]}
+	var best []r2.Point
+	bestErr := math.Inf(1)
+	for s := 0; s < 4; s++ {
+		imgCorners := []r2.Point{quad[s], quad[(s+1)%4], quad[(s+2)%4], quad[(s+3)%4]}
+		h, err := fitHomography(gridCorners, imgCorners)
+		if err != nil {
+			continue
+		}
+		// match the projected grid to the corners, then refit the homography to all of them and match again, which
+		// copes with the lens distortion bending the outer rows
+		matched, errSum, ok := matchGrid(h, grid, pts)
+		if !ok {
+			continue
+		}
+		if h, err = fitHomography(grid, matched); err == nil {
+			if rematched, reErr, ok := matchGrid(h, grid, pts); ok {
+				matched, errSum = rematched, reErr
+			}
+		}
+		if errSum < bestErr {
+			bestErr = errSum
+			best = matched
+		}
+	}
+	if best == nil {
+		return nil, errors.New("could not match the corners found to a checkerboard grid")
+	}
+	return best, nil
+}
+
+// matchGrid assigns to every point of the grid, projected by h, its closest point. It fails if two grid points share
+// a point, and returns the sum of distances from the projections to their points.
+func matchGrid(h *Homography, grid, pts []r2.Point) ([]r2.Point, float64, bool) {
+	used := make([]bool, len(pts))
+	matched := make([]r2.Point, len(grid))
+	errSum := 0.
+	for i, g := range grid {
+		proj := h.Apply(g)
+		closest, closestDist := -1, math.Inf(1)
+		for j, p := range pts {
+			if d := proj.Sub(p).Norm(); d < closestDist {
+				closest, closestDist = j, d
+			}
+		}
+		if closest < 0 || used[closest] {
+			return nil, 0, false
+		}
+		used[closest] = true
+		matched[i] = pts[closest]
+		errSum += closestDist
+	}
+	return matched, errSum, true
+}
+
+// convexHull returns the convex hull of the points in counterclockwise order, using Andrew's monotone chain.
+func convexHull(pts []r2.Point) []r2.Point {
+	sorted := append([]r2.Point{}, pts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X == sorted[j].X {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	hull := make([]r2.Point, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && hull[len(hull)-1].Sub(hull[len(hull)-2]).Cross(p.Sub(hull[len(hull)-2])) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && hull[len(hull)-1].Sub(hull[len(hull)-2]).Cross(p.Sub(hull[len(hull)-2])) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// polygonArea returns the signed area of the polygon, positive for counterclockwise polygons.
+func polygonArea(poly []r2.Point) float64 {
+	area := 0.
+	for i, p := range poly {
+		area += p.Cross(poly[(i+1)%len(poly)])
+	}
+	return area / 2
+}
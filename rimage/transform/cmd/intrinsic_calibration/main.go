@@ -0,0 +1,120 @@
+// Finds a checkerboard in a set of images taken by one camera, solves for the intrinsic parameters
+// and the Brown-Conrady distortion of the camera, and prints the intrinsic_parameters and
+// distortion_parameters JSON block that camera configs expect.
+// The board is described by the number of its inner corners, i.e. one less than its number of squares,
+// and the side length of its squares. Images can be given as files or as directories of PNG and JPEG images.
+// $./intrinsic_calibration -cols=9 -rows=6 -square_size_mm=25 /path/to/images
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+)
+
+func main() {
+	colsPtr := flag.Int("cols", 9, "number of inner corners along the width of the checkerboard")
+	rowsPtr := flag.Int("rows", 6, "number of inner corners along the height of the checkerboard")
+	squarePtr := flag.Float64("square_size_mm", 25, "side length of the checkerboard squares in mm")
+	outPtr := flag.String("out", "", "path of a file to write the JSON to instead of stdout")
+	flag.Parse()
+	logger := logging.NewLogger("intrinsic_calibration")
+
+	out := io.Writer(os.Stdout)
+	if *outPtr != "" {
+		f, err := os.Create(*outPtr)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				logger.Error(err)
+			}
+		}()
+		out = f
+	}
+	board := &transform.Checkerboard{Cols: *colsPtr, Rows: *rowsPtr, SquareSizeMm: *squarePtr}
+	if err := calibrate(flag.Args(), board, out, logger); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+func calibrate(paths []string, board *transform.Checkerboard, out io.Writer, logger logging.Logger) error {
+	files, err := imageFiles(paths, logger)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no images given to calibrate with")
+	}
+	imgs := make([]image.Image, 0, len(files))
+	for _, f := range files {
+		img, err := rimage.ReadImageFromFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read image %q", f)
+		}
+		imgs = append(imgs, img)
+	}
+	calib, err := transform.RunPinholeIntrinsicCalibration(imgs, board, logger)
+	if err != nil {
+		return err
+	}
+	logger.Infof("RMS reprojection error: %.3f px", calib.RMSError)
+	for i, e := range calib.ImageErrors {
+		logger.Debugf("image %q RMS reprojection error: %.3f px", files[calib.Images[i]], e)
+	}
+	b, err := json.MarshalIndent(calib, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(b, '\n'))
+	return err
+}
+
+// imageExtensions are the extensions of the files in directories which are read as images.
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true}
+
+// imageFiles expands the directories among the paths to the images in them, in name order. Other files in the
+// directories are skipped with a warning.
+func imageFiles(paths []string, logger logging.Logger) ([]string, error) {
+	files := []string{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		dirFiles := []string{}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			if !imageExtensions[strings.ToLower(filepath.Ext(e.Name()))] {
+				logger.Warnf("skipping %q, which is not a PNG or JPEG image", filepath.Join(p, e.Name()))
+				continue
+			}
+			dirFiles = append(dirFiles, filepath.Join(p, e.Name()))
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/testutils"
+)
+
+// renderBoard renders the board tilted about the x and y axes of a camera without distortion, with its center
+// 500 mm in front of the camera.
+func renderBoard(t *testing.T, board *transform.Checkerboard, k *transform.PinholeCameraIntrinsics, tiltX, tiltY float64) image.Image {
+	t.Helper()
+	cx, sx, cy, sy := math.Cos(tiltX), math.Sin(tiltX), math.Cos(tiltY), math.Sin(tiltY)
+	// rotation about y then x, as columns
+	col1 := []float64{cy, sx * sy, -cx * sy}
+	col2 := []float64{0, cx, sx}
+	center := []float64{float64(board.Cols-1) * board.SquareSizeMm / 2, float64(board.Rows-1) * board.SquareSizeMm / 2}
+	tr := make([]float64, 3)
+	for i := range tr {
+		tr[i] = -col1[i]*center[0] - col2[i]*center[1]
+	}
+	tr[2] += 500
+	// the homography from the board plane to the image
+	h, err := transform.NewHomography([]float64{
+		k.Fx*col1[0] + k.Ppx*col1[2], k.Fx*col2[0] + k.Ppx*col2[2], k.Fx*tr[0] + k.Ppx*tr[2],
+		k.Fy*col1[1] + k.Ppy*col1[2], k.Fy*col2[1] + k.Ppy*col2[2], k.Fy*tr[1] + k.Ppy*tr[2],
+		col1[2], col2[2], tr[2],
+	})
+	test.That(t, err, test.ShouldBeNil)
+	inv, err := h.Inverse()
+	test.That(t, err, test.ShouldBeNil)
+	toBoard := func(u, v float64) r2.Point { return inv.Apply(r2.Point{X: u, Y: v}) }
+	return testutils.RenderCheckerboard(k.Width, k.Height, board.Cols, board.Rows, board.SquareSizeMm, toBoard)
+}
+
+func TestMainCalibrate(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	board := &transform.Checkerboard{Cols: 7, Rows: 5, SquareSizeMm: 30}
+	k := &transform.PinholeCameraIntrinsics{Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 160, Ppy: 120}
+
+	dir := t.TempDir()
+	for i, tilt := range [][]float64{{0.3, 0.1}, {-0.3, 0.2}, {0.1, -0.35}, {-0.2, -0.3}} {
+		img := renderBoard(t, board, k, tilt[0], tilt[1])
+		test.That(t, rimage.WriteImageToFile(filepath.Join(dir, string(rune('a'+i))+".png"), img), test.ShouldBeNil)
+	}
+	// an image without the board is skipped, and the errors are logged for the files they are of
+	test.That(t, rimage.WriteImageToFile(filepath.Join(dir, "blank.png"), image.NewGray(image.Rect(0, 0, 320, 240))),
+		test.ShouldBeNil)
+	// as are the files of a directory which are not images
+	test.That(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("calibration images"), 0o600), test.ShouldBeNil)
+	test.That(t, os.Mkdir(filepath.Join(dir, "nested"), 0o700), test.ShouldBeNil)
+	files, err := imageFiles([]string{dir}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(files), test.ShouldEqual, 5)
+
+	var out bytes.Buffer
+	test.That(t, calibrate(nil, board, &out, logger), test.ShouldNotBeNil)
+	test.That(t, calibrate([]string{filepath.Join(dir, "missing.png")}, board, &out, logger), test.ShouldNotBeNil)
+	test.That(t, calibrate([]string{dir}, &transform.Checkerboard{Cols: 7, Rows: 5}, &out, logger), test.ShouldNotBeNil)
+
+	test.That(t, calibrate([]string{dir}, board, &out, logger), test.ShouldBeNil)
+	var calib struct {
+		Intrinsics transform.PinholeCameraIntrinsics `json:"intrinsic_parameters"`
+		Distortion transform.BrownConrady            `json:"distortion_parameters"`
+	}
+	test.That(t, json.Unmarshal(out.Bytes(), &calib), test.ShouldBeNil)
+	test.That(t, calib.Intrinsics.Width, test.ShouldEqual, 320)
+	test.That(t, calib.Intrinsics.Fx, test.ShouldAlmostEqual, k.Fx, 6)
+	test.That(t, calib.Intrinsics.Ppy, test.ShouldAlmostEqual, k.Ppy, 6)
+
+	var block map[string]interface{}
+	test.That(t, json.Unmarshal(out.Bytes(), &block), test.ShouldBeNil)
+	test.That(t, len(block), test.ShouldEqual, 2)
+	test.That(t, block, test.ShouldContainKey, "distortion_parameters")
+}
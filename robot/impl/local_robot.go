@@ -485,6 +485,7 @@ func newWithResources(
 	r.webSvc = web.New(r, logger, rOpts.webOptions...)
 	if r.ftdc != nil {
 		r.ftdc.Add("web", r.webSvc.RequestCounter())
+		r.ftdc.Add("streams", r.webSvc.StreamStatser())
 	}
 	r.frameSvc, err = framesystem.New(ctx, resource.Dependencies{}, logger.Sublogger("framesystem"))
 	if err != nil {
@@ -0,0 +1,249 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	camerapb "go.viam.com/api/component/camera/v1"
+	"go.viam.com/utils/rpc"
+	"goji.io"
+	"goji.io/pat"
+	"golang.org/x/image/draw"
+	"google.golang.org/grpc/metadata"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/rimage"
+	rutils "go.viam.com/rdk/utils"
+)
+
+const (
+	// defaultMJPEGFPS is the frame rate of MJPEG streams when the client does not ask for one.
+	defaultMJPEGFPS = 10.
+	mjpegBoundary   = "viamframe"
+	httpJPEGQuality = 75
+)
+
+// initCameraHTTP serves the snapshot of a camera at /camera/<name>/snapshot.jpg and an MJPEG stream of it at
+// /camera/<name>/stream.mjpg, when enabled. Both accept width and height query parameters bounding the size of the
+// images, and the stream an fps query parameter, all capped by the configured limits.
+func (svc *webService) initCameraHTTP(mux *goji.Mux, conf *config.CameraHTTPConfig, auth config.AuthConfig) {
+	if conf == nil {
+		return
+	}
+	mux.HandleFunc(pat.Get("/camera/:name/snapshot.jpg"), svc.cameraHTTPHandler(conf, auth, false))
+	mux.HandleFunc(pat.Get("/camera/:name/stream.mjpg"), svc.cameraHTTPHandler(conf, auth, true))
+}
+
+func (svc *webService) cameraHTTPHandler(conf *config.CameraHTTPConfig, auth config.AuthConfig, stream bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !svc.authenticateHTTP(r, auth) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="viam"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		name := pat.Param(r, "name")
+		cam, err := camera.FromRobot(svc.r, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		maxWidth, maxHeight, fps, err := cameraHTTPLimits(r, conf)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		method := "CameraHTTP/Snapshot"
+		if stream {
+			method = "CameraHTTP/Stream"
+		}
+		done, err := svc.requestCounter.startHTTPRequest(
+			name+"."+camerapb.CameraService_ServiceDesc.ServiceName, name+"."+method)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		if !stream {
+			frame, err := cameraJPEG(r.Context(), cam, maxWidth, maxHeight)
+			if err != nil {
+				done(0, true)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", rutils.MimeTypeJPEG)
+			w.Header().Set("Cache-Control", "no-store")
+			n, err := w.Write(frame)
+			done(n, err != nil)
+			return
+		}
+		sent, err := svc.serveMJPEG(w, r, cam, maxWidth, maxHeight, fps)
+		done(sent, err != nil)
+	}
+}
+
+// serveMJPEG writes the frames of the camera as a multipart/x-mixed-replace stream at the given frame rate, until the
+// client goes away. It returns the number of bytes sent.
+func (svc *webService) serveMJPEG(
+	w http.ResponseWriter, r *http.Request, cam camera.Camera, maxWidth, maxHeight int, fps float64,
+) (int, error) {
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+	w.Header().Set("Cache-Control", "no-store")
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / fps))
+	defer ticker.Stop()
+
+	sent := 0
+	failing := false
+	for {
+		frame, err := cameraJPEG(r.Context(), cam, maxWidth, maxHeight)
+		switch {
+		case err != nil && r.Context().Err() != nil:
+			return sent, nil
+		case err != nil:
+			if !failing {
+				svc.logger.Debugw("cannot get camera image for MJPEG stream", "camera", cam.Name().ShortName(), "error", err)
+			}
+			failing = true
+		default:
+			failing = false
+			n, err := fmt.Fprintf(w, "--%s\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n",
+				mjpegBoundary, rutils.MimeTypeJPEG, len(frame))
+			sent += n
+			if err == nil {
+				n, err = w.Write(append(frame, '\r', '\n'))
+				sent += n
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				// the client went away
+				return sent, nil
+			}
+		}
+		select {
+		case <-r.Context().Done():
+			return sent, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// cameraHTTPLimits returns the size and frame rate asked for by the width, height and fps query parameters, capped by
+// the configured limits. Sizes of zero are unlimited.
+func cameraHTTPLimits(r *http.Request, conf *config.CameraHTTPConfig) (int, int, float64, error) {
+	query := r.URL.Query()
+	parse := func(key string) (float64, error) {
+		v := query.Get(key)
+		if v == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || math.IsInf(f, 0) {
+			return 0, errors.Errorf("%s must be a positive number, got %q", key, v)
+		}
+		return f, nil
+	}
+	limit := func(requested, max float64) float64 {
+		if max > 0 && (requested == 0 || requested > max) {
+			return max
+		}
+		return requested
+	}
+
+	width, err := parse("width")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	height, err := parse("height")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	fps, err := parse("fps")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if fps == 0 {
+		fps = defaultMJPEGFPS
+	}
+	return int(limit(width, float64(conf.MaxWidth))), int(limit(height, float64(conf.MaxHeight))), limit(fps, conf.MaxFPS), nil
+}
+
+// cameraJPEG returns an image of the camera as a JPEG image fitting in the given size. JPEG images of the camera which
+// fit are returned as they are.
+func cameraJPEG(ctx context.Context, cam camera.Camera, maxWidth, maxHeight int) ([]byte, error) {
+	b, md, err := cam.Image(ctx, rutils.MimeTypeJPEG, nil)
+	if err != nil {
+		return nil, err
+	}
+	if md.MimeType == rutils.MimeTypeJPEG {
+		conf, err := jpeg.DecodeConfig(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		if fits(conf.Width, conf.Height, maxWidth, maxHeight) {
+			return b, nil
+		}
+	}
+	img, err := rimage.DecodeImage(ctx, b, md.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	if width, height := img.Bounds().Dx(), img.Bounds().Dy(); !fits(width, height, maxWidth, maxHeight) {
+		scale := 1.
+		if maxWidth > 0 {
+			scale = math.Min(scale, float64(maxWidth)/float64(width))
+		}
+		if maxHeight > 0 {
+			scale = math.Min(scale, float64(maxHeight)/float64(height))
+		}
+		dst := image.NewRGBA(image.Rect(0, 0,
+			int(math.Max(1, math.Round(float64(width)*scale))), int(math.Max(1, math.Round(float64(height)*scale)))))
+		draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+		img = dst
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: httpJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func fits(width, height, maxWidth, maxHeight int) bool {
+	return (maxWidth == 0 || width <= maxWidth) && (maxHeight == 0 || height <= maxHeight)
+}
+
+// authenticateHTTP checks the credentials of an HTTP request, which are either an access token of the auth service as
+// a bearer token, or an API key ID and key as the basic auth username and password. Requests are not authenticated
+// when the server is not.
+func (svc *webService) authenticateHTTP(r *http.Request, auth config.AuthConfig) bool {
+	if len(auth.Handlers) == 0 {
+		return true
+	}
+	if id, key, ok := r.BasicAuth(); ok {
+		for _, handler := range auth.Handlers {
+			if expected, ok := config.ParseAPIKeys(handler)[id]; ok && subtle.ConstantTimeCompare([]byte(expected), []byte(key)) == 1 {
+				return true
+			}
+		}
+		return false
+	}
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return false
+	}
+	_, err := svc.rpcServer.EnsureAuthed(
+		metadata.NewIncomingContext(r.Context(), metadata.Pairs(rpc.MetadataFieldAuthorization, header)))
+	return err == nil
+}
@@ -228,6 +228,27 @@ func (rc *RequestCounter) incrInFlight(resource string) bool {
 	return true
 }
 
+// startHTTPRequest counts an HTTP request to a resource under the given request key, holding it to the same in flight
+// limit as the gRPC requests to the resource, whose limit key is given. It returns a function to call with the number
+// of bytes sent once the request is done, or a RequestLimitExceededError when the limit is reached.
+func (rc *RequestCounter) startHTTPRequest(limitKey, requestKey string) (func(dataSent int, wasError bool), error) {
+	if ok := rc.incrInFlight(limitKey); !ok {
+		rc.logger.Warnw(fmt.Sprintf("Request limit exceeded for resource. See %s for troubleshooting steps", ReqLimitExceededURL),
+			"method", requestKey,
+			"resource", limitKey)
+		return nil, &RequestLimitExceededError{
+			resource: limitKey,
+			limit:    rc.inFlightLimit,
+		}
+	}
+	rc.preRequestIncrement(requestKey)
+	start := time.Now()
+	return func(dataSent int, wasError bool) {
+		rc.postRequestIncrement(requestKey, time.Since(start), dataSent, wasError)
+		rc.decrInFlight(limitKey)
+	}, nil
+}
+
 // StreamInterceptor extracts the service and method names before invoking the handler to complete the RPC.
 // It is called once per stream and will run on:
 // Client streaming: rpc Method (stream a) returns (b)
@@ -0,0 +1,56 @@
+package webstream_test
+
+import (
+	"context"
+	"testing"
+
+	streampb "go.viam.com/api/stream/v1"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/audioinput"
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/gostream/codec/vp8"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	webstream "go.viam.com/rdk/robot/web/stream"
+	"go.viam.com/rdk/testutils/inject"
+)
+
+// silentAudioInput is an audio input which is never streamed from.
+type silentAudioInput struct {
+	audioinput.AudioInput
+	name resource.Name
+}
+
+func (ai *silentAudioInput) Name() resource.Name {
+	return ai.name
+}
+
+// TestStreamsWithoutAudioEncoder asserts that audio inputs do not keep the video streams from starting with the stream
+// config of builds without cgo, which has no audio encoder.
+func TestStreamsWithoutAudioEncoder(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	cam := inject.NewCamera("cam")
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{}, nil
+	}
+	audio := &silentAudioInput{name: audioinput.Named("audio")}
+	robot := &inject.Robot{}
+	robot.LoggerFunc = func() logging.Logger { return logger }
+	robot.MockResourcesFromMap(map[resource.Name]resource.Resource{cam.Name(): cam, audio.Name(): audio})
+
+	test.That(t, vp8.DefaultStreamConfig.AudioEncoderFactory, test.ShouldBeNil)
+	server := webstream.NewServer(robot, vp8.DefaultStreamConfig, logger)
+	defer func() {
+		test.That(t, server.Close(), test.ShouldBeNil)
+	}()
+	test.That(t, server.AddNewStreams(ctx), test.ShouldBeNil)
+
+	resp, err := server.ListStreams(ctx, &streampb.ListStreamsRequest{})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp.Names, test.ShouldResemble, []string{"cam"})
+
+	// and again when the robot is reconfigured
+	test.That(t, server.AddNewStreams(ctx), test.ShouldBeNil)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"image"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -218,25 +219,38 @@ func (server *Server) AddStream(ctx context.Context, req *streampb.AddStreamRequ
 	})
 	defer guard.OnFail()
 
-	addTrack := func(track webrtc.TrackLocal) error {
+	addTrack := func(track webrtc.TrackLocal) (*webrtc.RTPSender, error) {
 		sender, err := pc.AddTrack(track)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		ps.senders = append(ps.senders, sender)
-		return nil
+		return sender, nil
 	}
 
 	// if the stream supports video, add the video track
 	if trackLocal, haveTrackLocal := streamStateToAdd.Stream.VideoTrackLocal(); haveTrackLocal {
-		if err := addTrack(trackLocal); err != nil {
+		sender, err := addTrack(trackLocal)
+		if err != nil {
 			server.logger.Error(err.Error())
 			return nil, err
 		}
+		// the video adapts to the RTCP feedback of the peer, which is read until the sender stops along with the track
+		// or the peer connection
+		stream, peer := streamStateToAdd.Stream, fmt.Sprintf("%p", pc)
+		utils.PanicCapturingGo(func() {
+			for {
+				pkts, _, err := sender.ReadRTCP()
+				if err != nil {
+					return
+				}
+				stream.HandleRTCP(peer, pkts)
+			}
+		})
 	}
 	// if the stream supports audio, add the audio track
 	if trackLocal, haveTrackLocal := streamStateToAdd.Stream.AudioTrackLocal(); haveTrackLocal {
-		if err := addTrack(trackLocal); err != nil {
+		if _, err := addTrack(trackLocal); err != nil {
 			server.logger.Error(err.Error())
 			return nil, err
 		}
@@ -507,6 +521,19 @@ func (server *Server) AddNewStreams(ctx context.Context) error {
 		server.startVideoStream(ctx, server.videoSources[name], stream)
 	}
 
+	if server.streamConfig.AudioEncoderFactory == nil {
+		// Builds without cgo have no audio encoder, which must not keep the video streams from starting.
+		if len(server.audioSources) != 0 {
+			names := make([]string, 0, len(server.audioSources))
+			for name := range server.audioSources {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			server.logger.Warnf("not streaming audio inputs %v, as no audio encoder is available", names)
+		}
+		return nil
+	}
+
 	for name := range server.audioSources {
 		// Similarly, we walk the updated set of `audioSources` and ensure all of the audio sources
 		// are "created" and "started". `createStream` and `startAudioStream` have the same
@@ -527,6 +554,20 @@ func (server *Server) AddNewStreams(ctx context.Context) error {
 	return nil
 }
 
+// Stats returns statistics of the video sent by every video stream, keyed by stream name. It satisfies the
+// ftdc.Statser interface.
+func (server *Server) Stats() any {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+	stats := make(map[string]gostream.StreamStats, len(server.nameToStreamState))
+	for name, streamState := range server.nameToStreamState {
+		if _, ok := streamState.Stream.VideoTrackLocal(); ok {
+			stats[name] = streamState.Stream.Stats()
+		}
+	}
+	return stats
+}
+
 // Close closes the Server and waits for spun off goroutines to complete.
 func (server *Server) Close() error {
 	server.closedFn()
@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/pion/mediadevices/pkg/prop"
 	"github.com/pion/mediadevices/pkg/wave"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
 	"github.com/viamrobotics/webrtc/v3"
 	"go.viam.com/test"
@@ -71,6 +72,15 @@ func (mS *mockStream) InputAudioChunks(props prop.Audio) (chan<- gostream.MediaR
 	return make(chan gostream.MediaReleasePair[wave.Audio]), nil
 }
 
+func (mS *mockStream) HandleRTCP(peer string, pkts []rtcp.Packet) {
+	test.That(mS.t, "should not be called", test.ShouldBeFalse)
+}
+
+func (mS *mockStream) Stats() gostream.StreamStats {
+	test.That(mS.t, "should not be called", test.ShouldBeFalse)
+	return gostream.StreamStats{}
+}
+
 func (mS *mockStream) VideoTrackLocal() (webrtc.TrackLocal, bool) {
 	test.That(mS.t, "should not be called", test.ShouldBeFalse)
 	return nil, false
@@ -1,5 +1,3 @@
-//go:build !no_cgo || android
-
 // Package webstream provides controls for streaming from the web server.
 package webstream
 
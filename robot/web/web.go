@@ -38,6 +38,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"go.viam.com/rdk/config"
+	"go.viam.com/rdk/ftdc"
 	"go.viam.com/rdk/grpc"
 	"go.viam.com/rdk/logging"
 	"go.viam.com/rdk/module"
@@ -85,6 +86,9 @@ type Service interface {
 
 	RequestCounter() *RequestCounter
 
+	// StreamStatser returns the ftdc.Statser of the video streams sent to peers.
+	StreamStatser() ftdc.Statser
+
 	ModPeerConnTracker() *grpc.ModPeerConnTracker
 }
 
@@ -108,7 +112,6 @@ type webService struct {
 	unixModServer rpc.Server
 	tcpModServer  rpc.Server
 
-	// Will be nil on non-cgo builds.
 	streamServer *webstream.Server
 	opts         options
 	addr         string
@@ -769,6 +772,8 @@ func (svc *webService) initMux(options weboptions.Options) *goji.Mux {
 	// serve restart status
 	mux.HandleFunc(pat.New("/restart_status"), svc.handleRestartStatus)
 
+	svc.initCameraHTTP(mux, options.Network.CameraHTTP, options.Auth)
+
 	prefix := "/viam"
 	addPrefix := func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -993,6 +998,21 @@ func (svc *webService) Stats() any {
 	return stats{svc.rpcServer.Stats()}
 }
 
+// streamStatser returns the statistics of the video streams of the current stream server.
+type streamStatser struct {
+	svc *webService
+}
+
+func (ss streamStatser) Stats() any {
+	return ss.svc.streamStats()
+}
+
+// StreamStatser returns the ftdc.Statser of the video streams sent to peers, whose statistics are keyed by stream
+// name.
+func (svc *webService) StreamStatser() ftdc.Statser {
+	return streamStatser{svc}
+}
+
 // RestartStatusResponse is the JSON response of the `restart_status` HTTP
 // endpoint.
 type RestartStatusResponse struct {
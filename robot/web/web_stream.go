@@ -1,5 +1,3 @@
-//go:build !no_cgo || android
-
 package web
 
 import (
@@ -41,6 +39,15 @@ func (svc *webService) closeStreamServer() {
 	}
 }
 
+func (svc *webService) streamStats() any {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.streamServer == nil {
+		return map[string]gostream.StreamStats{}
+	}
+	return svc.streamServer.Stats()
+}
+
 func (svc *webService) initStreamServer(ctx context.Context, srv rpc.Server) error {
 	// The webService depends on the stream server in addition to modules. We relax expectations on
 	// what will be started first and allow for any order.
@@ -1,6 +1,7 @@
 package web_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -8,8 +9,13 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"image"
+	"image/jpeg"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net"
+	"net/http"
 	"os"
 	"sync"
 	"testing"
@@ -1704,3 +1710,98 @@ func TestPerResourceLimitsAndFTDC(t *testing.T) {
 		)
 	})
 }
+
+func TestCameraHTTP(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	originalRequestLimit := os.Getenv(rutils.ViamResourceRequestsLimitEnvVar)
+	os.Setenv(rutils.ViamResourceRequestsLimitEnvVar, "1")
+	t.Cleanup(func() {
+		os.Setenv(rutils.ViamResourceRequestsLimitEnvVar, originalRequestLimit)
+	})
+
+	injectRobot := &inject.Robot{}
+	cam := inject.NewCamera("camera1")
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 48)), nil)
+		return buf.Bytes(), camera.ImageMetadata{MimeType: rutils.MimeTypeJPEG}, err
+	}
+	injectRobot.MockResourcesFromMap(map[resource.Name]resource.Resource{cam.Name(): cam})
+	injectRobot.LoggerFunc = func() logging.Logger { return logger }
+
+	svc := web.New(injectRobot, logger)
+	options, _, addr := robottestutils.CreateBaseOptionsAndListener(t)
+	apiKeyID := uuid.New().String()
+	apiKey := utils.RandomAlphaString(32)
+	options.Auth.Handlers = []config.AuthHandlerConfig{
+		{
+			Type:   rpc.CredentialsTypeAPIKey,
+			Config: rutils.AttributeMap{apiKeyID: apiKey, "keys": []string{apiKeyID}},
+		},
+	}
+	options.Network.CameraHTTP = &config.CameraHTTPConfig{MaxFPS: 20, MaxWidth: 32}
+	test.That(t, svc.Start(context.Background(), options), test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(context.Background()), test.ShouldBeNil)
+	}()
+
+	get := func(ctx context.Context, path, key string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+		test.That(t, err, test.ShouldBeNil)
+		if key != "" {
+			req.SetBasicAuth(apiKeyID, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		test.That(t, err, test.ShouldBeNil)
+		return resp
+	}
+	status := func(path, key string) int {
+		t.Helper()
+		resp := get(context.Background(), path, key)
+		test.That(t, resp.Body.Close(), test.ShouldBeNil)
+		return resp.StatusCode
+	}
+
+	test.That(t, status("/camera/camera1/snapshot.jpg", ""), test.ShouldEqual, http.StatusUnauthorized)
+	test.That(t, status("/camera/camera1/snapshot.jpg", "wrong"), test.ShouldEqual, http.StatusUnauthorized)
+	test.That(t, status("/camera/camera2/snapshot.jpg", apiKey), test.ShouldEqual, http.StatusNotFound)
+	test.That(t, status("/camera/camera1/snapshot.jpg?width=abc", apiKey), test.ShouldEqual, http.StatusBadRequest)
+
+	// images are scaled down to the configured size
+	resp := get(context.Background(), "/camera/camera1/snapshot.jpg", apiKey)
+	test.That(t, resp.StatusCode, test.ShouldEqual, http.StatusOK)
+	test.That(t, resp.Header.Get("Content-Type"), test.ShouldEqual, rutils.MimeTypeJPEG)
+	img, err := jpeg.Decode(resp.Body)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp.Body.Close(), test.ShouldBeNil)
+	test.That(t, img.Bounds(), test.ShouldResemble, image.Rect(0, 0, 32, 24))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp = get(ctx, "/camera/camera1/stream.mjpg?fps=100&width=16", apiKey)
+	test.That(t, resp.StatusCode, test.ShouldEqual, http.StatusOK)
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, mediaType, test.ShouldEqual, "multipart/x-mixed-replace")
+	parts := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; i < 3; i++ {
+		part, err := parts.NextPart()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, part.Header.Get("Content-Type"), test.ShouldEqual, rutils.MimeTypeJPEG)
+		img, err := jpeg.Decode(part)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, img.Bounds(), test.ShouldResemble, image.Rect(0, 0, 16, 12))
+	}
+
+	// the stream counts against the request limit of the camera until it is closed
+	test.That(t, status("/camera/camera1/snapshot.jpg", apiKey), test.ShouldEqual, http.StatusTooManyRequests)
+	cancel()
+	test.That(t, resp.Body.Close(), test.ShouldBeNil)
+	testutils.WaitForAssertion(t, func(tb testing.TB) {
+		test.That(tb, status("/camera/camera1/snapshot.jpg", apiKey), test.ShouldEqual, http.StatusOK)
+	})
+	stats := svc.RequestCounter().Stats().(map[string]int64)
+	test.That(t, stats["camera1.CameraHTTP/Snapshot"], test.ShouldBeGreaterThanOrEqualTo, 2)
+	test.That(t, stats["camera1.CameraHTTP/Stream"], test.ShouldEqual, 1)
+	test.That(t, stats["camera1.CameraHTTP/Stream.dataSentBytes"], test.ShouldBeGreaterThan, 0)
+}
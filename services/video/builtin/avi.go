@@ -0,0 +1,148 @@
+package builtin
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// aviHeaderSize is the size of the headers of the AVI files written, up to the first frame chunk of the movi list. The
+// files hold a single video stream, so the headers always have the same size.
+const aviHeaderSize = 224
+
+// aviIndexEntrySize is the size of an entry of the idx1 chunk.
+const aviIndexEntrySize = 16
+
+// maxAVISize is the size of the largest AVI file written. The sizes and offsets of AVI files are 32 bit, and the
+// OpenDML extensions lifting the limit are not written.
+const maxAVISize = math.MaxUint32
+
+const (
+	avifHasIndex   = 0x10
+	aviifKeyframe  = 0x10
+	aviFrameChunk  = "00dc"
+	aviStreamVideo = "vids"
+)
+
+// frameChunkSize returns the size taken by a frame chunk of the given size in the movi list, which is padded to an
+// even size.
+func frameChunkSize(size int) int {
+	return 8 + size + size%2
+}
+
+// aviSize returns the size of an AVI file of the given number of frames, whose frame chunks take moviSize bytes.
+func aviSize(frames, moviSize int) int64 {
+	return int64(aviHeaderSize) + int64(moviSize) + 8 + int64(frames)*aviIndexEntrySize
+}
+
+// aviHeader returns the headers of an AVI file of the given frames, up to the first frame chunk. moviSize is the size of
+// all the frame chunks, whose AVI file must not be larger than maxAVISize. AVI files have a constant frame rate, so
+// frameDuration is the duration of every frame.
+func aviHeader(fourCC string, width, height, frames, moviSize int, frameDuration time.Duration) []byte {
+	b := make([]byte, 0, aviHeaderSize)
+	le := binary.LittleEndian
+	u32 := func(v int) { b = le.AppendUint32(b, uint32(v)) }
+	u16 := func(v int) { b = le.AppendUint16(b, uint16(v)) }
+	fcc := func(s string) { b = append(b, s...) }
+
+	usPerFrame := int(frameDuration / time.Microsecond)
+	if usPerFrame <= 0 {
+		usPerFrame = 1
+	}
+
+	fcc("RIFF")
+	u32(aviHeaderSize - 8 + moviSize + 8 + frames*aviIndexEntrySize)
+	fcc("AVI ")
+
+	fcc("LIST")
+	u32(192)
+	fcc("hdrl")
+
+	fcc("avih")
+	u32(56)
+	u32(usPerFrame)
+	u32(0) // max bytes per second
+	u32(0) // padding granularity
+	u32(avifHasIndex)
+	u32(frames)
+	u32(0) // initial frames
+	u32(1) // streams
+	u32(0) // suggested buffer size
+	u32(width)
+	u32(height)
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	fcc("LIST")
+	u32(116)
+	fcc("strl")
+
+	fcc("strh")
+	u32(56)
+	fcc(aviStreamVideo)
+	fcc(fourCC)
+	u32(0) // flags
+	u16(0) // priority
+	u16(0) // language
+	u32(0) // initial frames
+	u32(usPerFrame)
+	u32(int(time.Second / time.Microsecond))
+	u32(0) // start
+	u32(frames)
+	u32(0)  // suggested buffer size
+	u32(-1) // quality
+	u32(0)  // sample size
+	u16(0)
+	u16(0)
+	u16(width)
+	u16(height)
+
+	fcc("strf")
+	u32(40)
+	u32(40)
+	u32(width)
+	u32(height)
+	u16(1)  // planes
+	u16(24) // bits per pixel
+	fcc(fourCC)
+	u32(width * height * 3)
+	u32(0)
+	u32(0)
+	u32(0)
+	u32(0)
+
+	fcc("LIST")
+	u32(4 + moviSize)
+	fcc("movi")
+	return b
+}
+
+// frameChunkHeader returns the header of the chunk of a frame of the given size.
+func frameChunkHeader(size int) []byte {
+	return binary.LittleEndian.AppendUint32([]byte(aviFrameChunk), uint32(size))
+}
+
+// aviIndex returns the idx1 chunk indexing the frames, which follows the movi list, of an AVI file not larger than
+// maxAVISize.
+func aviIndex(frames []frameEntry) []byte {
+	le := binary.LittleEndian
+	b := make([]byte, 0, 8+len(frames)*aviIndexEntrySize)
+	b = append(b, "idx1"...)
+	b = le.AppendUint32(b, uint32(len(frames)*aviIndexEntrySize))
+	// offsets are relative to the movi fourcc
+	offset := 4
+	for _, f := range frames {
+		flags := 0
+		if f.key {
+			flags = aviifKeyframe
+		}
+		b = append(b, aviFrameChunk...)
+		b = le.AppendUint32(b, uint32(flags))
+		b = le.AppendUint32(b, uint32(offset))
+		b = le.AppendUint32(b, f.size)
+		offset += frameChunkSize(int(f.size))
+	}
+	return b
+}
@@ -0,0 +1,364 @@
+// Package builtin implements a video service which continuously records cameras into a local ring buffer of segment
+// files, and returns the video recorded within a time window as an AVI file.
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	goutils "go.viam.com/utils"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/video"
+	"go.viam.com/rdk/utils"
+)
+
+var model = resource.DefaultModelFamily.WithModel("builtin")
+
+const (
+	defaultFramerateHz    = 5.
+	defaultSegmentSeconds = 60.
+	defaultMaxStorageMB   = 1024.
+
+	containerAVI = "avi"
+	// chunkSize is the size above which the video returned is split into chunks
+	chunkSize = 1 << 20
+	// extraCameraName is the extra of GetVideo selecting the camera, when several are recorded
+	extraCameraName = "camera_name"
+)
+
+func init() {
+	resource.RegisterService(video.API, model, resource.Registration[video.Service, *Config]{
+		Constructor: func(
+			ctx context.Context, deps resource.Dependencies, c resource.Config, logger logging.Logger,
+		) (video.Service, error) {
+			conf, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			return newBuiltIn(c.ResourceName(), deps, conf, logger)
+		},
+	})
+}
+
+// Config is the config of the builtin video service, which records the frames of Cameras at FramerateHz, defaulting to
+// 5, into segments of SegmentSeconds, defaulting to 60, under StoragePath, defaulting to ~/.viam/video/<service name>.
+// Codec is "mjpeg", the default, or "h264" on builds with cgo. The oldest segments are removed once the recordings take
+// more than MaxStorageMB, defaulting to 1024, or once they are older than RetentionHours when it is set.
+type Config struct {
+	Cameras        []string `json:"camera_names"`
+	StoragePath    string   `json:"storage_path,omitempty"`
+	Codec          string   `json:"codec,omitempty"`
+	FramerateHz    float64  `json:"framerate_hz,omitempty"`
+	SegmentSeconds float64  `json:"segment_seconds,omitempty"`
+	MaxStorageMB   float64  `json:"max_storage_mb,omitempty"`
+	RetentionHours float64  `json:"retention_hours,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if len(cfg.Cameras) == 0 {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "camera_names")
+	}
+	for name, v := range map[string]float64{
+		"framerate_hz":    cfg.FramerateHz,
+		"segment_seconds": cfg.SegmentSeconds,
+		"max_storage_mb":  cfg.MaxStorageMB,
+		"retention_hours": cfg.RetentionHours,
+	} {
+		if v < 0 {
+			return nil, nil, resource.NewConfigValidationError(path, errors.Errorf("%s cannot be negative", name))
+		}
+	}
+	if _, err := codecFourCC(cfg.codec()); err != nil {
+		return nil, nil, resource.NewConfigValidationError(path, err)
+	}
+	return cfg.Cameras, nil, nil
+}
+
+func (cfg *Config) codec() string {
+	if cfg.Codec == "" {
+		return codecMJPEG
+	}
+	return strings.ToLower(cfg.Codec)
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// builtIn records every camera with its own recorder, and shares the storage limits between them.
+type builtIn struct {
+	resource.Named
+	resource.AlwaysRebuild
+	logger logging.Logger
+
+	codec      string
+	maxStorage int64
+	retention  time.Duration
+	recorders  map[string]*recorder
+	// limitsMu serializes the enforcement of the storage limits
+	limitsMu sync.Mutex
+
+	cancelFn context.CancelFunc
+	workers  sync.WaitGroup
+}
+
+func newBuiltIn(name resource.Name, deps resource.Dependencies, conf *Config, logger logging.Logger) (video.Service, error) {
+	if _, err := codecFourCC(conf.codec()); err != nil {
+		return nil, err
+	}
+	storagePath := conf.StoragePath
+	if storagePath == "" {
+		storagePath = filepath.Join(utils.ViamDotDir, "video", name.Name)
+	}
+	svc := &builtIn{
+		Named:      name.AsNamed(),
+		logger:     logger,
+		codec:      conf.codec(),
+		maxStorage: int64(orDefault(conf.MaxStorageMB, defaultMaxStorageMB) * (1 << 20)),
+		retention:  time.Duration(conf.RetentionHours * float64(time.Hour)),
+		recorders:  map[string]*recorder{},
+	}
+	dirNames := strings.NewReplacer("/", "_", ":", "_")
+	for _, camName := range conf.Cameras {
+		cam, err := camera.FromDependencies(deps, camName)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(storagePath, dirNames.Replace(camName))
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+		// the recordings of a previous run are kept, and returned by GetVideo
+		segments, err := loadSegments(dir, logger)
+		if err != nil {
+			return nil, err
+		}
+		svc.recorders[camName] = &recorder{
+			name:            camName,
+			cam:             cam,
+			dir:             dir,
+			codec:           svc.codec,
+			frameInterval:   time.Duration(float64(time.Second) / orDefault(conf.FramerateHz, defaultFramerateHz)),
+			segmentDuration: time.Duration(orDefault(conf.SegmentSeconds, defaultSegmentSeconds) * float64(time.Second)),
+			logger:          logger,
+			onSegmentDone:   svc.enforceStorageLimits,
+			segments:        segments,
+		}
+	}
+	svc.enforceStorageLimits()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.cancelFn = cancel
+	for _, r := range svc.recorders {
+		r := r
+		svc.workers.Add(1)
+		goutils.ManagedGo(func() { r.run(ctx) }, svc.workers.Done)
+	}
+	return svc, nil
+}
+
+// enforceStorageLimits removes the segments older than the retention, then the oldest segments of any camera until the
+// recordings fit in the storage quota. The segments being written are never removed.
+func (svc *builtIn) enforceStorageLimits() {
+	svc.limitsMu.Lock()
+	defer svc.limitsMu.Unlock()
+	if svc.retention > 0 {
+		before := time.Now().Add(-svc.retention)
+		for _, r := range svc.recorders {
+			r.removeEndedBefore(before)
+		}
+	}
+	var total int64
+	for _, r := range svc.recorders {
+		total += r.storageSize()
+	}
+	for total > svc.maxStorage {
+		var oldest *recorder
+		var oldestStart time.Time
+		for _, r := range svc.recorders {
+			if start, ok := r.oldest(); ok && (oldest == nil || start.Before(oldestStart)) {
+				oldest, oldestStart = r, start
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		total -= oldest.removeOldest()
+	}
+}
+
+// GetVideo returns the video recorded between the start and end times as an AVI file split into chunks. A zero start
+// returns the video from the oldest recording and a zero end the video up to now. The codec is the codec of the
+// recordings, the configured one by default, and the container is "avi". The camera is selected by the camera_name
+// extra when several are recorded. AVI files have a constant frame rate, so gaps in the recordings are not kept, and a
+// single frame size, so the video ends where the frame size changes, the rest being returned by starting after it.
+// Videos larger than the 4 GiB an AVI file can hold are not returned, shorter time ranges have to be asked for.
+func (svc *builtIn) GetVideo(
+	ctx context.Context,
+	startTime, endTime time.Time,
+	videoCodec, videoContainer string,
+	extra map[string]interface{},
+) (chan *video.Chunk, error) {
+	if videoContainer != "" && !strings.EqualFold(videoContainer, containerAVI) {
+		return nil, errors.Errorf("unsupported container %q, recordings can only be returned as %s", videoContainer, containerAVI)
+	}
+	codec := svc.codec
+	if videoCodec != "" {
+		codec = strings.ToLower(videoCodec)
+	}
+	fourCC, err := codecFourCC(codec)
+	if err != nil {
+		return nil, err
+	}
+	r, err := svc.recorder(extra)
+	if err != nil {
+		return nil, err
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if endTime.Before(startTime) {
+		return nil, errors.New("the end time is before the start time")
+	}
+
+	c, err := r.clip(startTime, endTime, fourCC)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.frames) == 0 {
+		return nil, multierr.Combine(
+			errors.Errorf("no %s video of camera %q recorded between %s and %s", codec, r.name,
+				startTime.Format(time.RFC3339), endTime.Format(time.RFC3339)),
+			c.close())
+	}
+	entries := c.entries()
+	moviSize := 0
+	for _, f := range entries {
+		moviSize += frameChunkSize(int(f.size))
+	}
+	if size := aviSize(len(entries), moviSize); size > maxAVISize {
+		return nil, multierr.Combine(
+			errors.Errorf("the video of camera %q between %s and %s takes %d bytes, more than the 4 GiB an AVI file can hold, "+
+				"ask for a shorter time range", r.name, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), size),
+			c.close())
+	}
+
+	ch := make(chan *video.Chunk, 1)
+	svc.workers.Add(1)
+	goutils.ManagedGo(func() {
+		defer func() {
+			if err := c.close(); err != nil {
+				svc.logger.Debugw("cannot close video segments", "error", err)
+			}
+			close(ch)
+		}()
+		send := func(data []byte) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case ch <- &video.Chunk{Data: data, Container: containerAVI}:
+				return true
+			}
+		}
+
+		buf := aviHeader(fourCC, c.width, c.height, len(entries), moviSize, meanFrameDuration(entries, r.frameInterval))
+		for _, f := range c.frames {
+			start := len(buf)
+			buf = append(buf, frameChunkHeader(int(f.size))...)
+			buf = append(buf, make([]byte, f.size+f.size%2)...)
+			if _, err := f.file.ReadAt(buf[start+8:start+8+int(f.size)], f.offset); err != nil {
+				svc.logger.Warnw("cannot read video segment", "path", f.file.Name(), "error", err)
+				return
+			}
+			if len(buf) >= chunkSize {
+				if !send(buf) {
+					return
+				}
+				buf = nil
+			}
+		}
+		send(append(buf, aviIndex(entries)...))
+	}, svc.workers.Done)
+	return ch, nil
+}
+
+// recorder returns the recorder of the camera given by the camera_name extra, or the only one.
+func (svc *builtIn) recorder(extra map[string]interface{}) (*recorder, error) {
+	name, ok := extra[extraCameraName].(string)
+	if !ok {
+		if len(svc.recorders) != 1 {
+			return nil, errors.Errorf("several cameras are recorded, select one with the %s extra", extraCameraName)
+		}
+		for _, r := range svc.recorders {
+			return r, nil
+		}
+	}
+	r, ok := svc.recorders[name]
+	if !ok {
+		return nil, errors.Errorf("camera %q is not recorded", name)
+	}
+	return r, nil
+}
+
+// DoCommand returns the recorded segments.
+//
+//	required key: segments
+//	input value: anything
+//	output value: a map from the name of each camera to the list of its segments, with their start and end times,
+//	    number of frames, size in bytes, codec and frame width and height, oldest first
+func (svc *builtIn) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := cmd["segments"]; !ok {
+		return nil, errors.Errorf("unknown command, the supported command is segments: %v", cmd)
+	}
+	fourCCCodecs := map[string]string{}
+	for name, c := range codecs {
+		fourCCCodecs[c.fourCC] = name
+	}
+	out := map[string]interface{}{}
+	for name, r := range svc.recorders {
+		r.mu.Lock()
+		segments := r.segments
+		if r.current != nil && len(r.current.frames) > 0 {
+			segments = append(segments[:len(segments):len(segments)], r.current)
+		}
+		list := make([]interface{}, 0, len(segments))
+		for _, s := range segments {
+			list = append(list, map[string]interface{}{
+				"start":  s.start().Format(time.RFC3339Nano),
+				"end":    s.end().Format(time.RFC3339Nano),
+				"frames": len(s.frames),
+				"bytes":  s.size(),
+				"codec":  fourCCCodecs[s.fourCC],
+				"width":  s.width,
+				"height": s.height,
+			})
+		}
+		r.mu.Unlock()
+		out[name] = list
+	}
+	return map[string]interface{}{"segments": out}, nil
+}
+
+// Close stops recording, finishes the segments being written and waits for the videos being returned.
+func (svc *builtIn) Close(ctx context.Context) error {
+	svc.cancelFn()
+	svc.workers.Wait()
+	var err error
+	for _, r := range svc.recorders {
+		err = multierr.Combine(err, r.finishSegment())
+	}
+	return err
+}
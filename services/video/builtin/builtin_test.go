@@ -0,0 +1,319 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/video"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+)
+
+func TestConfig(t *testing.T) {
+	_, _, err := (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{Cameras: []string{"cam"}, FramerateHz: -1}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{Cameras: []string{"cam"}, Codec: "vp9"}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	deps, _, err := (&Config{Cameras: []string{"cam1", "cam2"}, Codec: "MJPEG"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam1", "cam2"})
+}
+
+// newCamera returns a camera whose frames have a gray level increasing with every frame, returned as JPEG or PNG
+// images.
+func newCamera(name, mimeType string) *inject.Camera {
+	return newResizingCamera(name, mimeType, func(int) image.Point { return image.Pt(64, 48) })
+}
+
+// newResizingCamera returns a camera like newCamera, whose frames have the size returned by size for their number.
+func newResizingCamera(name, mimeType string, size func(frame int) image.Point) *inject.Camera {
+	cam := inject.NewCamera(name)
+	frame := 0
+	cam.ImageFunc = func(ctx context.Context, _ string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		sz := size(frame)
+		img := image.NewGray(image.Rect(0, 0, sz.X, sz.Y))
+		for i := range img.Pix {
+			img.Pix[i] = uint8(frame)
+		}
+		frame++
+		b, err := rimage.EncodeImage(ctx, img, mimeType)
+		return b, camera.ImageMetadata{MimeType: mimeType}, err
+	}
+	return cam
+}
+
+// readVideo reads the chunks of a video, returning the frames of the AVI file.
+func readVideo(t *testing.T, ch chan *video.Chunk) [][]byte {
+	t.Helper()
+	_, frames := readVideoFile(t, ch)
+	return frames
+}
+
+// readVideoFile reads the chunks of a video, returning the AVI file and its frames.
+func readVideoFile(t *testing.T, ch chan *video.Chunk) ([]byte, [][]byte) {
+	t.Helper()
+	var b []byte
+	for chunk := range ch {
+		test.That(t, chunk.Container, test.ShouldEqual, "avi")
+		b = append(b, chunk.Data...)
+	}
+	le := binary.LittleEndian
+	test.That(t, string(b[:4]), test.ShouldEqual, "RIFF")
+	test.That(t, int(le.Uint32(b[4:])), test.ShouldEqual, len(b)-8)
+	test.That(t, string(b[8:12]), test.ShouldEqual, "AVI ")
+	test.That(t, string(b[aviHeaderSize-4:aviHeaderSize]), test.ShouldEqual, "movi")
+	count := int(le.Uint32(b[48:]))
+	moviSize := int(le.Uint32(b[aviHeaderSize-8:])) - 4
+
+	var frames [][]byte
+	for movi := b[aviHeaderSize : aviHeaderSize+moviSize]; len(movi) > 0; {
+		test.That(t, string(movi[:4]), test.ShouldEqual, "00dc")
+		size := int(le.Uint32(movi[4:]))
+		frames = append(frames, movi[8:8+size])
+		movi = movi[frameChunkSize(size):]
+	}
+	test.That(t, len(frames), test.ShouldEqual, count)
+	index := b[aviHeaderSize+moviSize:]
+	test.That(t, string(index[:4]), test.ShouldEqual, "idx1")
+	test.That(t, int(le.Uint32(index[4:])), test.ShouldEqual, count*aviIndexEntrySize)
+	return b, frames
+}
+
+func grayLevel(t *testing.T, frame []byte) uint8 {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(frame))
+	test.That(t, err, test.ShouldBeNil)
+	return color.GrayModel.Convert(img.At(10, 10)).(color.Gray).Y
+}
+
+func TestBuiltIn(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	cam1 := newCamera("cam1", utils.MimeTypeJPEG)
+	cam2 := newCamera("cam2", utils.MimeTypePNG)
+	deps := resource.Dependencies{cam1.Name(): cam1, cam2.Name(): cam2}
+	dir := t.TempDir()
+	conf := &Config{Cameras: []string{"cam1", "cam2"}, StoragePath: dir, SegmentSeconds: 1, FramerateHz: 0.001}
+
+	_, err := newBuiltIn(video.Named("video"), deps, &Config{Cameras: []string{"missing"}, StoragePath: dir}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// frames are captured by hand rather than by the recorders, every 100ms for 2.5s
+	svc, err := newBuiltIn(video.Named("video"), deps, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	start := time.Now().Add(-time.Minute)
+	frameTime := func(i int) time.Time { return start.Add(time.Duration(i) * 100 * time.Millisecond) }
+	for i := 0; i < 25; i++ {
+		for _, r := range svc.(*builtIn).recorders {
+			test.That(t, r.capture(ctx, frameTime(i)), test.ShouldBeNil)
+		}
+	}
+
+	resp, err := svc.DoCommand(ctx, map[string]interface{}{"segments": true})
+	test.That(t, err, test.ShouldBeNil)
+	_, err = structpb.NewStruct(resp)
+	test.That(t, err, test.ShouldBeNil)
+	segments := resp["segments"].(map[string]interface{})["cam1"].([]interface{})
+	segments2 := resp["segments"].(map[string]interface{})["cam2"].([]interface{})
+	test.That(t, len(segments), test.ShouldEqual, 3)
+	test.That(t, len(segments2), test.ShouldEqual, 3)
+	test.That(t, segments[0].(map[string]interface{})["frames"], test.ShouldEqual, 10)
+	test.That(t, segments[0].(map[string]interface{})["codec"], test.ShouldEqual, "mjpeg")
+	test.That(t, segments[0].(map[string]interface{})["width"], test.ShouldEqual, 64)
+	test.That(t, segments[2].(map[string]interface{})["frames"], test.ShouldEqual, 5)
+
+	_, err = svc.DoCommand(ctx, map[string]interface{}{"unknown": true})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// the whole recording, across segments including the one being written
+	_, err = svc.GetVideo(ctx, time.Time{}, time.Time{}, "", "", nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	ch, err := svc.GetVideo(ctx, time.Time{}, time.Time{}, "", "", map[string]interface{}{"camera_name": "cam1"})
+	test.That(t, err, test.ShouldBeNil)
+	frames := readVideo(t, ch)
+	test.That(t, len(frames), test.ShouldEqual, 25)
+	test.That(t, grayLevel(t, frames[0]), test.ShouldAlmostEqual, 0, 1)
+	test.That(t, grayLevel(t, frames[24]), test.ShouldAlmostEqual, 24, 1)
+
+	// a window of the recording of the PNG camera, encoded to JPEG
+	ch, err = svc.GetVideo(ctx, frameTime(8), frameTime(12), "mjpeg", "avi", map[string]interface{}{"camera_name": "cam2"})
+	test.That(t, err, test.ShouldBeNil)
+	frames = readVideo(t, ch)
+	test.That(t, len(frames), test.ShouldEqual, 5)
+	test.That(t, grayLevel(t, frames[0]), test.ShouldAlmostEqual, 8, 1)
+
+	_, err = svc.GetVideo(ctx, time.Time{}, start.Add(-time.Second), "", "", map[string]interface{}{"camera_name": "cam1"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.GetVideo(ctx, time.Time{}, time.Time{}, "", "mp4", map[string]interface{}{"camera_name": "cam1"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.GetVideo(ctx, time.Time{}, time.Time{}, "vp9", "", map[string]interface{}{"camera_name": "cam1"})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = svc.GetVideo(ctx, time.Time{}, time.Time{}, "", "", map[string]interface{}{"camera_name": "cam3"})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	test.That(t, svc.Close(ctx), test.ShouldBeNil)
+
+	// the recordings are kept across restarts, with the oldest segments of any camera removed to fit the quota
+	lastSize := segments[2].(map[string]interface{})["bytes"].(int64) + segments2[2].(map[string]interface{})["bytes"].(int64)
+	conf.MaxStorageMB = float64(lastSize) / (1 << 20)
+	svc, err = newBuiltIn(video.Named("video"), deps, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+	resp, err = svc.DoCommand(ctx, map[string]interface{}{"segments": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["segments"].(map[string]interface{})["cam1"], test.ShouldResemble, segments[2:])
+	test.That(t, resp["segments"].(map[string]interface{})["cam2"], test.ShouldResemble, segments2[2:])
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(files), test.ShouldEqual, 4)
+}
+
+func TestResolutionChange(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	cam := newResizingCamera("cam", utils.MimeTypeJPEG, func(frame int) image.Point {
+		if frame < 12 {
+			return image.Pt(64, 48)
+		}
+		return image.Pt(128, 96)
+	})
+	conf := &Config{Cameras: []string{"cam"}, StoragePath: t.TempDir(), SegmentSeconds: 1, FramerateHz: 0.001}
+	svc, err := newBuiltIn(video.Named("video"), resource.Dependencies{cam.Name(): cam}, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+
+	// the size changes in the middle of the second segment, starting a third one
+	start := time.Now().Add(-time.Minute)
+	frameTime := func(i int) time.Time { return start.Add(time.Duration(i) * 100 * time.Millisecond) }
+	for i := 0; i < 20; i++ {
+		test.That(t, svc.(*builtIn).recorders["cam"].capture(ctx, frameTime(i)), test.ShouldBeNil)
+	}
+	resp, err := svc.DoCommand(ctx, map[string]interface{}{"segments": true})
+	test.That(t, err, test.ShouldBeNil)
+	segments := resp["segments"].(map[string]interface{})["cam"].([]interface{})
+	test.That(t, len(segments), test.ShouldEqual, 3)
+	test.That(t, segments[2].(map[string]interface{})["width"], test.ShouldEqual, 128)
+
+	frameSize := func(frame []byte) image.Point {
+		conf, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+		test.That(t, err, test.ShouldBeNil)
+		return image.Pt(conf.Width, conf.Height)
+	}
+	// a window across the change ends before it, and the rest is returned by starting after it
+	ch, err := svc.GetVideo(ctx, frameTime(5), frameTime(15), "", "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	b, frames := readVideoFile(t, ch)
+	test.That(t, len(frames), test.ShouldEqual, 7)
+	test.That(t, binary.LittleEndian.Uint32(b[64:]), test.ShouldEqual, 64)
+	for _, f := range frames {
+		test.That(t, frameSize(f), test.ShouldResemble, image.Pt(64, 48))
+	}
+	ch, err = svc.GetVideo(ctx, frameTime(12), frameTime(15), "", "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	b, frames = readVideoFile(t, ch)
+	test.That(t, len(frames), test.ShouldEqual, 4)
+	test.That(t, binary.LittleEndian.Uint32(b[64:]), test.ShouldEqual, 128)
+	test.That(t, binary.LittleEndian.Uint32(b[68:]), test.ShouldEqual, 96)
+	for _, f := range frames {
+		test.That(t, frameSize(f), test.ShouldResemble, image.Pt(128, 96))
+	}
+}
+
+func TestAVISizeLimit(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	cam := newCamera("cam1", utils.MimeTypeJPEG)
+	deps := resource.Dependencies{cam.Name(): cam}
+	conf := &Config{Cameras: []string{"cam1"}, StoragePath: t.TempDir(), SegmentSeconds: 60, FramerateHz: 0.001}
+	svc, err := newBuiltIn(video.Named("video"), deps, conf, logger)
+	test.That(t, err, test.ShouldBeNil)
+	defer func() {
+		test.That(t, svc.Close(ctx), test.ShouldBeNil)
+	}()
+	r := svc.(*builtIn).recorders["cam1"]
+	start := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		test.That(t, r.capture(ctx, start.Add(time.Duration(i)*time.Second)), test.ShouldBeNil)
+	}
+
+	// frames too large to fit in a single AVI file together
+	r.mu.Lock()
+	for i := range r.current.frames {
+		r.current.frames[i].size = 1 << 31
+	}
+	r.mu.Unlock()
+	_, err = svc.GetVideo(ctx, time.Time{}, time.Time{}, "", "", nil)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "4 GiB")
+
+	// segments cannot grow past the limit either
+	s, err := createSegment(t.TempDir(), start, "MJPG", 64, 48)
+	test.That(t, err, test.ShouldBeNil)
+	s.moviSize = maxAVISize - aviHeaderSize - 8 - frameChunkSize(10)
+	err = s.append(start, make([]byte, 10), true)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "4 GiB")
+	test.That(t, len(s.frames), test.ShouldEqual, 0)
+	test.That(t, s.close(), test.ShouldBeNil)
+}
+
+func TestSegmentRecovery(t *testing.T) {
+	logger := logging.NewTestLogger(t)
+	dir := t.TempDir()
+	start := time.Now()
+	s, err := createSegment(dir, start, "MJPG", 64, 48)
+	test.That(t, err, test.ShouldBeNil)
+	for i := 0; i < 3; i++ {
+		test.That(t, s.append(start.Add(time.Duration(i)*time.Second), []byte{1, 2, 3}, true), test.ShouldBeNil)
+	}
+	// a frame partly written before a crash
+	_, err = s.file.Write(frameChunkHeader(100))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, s.close(), test.ShouldBeNil)
+
+	segments, err := loadSegments(dir, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(segments), test.ShouldEqual, 1)
+	test.That(t, len(segments[0].frames), test.ShouldEqual, 3)
+	test.That(t, segments[0].end(), test.ShouldEqual, time.Unix(0, start.Add(2*time.Second).UnixNano()))
+	stat, err := os.Stat(s.path + aviExt)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, stat.Size(), test.ShouldEqual, aviHeaderSize+3*frameChunkSize(3)+8+3*aviIndexEntrySize)
+	b, err := os.ReadFile(s.path + aviExt)
+	test.That(t, err, test.ShouldBeNil)
+	// the header has the number of frames and their duration
+	test.That(t, binary.LittleEndian.Uint32(b[48:]), test.ShouldEqual, 3)
+	test.That(t, binary.LittleEndian.Uint32(b[32:]), test.ShouldEqual, 1000000)
+
+	// segments without frames are removed
+	_, err = createSegment(dir, start.Add(time.Minute), "MJPG", 64, 48)
+	test.That(t, err, test.ShouldBeNil)
+	segments, err = loadSegments(dir, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(segments), test.ShouldEqual, 1)
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(files), test.ShouldEqual, 2)
+}
@@ -0,0 +1,75 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	codecMJPEG = "mjpeg"
+	codecH264  = "h264"
+)
+
+// frameEncoder encodes the frames of a segment.
+type frameEncoder interface {
+	// encode returns the encoded frame, and whether it can be decoded without the frames before it.
+	encode(ctx context.Context, img image.Image) ([]byte, bool, error)
+	close() error
+}
+
+// encoderConstructor returns an encoder of frames of the given size, with a key frame every keyFrameInterval frames.
+type encoderConstructor func(width, height, keyFrameInterval int, logger logging.Logger) (frameEncoder, error)
+
+// videoCodec is a codec recordings can be encoded with, along with its AVI fourcc.
+type videoCodec struct {
+	fourCC      string
+	constructor encoderConstructor
+}
+
+// codecs holds the codecs recordings can be encoded with by name. H.264 is added on builds with cgo.
+var codecs = map[string]videoCodec{
+	codecMJPEG: {fourCC: "MJPG", constructor: func(int, int, int, logging.Logger) (frameEncoder, error) {
+		return jpegEncoder{}, nil
+	}},
+}
+
+func supportedCodecs() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func codecFourCC(codec string) (string, error) {
+	c, ok := codecs[codec]
+	if !ok {
+		return "", errors.Errorf("unsupported codec %q, supported codecs are %v", codec, supportedCodecs())
+	}
+	return c.fourCC, nil
+}
+
+// jpegQuality is the quality of the frames of MJPEG recordings which are not already JPEG images.
+const jpegQuality = 75
+
+type jpegEncoder struct{}
+
+func (jpegEncoder) encode(_ context.Context, img image.Image) ([]byte, bool, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+func (jpegEncoder) close() error {
+	return nil
+}
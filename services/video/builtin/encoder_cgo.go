@@ -0,0 +1,47 @@
+//go:build !no_cgo
+
+package builtin
+
+import (
+	"context"
+	"image"
+
+	"github.com/bluenviron/mediacommon/pkg/codecs/h264"
+
+	"go.viam.com/rdk/gostream/codec"
+	"go.viam.com/rdk/gostream/codec/x264"
+	"go.viam.com/rdk/logging"
+)
+
+func init() {
+	codecs[codecH264] = videoCodec{fourCC: "H264", constructor: newH264Encoder}
+}
+
+// h264Encoder encodes frames to H.264 access units in Annex B format with the x264 encoder.
+type h264Encoder struct {
+	enc codec.VideoEncoder
+}
+
+func newH264Encoder(width, height, keyFrameInterval int, logger logging.Logger) (frameEncoder, error) {
+	enc, err := x264.NewEncoder(width, height, keyFrameInterval, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &h264Encoder{enc: enc}, nil
+}
+
+func (e *h264Encoder) encode(ctx context.Context, img image.Image) ([]byte, bool, error) {
+	b, err := e.enc.Encode(ctx, img)
+	if err != nil {
+		return nil, false, err
+	}
+	au, err := h264.AnnexBUnmarshal(b)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, h264.IDRPresent(au), nil
+}
+
+func (e *h264Encoder) close() error {
+	return e.enc.Close()
+}
@@ -0,0 +1,277 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/utils"
+)
+
+// recorder records a camera into segments of its directory.
+type recorder struct {
+	name            string
+	cam             camera.Camera
+	dir             string
+	codec           string
+	frameInterval   time.Duration
+	segmentDuration time.Duration
+	logger          logging.Logger
+	// onSegmentDone is called after a segment is finished, to enforce the storage limits
+	onSegmentDone func()
+
+	// enc and failing are only used by the recording goroutine
+	enc     frameEncoder
+	failing bool
+
+	mu sync.Mutex
+	// segments are the finished segments, oldest first, and current the one being written
+	segments []*segment
+	current  *segment
+}
+
+// run captures a frame of the camera every frame interval until the context is done.
+func (r *recorder) run(ctx context.Context) {
+	ticker := time.NewTicker(r.frameInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := r.capture(ctx, time.Now())
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil && !r.failing:
+			r.logger.Warnw("cannot record camera, retrying every frame", "camera", r.name, "error", err)
+		case err == nil && r.failing:
+			r.logger.Infow("recording camera again", "camera", r.name)
+		}
+		r.failing = err != nil
+	}
+}
+
+// capture records a frame of the camera taken at the given time, starting a new segment when the current one is long
+// or large enough, or the size of the frames changed.
+func (r *recorder) capture(ctx context.Context, now time.Time) error {
+	b, md, err := r.cam.Image(ctx, utils.MimeTypeJPEG, nil)
+	if err != nil {
+		return err
+	}
+	// JPEG images are recorded as they are in MJPEG recordings
+	var img image.Image
+	var width, height int
+	if r.codec == codecMJPEG && md.MimeType == utils.MimeTypeJPEG {
+		conf, err := jpeg.DecodeConfig(bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		width, height = conf.Width, conf.Height
+	} else {
+		if img, err = rimage.DecodeImage(ctx, b, md.MimeType); err != nil {
+			return err
+		}
+		width, height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	if r.current != nil && (now.Sub(r.current.start()) >= r.segmentDuration ||
+		aviSize(len(r.current.frames), r.current.moviSize) >= maxSegmentSize ||
+		r.current.width != width || r.current.height != height) {
+		if err := r.finishSegment(); err != nil {
+			r.logger.Warnw("cannot finish video segment", "camera", r.name, "error", err)
+		}
+		r.onSegmentDone()
+	}
+
+	if r.enc == nil {
+		// a key frame every second, so that clips start close to the time asked for
+		keyFrameInterval := int(time.Second / r.frameInterval)
+		if keyFrameInterval < 1 {
+			keyFrameInterval = 1
+		}
+		if r.enc, err = codecs[r.codec].constructor(width, height, keyFrameInterval, r.logger); err != nil {
+			return err
+		}
+	}
+	key := true
+	if img != nil {
+		if b, key, err = r.enc.encode(ctx, img); err != nil {
+			return err
+		}
+	}
+
+	if r.current == nil {
+		if !key {
+			// segments start with a key frame
+			return nil
+		}
+		s, err := createSegment(r.dir, now, codecs[r.codec].fourCC, width, height)
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		r.current = s
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current.append(now, b, key)
+}
+
+// finishSegment finishes the current segment and closes the encoder, so that the next segment starts with a key frame.
+func (r *recorder) finishSegment() error {
+	var err error
+	if r.enc != nil {
+		err = r.enc.close()
+		r.enc = nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		return err
+	}
+	err = multierr.Combine(err, r.current.finish())
+	if len(r.current.frames) > 0 {
+		r.segments = append(r.segments, r.current)
+	}
+	r.current = nil
+	return err
+}
+
+// storageSize returns the disk space taken by the segments of the recorder.
+func (r *recorder) storageSize() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var size int64
+	for _, s := range r.segments {
+		size += s.size()
+	}
+	if r.current != nil {
+		size += r.current.size()
+	}
+	return size
+}
+
+// oldest returns the start of the oldest finished segment.
+func (r *recorder) oldest() (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.segments) == 0 {
+		return time.Time{}, false
+	}
+	return r.segments[0].start(), true
+}
+
+// removeOldest removes the oldest finished segment, returning the disk space freed.
+func (r *recorder) removeOldest() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.segments) == 0 {
+		return 0
+	}
+	s := r.segments[0]
+	r.segments = r.segments[1:]
+	if err := s.remove(); err != nil {
+		r.logger.Warnw("cannot remove video segment", "path", s.path, "error", err)
+	}
+	return s.size()
+}
+
+// removeEndedBefore removes the finished segments whose last frame is before the given time.
+func (r *recorder) removeEndedBefore(t time.Time) {
+	for {
+		r.mu.Lock()
+		expired := len(r.segments) > 0 && r.segments[0].end().Before(t)
+		r.mu.Unlock()
+		if !expired {
+			return
+		}
+		r.removeOldest()
+	}
+}
+
+// clipFrame is a frame of a clip, read from the file of its segment.
+type clipFrame struct {
+	frameEntry
+	file *os.File
+}
+
+// clip is the list of frames recorded within a time window.
+type clip struct {
+	width, height int
+	frames        []clipFrame
+	files         []*os.File
+}
+
+func (c *clip) close() error {
+	var err error
+	for _, f := range c.files {
+		err = multierr.Combine(err, f.Close())
+	}
+	return err
+}
+
+func (c *clip) entries() []frameEntry {
+	entries := make([]frameEntry, 0, len(c.frames))
+	for _, f := range c.frames {
+		entries = append(entries, f.frameEntry)
+	}
+	return entries
+}
+
+// clip returns the frames of the given codec recorded between start and end, opening the files of their segments so
+// that they can be read even if the segments are removed in the meantime. The clip starts at the key frame before the
+// start, so that it can be decoded, and ends before the first segment of another frame size than its first frame.
+func (r *recorder) clip(start, end time.Time, fourCC string) (*clip, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	segments := r.segments
+	if r.current != nil {
+		segments = append(segments[:len(segments):len(segments)], r.current)
+	}
+	c := &clip{}
+	for _, s := range segments {
+		if s.fourCC != fourCC || len(s.frames) == 0 || s.start().After(end) || s.end().Before(start) {
+			continue
+		}
+		first, last := -1, -1
+		for i, f := range s.frames {
+			if f.time > end.UnixNano() {
+				break
+			}
+			if f.key && (first < 0 || f.time <= start.UnixNano()) {
+				first = i
+			}
+			last = i
+		}
+		if first < 0 || first > last {
+			continue
+		}
+		if len(c.frames) > 0 && (s.width != c.width || s.height != c.height) {
+			break
+		}
+		file, err := os.Open(s.path + aviExt)
+		if err != nil {
+			return nil, multierr.Combine(err, c.close())
+		}
+		c.files = append(c.files, file)
+		if len(c.frames) == 0 {
+			c.width, c.height = s.width, s.height
+		}
+		for _, f := range s.frames[first : last+1] {
+			c.frames = append(c.frames, clipFrame{frameEntry: f, file: file})
+		}
+	}
+	return c, nil
+}
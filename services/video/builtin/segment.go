@@ -0,0 +1,251 @@
+package builtin
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"go.viam.com/rdk/logging"
+)
+
+const (
+	aviExt   = ".avi"
+	indexExt = ".idx"
+
+	// an index file starts with the fourcc of the codec and the size of the frames, followed by an entry per frame
+	indexHeaderSize = 12
+	indexEntrySize  = 21
+
+	// maxSegmentSize is the size past which segments are finished, half of what an AVI file can hold so that the next
+	// frame always fits
+	maxSegmentSize = maxAVISize / 2
+)
+
+// frameEntry locates a frame in the file of its segment.
+type frameEntry struct {
+	time   int64 // unix nanoseconds
+	offset int64 // of the frame data
+	size   uint32
+	key    bool
+}
+
+// segment is an AVI file of a recording of a camera. AVI frames have no timestamps, so the time of every frame is kept
+// in a sidecar index file, which is written along with the frames so that a segment cut short by a crash can be
+// finished when the service starts again.
+type segment struct {
+	path          string // without extension
+	fourCC        string
+	width, height int
+	frames        []frameEntry
+	moviSize      int
+
+	// file and index are set while the segment is written
+	file  *os.File
+	index *os.File
+}
+
+// createSegment creates the files of a segment starting at the given time.
+func createSegment(dir string, start time.Time, fourCC string, width, height int) (*segment, error) {
+	s := &segment{
+		path:   filepath.Join(dir, strconv.FormatInt(start.UnixNano(), 10)),
+		fourCC: fourCC,
+		width:  width,
+		height: height,
+	}
+	var err error
+	if s.file, err = os.Create(s.path + aviExt); err != nil {
+		return nil, err
+	}
+	if s.index, err = os.Create(s.path + indexExt); err != nil {
+		return nil, multierr.Combine(err, s.file.Close(), os.Remove(s.path+aviExt))
+	}
+	header := append([]byte(fourCC), make([]byte, 8)...)
+	binary.LittleEndian.PutUint32(header[4:], uint32(width))
+	binary.LittleEndian.PutUint32(header[8:], uint32(height))
+	if _, err := s.index.Write(header); err != nil {
+		return nil, multierr.Combine(err, s.close(), s.remove())
+	}
+	// the headers are written again with the number of frames when the segment is finished
+	if _, err := s.file.Write(aviHeader(fourCC, width, height, 0, s.moviSize, 0)); err != nil {
+		return nil, multierr.Combine(err, s.close(), s.remove())
+	}
+	return s, nil
+}
+
+// append writes a frame at the end of the segment.
+func (s *segment) append(t time.Time, data []byte, key bool) error {
+	if aviSize(len(s.frames)+1, s.moviSize+frameChunkSize(len(data))) > maxAVISize {
+		return errors.Errorf("a frame of %d bytes would make the segment larger than the 4 GiB an AVI file can hold", len(data))
+	}
+	f := frameEntry{time: t.UnixNano(), offset: int64(aviHeaderSize + s.moviSize + 8), size: uint32(len(data)), key: key}
+	chunk := append(frameChunkHeader(len(data)), data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	if _, err := s.file.Write(chunk); err != nil {
+		return err
+	}
+	entry := make([]byte, indexEntrySize)
+	binary.LittleEndian.PutUint64(entry, uint64(f.time))
+	binary.LittleEndian.PutUint64(entry[8:], uint64(f.offset))
+	binary.LittleEndian.PutUint32(entry[16:], f.size)
+	if key {
+		entry[20] = 1
+	}
+	if _, err := s.index.Write(entry); err != nil {
+		return err
+	}
+	s.frames = append(s.frames, f)
+	s.moviSize += frameChunkSize(len(data))
+	return nil
+}
+
+// finish writes the AVI index after the frames and the headers with the number of frames, then closes the segment.
+func (s *segment) finish() error {
+	end := int64(aviHeaderSize + s.moviSize)
+	if err := s.file.Truncate(end); err != nil {
+		return multierr.Combine(err, s.close())
+	}
+	if _, err := s.file.WriteAt(aviIndex(s.frames), end); err != nil {
+		return multierr.Combine(err, s.close())
+	}
+	header := aviHeader(s.fourCC, s.width, s.height, len(s.frames), s.moviSize, s.frameDuration(time.Second))
+	if _, err := s.file.WriteAt(header, 0); err != nil {
+		return multierr.Combine(err, s.close())
+	}
+	return s.close()
+}
+
+func (s *segment) close() error {
+	var err error
+	if s.file != nil {
+		err = multierr.Combine(err, s.file.Close())
+		s.file = nil
+	}
+	if s.index != nil {
+		err = multierr.Combine(err, s.index.Close())
+		s.index = nil
+	}
+	return err
+}
+
+// remove deletes the files of the segment.
+func (s *segment) remove() error {
+	err := os.Remove(s.path + aviExt)
+	if errIdx := os.Remove(s.path + indexExt); !os.IsNotExist(errIdx) {
+		err = multierr.Combine(err, errIdx)
+	}
+	return err
+}
+
+func (s *segment) start() time.Time {
+	return time.Unix(0, s.frames[0].time)
+}
+
+func (s *segment) end() time.Time {
+	return time.Unix(0, s.frames[len(s.frames)-1].time)
+}
+
+// size returns the disk space taken by the segment once finished.
+func (s *segment) size() int64 {
+	return int64(aviHeaderSize+s.moviSize+8+len(s.frames)*aviIndexEntrySize) +
+		int64(indexHeaderSize+len(s.frames)*indexEntrySize)
+}
+
+// frameDuration returns the mean duration of the frames of the segment, or def when it has a single frame.
+func (s *segment) frameDuration(def time.Duration) time.Duration {
+	return meanFrameDuration(s.frames, def)
+}
+
+func meanFrameDuration(frames []frameEntry, def time.Duration) time.Duration {
+	if len(frames) < 2 {
+		return def
+	}
+	return time.Duration(frames[len(frames)-1].time-frames[0].time) / time.Duration(len(frames)-1)
+}
+
+// loadSegments reads the index files of the segments in the directory, oldest first. Segments that were not finished
+// are finished and segments without frames are removed.
+func loadSegments(dir string, logger logging.Logger) ([]*segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var segments []*segment
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), indexExt) {
+			continue
+		}
+		s, err := loadSegment(filepath.Join(dir, strings.TrimSuffix(e.Name(), indexExt)))
+		if err == nil && len(s.frames) == 0 {
+			err = errors.New("no frames")
+		}
+		if err != nil {
+			logger.Warnw("removing unreadable video segment", "path", s.path, "error", err)
+			if err := s.remove(); err != nil && !os.IsNotExist(err) {
+				logger.Warnw("cannot remove video segment", "path", s.path, "error", err)
+			}
+			continue
+		}
+		segments = append(segments, s)
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].frames[0].time < segments[j].frames[0].time })
+	return segments, nil
+}
+
+// loadSegment reads the index of the segment at the path, and finishes the segment if it was not.
+func loadSegment(path string) (*segment, error) {
+	s := &segment{path: path}
+	b, err := os.ReadFile(path + indexExt)
+	if err != nil {
+		return s, err
+	}
+	if len(b) < indexHeaderSize {
+		return s, io.ErrUnexpectedEOF
+	}
+	le := binary.LittleEndian
+	s.fourCC = string(b[:4])
+	s.width = int(le.Uint32(b[4:]))
+	s.height = int(le.Uint32(b[8:]))
+	stat, err := os.Stat(path + aviExt)
+	if err != nil {
+		return s, err
+	}
+	// a frame missing from the AVI file after a crash is dropped, along with a partly written index entry
+	for b = b[indexHeaderSize:]; len(b) >= indexEntrySize; b = b[indexEntrySize:] {
+		f := frameEntry{
+			time:   int64(le.Uint64(b)),
+			offset: int64(le.Uint64(b[8:])),
+			size:   le.Uint32(b[16:]),
+			key:    b[20] == 1,
+		}
+		if f.offset+int64(f.size) > stat.Size() || f.offset != int64(aviHeaderSize+s.moviSize+8) {
+			break
+		}
+		s.frames = append(s.frames, f)
+		s.moviSize += frameChunkSize(int(f.size))
+	}
+
+	finished := int64(aviHeaderSize + s.moviSize + 8 + len(s.frames)*aviIndexEntrySize)
+	if stat.Size() == finished || len(s.frames) == 0 {
+		return s, nil
+	}
+	if err := os.Truncate(path+indexExt, int64(indexHeaderSize+len(s.frames)*indexEntrySize)); err != nil {
+		return s, err
+	}
+	if s.file, err = os.OpenFile(path+aviExt, os.O_RDWR, 0); err != nil {
+		return s, err
+	}
+	return s, s.finish()
+}
@@ -4,5 +4,6 @@ package register
 import (
 	// register video.
 	_ "go.viam.com/rdk/services/video"
+	_ "go.viam.com/rdk/services/video/builtin"
 	_ "go.viam.com/rdk/services/video/fake"
 )
@@ -0,0 +1,132 @@
+// Package fiducial implements a vision service which detects square fiducial markers, like ArUco markers and
+// AprilTags, and estimates their poses when the camera has intrinsics.
+package fiducial
+
+import (
+	"context"
+	"image"
+	"strconv"
+
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/pointcloud"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/spatialmath"
+	viz "go.viam.com/rdk/vision"
+	"go.viam.com/rdk/vision/fiducial"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/segmentation"
+)
+
+var model = resource.DefaultModelFamily.WithModel("fiducial")
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		Constructor: func(
+			ctx context.Context, deps resource.Dependencies, c resource.Config, logger logging.Logger,
+		) (vision.Service, error) {
+			conf, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			return newFiducialDetector(c.ResourceName(), deps, conf, logger)
+		},
+	})
+}
+
+// Config is the config of the fiducial vision service. Family is the name of the marker family, aruco_original by
+// default. Families like the AprilTag ones are read from the JSON file at FamilyPath, see fiducial.Family.
+// MarkerSizeMm is the side length of the black square of the markers, which is needed for their poses.
+type Config struct {
+	Family        string  `json:"family,omitempty"`
+	FamilyPath    string  `json:"family_path,omitempty"`
+	MarkerSizeMm  float64 `json:"marker_size_mm,omitempty"`
+	DefaultCamera string  `json:"camera_name,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.MarkerSizeMm < 0 {
+		return nil, nil, resource.NewConfigValidationError(path,
+			errors.Errorf("marker_size_mm cannot be negative, got %v", cfg.MarkerSizeMm))
+	}
+	if cfg.FamilyPath == "" && cfg.Family != "" && cfg.Family != "aruco_original" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "family_path")
+	}
+	var deps []string
+	if cfg.DefaultCamera != "" {
+		deps = append(deps, cfg.DefaultCamera)
+	}
+	return deps, nil, nil
+}
+
+func newFiducialDetector(
+	name resource.Name,
+	deps resource.Dependencies,
+	conf *Config,
+	logger logging.Logger,
+) (vision.Service, error) {
+	family, err := fiducial.NewFamily(conf.Family, conf.FamilyPath)
+	if err != nil {
+		return nil, err
+	}
+	detector := func(ctx context.Context, img image.Image) ([]objectdetection.Detection, error) {
+		markers, err := fiducial.Detect(img, family)
+		if err != nil {
+			return nil, err
+		}
+		detections := make([]objectdetection.Detection, 0, len(markers))
+		for _, m := range markers {
+			detections = append(detections, objectdetection.NewDetection(img.Bounds(), m.BoundingBox(), score(family, m), label(m)))
+		}
+		return detections, nil
+	}
+	// the poses of markers need their size
+	var segmenter segmentation.Segmenter
+	if conf.MarkerSizeMm > 0 {
+		segmenter = func(ctx context.Context, cam camera.Camera) ([]*viz.Object, error) {
+			return markerObjects(ctx, cam, family, conf.MarkerSizeMm)
+		}
+	}
+	return vision.NewService(name, deps, logger, nil, nil, detector, segmenter, conf.DefaultCamera)
+}
+
+// label is the label of the detections and objects of a marker, its ID.
+func label(m fiducial.Marker) string {
+	return strconv.Itoa(m.ID)
+}
+
+// score is the fraction of the bits of the marker which were read right.
+func score(family *fiducial.Family, m fiducial.Marker) float64 {
+	return 1 - float64(m.CorrectedBits)/float64(family.DataBits*family.DataBits)
+}
+
+// markerObjects returns an object for every marker seen by the camera, made of the corners and center of the marker in
+// the camera frame, with a flat box geometry at the pose of the marker.
+func markerObjects(ctx context.Context, cam camera.Camera, family *fiducial.Family, sizeMm float64) ([]*viz.Object, error) {
+	markers, poses, err := fiducial.DetectPosesFromCamera(ctx, cam, family, sizeMm)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*viz.Object, 0, len(markers))
+	for i, m := range markers {
+		cloud := pointcloud.NewBasicEmpty()
+		corners := fiducial.CornerPoints(sizeMm)
+		for _, c := range append(corners[:], r3.Vector{}) {
+			pt := spatialmath.Compose(poses[i], spatialmath.NewPoseFromPoint(c)).Point()
+			if err := cloud.Set(pt, nil); err != nil {
+				return nil, err
+			}
+		}
+		box, err := spatialmath.NewBox(poses[i], r3.Vector{X: sizeMm, Y: sizeMm, Z: 1}, label(m))
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, &viz.Object{PointCloud: cloud, Geometry: box})
+	}
+	return objects, nil
+}
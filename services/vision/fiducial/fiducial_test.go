@@ -0,0 +1,100 @@
+package fiducial
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/fiducial"
+)
+
+func TestConfig(t *testing.T) {
+	conf := &Config{}
+	deps, _, err := conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldBeEmpty)
+
+	conf = &Config{DefaultCamera: "cam", MarkerSizeMm: 100}
+	deps, _, err = conf.Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam"})
+
+	_, _, err = (&Config{MarkerSizeMm: -1}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{Family: "tag36h11"}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{Family: "tag36h11", FamilyPath: "tag36h11.json"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+}
+
+func TestFiducialDetector(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	aruco := fiducial.ArucoOriginal()
+	img := image.NewGray(image.Rect(0, 0, 320, 240))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+	// 7 cells of 10 pixels, so a 100 mm marker is 300*100/70 mm away from a camera with a focal length of 300 pixels
+	testutils.DrawFiducialMarker(img, aruco.Codes[42], aruco.DataBits, 125, 85, 10)
+	cam := inject.NewCamera("cam")
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, img, utils.MimeTypePNG)
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{IntrinsicParams: &transform.PinholeCameraIntrinsics{
+			Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 159.5, Ppy: 119.5,
+		}}, nil
+	}
+	deps := resource.Dependencies{cam.Name(): cam}
+
+	_, err := newFiducialDetector(vision.Named("tags"), deps, &Config{Family: "tag36h11"}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// without a marker size there are no poses
+	srv, err := newFiducialDetector(vision.Named("tags"), deps, &Config{DefaultCamera: "cam"}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	props, err := srv.GetProperties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.DetectionSupported, test.ShouldBeTrue)
+	test.That(t, props.ObjectPCDsSupported, test.ShouldBeFalse)
+	dets, err := srv.DetectionsFromCamera(ctx, "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(dets), test.ShouldEqual, 1)
+	test.That(t, dets[0].Label(), test.ShouldEqual, "42")
+	test.That(t, dets[0].Score(), test.ShouldEqual, 1)
+	box := dets[0].BoundingBox()
+	test.That(t, box.Min.X, test.ShouldBeBetweenOrEqual, 124, 126)
+	test.That(t, box.Max.Y, test.ShouldBeBetweenOrEqual, 154, 156)
+
+	srv, err = newFiducialDetector(vision.Named("tags"), deps, &Config{DefaultCamera: "cam", MarkerSizeMm: 100}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	objs, err := srv.GetObjectPointClouds(ctx, "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(objs), test.ShouldEqual, 1)
+	test.That(t, objs[0].Size(), test.ShouldEqual, 5)
+	test.That(t, objs[0].Geometry.Label(), test.ShouldEqual, "42")
+	pose := objs[0].Geometry.Pose()
+	test.That(t, pose.Point().Z, test.ShouldAlmostEqual, 300*100/70., 5)
+	test.That(t, pose.Point().X, test.ShouldAlmostEqual, 0, 2)
+	test.That(t, pose.Point().Y, test.ShouldAlmostEqual, 0, 2)
+
+	// poses need intrinsics
+	cam.PropertiesFunc = func(ctx context.Context) (camera.Properties, error) {
+		return camera.Properties{}, nil
+	}
+	_, err = srv.GetObjectPointClouds(ctx, "", nil)
+	test.That(t, err, test.ShouldWrap, transform.ErrNoIntrinsics)
+}
@@ -0,0 +1,310 @@
+// Package motiondetector implements a vision service which detects the regions of the images of a camera which
+// changed from a running average of its previous images.
+package motiondetector
+
+import (
+	"context"
+	"image"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/vision/classification"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/viscapture"
+)
+
+var model = resource.DefaultModelFamily.WithModel("motion_detector")
+
+const (
+	// MotionLabel and NoMotionLabel are the labels of the classifications of images with and without motion, the
+	// former also being the label of the detections of moving regions.
+	MotionLabel   = "motion"
+	NoMotionLabel = "no motion"
+
+	defaultSensitivity  = 0.5
+	defaultLearningRate = 0.05
+	defaultMinAreaPx    = 100
+
+	// minThreshold and maxThreshold are the luma differences from the background above which pixels changed at the
+	// highest and lowest sensitivities.
+	minThreshold = 4.0
+	maxThreshold = 64.0
+	// processingWidth is the width images are downscaled to, by averaging blocks of pixels, before being compared to
+	// the background, which also smooths out noise.
+	processingWidth = 320
+)
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		Constructor: func(
+			ctx context.Context, deps resource.Dependencies, c resource.Config, logger logging.Logger,
+		) (vision.Service, error) {
+			conf, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			return newMotionDetector(c.ResourceName(), deps, conf, logger)
+		},
+	})
+}
+
+// Config is the config of the motion detector. Pixels changed when their luma differs from that of the background by
+// more than a threshold which decreases as Sensitivity, from 0 to 1, increases, defaulting to 0.5 when unset. The
+// background is the running average of the images, each image weighing LearningRate, defaulting to 0.05. Changed
+// regions smaller than MinAreaPx pixels, defaulting to 100, and changes within the masks are ignored. When
+// CaptureMotionOnly is set, images without motion are not captured by data management.
+type Config struct {
+	DefaultCamera     string   `json:"camera_name"`
+	Sensitivity       *float64 `json:"sensitivity,omitempty"`
+	LearningRate      float64  `json:"learning_rate,omitempty"`
+	MinAreaPx         int      `json:"min_area_px,omitempty"`
+	Masks             []Mask   `json:"masks,omitempty"`
+	CaptureMotionOnly bool     `json:"capture_motion_only,omitempty"`
+}
+
+// Mask is a rectangle of the image, in pixels, in which changes are ignored.
+type Mask struct {
+	XMin int `json:"x_min"`
+	YMin int `json:"y_min"`
+	XMax int `json:"x_max"`
+	YMax int `json:"y_max"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.DefaultCamera == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "camera_name")
+	}
+	if cfg.Sensitivity != nil && (*cfg.Sensitivity < 0 || *cfg.Sensitivity > 1) {
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("sensitivity must be between 0 and 1"))
+	}
+	if cfg.LearningRate < 0 || cfg.LearningRate > 1 {
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("learning_rate must be between 0 and 1"))
+	}
+	if cfg.MinAreaPx < 0 {
+		return nil, nil, resource.NewConfigValidationError(path, errors.New("min_area_px cannot be negative"))
+	}
+	for i, m := range cfg.Masks {
+		if m.XMin >= m.XMax || m.YMin >= m.YMax {
+			return nil, nil, resource.NewConfigValidationError(path, errors.Errorf("mask %d is empty", i))
+		}
+	}
+	return []string{cfg.DefaultCamera}, nil, nil
+}
+
+// threshold returns the luma difference from the background above which pixels changed.
+func (cfg *Config) threshold() float64 {
+	sensitivity := defaultSensitivity
+	if cfg.Sensitivity != nil {
+		sensitivity = *cfg.Sensitivity
+	}
+	return maxThreshold - sensitivity*(maxThreshold-minThreshold)
+}
+
+// motionDetector is a vision service detecting the regions of images which changed from the background.
+type motionDetector struct {
+	vision.Service
+	conf         *Config
+	threshold    float64
+	learningRate float64
+	minAreaPx    int
+
+	mu sync.Mutex
+	// background is the running average of the downscaled luma of the images, of size width by height
+	background    []float64
+	width, height int
+	// lastImage is the last image detected on and detections the regions which changed in it, kept for the
+	// detections and classifications of the same image not to update the background twice
+	lastImage  image.Image
+	detections []objectdetection.Detection
+}
+
+func newMotionDetector(
+	name resource.Name,
+	deps resource.Dependencies,
+	conf *Config,
+	logger logging.Logger,
+) (vision.Service, error) {
+	md := &motionDetector{
+		conf:         conf,
+		threshold:    conf.threshold(),
+		learningRate: conf.LearningRate,
+		minAreaPx:    conf.MinAreaPx,
+	}
+	if md.learningRate == 0 {
+		md.learningRate = defaultLearningRate
+	}
+	if md.minAreaPx == 0 {
+		md.minAreaPx = defaultMinAreaPx
+	}
+	// every image detected on is compared to the background of the previous ones, so the service should only see a
+	// single stream
+	srv, err := vision.NewService(name, deps, logger, nil, md.classify, md.detect, nil, conf.DefaultCamera)
+	if err != nil {
+		return nil, err
+	}
+	md.Service = srv
+	return md, nil
+}
+
+func (md *motionDetector) detect(ctx context.Context, img image.Image) ([]objectdetection.Detection, error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return md.update(img), nil
+}
+
+func (md *motionDetector) classify(ctx context.Context, img image.Image) (classification.Classifications, error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if len(md.update(img)) == 0 {
+		return classification.Classifications{classification.NewClassification(1, NoMotionLabel)}, nil
+	}
+	return classification.Classifications{classification.NewClassification(1, MotionLabel)}, nil
+}
+
+// update returns the regions of the image which changed from the background, and blends the image into the background
+// unless it is the last image. The first image, or the first of a new size, only initializes the background.
+func (md *motionDetector) update(img image.Image) []objectdetection.Detection {
+	if sameImage(img, md.lastImage) {
+		return md.detections
+	}
+	bounds := img.Bounds()
+	scale := max(1, int(math.Ceil(float64(bounds.Dx())/processingWidth)))
+	luma := downscale(rimage.LumaImage(img), scale)
+	md.lastImage = img
+	md.detections = []objectdetection.Detection{}
+	if md.background == nil || luma.Rect.Dx() != md.width || luma.Rect.Dy() != md.height {
+		md.width, md.height = luma.Rect.Dx(), luma.Rect.Dy()
+		md.background = make([]float64, len(luma.Pix))
+		for i, l := range luma.Pix {
+			md.background[i] = float64(l)
+		}
+		return md.detections
+	}
+
+	changed := image.NewGray(luma.Rect)
+	for y := 0; y < md.height; y++ {
+		for x := 0; x < md.width; x++ {
+			i := y*md.width + x
+			l := float64(luma.Pix[i])
+			if math.Abs(l-md.background[i]) > md.threshold && !md.masked(x*scale+scale/2, y*scale+scale/2) {
+				changed.Pix[i] = 255
+			}
+			md.background[i] += md.learningRate * (l - md.background[i])
+		}
+	}
+
+	isChanged := func(_ image.Image, pt image.Point) bool {
+		return changed.Pix[changed.PixOffset(pt.X, pt.Y)] != 0
+	}
+	clusters, rectangles := objectdetection.ConnectedComponents(changed, isChanged)
+	imageRect := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	for i, cluster := range clusters {
+		if len(cluster)*scale*scale < md.minAreaPx {
+			continue
+		}
+		// the rectangles of connected components include their maximum points
+		r := rectangles[i]
+		box := image.Rect(r.Min.X*scale, r.Min.Y*scale, (r.Max.X+1)*scale, (r.Max.Y+1)*scale).Intersect(imageRect)
+		md.detections = append(md.detections, objectdetection.NewDetection(bounds, box.Add(bounds.Min), 1, MotionLabel))
+	}
+	return md.detections
+}
+
+// masked returns whether the point, relative to the origin of the image, is in a mask.
+func (md *motionDetector) masked(x, y int) bool {
+	for _, m := range md.conf.Masks {
+		if x >= m.XMin && x < m.XMax && y >= m.YMin && y < m.YMax {
+			return true
+		}
+	}
+	return false
+}
+
+// reset forgets the background, the next image initializing it again.
+func (md *motionDetector) reset() {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.background = nil
+	md.lastImage = nil
+	md.detections = nil
+}
+
+// moving returns whether the last image detected on had motion.
+func (md *motionDetector) moving() bool {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	return len(md.detections) > 0
+}
+
+// CaptureAllFromCamera returns the next image from the camera with its motion. When CaptureMotionOnly is set,
+// captures by data management of images without motion return data.ErrNoCaptureToStore.
+func (md *motionDetector) CaptureAllFromCamera(
+	ctx context.Context,
+	cameraName string,
+	opt viscapture.CaptureOptions,
+	extra map[string]interface{},
+) (viscapture.VisCapture, error) {
+	if fromDM, ok := extra[data.FromDMString].(bool); !ok || !fromDM || !md.conf.CaptureMotionOnly {
+		return md.Service.CaptureAllFromCamera(ctx, cameraName, opt, extra)
+	}
+	// the motion of the image is needed to filter it out
+	opt.ReturnDetections = true
+	capture, err := md.Service.CaptureAllFromCamera(ctx, cameraName, opt, extra)
+	if err != nil {
+		return capture, err
+	}
+	if !md.moving() {
+		return viscapture.VisCapture{}, data.ErrNoCaptureToStore
+	}
+	return capture, nil
+}
+
+// DoCommand supports the following commands
+//   - reset forgets the background, the next image initializing it again
+//     required key: reset
+func (md *motionDetector) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := cmd["reset"]; ok {
+		md.reset()
+		return map[string]interface{}{"reset": true}, nil
+	}
+	return nil, errors.Errorf("unknown command, expected reset, got %v", cmd)
+}
+
+// downscale returns the gray image, whose origin is at zero, averaged over blocks of scale by scale pixels.
+func downscale(img *image.Gray, scale int) *image.Gray {
+	if scale == 1 {
+		return img
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewGray(image.Rect(0, 0, (width+scale-1)/scale, (height+scale-1)/scale))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			var sum, n int
+			for by := y * scale; by < min(height, (y+1)*scale); by++ {
+				for bx := x * scale; bx < min(width, (x+1)*scale); bx++ {
+					sum += int(img.Pix[by*img.Stride+bx])
+					n++
+				}
+			}
+			out.Pix[y*out.Stride+x] = uint8(sum / n)
+		}
+	}
+	return out
+}
+
+// sameImage returns whether both images are the same image, rather than equal ones.
+func sameImage(a, b image.Image) bool {
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,156 @@
+package motiondetector
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/data"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/viscapture"
+)
+
+func TestConfig(t *testing.T) {
+	_, _, err := (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	deps, _, err := (&Config{DefaultCamera: "cam"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"cam"})
+
+	sensitivity := 1.5
+	_, _, err = (&Config{DefaultCamera: "cam", Sensitivity: &sensitivity}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{DefaultCamera: "cam", LearningRate: -0.1}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{DefaultCamera: "cam", Masks: []Mask{{XMin: 10, XMax: 10, YMax: 10}}}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	test.That(t, (&Config{}).threshold(), test.ShouldEqual, 34)
+	sensitivity = 1
+	test.That(t, (&Config{Sensitivity: &sensitivity}).threshold(), test.ShouldEqual, minThreshold)
+	// a zero sensitivity is the least sensitive rather than the default
+	sensitivity = 0
+	_, _, err = (&Config{DefaultCamera: "cam", Sensitivity: &sensitivity}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, (&Config{Sensitivity: &sensitivity}).threshold(), test.ShouldEqual, maxThreshold)
+}
+
+// scene returns a gray image with bright squares at the given points.
+func scene(width, height int, squares ...image.Point) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 50
+	}
+	for _, pt := range squares {
+		for y := pt.Y; y < pt.Y+20; y++ {
+			for x := pt.X; x < pt.X+20; x++ {
+				img.SetGray(x, y, color.Gray{200})
+			}
+		}
+	}
+	return img
+}
+
+func TestMotionDetector(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+	deps := resource.Dependencies{}
+	srv, err := newMotionDetector(vision.Named("motion"), deps, &Config{
+		DefaultCamera: "cam",
+		Masks:         []Mask{{XMin: 0, YMin: 0, XMax: 40, YMax: 40}},
+	}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	props, err := srv.GetProperties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.DetectionSupported, test.ShouldBeTrue)
+	test.That(t, props.ClassificationSupported, test.ShouldBeTrue)
+
+	// the first image is the background
+	dets, err := srv.Detections(ctx, scene(200, 100), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+
+	// a square appears, and another in the mask
+	img := scene(200, 100, image.Point{100, 50}, image.Point{10, 10})
+	dets, err = srv.Detections(ctx, img, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(dets), test.ShouldEqual, 1)
+	test.That(t, *dets[0].BoundingBox(), test.ShouldResemble, image.Rect(100, 50, 120, 70))
+	test.That(t, dets[0].Label(), test.ShouldEqual, MotionLabel)
+	// the same image is not blended into the background again
+	classifications, err := srv.Classifications(ctx, img, 1, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, classifications[0].Label(), test.ShouldEqual, MotionLabel)
+
+	// a speck is smaller than the minimum area
+	dets, err = srv.Detections(ctx, scene(200, 100), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+	speck := scene(200, 100)
+	speck.SetGray(150, 20, color.Gray{255})
+	classifications, err = srv.Classifications(ctx, speck, 1, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, classifications[0].Label(), test.ShouldEqual, NoMotionLabel)
+
+	resp, err := srv.DoCommand(ctx, map[string]interface{}{"reset": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["reset"], test.ShouldBeTrue)
+	dets, err = srv.Detections(ctx, scene(200, 100, image.Point{100, 50}), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+	_, err = srv.DoCommand(ctx, map[string]interface{}{"foo": true})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestMotionDetectorDownscaled(t *testing.T) {
+	ctx := context.Background()
+	srv, err := newMotionDetector(vision.Named("motion"), resource.Dependencies{}, &Config{DefaultCamera: "cam"},
+		logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = srv.Detections(ctx, scene(1280, 720), nil)
+	test.That(t, err, test.ShouldBeNil)
+	dets, err := srv.Detections(ctx, scene(1280, 720, image.Point{600, 300}), nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(dets), test.ShouldEqual, 1)
+	test.That(t, *dets[0].BoundingBox(), test.ShouldResemble, image.Rect(600, 300, 620, 320))
+}
+
+func TestCaptureMotionOnly(t *testing.T) {
+	ctx := context.Background()
+	frames := []*image.Gray{scene(100, 100), scene(100, 100), scene(100, 100, image.Point{40, 40})}
+	frame := 0
+	cam := inject.NewCamera("cam")
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, frames[frame%len(frames)], utils.MimeTypePNG)
+		frame++
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+	deps := resource.Dependencies{cam.Name(): cam}
+	srv, err := newMotionDetector(vision.Named("motion"), deps, &Config{DefaultCamera: "cam", CaptureMotionOnly: true},
+		logging.NewTestLogger(t))
+	test.That(t, err, test.ShouldBeNil)
+
+	opt := viscapture.CaptureOptions{ReturnImage: true, ReturnClassifications: true}
+	_, err = srv.CaptureAllFromCamera(ctx, "", opt, data.FromDMExtraMap)
+	test.That(t, err, test.ShouldEqual, data.ErrNoCaptureToStore)
+	// images without motion are returned outside of data management
+	capture, err := srv.CaptureAllFromCamera(ctx, "", opt, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, capture.Classifications[0].Label(), test.ShouldEqual, NoMotionLabel)
+	capture, err = srv.CaptureAllFromCamera(ctx, "", opt, data.FromDMExtraMap)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, capture.Image, test.ShouldNotBeNil)
+	test.That(t, capture.Classifications[0].Label(), test.ShouldEqual, MotionLabel)
+	test.That(t, len(capture.Detections), test.ShouldEqual, 1)
+}
@@ -0,0 +1,237 @@
+// Package objecttracker implements a vision service which tracks the detections of another vision service across
+// frames, giving them persistent IDs, and counts the objects crossing lines of the image.
+package objecttracker
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/vision/objectdetection"
+	"go.viam.com/rdk/vision/objecttracking"
+)
+
+var model = resource.DefaultModelFamily.WithModel("object_tracker")
+
+const (
+	defaultMaxAgeFrames  = 5
+	defaultMinHits       = 3
+	defaultIoUThreshold  = 0.3
+	defaultHistoryLength = 30
+)
+
+func init() {
+	resource.RegisterService(vision.API, model, resource.Registration[vision.Service, *Config]{
+		Constructor: func(
+			ctx context.Context, deps resource.Dependencies, c resource.Config, logger logging.Logger,
+		) (vision.Service, error) {
+			conf, err := resource.NativeConfig[*Config](c)
+			if err != nil {
+				return nil, err
+			}
+			return newObjectTracker(c.ResourceName(), deps, conf, logger)
+		},
+	})
+}
+
+// Config is the config of the object tracker. DetectorName is the vision service whose detections are tracked.
+// A track is kept for MaxAgeFrames frames without a matching detection, defaulting to 5, and is only reported after
+// MinHits matching detections, defaulting to 3. Detections are matched to tracks when their intersection over union
+// is at least IoUThreshold, defaulting to 0.3. The last HistoryLength centers of every track are kept, defaulting
+// to 30.
+type Config struct {
+	DetectorName  string      `json:"detector_name"`
+	MaxAgeFrames  int         `json:"max_age_frames,omitempty"`
+	MinHits       int         `json:"min_hits,omitempty"`
+	IoUThreshold  float64     `json:"iou_threshold,omitempty"`
+	HistoryLength int         `json:"history_length,omitempty"`
+	CountLines    []CountLine `json:"count_lines,omitempty"`
+	DefaultCamera string      `json:"camera_name,omitempty"`
+}
+
+// CountLine is a line of the image, in pixels, the crossings of which are counted. Crossings from the right of the
+// line to its left, when looking from start to end, are positive.
+type CountLine struct {
+	Name  string      `json:"name"`
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
+}
+
+// Validate ensures all parts of the config are valid.
+func (cfg *Config) Validate(path string) ([]string, []string, error) {
+	if cfg.DetectorName == "" {
+		return nil, nil, resource.NewConfigValidationFieldRequiredError(path, "detector_name")
+	}
+	tc := cfg.trackerConfig()
+	if err := tc.CheckValid(); err != nil {
+		return nil, nil, resource.NewConfigValidationError(path, err)
+	}
+	deps := []string{cfg.DetectorName}
+	if cfg.DefaultCamera != "" {
+		deps = append(deps, cfg.DefaultCamera)
+	}
+	return deps, nil, nil
+}
+
+func (cfg *Config) trackerConfig() objecttracking.Config {
+	tc := objecttracking.Config{
+		MaxAge: cfg.MaxAgeFrames, MinHits: cfg.MinHits, IoUThreshold: cfg.IoUThreshold, HistoryLength: cfg.HistoryLength,
+	}
+	if tc.MaxAge == 0 {
+		tc.MaxAge = defaultMaxAgeFrames
+	}
+	if tc.MinHits == 0 {
+		tc.MinHits = defaultMinHits
+	}
+	if tc.IoUThreshold == 0 {
+		tc.IoUThreshold = defaultIoUThreshold
+	}
+	if tc.HistoryLength == 0 {
+		tc.HistoryLength = defaultHistoryLength
+	}
+	for _, l := range cfg.CountLines {
+		tc.Lines = append(tc.Lines, objecttracking.Line{
+			Name:  l.Name,
+			Start: r2.Point{X: float64(l.Start.X), Y: float64(l.Start.Y)},
+			End:   r2.Point{X: float64(l.End.X), Y: float64(l.End.Y)},
+		})
+	}
+	return tc
+}
+
+// objectTracker is a vision service whose detections are the tracks of the detections of another vision service.
+type objectTracker struct {
+	vision.Service
+	tracker *objecttracking.Tracker
+}
+
+func newObjectTracker(
+	name resource.Name,
+	deps resource.Dependencies,
+	conf *Config,
+	logger logging.Logger,
+) (vision.Service, error) {
+	detector, err := vision.FromProvider(deps, conf.DetectorName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find detector %q", conf.DetectorName)
+	}
+	tracker, err := objecttracking.NewTracker(conf.trackerConfig())
+	if err != nil {
+		return nil, err
+	}
+	// every image detected on is the next frame of the tracker, so the service should only see a single stream
+	detect := func(ctx context.Context, img image.Image) ([]objectdetection.Detection, error) {
+		dets, err := detector.Detections(ctx, img, nil)
+		if err != nil {
+			return nil, err
+		}
+		tracks := tracker.Update(dets, time.Now())
+		out := make([]objectdetection.Detection, 0, len(tracks))
+		for _, t := range tracks {
+			out = append(out, objectdetection.NewDetection(img.Bounds(), t.Box.Intersect(img.Bounds()), t.Score, trackLabel(t)))
+		}
+		return out, nil
+	}
+	srv, err := vision.NewService(name, deps, logger, nil, nil, detect, nil, conf.DefaultCamera)
+	if err != nil {
+		return nil, err
+	}
+	return &objectTracker{Service: srv, tracker: tracker}, nil
+}
+
+// trackLabel is the label of the detection of a track, the label of the object followed by the ID of the track.
+func trackLabel(t objecttracking.Track) string {
+	return fmt.Sprintf("%s_%d", t.Label, t.ID)
+}
+
+// DoCommand supports the following commands, which can be combined in a single call
+//   - tracks returns the confirmed tracks with their histories
+//     required key: tracks
+//     output value: a list of tracks, with their id, label, box, score, hits, age, frames_since_update,
+//     first_seen, last_seen and history
+//   - events returns the entered, exited and crossed events of the tracks since the last call, oldest first
+//     required key: events
+//     output value: a list of events, with their type, track_id, label and time, and the line and direction of
+//     crossings
+//   - counts returns the number of crossings of every counting line
+//     required key: counts
+//     output value: a map from the name of every line to its positive and negative counts
+//   - reset forgets all the tracks, events and counts
+//     required key: reset
+func (ot *objectTracker) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	resp := make(map[string]interface{})
+	if _, ok := cmd["tracks"]; ok {
+		tracks := ot.tracker.Tracks()
+		out := make([]interface{}, 0, len(tracks))
+		for _, t := range tracks {
+			out = append(out, trackToMap(t))
+		}
+		resp["tracks"] = out
+	}
+	if _, ok := cmd["events"]; ok {
+		events := ot.tracker.Events()
+		out := make([]interface{}, 0, len(events))
+		for _, e := range events {
+			out = append(out, eventToMap(e))
+		}
+		resp["events"] = out
+	}
+	if _, ok := cmd["counts"]; ok {
+		out := make(map[string]interface{})
+		for name, c := range ot.tracker.Counts() {
+			out[name] = map[string]interface{}{"positive": c.Positive, "negative": c.Negative}
+		}
+		resp["counts"] = out
+	}
+	if _, ok := cmd["reset"]; ok {
+		ot.tracker.Reset()
+		resp["reset"] = true
+	}
+	if len(resp) == 0 {
+		return nil, errors.Errorf("unknown command, expected one of tracks, events, counts or reset, got %v", cmd)
+	}
+	return resp, nil
+}
+
+func trackToMap(t objecttracking.Track) map[string]interface{} {
+	history := make([]interface{}, 0, len(t.History))
+	for _, p := range t.History {
+		history = append(history, []interface{}{p.X, p.Y})
+	}
+	return map[string]interface{}{
+		"id":                  t.ID,
+		"label":               t.Label,
+		"box":                 []interface{}{t.Box.Min.X, t.Box.Min.Y, t.Box.Max.X, t.Box.Max.Y},
+		"score":               t.Score,
+		"hits":                t.Hits,
+		"age":                 t.Age,
+		"frames_since_update": t.FramesSinceUpdate,
+		"first_seen":          t.FirstSeen.Format(time.RFC3339Nano),
+		"last_seen":           t.LastSeen.Format(time.RFC3339Nano),
+		"history":             history,
+	}
+}
+
+func eventToMap(e objecttracking.Event) map[string]interface{} {
+	out := map[string]interface{}{
+		"type":     string(e.Type),
+		"track_id": e.TrackID,
+		"label":    e.Label,
+		"time":     e.Time.Format(time.RFC3339Nano),
+	}
+	if e.Type == objecttracking.Crossed {
+		out["line"] = e.Line
+		out["direction"] = "negative"
+		if e.Positive {
+			out["direction"] = "positive"
+		}
+	}
+	return out
+}
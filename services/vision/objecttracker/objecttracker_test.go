@@ -0,0 +1,126 @@
+package objecttracker
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"go.viam.com/test"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+	"go.viam.com/rdk/rimage"
+	"go.viam.com/rdk/services/vision"
+	"go.viam.com/rdk/testutils/inject"
+	"go.viam.com/rdk/utils"
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+func TestConfig(t *testing.T) {
+	_, _, err := (&Config{}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	deps, _, err := (&Config{DetectorName: "det", DefaultCamera: "cam"}).Validate("path")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, deps, test.ShouldResemble, []string{"det", "cam"})
+
+	_, _, err = (&Config{DetectorName: "det", IoUThreshold: 1.5}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+	_, _, err = (&Config{DetectorName: "det", CountLines: []CountLine{{Name: "line"}}}).Validate("path")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	tc := (&Config{DetectorName: "det", MinHits: 1}).trackerConfig()
+	test.That(t, tc.MinHits, test.ShouldEqual, 1)
+	test.That(t, tc.MaxAge, test.ShouldEqual, defaultMaxAgeFrames)
+	test.That(t, tc.IoUThreshold, test.ShouldEqual, defaultIoUThreshold)
+}
+
+func TestObjectTracker(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewTestLogger(t)
+
+	// a box moving down a conveyor, across a line near the top of the image
+	frame := 0
+	det := inject.NewVisionService("det")
+	det.DetectionsFunc = func(ctx context.Context, img image.Image, extra map[string]interface{}) ([]objectdetection.Detection, error) {
+		box := image.Rect(100, 40+10*frame, 140, 70+10*frame)
+		frame++
+		return []objectdetection.Detection{objectdetection.NewDetection(img.Bounds(), box, 0.8, "part")}, nil
+	}
+	img := image.NewGray(image.Rect(0, 0, 320, 240))
+	cam := inject.NewCamera("cam")
+	cam.ImageFunc = func(ctx context.Context, mimeType string, extra map[string]interface{}) ([]byte, camera.ImageMetadata, error) {
+		b, err := rimage.EncodeImage(ctx, img, utils.MimeTypePNG)
+		return b, camera.ImageMetadata{MimeType: utils.MimeTypePNG}, err
+	}
+	deps := resource.Dependencies{det.Name(): det, cam.Name(): cam}
+
+	_, err := newObjectTracker(vision.Named("tracker"), deps, &Config{DetectorName: "missing"}, logger)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	srv, err := newObjectTracker(vision.Named("tracker"), deps, &Config{
+		DetectorName:  "det",
+		MinHits:       2,
+		DefaultCamera: "cam",
+		CountLines:    []CountLine{{Name: "middle", Start: image.Point{0, 80}, End: image.Point{320, 80}}},
+	}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	props, err := srv.GetProperties(ctx, nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, props.DetectionSupported, test.ShouldBeTrue)
+
+	dets, err := srv.DetectionsFromCamera(ctx, "", nil)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, dets, test.ShouldBeEmpty)
+	for i := 0; i < 5; i++ {
+		dets, err = srv.Detections(ctx, img, nil)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(dets), test.ShouldEqual, 1)
+		test.That(t, dets[0].Label(), test.ShouldEqual, "part_1")
+		test.That(t, dets[0].Score(), test.ShouldEqual, 0.8)
+	}
+	test.That(t, dets[0].BoundingBox().Min.Y, test.ShouldAlmostEqual, 90, 3)
+
+	resp, err := srv.DoCommand(ctx, map[string]interface{}{"tracks": true, "events": true, "counts": true})
+	test.That(t, err, test.ShouldBeNil)
+	// the response can be sent over the wire
+	_, err = structpb.NewStruct(resp)
+	test.That(t, err, test.ShouldBeNil)
+	tracks := resp["tracks"].([]interface{})
+	test.That(t, len(tracks), test.ShouldEqual, 1)
+	track := tracks[0].(map[string]interface{})
+	test.That(t, track["id"], test.ShouldEqual, 1)
+	test.That(t, track["label"], test.ShouldEqual, "part")
+	test.That(t, track["hits"], test.ShouldEqual, 6)
+	test.That(t, len(track["history"].([]interface{})), test.ShouldEqual, 6)
+	events := resp["events"].([]interface{})
+	test.That(t, len(events), test.ShouldEqual, 2)
+	test.That(t, events[0].(map[string]interface{})["type"], test.ShouldEqual, "entered")
+	crossed := events[1].(map[string]interface{})
+	test.That(t, crossed["type"], test.ShouldEqual, "crossed")
+	test.That(t, crossed["line"], test.ShouldEqual, "middle")
+	test.That(t, crossed["direction"], test.ShouldEqual, "positive")
+	test.That(t, resp["counts"], test.ShouldResemble, map[string]interface{}{
+		"middle": map[string]interface{}{"positive": 1, "negative": 0},
+	})
+
+	// events are only returned once
+	resp, err = srv.DoCommand(ctx, map[string]interface{}{"events": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["events"], test.ShouldBeEmpty)
+
+	resp, err = srv.DoCommand(ctx, map[string]interface{}{"reset": true, "tracks": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["reset"], test.ShouldBeTrue)
+	resp, err = srv.DoCommand(ctx, map[string]interface{}{"tracks": true, "counts": true})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, resp["tracks"], test.ShouldBeEmpty)
+	test.That(t, resp["counts"], test.ShouldResemble, map[string]interface{}{
+		"middle": map[string]interface{}{"positive": 0, "negative": 0},
+	})
+
+	_, err = srv.DoCommand(ctx, map[string]interface{}{"unknown": true})
+	test.That(t, err, test.ShouldNotBeNil)
+}
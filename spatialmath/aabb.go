@@ -0,0 +1,107 @@
+package spatialmath
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+)
+
+// AABB is an axis-aligned bounding box described by its minimum and maximum corners.
+type AABB struct {
+	Min r3.Vector
+	Max r3.Vector
+}
+
+// newEmptyAABB returns an AABB which contains nothing and can be grown by calls to expand.
+func newEmptyAABB() AABB {
+	return AABB{
+		Min: r3.Vector{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)},
+		Max: r3.Vector{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)},
+	}
+}
+
+// expand grows the AABB so that it contains the given point.
+func (a *AABB) expand(pt r3.Vector) {
+	a.Min = r3.Vector{X: math.Min(a.Min.X, pt.X), Y: math.Min(a.Min.Y, pt.Y), Z: math.Min(a.Min.Z, pt.Z)}
+	a.Max = r3.Vector{X: math.Max(a.Max.X, pt.X), Y: math.Max(a.Max.Y, pt.Y), Z: math.Max(a.Max.Z, pt.Z)}
+}
+
+// union returns the smallest AABB containing both a and b.
+func (a AABB) union(b AABB) AABB {
+	a.expand(b.Min)
+	a.expand(b.Max)
+	return a
+}
+
+// Center returns the center point of the AABB.
+func (a AABB) Center() r3.Vector {
+	return a.Min.Add(a.Max).Mul(0.5)
+}
+
+// halfSize returns the half extents of the AABB along each axis.
+func (a AABB) halfSize() r3.Vector {
+	return a.Max.Sub(a.Min).Mul(0.5)
+}
+
+// Intersects returns true if the two AABBs are within the given buffer of one another.
+func (a AABB) Intersects(b AABB, buffer float64) bool {
+	return a.Min.X-buffer <= b.Max.X && b.Min.X-buffer <= a.Max.X &&
+		a.Min.Y-buffer <= b.Max.Y && b.Min.Y-buffer <= a.Max.Y &&
+		a.Min.Z-buffer <= b.Max.Z && b.Min.Z-buffer <= a.Max.Z
+}
+
+// Distance returns the separation distance between two AABBs, or 0 if they overlap.
+// This is a lower bound on the distance between any two geometries which they bound.
+func (a AABB) Distance(b AABB) float64 {
+	gap := func(aMin, aMax, bMin, bMax float64) float64 {
+		return math.Max(0, math.Max(bMin-aMax, aMin-bMax))
+	}
+	return r3.Vector{
+		X: gap(a.Min.X, a.Max.X, b.Min.X, b.Max.X),
+		Y: gap(a.Min.Y, a.Max.Y, b.Min.Y, b.Max.Y),
+		Z: gap(a.Min.Z, a.Max.Z, b.Min.Z, b.Max.Z),
+	}.Norm()
+}
+
+// distanceToPoint returns the distance from the AABB to the given point, or 0 if the point is inside it.
+func (a AABB) distanceToPoint(pt r3.Vector) float64 {
+	return a.Distance(AABB{Min: pt, Max: pt})
+}
+
+// Transform returns an AABB in the parent frame which conservatively bounds this AABB after it has been moved by the given pose.
+func (a AABB) Transform(pose Pose) AABB {
+	return newRigidTransform(pose).aabb(a)
+}
+
+// GeometryAABB returns an axis-aligned box in the geometry's parent frame which fully contains the geometry.
+// The second return value is false if the geometry type is not one for which a bounding box can be computed.
+func GeometryAABB(g Geometry) (AABB, bool) {
+	switch geom := g.(type) {
+	case *box:
+		local := AABB{
+			Min: r3.Vector{X: -geom.halfSize[0], Y: -geom.halfSize[1], Z: -geom.halfSize[2]},
+			Max: r3.Vector{X: geom.halfSize[0], Y: geom.halfSize[1], Z: geom.halfSize[2]},
+		}
+		return local.Transform(geom.center), true
+	case *sphere:
+		r := r3.Vector{X: geom.radius, Y: geom.radius, Z: geom.radius}
+		c := geom.pose.Point()
+		return AABB{Min: c.Sub(r), Max: c.Add(r)}, true
+	case *capsule:
+		r := r3.Vector{X: geom.radius, Y: geom.radius, Z: geom.radius}
+		bounds := newEmptyAABB()
+		bounds.expand(geom.segA)
+		bounds.expand(geom.segB)
+		return AABB{Min: bounds.Min.Sub(r), Max: bounds.Max.Add(r)}, true
+	case *point:
+		return AABB{Min: geom.position, Max: geom.position}, true
+	case *Mesh:
+		root := geom.bvhRoot()
+		if root == nil {
+			return AABB{Min: geom.pose.Point(), Max: geom.pose.Point()}, true
+		}
+		return root.bounds.Transform(geom.pose), true
+	default:
+		return AABB{}, false
+	}
+}
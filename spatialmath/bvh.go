@@ -0,0 +1,262 @@
+package spatialmath
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/golang/geo/r3"
+)
+
+// bvhLeafSize is the maximum number of triangles stored in a single leaf of a bounding volume hierarchy.
+const bvhLeafSize = 4
+
+// bvhNode is a node in a bounding volume hierarchy over a set of triangles. Interior nodes have exactly two children and no
+// triangles, leaf nodes have no children and at most bvhLeafSize triangles.
+type bvhNode struct {
+	bounds      AABB
+	left, right *bvhNode
+	triangles   []*Triangle
+}
+
+func (n *bvhNode) isLeaf() bool {
+	return n.left == nil
+}
+
+// meshBVH lazily builds and caches the bounding volume hierarchy of a mesh. Since triangles are stored in the frame of the mesh,
+// the hierarchy is unaffected by Transform and may be shared between all copies of a mesh.
+type meshBVH struct {
+	once sync.Once
+	root *bvhNode
+}
+
+func (b *meshBVH) get(triangles []*Triangle) *bvhNode {
+	b.once.Do(func() { b.root = buildBVH(triangles) })
+	return b.root
+}
+
+// buildBVH recursively constructs a bounding volume hierarchy over the given triangles by splitting at the median centroid along
+// the longest axis of the centroid bounds.
+func buildBVH(triangles []*Triangle) *bvhNode {
+	if len(triangles) == 0 {
+		return nil
+	}
+	node := &bvhNode{bounds: newEmptyAABB()}
+	centroidBounds := newEmptyAABB()
+	for _, tri := range triangles {
+		node.bounds.expand(tri.p0)
+		node.bounds.expand(tri.p1)
+		node.bounds.expand(tri.p2)
+		centroidBounds.expand(tri.Centroid())
+	}
+	if len(triangles) <= bvhLeafSize {
+		node.triangles = triangles
+		return node
+	}
+
+	extent := centroidBounds.Max.Sub(centroidBounds.Min)
+	axis := func(v r3.Vector) float64 { return v.X }
+	if extent.Y > extent.X && extent.Y >= extent.Z {
+		axis = func(v r3.Vector) float64 { return v.Y }
+	} else if extent.Z > extent.X && extent.Z > extent.Y {
+		axis = func(v r3.Vector) float64 { return v.Z }
+	}
+
+	// Copy so that the ordering of the caller's triangles is untouched
+	sorted := make([]*Triangle, len(triangles))
+	copy(sorted, triangles)
+	sort.Slice(sorted, func(i, j int) bool {
+		return axis(sorted[i].Centroid()) < axis(sorted[j].Centroid())
+	})
+	mid := len(sorted) / 2
+	node.left = buildBVH(sorted[:mid])
+	node.right = buildBVH(sorted[mid:])
+	return node
+}
+
+// rigidTransform is a pose decomposed into a rotation matrix and translation, which is much cheaper to apply to many points than
+// composing dual quaternions. The rows of the rotation matrix are the axes of the rotated frame, so rotating a point into the
+// parent frame multiplies by its transpose.
+type rigidTransform struct {
+	rm    *RotationMatrix
+	trans r3.Vector
+}
+
+func newRigidTransform(pose Pose) rigidTransform {
+	return rigidTransform{rm: pose.Orientation().RotationMatrix(), trans: pose.Point()}
+}
+
+func (rt rigidTransform) point(pt r3.Vector) r3.Vector {
+	return r3.Vector{X: rt.rm.Col(0).Dot(pt), Y: rt.rm.Col(1).Dot(pt), Z: rt.rm.Col(2).Dot(pt)}.Add(rt.trans)
+}
+
+func (rt rigidTransform) triangle(t *Triangle) *Triangle {
+	return NewTriangle(rt.point(t.p0), rt.point(t.p1), rt.point(t.p2))
+}
+
+func (rt rigidTransform) aabb(a AABB) AABB {
+	center := rt.point(a.Center())
+	h := a.halfSize()
+	extents := r3.Vector{
+		X: math.Abs(rt.rm.At(0, 0))*h.X + math.Abs(rt.rm.At(1, 0))*h.Y + math.Abs(rt.rm.At(2, 0))*h.Z,
+		Y: math.Abs(rt.rm.At(0, 1))*h.X + math.Abs(rt.rm.At(1, 1))*h.Y + math.Abs(rt.rm.At(2, 1))*h.Z,
+		Z: math.Abs(rt.rm.At(0, 2))*h.X + math.Abs(rt.rm.At(1, 2))*h.Y + math.Abs(rt.rm.At(2, 2))*h.Z,
+	}
+	return AABB{Min: center.Sub(extents), Max: center.Add(extents)}
+}
+
+// bvhPairQuery walks two bounding volume hierarchies simultaneously. Triangles and bounds of the second hierarchy are expressed
+// in the frame of the first by applying toFirst, and transformed triangles are cached so that each is only transformed once.
+type bvhPairQuery struct {
+	toFirst     rigidTransform
+	transformed map[*Triangle]*Triangle
+}
+
+func newBVHPairQuery(toFirst Pose) *bvhPairQuery {
+	return &bvhPairQuery{toFirst: newRigidTransform(toFirst), transformed: map[*Triangle]*Triangle{}}
+}
+
+func (q *bvhPairQuery) triangle(t *Triangle) *Triangle {
+	if tt, ok := q.transformed[t]; ok {
+		return tt
+	}
+	tt := q.toFirst.triangle(t)
+	q.transformed[t] = tt
+	return tt
+}
+
+// collides returns true if any triangle of a is within collisionBufferMM of any triangle of b.
+func (q *bvhPairQuery) collides(a, b *bvhNode, collisionBufferMM float64) bool {
+	if !a.bounds.Intersects(q.toFirst.aabb(b.bounds), collisionBufferMM) {
+		return false
+	}
+	switch {
+	case a.isLeaf() && b.isLeaf():
+		for _, triA := range a.triangles {
+			for _, triB := range b.triangles {
+				if triangleDistance(triA, q.triangle(triB), collisionBufferMM) <= collisionBufferMM {
+					return true
+				}
+			}
+		}
+		return false
+	case b.isLeaf() || (!a.isLeaf() && a.bounds.halfSize().Norm2() >= b.bounds.halfSize().Norm2()):
+		return q.collides(a.left, b, collisionBufferMM) || q.collides(a.right, b, collisionBufferMM)
+	default:
+		return q.collides(a, b.left, collisionBufferMM) || q.collides(a, b.right, collisionBufferMM)
+	}
+}
+
+// distance returns the minimum distance between any triangle of a and any triangle of b, or best if that is smaller.
+func (q *bvhPairQuery) distance(a, b *bvhNode, best float64) float64 {
+	if a.bounds.Distance(q.toFirst.aabb(b.bounds)) >= best {
+		return best
+	}
+	if a.isLeaf() && b.isLeaf() {
+		for _, triA := range a.triangles {
+			for _, triB := range b.triangles {
+				best = math.Min(best, triangleDistance(triA, q.triangle(triB), math.Inf(-1)))
+			}
+		}
+		return best
+	}
+
+	var pairs [2][2]*bvhNode
+	if b.isLeaf() || (!a.isLeaf() && a.bounds.halfSize().Norm2() >= b.bounds.halfSize().Norm2()) {
+		pairs = [2][2]*bvhNode{{a.left, b}, {a.right, b}}
+	} else {
+		pairs = [2][2]*bvhNode{{a, b.left}, {a, b.right}}
+	}
+	// Descend into the closer pair first so that the bound tightens as quickly as possible
+	d0 := pairs[0][0].bounds.Distance(q.toFirst.aabb(pairs[0][1].bounds))
+	d1 := pairs[1][0].bounds.Distance(q.toFirst.aabb(pairs[1][1].bounds))
+	if d1 < d0 {
+		pairs[0], pairs[1] = pairs[1], pairs[0]
+	}
+	for _, pair := range pairs {
+		best = q.distance(pair[0], pair[1], best)
+	}
+	return best
+}
+
+// triangleDistance returns the minimum distance between two triangles. If two triangles intersect, then the segment between two
+// vertices of one triangle intersects the other triangle, so checking all edges against both triangles is sufficient.
+// The search exits early as soon as a distance at or below stopAt is found.
+func triangleDistance(t1, t2 *Triangle, stopAt float64) float64 {
+	minDist := math.Inf(1)
+	p1 := t1.Points()
+	p2 := t2.Points()
+	for i := 0; i < 3; i++ {
+		bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(p1[i], p1[(i+1)%3], t2)
+		if minDist = math.Min(minDist, bestSegPt.Sub(bestTriPt).Norm()); minDist <= stopAt {
+			return minDist
+		}
+	}
+	for i := 0; i < 3; i++ {
+		bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(p2[i], p2[(i+1)%3], t1)
+		if minDist = math.Min(minDist, bestSegPt.Sub(bestTriPt).Norm()); minDist <= stopAt {
+			return minDist
+		}
+	}
+	return minDist
+}
+
+// pointDistance returns the minimum distance between the given point and any triangle under the node, or best if that is smaller.
+func (n *bvhNode) pointDistance(pt r3.Vector, best float64) float64 {
+	if n.bounds.distanceToPoint(pt) >= best {
+		return best
+	}
+	if n.isLeaf() {
+		for _, tri := range n.triangles {
+			best = math.Min(best, ClosestPointTrianglePoint(tri, pt).Sub(pt).Norm())
+		}
+		return best
+	}
+	first, second := n.left, n.right
+	if second.bounds.distanceToPoint(pt) < first.bounds.distanceToPoint(pt) {
+		first, second = second, first
+	}
+	return second.pointDistance(pt, first.pointDistance(pt, best))
+}
+
+// pointWithin returns true if any triangle under the node is within the given distance of the point.
+func (n *bvhNode) pointWithin(pt r3.Vector, dist float64) bool {
+	if n.bounds.distanceToPoint(pt) > dist {
+		return false
+	}
+	if n.isLeaf() {
+		for _, tri := range n.triangles {
+			if ClosestPointTrianglePoint(tri, pt).Sub(pt).Norm() <= dist {
+				return true
+			}
+		}
+		return false
+	}
+	return n.left.pointWithin(pt, dist) || n.right.pointWithin(pt, dist)
+}
+
+// segmentDistance returns the minimum distance between the segment ab and any triangle under the node, or best if that is smaller.
+func (n *bvhNode) segmentDistance(a, b r3.Vector, best float64) float64 {
+	segBounds := newEmptyAABB()
+	segBounds.expand(a)
+	segBounds.expand(b)
+	return n.segmentDistanceBounded(a, b, segBounds, best)
+}
+
+func (n *bvhNode) segmentDistanceBounded(a, b r3.Vector, segBounds AABB, best float64) float64 {
+	if n.bounds.Distance(segBounds) >= best {
+		return best
+	}
+	if n.isLeaf() {
+		for _, tri := range n.triangles {
+			segPt, triPt := ClosestPointsSegmentTriangle(a, b, tri)
+			best = math.Min(best, segPt.Sub(triPt).Norm())
+		}
+		return best
+	}
+	first, second := n.left, n.right
+	if second.bounds.Distance(segBounds) < first.bounds.Distance(segBounds) {
+		first, second = second, first
+	}
+	return second.segmentDistanceBounded(a, b, segBounds, first.segmentDistanceBounded(a, b, segBounds, best))
+}
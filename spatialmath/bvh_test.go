@@ -0,0 +1,164 @@
+package spatialmath
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+)
+
+// makeSphereMesh returns a triangulated UV sphere of the given radius at the pose. It is testutils.MakeSphereMesh,
+// which cannot be imported here since testutils depends on spatialmath.
+func makeSphereMesh(pose Pose, radius float64, rings, segments int, label string) *Mesh {
+	vertex := func(ring, seg int) r3.Vector {
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(seg) / float64(segments)
+		return r3.Vector{
+			X: radius * math.Sin(theta) * math.Cos(phi),
+			Y: radius * math.Sin(theta) * math.Sin(phi),
+			Z: radius * math.Cos(theta),
+		}
+	}
+	triangles := []*Triangle{}
+	for ring := 0; ring < rings; ring++ {
+		for seg := 0; seg < segments; seg++ {
+			p00, p01 := vertex(ring, seg), vertex(ring, seg+1)
+			p10, p11 := vertex(ring+1, seg), vertex(ring+1, seg+1)
+			if ring != 0 {
+				triangles = append(triangles, NewTriangle(p00, p10, p01))
+			}
+			if ring != rings-1 {
+				triangles = append(triangles, NewTriangle(p01, p10, p11))
+			}
+		}
+	}
+	return NewMesh(pose, triangles, label)
+}
+
+// bruteForceMeshDistance compares every pair of triangles between two meshes.
+func bruteForceMeshDistance(m1, m2 *Mesh) float64 {
+	minDist := math.Inf(1)
+	for _, t1 := range m1.triangles {
+		for _, t2 := range m2.triangles {
+			minDist = math.Min(minDist, triangleDistance(t1.Transform(m1.pose), t2.Transform(m2.pose), math.Inf(-1)))
+		}
+	}
+	return minDist
+}
+
+func TestAABB(t *testing.T) {
+	a := AABB{Min: r3.Vector{X: 0, Y: 0, Z: 0}, Max: r3.Vector{X: 1, Y: 1, Z: 1}}
+	b := AABB{Min: r3.Vector{X: 2, Y: 0, Z: 0}, Max: r3.Vector{X: 3, Y: 1, Z: 1}}
+	test.That(t, a.Intersects(b, 0), test.ShouldBeFalse)
+	test.That(t, a.Intersects(b, 1), test.ShouldBeTrue)
+	test.That(t, a.Distance(b), test.ShouldAlmostEqual, 1)
+	test.That(t, a.Distance(a), test.ShouldEqual, 0)
+
+	// rotating a unit cube 45 degrees about Z grows its bounds in X and Y by sqrt(2)
+	rotated := a.Transform(NewPose(r3.Vector{X: 10}, &OrientationVectorDegrees{OZ: 1, Theta: 45}))
+	test.That(t, rotated.Max.X-rotated.Min.X, test.ShouldAlmostEqual, math.Sqrt2)
+	test.That(t, rotated.Max.Z-rotated.Min.Z, test.ShouldAlmostEqual, 1)
+
+	t.Run("geometry bounds", func(t *testing.T) {
+		pose := NewPose(r3.Vector{X: 1, Y: 2, Z: 3}, &OrientationVectorDegrees{OX: 1, Theta: 30})
+		boxGeom, err := NewBox(pose, r3.Vector{X: 10, Y: 20, Z: 30}, "")
+		test.That(t, err, test.ShouldBeNil)
+		sphereGeom, err := NewSphere(pose, 5, "")
+		test.That(t, err, test.ShouldBeNil)
+		capsuleGeom, err := NewCapsule(pose, 5, 40, "")
+		test.That(t, err, test.ShouldBeNil)
+		for _, g := range []Geometry{boxGeom, sphereGeom, capsuleGeom, NewPoint(pose.Point(), "")} {
+			bounds, ok := GeometryAABB(g)
+			test.That(t, ok, test.ShouldBeTrue)
+			// every point of a grid which is inside the geometry must also be inside its bounds
+			for x := -30.; x <= 30; x += 2 {
+				for y := -30.; y <= 30; y += 2 {
+					for z := -30.; z <= 30; z += 2 {
+						pt := pose.Point().Add(r3.Vector{X: x, Y: y, Z: z})
+						collides, err := g.CollidesWith(NewPoint(pt, ""), 0)
+						test.That(t, err, test.ShouldBeNil)
+						if collides {
+							test.That(t, bounds.Intersects(AABB{Min: pt, Max: pt}, 1e-6), test.ShouldBeTrue)
+						}
+					}
+				}
+			}
+		}
+
+		mesh := makeSphereMesh(pose, 8, 6, 8, "")
+		bounds, ok := GeometryAABB(mesh)
+		test.That(t, ok, test.ShouldBeTrue)
+		for _, pt := range mesh.ToPoints(0.1) {
+			test.That(t, bounds.Intersects(AABB{Min: pt, Max: pt}, 1e-6), test.ShouldBeTrue)
+		}
+	})
+}
+
+func TestMeshBVHMatchesBruteForce(t *testing.T) {
+	m1 := makeSphereMesh(NewZeroPose(), 50, 12, 16, "")
+	poses := []Pose{
+		NewPose(r3.Vector{X: 120}, &OrientationVectorDegrees{OY: 1, Theta: 30}),
+		NewPose(r3.Vector{X: 60, Y: 40, Z: -20}, &OrientationVectorDegrees{OX: 1, Theta: 75}),
+		NewPose(r3.Vector{X: 300, Z: 10}, NewZeroOrientation()),
+		NewPose(r3.Vector{X: 99}, NewZeroOrientation()),
+	}
+	for _, pose := range poses {
+		m2 := makeSphereMesh(pose, 50, 10, 10, "")
+		expected := bruteForceMeshDistance(m1, m2)
+		dist, err := m1.DistanceFrom(m2)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, dist, test.ShouldAlmostEqual, expected, 1e-6)
+
+		collides, err := m1.CollidesWith(m2, defaultCollisionBufferMM)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, collides, test.ShouldEqual, expected <= defaultCollisionBufferMM)
+
+		// a buffer just larger than the separation must always report a collision
+		collides, err = m1.CollidesWith(m2, expected+1e-3)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, collides, test.ShouldBeTrue)
+	}
+
+	t.Run("sphere and capsule", func(t *testing.T) {
+		mesh := makeSphereMesh(NewPose(r3.Vector{X: 5}, &OrientationVectorDegrees{OZ: 1, Theta: 20}), 50, 12, 16, "")
+		s, err := NewSphere(NewPoseFromPoint(r3.Vector{X: 100, Y: 10}), 10, "")
+		test.That(t, err, test.ShouldBeNil)
+		c, err := NewCapsule(NewPose(r3.Vector{Y: 100}, &OrientationVectorDegrees{OX: 1, Theta: 90}), 10, 60, "")
+		test.That(t, err, test.ShouldBeNil)
+
+		expectedSphere, expectedCapsule := math.Inf(1), math.Inf(1)
+		for _, tri := range mesh.triangles {
+			worldTri := tri.Transform(mesh.pose)
+			pt := s.Pose().Point()
+			expectedSphere = math.Min(expectedSphere, ClosestPointTrianglePoint(worldTri, pt).Sub(pt).Norm()-10)
+			expectedCapsule = math.Min(expectedCapsule, capsuleVsTriangleDistance(c.(*capsule), worldTri))
+		}
+		dist, err := mesh.DistanceFrom(s)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, dist, test.ShouldAlmostEqual, expectedSphere, 1e-6)
+		dist, err = mesh.DistanceFrom(c)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, dist, test.ShouldAlmostEqual, expectedCapsule, 1e-6)
+	})
+
+	t.Run("hierarchy is shared across transforms", func(t *testing.T) {
+		transformed := m1.Transform(NewPoseFromPoint(r3.Vector{X: 1})).(*Mesh)
+		test.That(t, transformed.bvhRoot(), test.ShouldEqual, m1.bvhRoot())
+	})
+}
+
+func BenchmarkMeshDistance(b *testing.B) {
+	m1 := makeSphereMesh(NewZeroPose(), 50, 24, 32, "")
+	m2 := makeSphereMesh(NewPose(r3.Vector{X: 120}, &OrientationVectorDegrees{OY: 1, Theta: 30}), 50, 24, 32, "")
+	b.Run("bvh", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m1.distanceFromMesh(m2)
+		}
+	})
+	b.Run("brute force", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bruteForceMeshDistance(m1, m2)
+		}
+	})
+}
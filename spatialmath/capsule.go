@@ -276,17 +276,13 @@ func capsuleVsBoxDistance(c *capsule, other *box) float64 {
 // IMPORTANT: meshes are not considered solid. A mesh is not guaranteed to represent an enclosed area. This will measure ONLY the distance
 // to the closest triangle in the mesh.
 func capsuleVsMeshDistance(c *capsule, other *Mesh) float64 {
-	lowDist := math.Inf(1)
-	for _, t := range other.triangles {
-		// Measure distance to each mesh triangle
-		// Make sure the triangle is transformed by the pose of the mesh to ensure that it is properly positioned
-		properlyPositionedTriangle := t.Transform(other.Pose())
-		dist := capsuleVsTriangleDistance(c, properlyPositionedTriangle)
-		if dist < lowDist {
-			lowDist = dist
-		}
+	root := other.bvhRoot()
+	if root == nil {
+		return math.Inf(1)
 	}
-	return lowDist
+	// Bring the capsule's line segment into the frame of the mesh rather than moving every triangle
+	toMesh := newRigidTransform(PoseInverse(other.Pose()))
+	return root.segmentDistance(toMesh.point(c.segA), toMesh.point(c.segB), math.Inf(1)) - c.radius
 }
 
 func capsuleVsTriangleDistance(c *capsule, other *Triangle) float64 {
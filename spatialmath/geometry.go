@@ -62,6 +62,7 @@ const (
 	SphereType  = GeometryType("sphere")
 	CapsuleType = GeometryType("capsule")
 	PointType   = GeometryType("point")
+	MeshType    = GeometryType("mesh")
 )
 
 // GeometryConfig specifies the format of geometries specified through JSON configuration files.
@@ -79,6 +80,11 @@ type GeometryConfig struct {
 	// parameter used for defining a capsule's length
 	L float64 `json:"l"`
 
+	// parameter used for defining a mesh by the path to a PLY or STL file
+	MeshFilePath string `json:"mesh_file_path,omitempty"`
+	// parameter used for defining a mesh by its PLY data, used when there is no mesh file path
+	MeshPLY []byte `json:"mesh_ply,omitempty"`
+
 	// define an offset to position the geometry
 	TranslationOffset r3.Vector         `json:"translation,omitempty"`
 	OrientationOffset OrientationConfig `json:"orientation,omitempty"`
@@ -141,6 +147,19 @@ func (config *GeometryConfig) ParseConfig() (Geometry, error) {
 		return NewCapsule(offset, config.R, config.L, config.Label)
 	case PointType:
 		return NewPoint(offset.Point(), config.Label), nil
+	case MeshType:
+		var mesh *Mesh
+		if config.MeshFilePath == "" && len(config.MeshPLY) > 0 {
+			mesh, err = newMeshFromBytes(NewZeroPose(), config.MeshPLY, config.Label)
+		} else {
+			mesh, err = NewMeshFromFile(config.MeshFilePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		geom := mesh.Transform(offset)
+		geom.SetLabel(config.Label)
+		return geom, nil
 	case UnknownType:
 		// no type specified, iterate through supported types and try to infer intent
 		boxDims := r3.Vector{X: config.X, Y: config.Y, Z: config.Z}
@@ -1,11 +1,15 @@
 package spatialmath
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/chenzhekl/goply"
 	"github.com/golang/geo/r3"
@@ -31,6 +35,9 @@ type Mesh struct {
 	triangles []*Triangle
 	label     string
 
+	// bounding volume hierarchy over the triangles, built lazily and shared by all transformed copies of this mesh
+	bvh *meshBVH
+
 	// information used for encoding to protobuf
 	fileType meshType
 	rawBytes []byte
@@ -42,6 +49,7 @@ func NewMesh(pose Pose, triangles []*Triangle, label string) *Mesh {
 		pose:      pose,
 		triangles: triangles,
 		label:     label,
+		bvh:       &meshBVH{},
 	}
 
 	// Convert triangles to PLY for protobuf
@@ -68,6 +76,105 @@ func NewMeshFromPLYFile(path string) (*Mesh, error) {
 	return newMeshFromBytes(NewZeroPose(), bytes, path)
 }
 
+// NewMeshFromSTLFile is a helper function to create a Mesh geometry from an ascii or binary STL file.
+// As with PLY files, the STL file's units are assumed to be meters.
+func NewMeshFromSTLFile(path string) (*Mesh, error) {
+	//nolint:gosec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	triangles, err := trianglesFromSTLBytes(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading mesh %s", path)
+	}
+	// Protobuf meshes may only be PLY, so NewMesh will take care of the conversion
+	return NewMesh(NewZeroPose(), triangles, path), nil
+}
+
+// NewMeshFromFile creates a Mesh geometry from a PLY or STL file, as determined by the file's extension.
+func NewMeshFromFile(path string) (*Mesh, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".ply":
+		return NewMeshFromPLYFile(path)
+	case ".stl":
+		return NewMeshFromSTLFile(path)
+	default:
+		return nil, fmt.Errorf("unsupported mesh file extension %q, supported extensions are .ply and .stl", ext)
+	}
+}
+
+// trianglesFromSTLBytes parses the triangles of an STL file, scaling them from meters to millimeters.
+func trianglesFromSTLBytes(data []byte) ([]*Triangle, error) {
+	const (
+		stlHeaderSize   = 80
+		stlTriangleSize = 50
+	)
+	toMM := func(x, y, z float64) r3.Vector {
+		return r3.Vector{X: x * 1000, Y: y * 1000, Z: z * 1000}
+	}
+
+	// Binary STL files are identified by their size matching the triangle count in their header, since many binary files
+	// also begin with the word "solid"
+	if len(data) >= stlHeaderSize+4 {
+		count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+		if len(data) == stlHeaderSize+4+count*stlTriangleSize {
+			triangles := make([]*Triangle, 0, count)
+			for i := 0; i < count; i++ {
+				// skip the 12 byte facet normal, which is recomputed by NewTriangle
+				offset := stlHeaderSize + 4 + i*stlTriangleSize + 12
+				pts := make([]r3.Vector, 3)
+				for j := range pts {
+					read := func(k int) float64 {
+						return float64(math.Float32frombits(binary.LittleEndian.Uint32(data[offset+12*j+4*k:])))
+					}
+					pts[j] = toMM(read(0), read(1), read(2))
+				}
+				triangles = append(triangles, NewTriangle(pts[0], pts[1], pts[2]))
+			}
+			return triangles, nil
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, errors.New("file is neither a binary nor an ascii STL")
+	}
+	triangles := []*Triangle{}
+	pts := make([]r3.Vector, 0, 3)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "vertex":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("malformed vertex line %q", scanner.Text())
+			}
+			coords := make([]float64, 3)
+			for i := range coords {
+				v, err := cast.ToFloat64E(fields[i+1])
+				if err != nil {
+					return nil, err
+				}
+				coords[i] = v
+			}
+			pts = append(pts, toMM(coords[0], coords[1], coords[2]))
+		case "endfacet":
+			if len(pts) != 3 {
+				return nil, errors.New("triangle did not have three points")
+			}
+			triangles = append(triangles, NewTriangle(pts[0], pts[1], pts[2]))
+			pts = pts[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return triangles, nil
+}
+
 func newMeshFromBytes(pose Pose, data []byte, label string) (mesh *Mesh, err error) {
 	// the library we are using for PLY parsing is fragile, so
 	defer func() {
@@ -108,6 +215,7 @@ func newMeshFromBytes(pose Pose, data []byte, label string) (mesh *Mesh, err err
 		pose:      pose,
 		triangles: triangles,
 		label:     label,
+		bvh:       &meshBVH{},
 		fileType:  plyType,
 		rawBytes:  data,
 	}, nil
@@ -161,6 +269,7 @@ func (m *Mesh) Transform(pose Pose) Geometry {
 		pose:      Compose(pose, m.pose),
 		triangles: m.triangles,
 		label:     m.label,
+		bvh:       m.bvh,
 		fileType:  m.fileType,
 		rawBytes:  m.rawBytes,
 	}
@@ -178,8 +287,7 @@ func (m *Mesh) CollidesWith(g Geometry, collisionBufferMM float64) (bool, error)
 		// Convert box to mesh and check triangle collisions
 		return m.collidesWithMesh(other.toMesh(), collisionBufferMM), nil
 	case *capsule:
-		// Use existing capsule vs mesh distance check
-		// TODO: This is inefficient! Replace with a function with a short-circuit.
+		// The mesh's bounding volume hierarchy prunes all triangles farther away than the closest one found so far
 		dist := capsuleVsMeshDistance(other, m)
 		return dist <= collisionBufferMM, nil
 	case *point:
@@ -259,121 +367,50 @@ func (m *Mesh) boxIntersectsVertex(b *box) bool {
 	return false
 }
 
+// bvhRoot returns the root of the mesh's bounding volume hierarchy, building it on first use.
+func (m *Mesh) bvhRoot() *bvhNode {
+	if m.bvh == nil {
+		return buildBVH(m.triangles)
+	}
+	return m.bvh.get(m.triangles)
+}
+
 func (m *Mesh) distanceFromSphere(s *sphere) float64 {
-	pt := s.pose.Point()
-	minDist := math.Inf(1)
-	// Transform all triangles to world space once
-	for _, tri := range m.triangles {
-		closestPt := ClosestPointTrianglePoint(tri.Transform(m.pose), pt)
-		dist := closestPt.Sub(pt).Norm() - s.radius
-		if dist < minDist {
-			minDist = dist
-		}
+	root := m.bvhRoot()
+	if root == nil {
+		return math.Inf(1)
 	}
-	return minDist
+	// Bring the sphere center into the frame of the mesh rather than moving every triangle
+	pt := PoseBetween(m.pose, s.pose).Point()
+	return root.pointDistance(pt, math.Inf(1)) - s.radius
 }
 
 func (m *Mesh) collidesWithSphere(s *sphere, buffer float64) bool {
-	pt := s.pose.Point()
-	// Transform all triangles to world space once
-	for _, tri := range m.triangles {
-		closestPt := ClosestPointTrianglePoint(tri.Transform(m.pose), pt)
-		if closestPt.Sub(pt).Norm() <= s.radius+buffer {
-			return true
-		}
+	root := m.bvhRoot()
+	if root == nil {
+		return false
 	}
-	return false
+	pt := PoseBetween(m.pose, s.pose).Point()
+	return root.pointWithin(pt, s.radius+buffer)
 }
 
-// collidesWithMesh checks if this mesh collides with another mesh
-// TODO: This function is *begging* for GPU acceleration.
+// collidesWithMesh checks if this mesh collides with another mesh.
+// Both meshes' bounding volume hierarchies are traversed together so that only triangles whose bounds overlap are compared.
 func (m *Mesh) collidesWithMesh(other *Mesh, collisionBufferMM float64) bool {
-	// Transform all triangles to world space
-	worldTris1 := make([]*Triangle, len(m.triangles))
-	for i, tri := range m.triangles {
-		worldTris1[i] = tri.Transform(m.pose)
-	}
-	worldTris2 := make([]*Triangle, len(other.triangles))
-	for i, tri := range other.triangles {
-		worldTris2[i] = tri.Transform(other.pose)
-	}
-
-	// Check if any triangles from either mesh collide.
-	// If two triangles intersect, then the segment between two vertices of one triangle intersects the other triangle.
-	for _, worldTri1 := range worldTris1 {
-		p1 := worldTri1.Points()
-
-		for _, worldTri2 := range worldTris2 {
-			p2 := worldTri2.Points()
-
-			// Check segments from tri1 against tri2
-			for i := 0; i < 3; i++ {
-				start := p1[i]
-				end := p1[(i+1)%3]
-				bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(start, end, worldTri2)
-				if bestSegPt.Sub(bestTriPt).Norm() <= collisionBufferMM {
-					return true
-				}
-			}
-
-			// Check segments from tri2 against tri1
-			for i := 0; i < 3; i++ {
-				start := p2[i]
-				end := p2[(i+1)%3]
-				bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(start, end, worldTri1)
-				if bestSegPt.Sub(bestTriPt).Norm() <= collisionBufferMM {
-					return true
-				}
-			}
-		}
+	root, otherRoot := m.bvhRoot(), other.bvhRoot()
+	if root == nil || otherRoot == nil {
+		return false
 	}
-	return false
+	return newBVHPairQuery(PoseBetween(m.pose, other.pose)).collides(root, otherRoot, collisionBufferMM)
 }
 
 // distanceFromMesh returns the minimum distance between this mesh and another mesh.
 func (m *Mesh) distanceFromMesh(other *Mesh) float64 {
-	// Transform all triangles to world space
-	worldTris1 := make([]*Triangle, len(m.triangles))
-	for i, tri := range m.triangles {
-		worldTris1[i] = tri.Transform(m.pose)
-	}
-
-	worldTris2 := make([]*Triangle, len(other.triangles))
-	for i, tri := range other.triangles {
-		worldTris2[i] = tri.Transform(other.pose)
-	}
-
-	minDist := math.Inf(1)
-	for _, worldTri1 := range worldTris1 {
-		p1 := worldTri1.Points()
-
-		for _, worldTri2 := range worldTris2 {
-			p2 := worldTri2.Points()
-
-			// Check segments from tri1 against tri2
-			for i := 0; i < 3; i++ {
-				start := p1[i]
-				end := p1[(i+1)%3]
-				bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(start, end, worldTri2)
-				dist := bestSegPt.Sub(bestTriPt).Norm()
-				if dist < minDist {
-					minDist = dist
-				}
-			}
-
-			// Check segments from tri2 against tri1
-			for i := 0; i < 3; i++ {
-				start := p2[i]
-				end := p2[(i+1)%3]
-				bestSegPt, bestTriPt := ClosestPointsSegmentTriangle(start, end, worldTri1)
-				dist := bestSegPt.Sub(bestTriPt).Norm()
-				if dist < minDist {
-					minDist = dist
-				}
-			}
-		}
+	root, otherRoot := m.bvhRoot(), other.bvhRoot()
+	if root == nil || otherRoot == nil {
+		return math.Inf(1)
 	}
-	return minDist
+	return newBVHPairQuery(PoseBetween(m.pose, other.pose)).distance(root, otherRoot, math.Inf(1))
 }
 
 // SetLabel sets the name of the mesh.
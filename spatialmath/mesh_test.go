@@ -1,7 +1,10 @@
 package spatialmath
 
 import (
+	"encoding/binary"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/golang/geo/r3"
@@ -737,3 +740,40 @@ func TestBoxTriangleIntersectionArea(t *testing.T) {
 		test.That(t, area, test.ShouldAlmostEqual, 0)
 	})
 }
+
+func TestSTLConversion(t *testing.T) {
+	expected := []r3.Vector{{X: 0, Y: 0, Z: 0}, {X: 1000, Y: 0, Z: 0}, {X: 0, Y: 500, Z: 0}}
+	dir := t.TempDir()
+
+	ascii := "solid test\n facet normal 0 0 1\n  outer loop\n   vertex 0 0 0\n   vertex 1 0 0\n   vertex 0 0.5 0\n" +
+		"  endloop\n endfacet\nendsolid test\n"
+	asciiPath := filepath.Join(dir, "ascii.stl")
+	test.That(t, os.WriteFile(asciiPath, []byte(ascii), 0o600), test.ShouldBeNil)
+
+	// binary STL files may also begin with "solid"
+	binaryData := make([]byte, 84, 134)
+	copy(binaryData, "solid binary")
+	binary.LittleEndian.PutUint32(binaryData[80:], 1)
+	for _, v := range append([]r3.Vector{{Z: 1}}, expected...) {
+		for _, f := range []float64{v.X, v.Y, v.Z} {
+			binaryData = binary.LittleEndian.AppendUint32(binaryData, math.Float32bits(float32(f/1000)))
+		}
+	}
+	binaryData = append(binaryData, 0, 0)
+	binaryPath := filepath.Join(dir, "binary.STL")
+	test.That(t, os.WriteFile(binaryPath, binaryData, 0o600), test.ShouldBeNil)
+
+	for _, path := range []string{asciiPath, binaryPath} {
+		m, err := NewMeshFromFile(path)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(m.Triangles()), test.ShouldEqual, 1)
+		for i, pt := range m.Triangles()[0].Points() {
+			test.That(t, R3VectorAlmostEqual(pt, expected[i], 1e-3), test.ShouldBeTrue)
+		}
+		// meshes loaded from STL files can be sent over the wire as PLY
+		test.That(t, m.ToProtobuf().GetMesh().GetContentType(), test.ShouldEqual, "ply")
+	}
+
+	_, err := NewMeshFromFile(filepath.Join(dir, "mesh.obj"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
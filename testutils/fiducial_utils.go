@@ -0,0 +1,31 @@
+package testutils
+
+import (
+	"image"
+	"image/color"
+)
+
+// DrawFiducialMarker draws an upright marker with the code and number of data bits along each side of a marker of a
+// fiducial family, with cells of the given size in pixels, its top left corner at (x, y) and a white margin one cell
+// wide around it.
+func DrawFiducialMarker(img *image.Gray, code uint64, dataBits, x, y, cellPx int) {
+	n := dataBits + 2
+	for row := -1; row <= n; row++ {
+		for col := -1; col <= n; col++ {
+			value := uint8(255)
+			switch {
+			case row == -1 || col == -1 || row == n || col == n:
+			case row == 0 || col == 0 || row == n-1 || col == n-1:
+				value = 0
+			default:
+				bit := code >> (dataBits*dataBits - 1 - ((row-1)*dataBits + col - 1)) & 1
+				value = uint8(255 * bit)
+			}
+			for py := 0; py < cellPx; py++ {
+				for px := 0; px < cellPx; px++ {
+					img.SetGray(x+col*cellPx+px, y+row*cellPx+py, color.Gray{value})
+				}
+			}
+		}
+	}
+}
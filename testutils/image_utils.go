@@ -0,0 +1,30 @@
+package testutils
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/golang/geo/r2"
+)
+
+// RenderCheckerboard renders a checkerboard with cols by rows inner corners and a white border one square wide,
+// averaging 4x4 samples per pixel. toBoard maps a point of the image to the plane of the board, in which the first
+// inner corner is at the origin and the squares have the given side length.
+func RenderCheckerboard(width, height, cols, rows int, squareSize float64, toBoard func(x, y float64) r2.Point) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for v := 0; v < height; v++ {
+		for u := 0; u < width; u++ {
+			sum := 0
+			for s := 0; s < 16; s++ {
+				p := toBoard(float64(u)+float64(s%4)/4-0.375, float64(v)+float64(s/4)/4-0.375)
+				i, j := math.Floor(p.X/squareSize), math.Floor(p.Y/squareSize)
+				if i < -1 || j < -1 || i >= float64(cols) || j >= float64(rows) || int(i+j)%2 != 0 {
+					sum += 255
+				}
+			}
+			img.SetGray(u, v, color.Gray{uint8(sum / 16)})
+		}
+	}
+	return img
+}
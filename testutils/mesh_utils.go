@@ -0,0 +1,37 @@
+package testutils
+
+import (
+	"math"
+
+	"github.com/golang/geo/r3"
+
+	"go.viam.com/rdk/spatialmath"
+)
+
+// MakeSphereMesh returns a triangulated UV sphere of the given radius at the pose with 2*rings*(segments-1)
+// triangles.
+func MakeSphereMesh(pose spatialmath.Pose, radius float64, rings, segments int, label string) *spatialmath.Mesh {
+	vertex := func(ring, seg int) r3.Vector {
+		theta := math.Pi * float64(ring) / float64(rings)
+		phi := 2 * math.Pi * float64(seg) / float64(segments)
+		return r3.Vector{
+			X: radius * math.Sin(theta) * math.Cos(phi),
+			Y: radius * math.Sin(theta) * math.Sin(phi),
+			Z: radius * math.Cos(theta),
+		}
+	}
+	triangles := []*spatialmath.Triangle{}
+	for ring := 0; ring < rings; ring++ {
+		for seg := 0; seg < segments; seg++ {
+			p00, p01 := vertex(ring, seg), vertex(ring, seg+1)
+			p10, p11 := vertex(ring+1, seg), vertex(ring+1, seg+1)
+			if ring != 0 {
+				triangles = append(triangles, spatialmath.NewTriangle(p00, p10, p01))
+			}
+			if ring != rings-1 {
+				triangles = append(triangles, spatialmath.NewTriangle(p01, p10, p11))
+			}
+		}
+	}
+	return spatialmath.NewMesh(pose, triangles, label)
+}
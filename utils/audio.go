@@ -1,6 +1,12 @@
 package utils
 
-import commonpb "go.viam.com/api/common/v1"
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+	commonpb "go.viam.com/api/common/v1"
+)
 
 // Common audio codec constants.
 const (
@@ -44,3 +50,71 @@ func AudioInfoStructToPb(info *AudioInfo) *commonpb.AudioInfo {
 		NumChannels:  info.NumChannels,
 	}
 }
+
+// AudioSampleSize returns the number of bytes of a sample of the uncompressed codec, or 0 for compressed codecs.
+func AudioSampleSize(codec string) int {
+	switch codec {
+	case CodecPCM16:
+		return 2
+	case CodecPCM32, CodecPCM32Float:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// EncodePCM encodes samples between -1 and 1 as little endian PCM data of the codec, clipping samples out of range.
+func EncodePCM(samples []float32, codec string) ([]byte, error) {
+	size := AudioSampleSize(codec)
+	if size == 0 {
+		return nil, errors.Errorf("cannot encode audio samples as %q, which is not a PCM codec", codec)
+	}
+	data := make([]byte, size*len(samples))
+	for i, s := range samples {
+		s = float32(math.Max(-1, math.Min(1, float64(s))))
+		switch codec {
+		case CodecPCM16:
+			binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(math.Round(float64(s)*math.MaxInt16))))
+		case CodecPCM32:
+			binary.LittleEndian.PutUint32(data[4*i:], uint32(int32(math.Round(float64(s)*math.MaxInt32))))
+		default:
+			binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(s))
+		}
+	}
+	return data, nil
+}
+
+// DecodePCM decodes little endian PCM data of the codec into samples between -1 and 1.
+func DecodePCM(data []byte, codec string) ([]float32, error) {
+	size := AudioSampleSize(codec)
+	if size == 0 {
+		return nil, errors.Errorf("cannot decode %q audio, which is not a PCM codec", codec)
+	}
+	if len(data)%size != 0 {
+		return nil, errors.Errorf("%q audio of %d bytes does not have whole samples", codec, len(data))
+	}
+	samples := make([]float32, len(data)/size)
+	for i := range samples {
+		switch codec {
+		case CodecPCM16:
+			samples[i] = float32(int16(binary.LittleEndian.Uint16(data[2*i:]))) / math.MaxInt16
+		case CodecPCM32:
+			samples[i] = float32(float64(int32(binary.LittleEndian.Uint32(data[4*i:]))) / math.MaxInt32)
+		default:
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	}
+	return samples, nil
+}
+
+// ConvertPCM converts PCM data from a codec to another.
+func ConvertPCM(data []byte, from, to string) ([]byte, error) {
+	if from == to {
+		return data, nil
+	}
+	samples, err := DecodePCM(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return EncodePCM(samples, to)
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestPCM(t *testing.T) {
+	samples := []float32{0, 0.5, -0.5, 1, -1}
+	for _, codec := range []string{CodecPCM16, CodecPCM32, CodecPCM32Float} {
+		data, err := EncodePCM(samples, codec)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, len(data), test.ShouldEqual, AudioSampleSize(codec)*len(samples))
+		decoded, err := DecodePCM(data, codec)
+		test.That(t, err, test.ShouldBeNil)
+		for i, s := range decoded {
+			test.That(t, s, test.ShouldAlmostEqual, samples[i], 1e-4)
+		}
+	}
+
+	// samples out of range are clipped
+	data, err := EncodePCM([]float32{2, -2}, CodecPCM16)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, data, test.ShouldResemble, []byte{0xff, 0x7f, 0x01, 0x80})
+
+	data, err = ConvertPCM(data, CodecPCM16, CodecPCM32Float)
+	test.That(t, err, test.ShouldBeNil)
+	decoded, err := DecodePCM(data, CodecPCM32Float)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldResemble, []float32{1, -1})
+
+	_, err = EncodePCM(samples, CodecOpus)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = DecodePCM([]byte{1, 2, 3}, CodecPCM16)
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = ConvertPCM([]byte{1, 2}, CodecMP3, CodecPCM16)
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -45,6 +45,9 @@ const (
 
 	// MimeTypeH264 used to indicate H264 frames.
 	MimeTypeH264 = "video/h264"
+
+	// MimeTypeWAV is the mime type of WAV audio.
+	MimeTypeWAV = "audio/wav"
 )
 
 // WithLazyMIMEType attaches the lazy suffix to a MIME.
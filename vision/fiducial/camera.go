@@ -0,0 +1,44 @@
+package fiducial
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/components/camera"
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// DetectPosesFromCamera finds the markers of the family in the next image of the camera and estimates their poses in
+// the frame of the camera, given the side length of their black squares. The camera needs intrinsics.
+func DetectPosesFromCamera(
+	ctx context.Context,
+	cam camera.Camera,
+	family *Family,
+	sizeMm float64,
+) ([]Marker, []spatialmath.Pose, error) {
+	props, err := cam.Properties(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not get properties of camera %q", cam.Name().ShortName())
+	}
+	if props.IntrinsicParams == nil {
+		return nil, nil, transform.NewNoIntrinsicsError("marker poses need the intrinsics of the camera")
+	}
+	model := &transform.PinholeCameraModel{PinholeCameraIntrinsics: props.IntrinsicParams, Distortion: props.DistortionParams}
+	img, err := camera.DecodeImageFromCamera(ctx, "", nil, cam)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not get image from camera %q", cam.Name().ShortName())
+	}
+	markers, err := Detect(img, family)
+	if err != nil {
+		return nil, nil, err
+	}
+	poses := make([]spatialmath.Pose, len(markers))
+	for i := range markers {
+		if poses[i], err = EstimatePose(&markers[i], sizeMm, model); err != nil {
+			return nil, nil, err
+		}
+	}
+	return markers, poses, nil
+}
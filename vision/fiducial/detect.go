@@ -0,0 +1,402 @@
+package fiducial
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/vision/delaunay"
+)
+
+const (
+	// minMarkerPixels is the smallest number of pixels in the black outline of a marker.
+	minMarkerPixels = 64
+	// minSidePx is the length of the shortest side of a marker in the image.
+	minSidePx = 8.
+	// thresholdOffset is how much darker than its neighborhood a pixel needs to be to be part of a marker outline.
+	thresholdOffset = 7.
+	// minContrast is the smallest difference between the black and white cells of a marker.
+	minContrast = 30.
+	// minQuadFill is the smallest ratio of the area of the quadrilateral fit to an outline to the area of its hull.
+	minQuadFill = 0.9
+)
+
+// Marker is a marker found in an image.
+type Marker struct {
+	Family string
+	ID     int
+	// Corners are the corners of the black square of the marker in the image, clockwise from its top left corner.
+	Corners [4]r2.Point
+	// CorrectedBits is the number of bits of the marker that were read wrong.
+	CorrectedBits int
+}
+
+// Center returns the center of the marker in the image.
+func (m *Marker) Center() r2.Point {
+	// the intersection of the diagonals
+	d1, d2 := m.Corners[2].Sub(m.Corners[0]), m.Corners[3].Sub(m.Corners[1])
+	den := d1.Cross(d2)
+	if den == 0 {
+		return m.Corners[0].Add(m.Corners[2]).Mul(0.5)
+	}
+	s := m.Corners[1].Sub(m.Corners[0]).Cross(d2) / den
+	return m.Corners[0].Add(d1.Mul(s))
+}
+
+// BoundingBox returns the smallest rectangle of the image containing the marker.
+func (m *Marker) BoundingBox() image.Rectangle {
+	minPt, maxPt := m.Corners[0], m.Corners[0]
+	for _, c := range m.Corners[1:] {
+		minPt = r2.Point{X: math.Min(minPt.X, c.X), Y: math.Min(minPt.Y, c.Y)}
+		maxPt = r2.Point{X: math.Max(maxPt.X, c.X), Y: math.Max(maxPt.Y, c.Y)}
+	}
+	return image.Rect(int(math.Floor(minPt.X)), int(math.Floor(minPt.Y)), int(math.Ceil(maxPt.X)), int(math.Ceil(maxPt.Y)))
+}
+
+// grayImage is a grayscale image with intensities between 0 and 255.
+type grayImage struct {
+	width, height int
+	pix           []float64
+}
+
+func newGrayImage(img image.Image) *grayImage {
+	b := img.Bounds()
+	g := &grayImage{width: b.Dx(), height: b.Dy(), pix: make([]float64, b.Dx()*b.Dy())}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			gray := color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray)
+			g.pix[y*g.width+x] = float64(gray.Y)
+		}
+	}
+	return g
+}
+
+// bilinear returns the intensity at the sub pixel location (x, y), where pixel centers are at integer coordinates.
+func (g *grayImage) bilinear(x, y float64) float64 {
+	clamp := func(v, hi int) int {
+		return int(math.Max(0, math.Min(float64(v), float64(hi-1))))
+	}
+	x0, y0 := math.Floor(x), math.Floor(y)
+	dx, dy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	at := func(xx, yy int) float64 {
+		return g.pix[clamp(yy, g.height)*g.width+clamp(xx, g.width)]
+	}
+	return (1-dx)*(1-dy)*at(ix, iy) + dx*(1-dy)*at(ix+1, iy) + (1-dx)*dy*at(ix, iy+1) + dx*dy*at(ix+1, iy+1)
+}
+
+// darkMask marks the pixels darker than the mean of their neighborhood, which the outlines of markers are made of.
+func (g *grayImage) darkMask() []bool {
+	radius := int(math.Max(5, float64(min(g.width, g.height))/16))
+	// integral image with a row and column of zeros in front
+	w := g.width + 1
+	integral := make([]float64, w*(g.height+1))
+	for y := 0; y < g.height; y++ {
+		rowSum := 0.
+		for x := 0; x < g.width; x++ {
+			rowSum += g.pix[y*g.width+x]
+			integral[(y+1)*w+x+1] = integral[y*w+x+1] + rowSum
+		}
+	}
+	mask := make([]bool, len(g.pix))
+	for y := 0; y < g.height; y++ {
+		y0, y1 := max(0, y-radius), min(g.height, y+radius+1)
+		for x := 0; x < g.width; x++ {
+			x0, x1 := max(0, x-radius), min(g.width, x+radius+1)
+			sum := integral[y1*w+x1] - integral[y0*w+x1] - integral[y1*w+x0] + integral[y0*w+x0]
+			mean := sum / float64((y1-y0)*(x1-x0))
+			mask[y*g.width+x] = g.pix[y*g.width+x] < mean-thresholdOffset
+		}
+	}
+	return mask
+}
+
+// outlines returns the boundary pixels of the connected dark regions of the mask which don't touch the image border.
+func outlines(mask []bool, width, height int) [][]r2.Point {
+	labels := make([]int, len(mask))
+	var result [][]r2.Point
+	stack := []int{}
+	label := 0
+	for start, dark := range mask {
+		if !dark || labels[start] != 0 {
+			continue
+		}
+		label++
+		labels[start] = label
+		stack = append(stack[:0], start)
+		count := 0
+		touchesBorder := false
+		boundary := []r2.Point{}
+		for len(stack) > 0 {
+			idx := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			count++
+			x, y := idx%width, idx/width
+			if x == 0 || y == 0 || x == width-1 || y == height-1 {
+				touchesBorder = true
+				continue
+			}
+			isBoundary := false
+			for _, n := range []int{idx - 1, idx + 1, idx - width, idx + width} {
+				if !mask[n] {
+					isBoundary = true
+					continue
+				}
+				if labels[n] == 0 {
+					labels[n] = label
+					stack = append(stack, n)
+				}
+			}
+			if isBoundary {
+				boundary = append(boundary, r2.Point{X: float64(x), Y: float64(y)})
+			}
+		}
+		if !touchesBorder && count >= minMarkerPixels {
+			result = append(result, boundary)
+		}
+	}
+	return result
+}
+
+// fitQuad fits a quadrilateral to the boundary of a region, with its corners ordered clockwise in the image.
+func fitQuad(boundary []r2.Point) ([4]r2.Point, bool) {
+	var quad [4]r2.Point
+	pts := make([]delaunay.Point, len(boundary))
+	for i, p := range boundary {
+		pts[i] = delaunay.Point(p)
+	}
+	hullPts := delaunay.ConvexHull(pts)
+	if len(hullPts) < 4 {
+		return quad, false
+	}
+	hull := make([]r2.Point, len(hullPts))
+	centroid := r2.Point{}
+	for i, p := range hullPts {
+		hull[i] = r2.Point(p)
+		centroid = centroid.Add(hull[i])
+	}
+	centroid = centroid.Mul(1 / float64(len(hull)))
+	// the farthest points of a convex polygon from any point are vertices, so the corners are found as the farthest
+	// point from the centroid, the farthest point from it, and the farthest points on either side of that diagonal
+	farthest := func(from r2.Point) r2.Point {
+		best, bestDist := hull[0], -1.
+		for _, p := range hull {
+			if d := p.Sub(from).Norm(); d > bestDist {
+				best, bestDist = p, d
+			}
+		}
+		return best
+	}
+	c0 := farthest(centroid)
+	c2 := farthest(c0)
+	diag := c2.Sub(c0)
+	c1, c3 := c0, c0
+	maxLeft, maxRight := 0., 0.
+	for _, p := range hull {
+		d := diag.Cross(p.Sub(c0))
+		if d > maxLeft {
+			c1, maxLeft = p, d
+		}
+		if -d > maxRight {
+			c3, maxRight = p, -d
+		}
+	}
+	quad = [4]r2.Point{c0, c1, c2, c3}
+	quadArea := polygonArea(quad[:])
+	if quadArea < 0 {
+		quad[1], quad[3] = quad[3], quad[1]
+		quadArea = -quadArea
+	}
+	if quadArea < minQuadFill*math.Abs(polygonArea(hull)) {
+		return quad, false
+	}
+	for i := range quad {
+		if quad[i].Sub(quad[(i+1)%4]).Norm() < minSidePx {
+			return quad, false
+		}
+	}
+	return quad, true
+}
+
+// refineQuad moves the sides of the quadrilateral to the sub pixel edges of the marker, found where the intensity
+// across every side crosses the midpoint between the black border and the light margin, then intersects them for the
+// corners.
+func refineQuad(g *grayImage, quad [4]r2.Point) [4]r2.Point {
+	center := quad[0].Add(quad[1]).Add(quad[2]).Add(quad[3]).Mul(0.25)
+	type line struct{ point, dir r2.Point }
+	lines := make([]line, 4)
+	for i := range quad {
+		a, b := quad[i], quad[(i+1)%4]
+		side := b.Sub(a)
+		length := side.Norm()
+		dir := side.Mul(1 / length)
+		normal := dir.Ortho()
+		if normal.Dot(a.Sub(center)) < 0 {
+			normal = normal.Mul(-1)
+		}
+		pts := []r2.Point{}
+		// leave out the ends of the side, which bend around the corners
+		for along := 0.15 * length; along <= 0.85*length; along++ {
+			p := a.Add(dir.Mul(along))
+			inner := g.bilinear(p.X-2*normal.X, p.Y-2*normal.Y)
+			outer := g.bilinear(p.X+2*normal.X, p.Y+2*normal.Y)
+			if outer-inner < minContrast {
+				continue
+			}
+			mid := (inner + outer) / 2
+			prev := inner
+			for off := -1.75; off <= 2; off += 0.25 {
+				v := g.bilinear(p.X+off*normal.X, p.Y+off*normal.Y)
+				if v >= mid && prev < mid {
+					// interpolate between the samples
+					cross := off - 0.25*(v-mid)/(v-prev)
+					pts = append(pts, p.Add(normal.Mul(cross)))
+					break
+				}
+				prev = v
+			}
+		}
+		if len(pts) < 2 {
+			return quad
+		}
+		var mean r2.Point
+		for _, p := range pts {
+			mean = mean.Add(p)
+		}
+		mean = mean.Mul(1 / float64(len(pts)))
+		var sxx, sxy, syy float64
+		for _, p := range pts {
+			d := p.Sub(mean)
+			sxx += d.X * d.X
+			sxy += d.X * d.Y
+			syy += d.Y * d.Y
+		}
+		// the direction of the edge is the principal axis of the points
+		theta := 0.5 * math.Atan2(2*sxy, sxx-syy)
+		lines[i] = line{mean, r2.Point{X: math.Cos(theta), Y: math.Sin(theta)}}
+	}
+	var refined [4]r2.Point
+	for i := range refined {
+		// corner i is on the sides i-1 and i
+		l1, l2 := lines[(i+3)%4], lines[i]
+		den := l1.dir.Cross(l2.dir)
+		if math.Abs(den) < 1e-9 {
+			return quad
+		}
+		s := l2.point.Sub(l1.point).Cross(l2.dir) / den
+		refined[i] = l1.point.Add(l1.dir.Mul(s))
+		if refined[i].Sub(quad[i]).Norm() > 3 {
+			return quad
+		}
+	}
+	return refined
+}
+
+// polygonArea returns the signed area of the polygon, positive for polygons clockwise in the image.
+func polygonArea(poly []r2.Point) float64 {
+	area := 0.
+	for i, p := range poly {
+		area += p.Cross(poly[(i+1)%len(poly)])
+	}
+	return area / 2
+}
+
+// readMarker samples the cells of the marker in the quadrilateral and decodes them.
+func readMarker(g *grayImage, quad [4]r2.Point, family *Family) (Marker, bool) {
+	n := family.cells()
+	fn := float64(n)
+	gridCorners := []r2.Point{{X: 0, Y: 0}, {X: fn, Y: 0}, {X: fn, Y: fn}, {X: 0, Y: fn}}
+	h, err := transform.EstimateExactHomographyFrom8Points(gridCorners, quad[:], false)
+	if err != nil {
+		return Marker{}, false
+	}
+	// every cell is the mean of samples away from its edges
+	cells := make([]float64, n*n)
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			sum := 0.
+			for _, dy := range []float64{0.3, 0.5, 0.7} {
+				for _, dx := range []float64{0.3, 0.5, 0.7} {
+					p := h.Apply(r2.Point{X: float64(col) + dx, Y: float64(row) + dy})
+					sum += g.bilinear(p.X, p.Y)
+				}
+			}
+			cells[row*n+col] = sum / 9
+		}
+	}
+	black, white := 0., 0.
+	numBorder := 0
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			v := cells[row*n+col]
+			if row == 0 || col == 0 || row == n-1 || col == n-1 {
+				black += v
+				numBorder++
+			} else {
+				white = math.Max(white, v)
+			}
+		}
+	}
+	black /= float64(numBorder)
+	if white-black < minContrast {
+		return Marker{}, false
+	}
+	threshold := (black + white) / 2
+	var read uint64
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			bright := cells[row*n+col] > threshold
+			if row == 0 || col == 0 || row == n-1 || col == n-1 {
+				if bright {
+					return Marker{}, false
+				}
+				continue
+			}
+			read <<= 1
+			if bright {
+				read |= 1
+			}
+		}
+	}
+	id, rot, dist, ok := family.decode(read)
+	if !ok {
+		return Marker{}, false
+	}
+	// the bits are the code turned rot times clockwise, so the top left corner of the marker is corner rot
+	m := Marker{Family: family.Name, ID: id, CorrectedBits: dist}
+	for i := range m.Corners {
+		m.Corners[i] = quad[(i+rot)%4]
+	}
+	return m, true
+}
+
+// Detect finds the markers of the family in the image.
+func Detect(img image.Image, family *Family) ([]Marker, error) {
+	if err := family.CheckValid(); err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, errors.New("no image to detect markers in")
+	}
+	g := newGrayImage(img)
+	markers := []Marker{}
+	for _, boundary := range outlines(g.darkMask(), g.width, g.height) {
+		quad, ok := fitQuad(boundary)
+		if !ok {
+			continue
+		}
+		quad = refineQuad(g, quad)
+		if m, ok := readMarker(g, quad, family); ok {
+			offset := r2.Point{X: float64(img.Bounds().Min.X), Y: float64(img.Bounds().Min.Y)}
+			for i := range m.Corners {
+				m.Corners[i] = m.Corners[i].Add(offset)
+			}
+			markers = append(markers, m)
+		}
+	}
+	return markers, nil
+}
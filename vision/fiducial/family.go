@@ -0,0 +1,132 @@
+// Package fiducial detects square fiducial markers, like ArUco markers and AprilTags, in images and estimates
+// their poses relative to the camera.
+package fiducial
+
+import (
+	"encoding/json"
+	"math/bits"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Family is a set of square markers made of a grid of data bits surrounded by a black border one cell wide, which is
+// itself expected to be surrounded by a lighter margin.
+type Family struct {
+	Name string `json:"name"`
+	// DataBits is the number of data bits along each side of the marker.
+	DataBits int `json:"data_bits"`
+	// Codes are the data bits of every marker, indexed by marker ID. The bits are read row by row from the top left
+	// corner of the marker into the code from its most significant bit, with white cells being 1.
+	Codes []uint64 `json:"codes"`
+	// MaxCorrectedBits is the number of bits which may be wrong for a marker to still be decoded.
+	MaxCorrectedBits int `json:"max_corrected_bits"`
+}
+
+// CheckValid checks that the family can be decoded.
+func (f *Family) CheckValid() error {
+	if f == nil {
+		return errors.New("no marker family provided")
+	}
+	if f.DataBits < 2 || f.DataBits > 8 {
+		return errors.Errorf("markers need between 2 and 8 data bits along each side, got %d", f.DataBits)
+	}
+	if len(f.Codes) == 0 {
+		return errors.Errorf("marker family %q has no codes", f.Name)
+	}
+	if f.MaxCorrectedBits < 0 {
+		return errors.Errorf("max_corrected_bits cannot be negative, got %d", f.MaxCorrectedBits)
+	}
+	return nil
+}
+
+// cells returns the number of cells along each side of a marker, including the black border.
+func (f *Family) cells() int {
+	return f.DataBits + 2
+}
+
+// arucoWords are the 5 bit words every row of an original ArUco marker is made of, each carrying 2 bits of the ID.
+var arucoWords = [4]uint64{0b10000, 0b10111, 0b01001, 0b01110}
+
+// ArucoOriginal returns the family of the 1024 original ArUco markers, which have 5x5 data bits.
+func ArucoOriginal() *Family {
+	codes := make([]uint64, 1024)
+	for id := range codes {
+		var code uint64
+		for row := 0; row < 5; row++ {
+			code = code<<5 | arucoWords[(id>>(2*(4-row)))&3]
+		}
+		codes[id] = code
+	}
+	return &Family{Name: "aruco_original", DataBits: 5, Codes: codes}
+}
+
+// NewFamilyFromFile reads a family from a JSON file with the fields of Family. This is how AprilTag families, whose
+// codebooks are published with the AprilTag library, are loaded.
+func NewFamilyFromFile(path string) (*Family, error) {
+	//nolint:gosec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read marker family %q", path)
+	}
+	f := &Family{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse marker family %q", path)
+	}
+	if err := f.CheckValid(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// rotateCode rotates the bits of a code of the given size by 90 degrees clockwise.
+func rotateCode(code uint64, size int) uint64 {
+	var out uint64
+	n := size * size
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			// the cell at (row, col) moves to (col, size-1-row)
+			bit := (code >> (n - 1 - (row*size + col))) & 1
+			out |= bit << (n - 1 - (col*size + size - 1 - row))
+		}
+	}
+	return out
+}
+
+// decode finds the code of the family closest to the bits read from a marker, trying all 4 rotations of the codes.
+// It returns the ID, the number of clockwise quarter turns of the marker in the bits, and the number of wrong bits.
+func (f *Family) decode(read uint64) (int, int, int, bool) {
+	bestID, bestRot, bestDist := -1, 0, f.MaxCorrectedBits+1
+	for id, code := range f.Codes {
+		for rot := 0; rot < 4; rot++ {
+			if dist := bits.OnesCount64(code ^ read); dist < bestDist {
+				bestID, bestRot, bestDist = id, rot, dist
+			}
+			code = rotateCode(code, f.DataBits)
+		}
+	}
+	if bestID < 0 {
+		return 0, 0, 0, false
+	}
+	return bestID, bestRot, bestDist, true
+}
+
+// NewFamily returns the builtin family with the given name, or the family read from the file at path if it is set.
+func NewFamily(name, path string) (*Family, error) {
+	if path != "" {
+		f, err := NewFamilyFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			f.Name = name
+		}
+		return f, nil
+	}
+	switch name {
+	case "", "aruco_original":
+		return ArucoOriginal(), nil
+	default:
+		return nil, errors.Errorf("unknown marker family %q, families other than aruco_original are read from a file", name)
+	}
+}
@@ -0,0 +1,217 @@
+package fiducial
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+var testCamera = &transform.PinholeCameraModel{
+	PinholeCameraIntrinsics: &transform.PinholeCameraIntrinsics{
+		Width: 320, Height: 240, Fx: 300, Fy: 300, Ppx: 160, Ppy: 120,
+	},
+}
+
+type testMarker struct {
+	code   uint64
+	sizeMm float64
+	pose   spatialmath.Pose
+}
+
+// renderMarkers renders markers with a white margin one cell wide on a gray background, averaging 4x4 samples per
+// pixel.
+func renderMarkers(family *Family, markers ...testMarker) image.Image {
+	k := testCamera.PinholeCameraIntrinsics
+	img := image.NewGray(image.Rect(0, 0, k.Width, k.Height))
+	n := family.cells()
+	for v := 0; v < k.Height; v++ {
+		for u := 0; u < k.Width; u++ {
+			sum := 0.
+			for s := 0; s < 16; s++ {
+				ray := r3.Vector{
+					X: (float64(u) + float64(s%4)/4 - 0.375 - k.Ppx) / k.Fx,
+					Y: (float64(v) + float64(s/4)/4 - 0.375 - k.Ppy) / k.Fy,
+					Z: 1,
+				}
+				value := 150.
+				for _, m := range markers {
+					t := m.pose.Point()
+					normal := spatialmath.Compose(m.pose, spatialmath.NewPoseFromPoint(r3.Vector{Z: 1})).Point().Sub(t)
+					hit := ray.Mul(normal.Dot(t) / normal.Dot(ray))
+					p := spatialmath.Compose(spatialmath.PoseInverse(m.pose), spatialmath.NewPoseFromPoint(hit)).Point()
+					cell := m.sizeMm / float64(n)
+					col := int(math.Floor((p.X + m.sizeMm/2) / cell))
+					row := int(math.Floor((p.Y + m.sizeMm/2) / cell))
+					switch {
+					case col < -1 || row < -1 || col > n || row > n:
+						continue
+					case col == -1 || row == -1 || col == n || row == n:
+						value = 255
+					case col == 0 || row == 0 || col == n-1 || row == n-1:
+						value = 0
+					default:
+						bit := (m.code >> (family.DataBits*family.DataBits - 1 - ((row-1)*family.DataBits + col - 1))) & 1
+						value = 255 * float64(bit)
+					}
+				}
+				sum += value
+			}
+			img.SetGray(u, v, color.Gray{uint8(sum / 16)})
+		}
+	}
+	return img
+}
+
+// projectCorners projects the corners of a marker in the given pose to the image.
+func projectCorners(sizeMm float64, pose spatialmath.Pose) [4]r2.Point {
+	var out [4]r2.Point
+	for i, c := range CornerPoints(sizeMm) {
+		p := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(c)).Point()
+		x, y := testCamera.PointToPixel(p.X, p.Y, p.Z)
+		out[i] = r2.Point{X: x, Y: y}
+	}
+	return out
+}
+
+func TestFamily(t *testing.T) {
+	aruco := ArucoOriginal()
+	test.That(t, aruco.CheckValid(), test.ShouldBeNil)
+	test.That(t, len(aruco.Codes), test.ShouldEqual, 1024)
+	// every row of marker 0 is the first word
+	test.That(t, aruco.Codes[0], test.ShouldEqual, uint64(0b10000_10000_10000_10000_10000))
+	test.That(t, aruco.Codes[1023], test.ShouldEqual, uint64(0b01110_01110_01110_01110_01110))
+
+	code := aruco.Codes[300]
+	rotated := rotateCode(code, 5)
+	test.That(t, rotated, test.ShouldNotEqual, code)
+	test.That(t, rotateCode(rotateCode(rotateCode(rotated, 5), 5), 5), test.ShouldEqual, code)
+	id, rot, dist, ok := aruco.decode(rotated)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, []int{id, rot, dist}, test.ShouldResemble, []int{300, 1, 0})
+	_, _, _, ok = aruco.decode(code ^ 1)
+	test.That(t, ok, test.ShouldBeFalse)
+
+	test.That(t, (&Family{Name: "empty", DataBits: 6}).CheckValid(), test.ShouldNotBeNil)
+	test.That(t, (&Family{Name: "big", DataBits: 9, Codes: []uint64{1}}).CheckValid(), test.ShouldNotBeNil)
+
+	dir := t.TempDir()
+	b, err := json.Marshal(map[string]interface{}{
+		"name": "tag36h11", "data_bits": 6, "max_corrected_bits": 2, "codes": []uint64{0xd7e00984b, 0xdda664ca7},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(dir, "family.json"), b, 0o600), test.ShouldBeNil)
+	family, err := NewFamilyFromFile(filepath.Join(dir, "family.json"))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, family.Codes[1], test.ShouldEqual, uint64(0xdda664ca7))
+	// two wrong bits are corrected
+	id, _, dist, ok = family.decode(0xdda664ca7 ^ 0b101)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, []int{id, dist}, test.ShouldResemble, []int{1, 2})
+
+	_, err = NewFamilyFromFile(filepath.Join(dir, "missing.json"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestDetect(t *testing.T) {
+	aruco := ArucoOriginal()
+	upright := spatialmath.NewPoseFromPoint(r3.Vector{X: -70, Y: 0, Z: 500})
+	// turned a quarter clockwise in the image, and tilted away from the camera
+	turned := spatialmath.NewPose(r3.Vector{X: 60, Y: 10, Z: 450}, &spatialmath.R4AA{Theta: math.Pi / 2, RZ: 1})
+	tilted := spatialmath.Compose(turned, spatialmath.NewPoseFromOrientation(&spatialmath.R4AA{Theta: 0.4, RX: 1}))
+	img := renderMarkers(aruco,
+		testMarker{aruco.Codes[123], 100, upright},
+		testMarker{aruco.Codes[7], 100, tilted},
+	)
+
+	markers, err := Detect(img, aruco)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(markers), test.ShouldEqual, 2)
+	if markers[0].ID != 123 {
+		markers[0], markers[1] = markers[1], markers[0]
+	}
+	test.That(t, markers[0].ID, test.ShouldEqual, 123)
+	test.That(t, markers[0].Family, test.ShouldEqual, "aruco_original")
+	test.That(t, markers[1].ID, test.ShouldEqual, 7)
+	test.That(t, markers[1].CorrectedBits, test.ShouldEqual, 0)
+
+	for i, expected := range [][4]r2.Point{projectCorners(100, upright), projectCorners(100, tilted)} {
+		for j, c := range markers[i].Corners {
+			test.That(t, c.Sub(expected[j]).Norm(), test.ShouldBeLessThan, 0.5)
+		}
+	}
+	center := markers[0].Center()
+	test.That(t, center.X, test.ShouldAlmostEqual, 160-70*300./500, 0.5)
+	test.That(t, center.Y, test.ShouldAlmostEqual, 120, 0.5)
+	box := markers[0].BoundingBox()
+	test.That(t, box.Dx(), test.ShouldBeBetweenOrEqual, 60, 62)
+
+	// the pose is recovered from the corners
+	pose, err := EstimatePose(&markers[1], 100, testCamera)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, pose.Point().Sub(tilted.Point()).Norm(), test.ShouldBeLessThan, 3)
+	for _, c := range CornerPoints(100) {
+		est := spatialmath.Compose(pose, spatialmath.NewPoseFromPoint(c)).Point()
+		truth := spatialmath.Compose(tilted, spatialmath.NewPoseFromPoint(c)).Point()
+		test.That(t, est.Sub(truth).Norm(), test.ShouldBeLessThan, 5)
+	}
+	_, err = EstimatePose(&markers[1], 100, &transform.PinholeCameraModel{})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = EstimatePose(&markers[1], 0, testCamera)
+	test.That(t, err, test.ShouldNotBeNil)
+
+	// markers of other families are not detected
+	markers, err = Detect(img, &Family{Name: "other", DataBits: 6, Codes: []uint64{0xd7e00984b}})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, markers, test.ShouldBeEmpty)
+	_, err = Detect(img, &Family{})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestDetectTag36h11(t *testing.T) {
+	// the first codes of the AprilTag tag36h11 family, loaded from a file as the other AprilTag families are
+	dir := t.TempDir()
+	b, err := json.Marshal(map[string]interface{}{
+		"data_bits": 6, "max_corrected_bits": 2, "codes": []uint64{0xd7e00984b, 0xdda664ca7},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, os.WriteFile(filepath.Join(dir, "tag36h11.json"), b, 0o600), test.ShouldBeNil)
+	family, err := NewFamily("tag36h11", filepath.Join(dir, "tag36h11.json"))
+	test.That(t, err, test.ShouldBeNil)
+	_, err = NewFamily("tag36h11", "")
+	test.That(t, err, test.ShouldNotBeNil)
+
+	upright := spatialmath.NewPoseFromPoint(r3.Vector{X: -70, Y: 0, Z: 500})
+	turned := spatialmath.NewPose(r3.Vector{X: 60, Y: 10, Z: 450}, &spatialmath.R4AA{Theta: -math.Pi / 2, RZ: 1})
+	img := renderMarkers(family, testMarker{family.Codes[1], 100, upright}, testMarker{family.Codes[0], 100, turned})
+
+	markers, err := Detect(img, family)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, len(markers), test.ShouldEqual, 2)
+	if markers[0].ID != 1 {
+		markers[0], markers[1] = markers[1], markers[0]
+	}
+	test.That(t, []int{markers[0].ID, markers[1].ID}, test.ShouldResemble, []int{1, 0})
+	test.That(t, markers[0].Family, test.ShouldEqual, "tag36h11")
+	for i, expected := range [][4]r2.Point{projectCorners(100, upright), projectCorners(100, turned)} {
+		for j, c := range markers[i].Corners {
+			test.That(t, c.Sub(expected[j]).Norm(), test.ShouldBeLessThan, 0.5)
+		}
+	}
+
+	// ArUco markers are not mistaken for tags of the family
+	aruco := ArucoOriginal()
+	markers, err = Detect(renderMarkers(aruco, testMarker{aruco.Codes[123], 100, upright}), family)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, markers, test.ShouldBeEmpty)
+}
@@ -0,0 +1,109 @@
+package fiducial
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+
+	"go.viam.com/rdk/rimage/transform"
+	"go.viam.com/rdk/spatialmath"
+)
+
+// CornerPoints returns the corners of the black square of a marker of the given side length in the frame of the marker,
+// in the order of Marker.Corners. The marker frame has its origin at the center of the marker, x to the right, y down
+// and z into the marker, so that an upright marker facing the camera has the orientation of the camera.
+func CornerPoints(sizeMm float64) [4]r3.Vector {
+	s := sizeMm / 2
+	return [4]r3.Vector{{X: -s, Y: -s}, {X: s, Y: -s}, {X: s, Y: s}, {X: -s, Y: s}}
+}
+
+// EstimatePose estimates the pose of the marker in the frame of the camera from its corners, given the side length of
+// the black square of the marker. See CornerPoints for the frame of the marker.
+func EstimatePose(m *Marker, sizeMm float64, model *transform.PinholeCameraModel) (spatialmath.Pose, error) {
+	if model == nil || model.PinholeCameraIntrinsics == nil {
+		return nil, transform.NewNoIntrinsicsError("intrinsics are needed to estimate the pose of a marker")
+	}
+	if sizeMm <= 0 {
+		return nil, errors.Errorf("marker size must be positive, got %v", sizeMm)
+	}
+	k := model.PinholeCameraIntrinsics
+	planePts := make([]r2.Point, 4)
+	imgPts := make([]r2.Point, 4)
+	for i, c := range CornerPoints(sizeMm) {
+		planePts[i] = r2.Point{X: c.X, Y: c.Y}
+		x, y := (m.Corners[i].X-k.Ppx)/k.Fx, (m.Corners[i].Y-k.Ppy)/k.Fy
+		if model.Distortion != nil {
+			x, y = undistort(model.Distortion, x, y)
+		}
+		imgPts[i] = r2.Point{X: x, Y: y}
+	}
+	h, err := transform.EstimateExactHomographyFrom8Points(planePts, imgPts, false)
+	if err != nil {
+		return nil, err
+	}
+	// the homography is [r1 r2 t] up to scale
+	col := func(c int) r3.Vector {
+		return r3.Vector{X: h.At(0, c), Y: h.At(1, c), Z: h.At(2, c)}
+	}
+	h1, h2, h3 := col(0), col(1), col(2)
+	scale := 2 / (h1.Norm() + h2.Norm())
+	// the marker is in front of the camera
+	if h3.Z < 0 {
+		scale = -scale
+	}
+	r1, r2, t := h1.Mul(scale), h2.Mul(scale), h3.Mul(scale)
+	r3v := r1.Cross(r2)
+	// the columns are only approximately orthonormal, so use the closest rotation
+	rot := mat.NewDense(3, 3, []float64{r1.X, r2.X, r3v.X, r1.Y, r2.Y, r3v.Y, r1.Z, r2.Z, r3v.Z})
+	var svd mat.SVD
+	if ok := svd.Factorize(rot, mat.SVDFull); !ok {
+		return nil, errors.New("cannot estimate the rotation of the marker")
+	}
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	rot.Mul(&u, v.T())
+	return spatialmath.NewPose(t, rotationToAxisAngle(rot)), nil
+}
+
+// undistort inverts the distortion of the normalized image point (x, y) by fixed point iteration.
+func undistort(d transform.Distorter, x, y float64) (float64, float64) {
+	ux, uy := x, y
+	for i := 0; i < 20; i++ {
+		dx, dy := d.Transform(ux, uy)
+		ux, uy = ux+x-dx, uy+y-dy
+	}
+	return ux, uy
+}
+
+// rotationToAxisAngle converts a rotation matrix to an axis angle.
+func rotationToAxisAngle(rot mat.Matrix) *spatialmath.R4AA {
+	cos := math.Max(-1, math.Min(1, (rot.At(0, 0)+rot.At(1, 1)+rot.At(2, 2)-1)/2))
+	theta := math.Acos(cos)
+	if theta < 1e-9 {
+		return spatialmath.NewR4AA()
+	}
+	axis := r3.Vector{X: rot.At(2, 1) - rot.At(1, 2), Y: rot.At(0, 2) - rot.At(2, 0), Z: rot.At(1, 0) - rot.At(0, 1)}
+	if axis.Norm() < 1e-9 {
+		// half a turn, the axis is the column of the largest diagonal element of rot + I
+		best := 0
+		for i := 1; i < 3; i++ {
+			if rot.At(i, i) > rot.At(best, best) {
+				best = i
+			}
+		}
+		axis = r3.Vector{X: rot.At(0, best), Y: rot.At(1, best), Z: rot.At(2, best)}
+		if best == 0 {
+			axis.X++
+		} else if best == 1 {
+			axis.Y++
+		} else {
+			axis.Z++
+		}
+	}
+	axis = axis.Normalize()
+	return &spatialmath.R4AA{Theta: theta, RX: axis.X, RY: axis.Y, RZ: axis.Z}
+}
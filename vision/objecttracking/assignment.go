@@ -0,0 +1,111 @@
+package objecttracking
+
+import (
+	"image"
+	"math"
+)
+
+// iou returns the intersection over union of two boxes.
+func iou(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	i := float64(inter.Dx() * inter.Dy())
+	union := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - i
+	if union <= 0 {
+		return 0
+	}
+	return i / union
+}
+
+// assign solves the assignment problem for the cost matrix with the Hungarian algorithm, returning the column
+// assigned to every row, or -1 for rows left unassigned when there are more rows than columns.
+func assign(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+	out := make([]int, rows)
+	if cols == 0 {
+		for i := range out {
+			out[i] = -1
+		}
+		return out
+	}
+	// the algorithm needs at most as many rows as columns
+	if rows > cols {
+		transposed := make([][]float64, cols)
+		for j := range transposed {
+			transposed[j] = make([]float64, rows)
+			for i := range cost {
+				transposed[j][i] = cost[i][j]
+			}
+		}
+		for i := range out {
+			out[i] = -1
+		}
+		for j, i := range assign(transposed) {
+			out[i] = j
+		}
+		return out
+	}
+
+	// potentials of the rows and columns and the row matched to every column, all indexed from 1 with 0 as a sentinel
+	u := make([]float64, rows+1)
+	v := make([]float64, cols+1)
+	match := make([]int, cols+1)
+	way := make([]int, cols+1)
+	for i := 1; i <= rows; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]float64, cols+1)
+		used := make([]bool, cols+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := match[j0], math.Inf(1), 0
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if match[j0] == 0 {
+				break
+			}
+		}
+		// flip the augmenting path
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+	for i := range out {
+		out[i] = -1
+	}
+	for j := 1; j <= cols; j++ {
+		if match[j] != 0 {
+			out[match[j]-1] = j - 1
+		}
+	}
+	return out
+}
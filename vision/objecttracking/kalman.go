@@ -0,0 +1,126 @@
+package objecttracking
+
+import (
+	"image"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// boxFilter is a constant velocity Kalman filter of a bounding box, with the state [cx, cy, s, r, vx, vy, vs] of the
+// center, area and aspect ratio of the box and the velocities of the center and area, as in SORT.
+type boxFilter struct {
+	x *mat.VecDense
+	p *mat.Dense
+}
+
+var (
+	// transition moves the center and area by their velocities, the aspect ratio is constant.
+	transition = mat.NewDense(7, 7, []float64{
+		1, 0, 0, 0, 1, 0, 0,
+		0, 1, 0, 0, 0, 1, 0,
+		0, 0, 1, 0, 0, 0, 1,
+		0, 0, 0, 1, 0, 0, 0,
+		0, 0, 0, 0, 1, 0, 0,
+		0, 0, 0, 0, 0, 1, 0,
+		0, 0, 0, 0, 0, 0, 1,
+	})
+	// measurement observes the box but not the velocities.
+	measurement = mat.NewDense(4, 7, []float64{
+		1, 0, 0, 0, 0, 0, 0,
+		0, 1, 0, 0, 0, 0, 0,
+		0, 0, 1, 0, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0,
+	})
+	measurementNoise = mat.NewDiagDense(4, []float64{1, 1, 10, 10})
+	processNoise     = mat.NewDiagDense(7, []float64{1, 1, 1, 1, 0.01, 0.01, 0.0001})
+)
+
+func newBoxFilter(box image.Rectangle) *boxFilter {
+	z := boxToMeasurement(box)
+	x := mat.NewVecDense(7, []float64{z[0], z[1], z[2], z[3], 0, 0, 0})
+	// the velocities are unknown
+	p := mat.NewDense(7, 7, nil)
+	for i, v := range []float64{10, 10, 10, 10, 10000, 10000, 10000} {
+		p.Set(i, i, v)
+	}
+	return &boxFilter{x: x, p: p}
+}
+
+// predict advances the state by one frame.
+func (f *boxFilter) predict() {
+	// the area cannot shrink below zero
+	if f.x.AtVec(2)+f.x.AtVec(6) <= 0 {
+		f.x.SetVec(6, 0)
+	}
+	f.x.MulVec(transition, f.x)
+	var p mat.Dense
+	p.Product(transition, f.p, transition.T())
+	p.Add(&p, processNoise)
+	f.p = &p
+}
+
+// update corrects the state with the observed box.
+func (f *boxFilter) update(box image.Rectangle) {
+	z := mat.NewVecDense(4, boxToMeasurement(box))
+	var y mat.VecDense
+	y.MulVec(measurement, f.x)
+	y.SubVec(z, &y)
+
+	var s mat.Dense
+	s.Product(measurement, f.p, measurement.T())
+	s.Add(&s, measurementNoise)
+	var sInv mat.Dense
+	if err := sInv.Inverse(&s); err != nil {
+		// the innovation covariance is positive definite, but keep the prediction if it is not invertible
+		return
+	}
+	var k mat.Dense
+	k.Product(f.p, measurement.T(), &sInv)
+
+	var dx mat.VecDense
+	dx.MulVec(&k, &y)
+	f.x.AddVec(f.x, &dx)
+
+	var kh, p mat.Dense
+	kh.Mul(&k, measurement)
+	kh.Sub(eye(7), &kh)
+	p.Mul(&kh, f.p)
+	f.p = &p
+}
+
+// box returns the bounding box of the state.
+func (f *boxFilter) box() image.Rectangle {
+	return measurementToBox(f.x.AtVec(0), f.x.AtVec(1), f.x.AtVec(2), f.x.AtVec(3))
+}
+
+func eye(n int) *mat.Dense {
+	m := mat.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+// boxToMeasurement returns the center, area and aspect ratio of the box.
+func boxToMeasurement(box image.Rectangle) []float64 {
+	w, h := float64(box.Dx()), float64(box.Dy())
+	r := 1.
+	if h > 0 {
+		r = w / h
+	}
+	return []float64{float64(box.Min.X) + w/2, float64(box.Min.Y) + h/2, w * h, r}
+}
+
+// measurementToBox returns the box with the given center, area and aspect ratio.
+func measurementToBox(cx, cy, s, r float64) image.Rectangle {
+	if s <= 0 || r <= 0 {
+		return image.Rect(int(math.Round(cx)), int(math.Round(cy)), int(math.Round(cx)), int(math.Round(cy)))
+	}
+	w := math.Sqrt(s * r)
+	h := s / w
+	return image.Rect(
+		int(math.Round(cx-w/2)), int(math.Round(cy-h/2)),
+		int(math.Round(cx+w/2)), int(math.Round(cy+h/2)),
+	)
+}
@@ -0,0 +1,370 @@
+// Package objecttracking follows detected objects across frames, giving them persistent track IDs with SORT, which
+// predicts the boxes of the tracks with Kalman filters and matches them to the new detections by their overlap.
+package objecttracking
+
+import (
+	"image"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/geo/r2"
+	"github.com/pkg/errors"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+// Config configures a Tracker.
+type Config struct {
+	// MaxAge is the number of frames a track is kept without a matching detection before it exits.
+	MaxAge int
+	// MinHits is the number of matched detections before a track is confirmed and enters.
+	MinHits int
+	// IoUThreshold is the smallest intersection over union of a detection with the predicted box of a track for them
+	// to be matched.
+	IoUThreshold float64
+	// HistoryLength is the number of centers kept in the history of every track.
+	HistoryLength int
+	// Lines are the lines the crossings of the tracks are counted for.
+	Lines []Line
+}
+
+// Line is a line segment of the image, in pixels. Crossings from the right of the line to its left, when looking from
+// Start to End, are positive, so that for a line going right in the image, moving down is positive.
+type Line struct {
+	Name  string
+	Start r2.Point
+	End   r2.Point
+}
+
+// side returns whether the point is left of the line, when looking from Start to End in the image.
+func (l Line) side(p r2.Point) bool {
+	return l.End.Sub(l.Start).Cross(p.Sub(l.Start)) > 0
+}
+
+// crossedBy returns whether the segment from a to b crosses the line, and whether it crosses it positively.
+func (l Line) crossedBy(a, b r2.Point) (bool, bool) {
+	sa, sb := l.side(a), l.side(b)
+	if sa == sb {
+		return false, false
+	}
+	// the ends of the line must also be on both sides of the segment
+	d := b.Sub(a)
+	c1, c2 := d.Cross(l.Start.Sub(a)), d.Cross(l.End.Sub(a))
+	if (c1 > 0 && c2 > 0) || (c1 < 0 && c2 < 0) {
+		return false, false
+	}
+	return true, sb
+}
+
+// CheckValid checks that the config can be tracked with.
+func (cfg *Config) CheckValid() error {
+	if cfg.MaxAge < 0 {
+		return errors.Errorf("max age cannot be negative, got %d", cfg.MaxAge)
+	}
+	if cfg.MinHits < 0 {
+		return errors.Errorf("min hits cannot be negative, got %d", cfg.MinHits)
+	}
+	if cfg.IoUThreshold < 0 || cfg.IoUThreshold > 1 {
+		return errors.Errorf("iou threshold must be between 0 and 1, got %v", cfg.IoUThreshold)
+	}
+	if cfg.HistoryLength < 0 {
+		return errors.Errorf("history length cannot be negative, got %d", cfg.HistoryLength)
+	}
+	names := map[string]bool{}
+	for _, l := range cfg.Lines {
+		if l.Name == "" {
+			return errors.New("counting lines need a name")
+		}
+		if names[l.Name] {
+			return errors.Errorf("counting line %q is defined twice", l.Name)
+		}
+		names[l.Name] = true
+		if l.Start == l.End {
+			return errors.Errorf("counting line %q has the same start and end", l.Name)
+		}
+	}
+	return nil
+}
+
+// EventType is the type of an Event.
+type EventType string
+
+// The events of tracks.
+const (
+	// Entered is when a track is confirmed.
+	Entered EventType = "entered"
+	// Exited is when a confirmed track has not been matched for more than the max age.
+	Exited EventType = "exited"
+	// Crossed is when a confirmed track crosses a counting line.
+	Crossed EventType = "crossed"
+)
+
+// Event is something which happened to a track.
+type Event struct {
+	Type    EventType
+	TrackID int
+	Label   string
+	Time    time.Time
+	// Line and Positive are the counting line and the direction of the crossing for Crossed events.
+	Line     string
+	Positive bool
+}
+
+// LineCount is the number of crossings of a counting line in each direction.
+type LineCount struct {
+	Positive int
+	Negative int
+}
+
+// Track is an object followed across frames.
+type Track struct {
+	ID    int
+	Label string
+	// Box is the box of the object estimated by the filter of the track.
+	Box   image.Rectangle
+	Score float64
+	// Hits is the number of detections matched to the track, Age the number of frames since it was created, and
+	// FramesSinceUpdate the number of frames since it was last matched.
+	Hits              int
+	Age               int
+	FramesSinceUpdate int
+	FirstSeen         time.Time
+	LastSeen          time.Time
+	// History holds the latest centers of the box of the track, oldest first.
+	History []r2.Point
+}
+
+// Center returns the center of the box of the track.
+func (t *Track) Center() r2.Point {
+	return r2.Point{X: float64(t.Box.Min.X+t.Box.Max.X) / 2, Y: float64(t.Box.Min.Y+t.Box.Max.Y) / 2}
+}
+
+type track struct {
+	Track
+	filter    *boxFilter
+	confirmed bool
+	// pending are the crossings of the track before it is confirmed
+	pending []Event
+}
+
+// Tracker assigns persistent IDs to the detections of successive frames. It is safe for concurrent use.
+type Tracker struct {
+	cfg Config
+
+	mu     sync.Mutex
+	tracks []*track
+	nextID int
+	events []Event
+	counts map[string]*LineCount
+}
+
+// NewTracker returns a tracker with the given config.
+func NewTracker(cfg Config) (*Tracker, error) {
+	if err := cfg.CheckValid(); err != nil {
+		return nil, err
+	}
+	t := &Tracker{cfg: cfg}
+	t.Reset()
+	return t, nil
+}
+
+// Reset forgets all the tracks, events and counts.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tracks = nil
+	t.nextID = 1
+	t.events = nil
+	t.counts = make(map[string]*LineCount, len(t.cfg.Lines))
+	for _, l := range t.cfg.Lines {
+		t.counts[l.Name] = &LineCount{}
+	}
+}
+
+// Update matches the detections of a new frame to the tracks, and returns the confirmed tracks seen in the frame.
+// Detections are only matched to tracks with the same label.
+func (t *Tracker) Update(dets []objectdetection.Detection, now time.Time) []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tr := range t.tracks {
+		tr.filter.predict()
+		tr.Age++
+		tr.FramesSinceUpdate++
+	}
+
+	matches := t.match(dets)
+	matched := make([]bool, len(dets))
+	for ti, di := range matches {
+		if di < 0 {
+			continue
+		}
+		matched[di] = true
+		tr := t.tracks[ti]
+		tr.filter.update(*dets[di].BoundingBox())
+		tr.Hits++
+		tr.FramesSinceUpdate = 0
+		tr.Score = dets[di].Score()
+		tr.LastSeen = now
+		t.moved(tr, now)
+	}
+	for di, d := range dets {
+		if matched[di] {
+			continue
+		}
+		tr := &track{
+			Track: Track{
+				ID: t.nextID, Label: d.Label(), Score: d.Score(), Hits: 1, FirstSeen: now, LastSeen: now,
+			},
+			filter: newBoxFilter(*d.BoundingBox()),
+		}
+		t.nextID++
+		t.tracks = append(t.tracks, tr)
+		t.moved(tr, now)
+	}
+
+	// confirm the tracks with enough hits, and drop the ones which have not been seen for too long
+	kept := t.tracks[:0]
+	var out []Track
+	for _, tr := range t.tracks {
+		if !tr.confirmed && tr.Hits >= t.cfg.MinHits {
+			tr.confirmed = true
+			t.addEvent(Event{Type: Entered, TrackID: tr.ID, Label: tr.Label, Time: now})
+			for _, e := range tr.pending {
+				t.count(e)
+			}
+			tr.pending = nil
+		}
+		if tr.FramesSinceUpdate > t.cfg.MaxAge {
+			if tr.confirmed {
+				t.addEvent(Event{Type: Exited, TrackID: tr.ID, Label: tr.Label, Time: now})
+			}
+			continue
+		}
+		kept = append(kept, tr)
+		if tr.confirmed && tr.FramesSinceUpdate == 0 {
+			out = append(out, tr.copy())
+		}
+	}
+	for i := len(kept); i < len(t.tracks); i++ {
+		t.tracks[i] = nil
+	}
+	t.tracks = kept
+	return out
+}
+
+// match returns the detection matched to every track, or -1.
+func (t *Tracker) match(dets []objectdetection.Detection) []int {
+	if len(t.tracks) == 0 || len(dets) == 0 {
+		out := make([]int, len(t.tracks))
+		for i := range out {
+			out[i] = -1
+		}
+		return out
+	}
+	overlaps := make([][]float64, len(t.tracks))
+	cost := make([][]float64, len(t.tracks))
+	for i, tr := range t.tracks {
+		predicted := tr.filter.box()
+		overlaps[i] = make([]float64, len(dets))
+		cost[i] = make([]float64, len(dets))
+		for j, d := range dets {
+			if d.Label() == tr.Label {
+				overlaps[i][j] = iou(predicted, *d.BoundingBox())
+			}
+			cost[i][j] = 1 - overlaps[i][j]
+		}
+	}
+	out := assign(cost)
+	for i, j := range out {
+		// pairs without enough overlap are only there to complete the assignment
+		if j >= 0 && (overlaps[i][j] == 0 || overlaps[i][j] < t.cfg.IoUThreshold) {
+			out[i] = -1
+		}
+	}
+	return out
+}
+
+// moved records the new box of the track in its history and counts the lines it crossed.
+func (t *Tracker) moved(tr *track, now time.Time) {
+	tr.Box = tr.filter.box()
+	center := tr.Center()
+	if len(tr.History) > 0 {
+		prev := tr.History[len(tr.History)-1]
+		for _, l := range t.cfg.Lines {
+			if crossed, positive := l.crossedBy(prev, center); crossed {
+				e := Event{Type: Crossed, TrackID: tr.ID, Label: tr.Label, Time: now, Line: l.Name, Positive: positive}
+				if tr.confirmed {
+					t.count(e)
+				} else {
+					tr.pending = append(tr.pending, e)
+				}
+			}
+		}
+	}
+	// the last center is kept to count crossings even without a history
+	tr.History = append(tr.History, center)
+	if keep := int(math.Max(1, float64(t.cfg.HistoryLength))); len(tr.History) > keep {
+		tr.History = append(tr.History[:0], tr.History[len(tr.History)-keep:]...)
+	}
+}
+
+// maxEvents is the number of events kept until they are read, the oldest ones are dropped first.
+const maxEvents = 1000
+
+func (t *Tracker) addEvent(e Event) {
+	if len(t.events) == maxEvents {
+		t.events = append(t.events[:0], t.events[1:]...)
+	}
+	t.events = append(t.events, e)
+}
+
+// count records the crossing event.
+func (t *Tracker) count(e Event) {
+	t.addEvent(e)
+	if e.Positive {
+		t.counts[e.Line].Positive++
+	} else {
+		t.counts[e.Line].Negative++
+	}
+}
+
+func (tr *track) copy() Track {
+	out := tr.Track
+	out.History = append([]r2.Point(nil), tr.History...)
+	return out
+}
+
+// Tracks returns the confirmed tracks, including the ones which were not seen in the last frame, ordered by ID.
+func (t *Tracker) Tracks() []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []Track
+	for _, tr := range t.tracks {
+		if tr.confirmed {
+			out = append(out, tr.copy())
+		}
+	}
+	return out
+}
+
+// Events returns the events since the last call, oldest first.
+func (t *Tracker) Events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := t.events
+	t.events = nil
+	return out
+}
+
+// Counts returns the number of crossings of every counting line.
+func (t *Tracker) Counts() map[string]LineCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]LineCount, len(t.counts))
+	for name, c := range t.counts {
+		out[name] = *c
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package objecttracking
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/r2"
+	"go.viam.com/test"
+
+	"go.viam.com/rdk/vision/objectdetection"
+)
+
+func box(x, y int) objectdetection.Detection {
+	return objectdetection.NewDetectionWithoutImgBounds(image.Rect(x, y, x+40, y+30), 0.9, "box")
+}
+
+func TestAssign(t *testing.T) {
+	test.That(t, assign(nil), test.ShouldBeEmpty)
+	test.That(t, assign([][]float64{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}}), test.ShouldResemble, []int{1, 0, 2})
+	// more rows than columns leaves rows unassigned
+	test.That(t, assign([][]float64{{1}, {0}, {2}}), test.ShouldResemble, []int{-1, 0, -1})
+	test.That(t, assign([][]float64{{5, 0, 1}, {0, 5, 5}}), test.ShouldResemble, []int{1, 0})
+}
+
+func TestIoU(t *testing.T) {
+	a := image.Rect(0, 0, 10, 10)
+	test.That(t, iou(a, a), test.ShouldEqual, 1)
+	test.That(t, iou(a, image.Rect(5, 0, 15, 10)), test.ShouldAlmostEqual, 1./3)
+	test.That(t, iou(a, image.Rect(20, 20, 30, 30)), test.ShouldEqual, 0)
+}
+
+func TestConfig(t *testing.T) {
+	test.That(t, (&Config{MaxAge: 1, MinHits: 3, IoUThreshold: 0.3}).CheckValid(), test.ShouldBeNil)
+	test.That(t, (&Config{MaxAge: -1}).CheckValid(), test.ShouldNotBeNil)
+	test.That(t, (&Config{IoUThreshold: 2}).CheckValid(), test.ShouldNotBeNil)
+	test.That(t, (&Config{Lines: []Line{{Start: r2.Point{}, End: r2.Point{X: 1}}}}).CheckValid(), test.ShouldNotBeNil)
+	test.That(t, (&Config{Lines: []Line{{Name: "a"}}}).CheckValid(), test.ShouldNotBeNil)
+	line := Line{Name: "a", End: r2.Point{X: 1}}
+	test.That(t, (&Config{Lines: []Line{line, line}}).CheckValid(), test.ShouldNotBeNil)
+}
+
+func TestLineCrossing(t *testing.T) {
+	line := Line{Name: "l", Start: r2.Point{X: 0, Y: 100}, End: r2.Point{X: 200, Y: 100}}
+	crossed, positive := line.crossedBy(r2.Point{X: 50, Y: 90}, r2.Point{X: 55, Y: 110})
+	test.That(t, crossed, test.ShouldBeTrue)
+	test.That(t, positive, test.ShouldBeTrue)
+	crossed, positive = line.crossedBy(r2.Point{X: 50, Y: 110}, r2.Point{X: 55, Y: 90})
+	test.That(t, crossed, test.ShouldBeTrue)
+	test.That(t, positive, test.ShouldBeFalse)
+	// beyond the end of the line
+	crossed, _ = line.crossedBy(r2.Point{X: 250, Y: 90}, r2.Point{X: 250, Y: 110})
+	test.That(t, crossed, test.ShouldBeFalse)
+	crossed, _ = line.crossedBy(r2.Point{X: 50, Y: 90}, r2.Point{X: 60, Y: 95})
+	test.That(t, crossed, test.ShouldBeFalse)
+}
+
+func TestTracker(t *testing.T) {
+	_, err := NewTracker(Config{MinHits: -1})
+	test.That(t, err, test.ShouldNotBeNil)
+
+	tracker, err := NewTracker(Config{
+		MaxAge: 2, MinHits: 3, IoUThreshold: 0.3, HistoryLength: 4,
+		Lines: []Line{{Name: "middle", Start: r2.Point{X: 0, Y: 100}, End: r2.Point{X: 320, Y: 100}}},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	start := time.Now()
+	frame := func(i int) time.Time { return start.Add(time.Duration(i) * 100 * time.Millisecond) }
+
+	// one box moves down across the line while another one stays still, and a detection of another label overlaps it
+	var tracks []Track
+	for i := 0; i < 10; i++ {
+		dets := []objectdetection.Detection{box(50, 40+10*i), box(200, 150)}
+		if i == 1 {
+			dets = append(dets, objectdetection.NewDetectionWithoutImgBounds(image.Rect(200, 150, 240, 180), 0.5, "other"))
+		}
+		tracks = tracker.Update(dets, frame(i))
+		if i < 2 {
+			test.That(t, tracks, test.ShouldBeEmpty)
+		}
+	}
+	test.That(t, len(tracks), test.ShouldEqual, 2)
+	test.That(t, tracks[0].ID, test.ShouldEqual, 1)
+	test.That(t, tracks[0].Label, test.ShouldEqual, "box")
+	test.That(t, tracks[0].Hits, test.ShouldEqual, 10)
+	test.That(t, tracks[0].FirstSeen, test.ShouldEqual, frame(0))
+	test.That(t, tracks[1].ID, test.ShouldEqual, 2)
+	// the filter follows the moving box
+	test.That(t, tracks[0].Center().Y, test.ShouldAlmostEqual, 40+90+15, 2)
+	test.That(t, tracks[1].Center(), test.ShouldResemble, r2.Point{X: 220, Y: 165})
+	test.That(t, len(tracks[0].History), test.ShouldEqual, 4)
+	test.That(t, tracks[0].History[3], test.ShouldResemble, tracks[0].Center())
+
+	events := tracker.Events()
+	test.That(t, len(events), test.ShouldEqual, 3)
+	test.That(t, events[0].Type, test.ShouldEqual, Entered)
+	test.That(t, events[0].Time, test.ShouldEqual, frame(2))
+	test.That(t, events[1].Type, test.ShouldEqual, Entered)
+	test.That(t, events[2].Type, test.ShouldEqual, Crossed)
+	test.That(t, events[2].TrackID, test.ShouldEqual, 1)
+	test.That(t, events[2].Line, test.ShouldEqual, "middle")
+	test.That(t, events[2].Positive, test.ShouldBeTrue)
+	test.That(t, tracker.Events(), test.ShouldBeEmpty)
+	test.That(t, tracker.Counts(), test.ShouldResemble, map[string]LineCount{"middle": {Positive: 1}})
+
+	// the still box disappears for less than the max age, then comes back with the same ID
+	for i := 10; i < 12; i++ {
+		tracks = tracker.Update([]objectdetection.Detection{box(50, 40+10*i)}, frame(i))
+		test.That(t, len(tracks), test.ShouldEqual, 1)
+	}
+	test.That(t, len(tracker.Tracks()), test.ShouldEqual, 2)
+	tracks = tracker.Update([]objectdetection.Detection{box(50, 160), box(200, 150)}, frame(12))
+	test.That(t, len(tracks), test.ShouldEqual, 2)
+	test.That(t, tracks[1].ID, test.ShouldEqual, 2)
+	test.That(t, tracker.Events(), test.ShouldBeEmpty)
+
+	// everything leaves
+	for i := 13; i < 16; i++ {
+		test.That(t, tracker.Update(nil, frame(i)), test.ShouldBeEmpty)
+	}
+	events = tracker.Events()
+	test.That(t, len(events), test.ShouldEqual, 2)
+	test.That(t, events[0].Type, test.ShouldEqual, Exited)
+	test.That(t, events[1].Type, test.ShouldEqual, Exited)
+	test.That(t, tracker.Tracks(), test.ShouldBeEmpty)
+
+	// a new box gets a new ID, until the tracker is reset
+	tracker.Update([]objectdetection.Detection{box(0, 0)}, frame(16))
+	test.That(t, tracker.tracks[0].ID, test.ShouldEqual, 4)
+	tracker.Reset()
+	tracker.Update([]objectdetection.Detection{box(0, 0)}, frame(17))
+	test.That(t, tracker.tracks[0].ID, test.ShouldEqual, 1)
+	test.That(t, tracker.Counts(), test.ShouldResemble, map[string]LineCount{"middle": {}})
+}
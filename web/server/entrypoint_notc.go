@@ -3,9 +3,12 @@
 package server
 
 import (
+	"go.viam.com/rdk/gostream/codec/vp8"
 	robotimpl "go.viam.com/rdk/robot/impl"
+	"go.viam.com/rdk/robot/web"
 )
 
+// createRobotOptions streams video with the pure Go VP8 encoder, as x264 and opus need cgo.
 func createRobotOptions() []robotimpl.Option {
-	return []robotimpl.Option{}
+	return []robotimpl.Option{robotimpl.WithWebOptions(web.WithStreamConfig(vp8.DefaultStreamConfig))}
 }